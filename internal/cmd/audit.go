@@ -9,7 +9,10 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/slsa-framework/source-tool/pkg/attest"
 	"github.com/slsa-framework/source-tool/pkg/audit"
+	"github.com/slsa-framework/source-tool/pkg/policy"
+	"github.com/slsa-framework/source-tool/pkg/slsa"
 	"github.com/slsa-framework/source-tool/pkg/sourcetool"
 )
 
@@ -18,6 +21,7 @@ type AuditMode int
 const (
 	AuditModeBasic AuditMode = 1
 	AuditModeFull  AuditMode = 2
+	AuditModeDeep  AuditMode = 3
 )
 
 const (
@@ -26,6 +30,7 @@ const (
 
 	auditModeBasicName = "basic"
 	auditModeFullName  = "full"
+	auditModeDeepName  = "deep"
 )
 
 // Enable audit mode enum
@@ -36,6 +41,8 @@ func (e *AuditMode) String() string {
 		return auditModeBasicName
 	case AuditModeFull:
 		return auditModeFullName
+	case AuditModeDeep:
+		return auditModeDeepName
 	}
 	return "error"
 }
@@ -49,8 +56,11 @@ func (e *AuditMode) Set(v string) error {
 	case auditModeFullName:
 		*e = AuditModeFull
 		return nil
+	case auditModeDeepName:
+		*e = AuditModeDeep
+		return nil
 	default:
-		return fmt.Errorf("must be one of %q or %q", auditModeBasicName, auditModeFullName)
+		return fmt.Errorf("must be one of %q, %q or %q", auditModeBasicName, auditModeFullName, auditModeDeepName)
 	}
 }
 
@@ -63,23 +73,41 @@ type auditOpts struct {
 	branchOptions
 	verifierOptions
 	outputOptions
-	auditDepth   int
-	endingCommit string
-	auditMode    AuditMode
+	policyVersionOptions
+	policySourceOptions
+	auditDepth     int
+	endingCommit   string
+	auditMode      AuditMode
+	workers        int
+	checkpointFile string
+	useLocalPolicy string
 }
 
 // AuditCommitResultJSON represents a single commit audit result in JSON format
 type AuditCommitResultJSON struct {
-	Commit            string      `json:"commit"`
-	Status            string      `json:"status"`
-	VerifiedLevels    []string    `json:"verified_levels,omitempty"`
-	PrevCommitMatches *bool       `json:"prev_commit_matches,omitempty"`
-	ProvControls      interface{} `json:"prov_controls,omitempty"`
-	Controls          interface{} `json:"controls,omitempty"`
-	PrevCommit        string      `json:"prev_commit,omitempty"`
-	PriorCommit       string      `json:"prior_commit,omitempty"`
-	Link              string      `json:"link,omitempty"`
-	Error             string      `json:"error,omitempty"`
+	Commit            string              `json:"commit"`
+	Status            string              `json:"status"`
+	VerifiedLevels    []string            `json:"verified_levels,omitempty"`
+	TlogEntries       []*attest.TlogEntry `json:"tlog_entries,omitempty"`
+	PrevCommitMatches *bool               `json:"prev_commit_matches,omitempty"`
+	ProvControls      interface{}         `json:"prov_controls,omitempty"`
+	Controls          interface{}         `json:"controls,omitempty"`
+	RecomputedLevels  []string            `json:"recomputed_levels,omitempty"`
+	Overclaims        []string            `json:"overclaims,omitempty"`
+	PolicyDigest      map[string]string   `json:"policy_digest,omitempty"`
+	PolicyMismatch    string              `json:"policy_mismatch,omitempty"`
+	PolicyLatest      bool                `json:"policy_latest,omitempty"`
+	PrevCommit        string              `json:"prev_commit,omitempty"`
+	PriorCommit       string              `json:"prior_commit,omitempty"`
+	MergedParents     []MergedParentJSON  `json:"merged_parents,omitempty"`
+	Link              string              `json:"link,omitempty"`
+	Error             string              `json:"error,omitempty"`
+}
+
+// MergedParentJSON represents a commit merged into the branch
+type MergedParentJSON struct {
+	Commit   string `json:"commit"`
+	Attested bool   `json:"attested"`
 }
 
 // AuditResultJSON represents the full audit result in JSON format
@@ -104,6 +132,11 @@ func (ao *auditOpts) Validate() error {
 		ao.branchOptions.Validate(),
 		ao.verifierOptions.Validate(),
 		ao.outputOptions.Validate(),
+		ao.policyVersionOptions.Validate(),
+		ao.policySourceOptions.Validate(),
+	}
+	if ao.workers < 1 {
+		errs = append(errs, errors.New("the number of workers must be at least 1"))
 	}
 	return errors.Join(errs...)
 }
@@ -112,10 +145,15 @@ func (ao *auditOpts) AddFlags(cmd *cobra.Command) {
 	ao.branchOptions.AddFlags(cmd)
 	ao.verifierOptions.AddFlags(cmd)
 	ao.outputOptions.AddFlags(cmd)
+	ao.policyVersionOptions.AddFlags(cmd)
+	ao.policySourceOptions.AddFlags(cmd)
 	cmd.PersistentFlags().IntVar(&ao.auditDepth, "depth", 0, "The max number of revisions to audit (depth <= audit all revisions).")
 	cmd.PersistentFlags().StringVar(&ao.endingCommit, "ending-commit", "", "The commit to stop auditing at.")
 	ao.auditMode = AuditModeBasic
-	cmd.PersistentFlags().Var(&ao.auditMode, "audit-mode", "'basic' for limited details (default), 'full' for all details")
+	cmd.PersistentFlags().Var(&ao.auditMode, "audit-mode", "'basic' for limited details (default), 'full' for all details, 'deep' to also re-evaluate the provenance against the policy")
+	cmd.PersistentFlags().IntVar(&ao.workers, "workers", 8, "Number of revisions to audit concurrently.")
+	cmd.PersistentFlags().StringVar(&ao.useLocalPolicy, "use_local_policy", "", "UNSAFE: Use the policy at this local path instead of the official one (deep audits).")
+	cmd.PersistentFlags().StringVar(&ao.checkpointFile, "checkpoint", "", "File to record the audit progress in. An existing checkpoint resumes the audit where it left off.")
 }
 
 func addAudit(parentCmd *cobra.Command) {
@@ -131,9 +169,22 @@ Revisions 'pass' an audit if they have:
 2. Corresponding source provenance
 3. The revision (commit) listed in the provenance matches the revision reported by GitHub
 
-Future:
-* Check the provenance to validate the verifiedLevels in the VSA match expectations
-  (i.e. that the VSA was issued correctly)
+In deep mode (--audit-mode=deep) the provenance is also evaluated again
+against the repository policy and revisions fail if their VSA claims a level
+or control the provenance does not support. Provenance created before the
+branch policy took effect is evaluated against the default policy.
+
+Deep audits use the policy version recorded in the VSA when the VSA policy
+was read from the same policy source. Otherwise, or for VSAs that don't
+record it, the policy is read as it was when the provenance was created and
+VSA policies from another source are reported as a policy mismatch.
+--policy-time and --policy-commit pin the evaluation to another version.
+
+Revisions are audited concurrently (see --workers) and reported in history
+order. Long audits can be resumed with --checkpoint: the progress is saved
+to the file after each revision and the file is removed when the audit
+reaches the first commit in the branch. Revisions that failed to audit are
+recorded in the checkpoint and audited again when resuming.
 `,
 		PreRunE: func(cmd *cobra.Command, args []string) error {
 			if len(args) > 0 {
@@ -156,7 +207,7 @@ Future:
 				return err
 			}
 
-			authenticator, err := CheckAuth()
+			authenticator, err := opts.checkAuth()
 			if err != nil {
 				return err
 			}
@@ -164,15 +215,35 @@ Future:
 			// Create a new sourcetool object
 			srctool, err := sourcetool.New(
 				sourcetool.WithAuthenticator(authenticator),
+				sourcetool.WithGitLabHosts(gitlabHosts...),
+				sourcetool.WithLocalBackend(opts.localBackend),
 				sourcetool.WithExpectedIdentity(opts.expectedIssuer, opts.expectedSan),
+				sourcetool.WithTrustPolicy(opts.trustPolicy),
+				sourcetool.WithTrustedRoot(opts.trustedRoot),
+				sourcetool.WithRequireTlog(opts.requireTlog),
 			)
 			if err != nil {
 				return err
 			}
 
+			// Deep audits evaluate the provenance against the policy again
+			var pe *policy.PolicyEvaluator
+			if opts.auditMode == AuditModeDeep {
+				pe = policy.NewPolicyEvaluator()
+				pe.UseLocalPolicy = opts.useLocalPolicy
+				pe.At = opts.PolicyPoint()
+				pe.Source, err = opts.PolicySource(authenticator)
+				if err != nil {
+					return err
+				}
+			}
+
 			auditor, err := audit.NewAuditor(
 				audit.WithAttester(srctool.Attester()),
 				audit.WithBackend(srctool.Backend()),
+				audit.WithWorkers(opts.workers),
+				audit.WithCheckpointFile(opts.checkpointFile),
+				audit.WithPolicyEvaluator(pe),
 			)
 			if err != nil {
 				return err
@@ -189,6 +260,16 @@ Future:
 				}
 			} else {
 				opts.writeTextf("Auditing branch %s\n", opts.branch)
+				if opts.checkpointFile != "" {
+					if cp, err := audit.LoadCheckpoint(opts.checkpointFile); err == nil && cp != nil {
+						if cp.Next != "" {
+							opts.writeTextf("Resuming from commit %s (%d revisions already audited)\n", cp.Next, cp.Audited)
+						}
+						if len(cp.Failed) > 0 {
+							opts.writeTextf("Retrying %d revisions that failed to audit\n", len(cp.Failed))
+						}
+					}
+				}
 			}
 
 			// Single loop for both JSON and text output
@@ -268,6 +349,9 @@ func printResult(owner, repo string, ar *audit.AuditCommitResult, mode AuditMode
 
 	if ar.VsaPred != nil {
 		fmt.Printf("\tvsa: %v\n", ar.VsaPred.GetVerifiedLevels())
+		for _, e := range ar.VsaTlogEntries {
+			fmt.Printf("\t\ttlog: %s\n", e)
+		}
 	} else {
 		fmt.Printf("\tvsa: none\n")
 	}
@@ -282,9 +366,25 @@ func printResult(owner, repo string, ar *audit.AuditCommitResult, mode AuditMode
 	} else {
 		fmt.Printf("\tprov: none\n")
 	}
+	for _, mp := range ar.MergedParents {
+		fmt.Printf("\tmerged: %s attested: %v\n", mp.Commit, mp.IsAttested())
+	}
 	if ar.ControlStatus != nil {
 		fmt.Printf("\tcontrols: %v\n", ar.ControlStatus.Controls)
 	}
+	if ar.Evaluation != nil {
+		fmt.Printf("\trecomputed: %v\n", ar.Evaluation.VerifiedLevels)
+		fmt.Printf("\tpolicy: %s %v\n", ar.Evaluation.PolicyPath, ar.Evaluation.PolicyVersion.DigestSet())
+		if ar.Evaluation.PolicyVersion != nil && ar.Evaluation.PolicyVersion.Latest {
+			fmt.Println("\tpolicy history: not available in the policy source, evaluated at the latest policy")
+		}
+		if ar.PolicyMismatch != "" {
+			fmt.Printf("\tpolicy mismatch: the VSA policy %s is not from the policy source\n", ar.PolicyMismatch)
+		}
+		if len(ar.Overclaims) > 0 {
+			fmt.Printf("\toverclaims: %v\n", ar.Overclaims)
+		}
+	}
 
 	fmt.Printf("\tlink: https://github.com/%s/%s/commit/%s\n", owner, repo, ar.PriorCommit)
 }
@@ -302,10 +402,11 @@ func convertAuditResultToJSON(owner, repo string, ar *audit.AuditCommitResult, m
 		Link:   fmt.Sprintf("https://github.com/%s/%s/commit/%s", owner, repo, ar.PriorCommit),
 	}
 
-	// Only include details if mode is Full or status is failed
-	if mode == AuditModeFull || !good {
+	// Only include details if mode is Full (or Deep) or status is failed
+	if mode != AuditModeBasic || !good {
 		if ar.VsaPred != nil {
 			result.VerifiedLevels = ar.VsaPred.GetVerifiedLevels()
+			result.TlogEntries = ar.VsaTlogEntries
 		}
 
 		if ar.ProvPred != nil {
@@ -316,9 +417,24 @@ func convertAuditResultToJSON(owner, repo string, ar *audit.AuditCommitResult, m
 			result.PrevCommitMatches = &matches
 		}
 
+		for _, mp := range ar.MergedParents {
+			result.MergedParents = append(result.MergedParents, MergedParentJSON{
+				Commit:   mp.Commit,
+				Attested: mp.IsAttested(),
+			})
+		}
+
 		if ar.ControlStatus != nil {
 			result.Controls = ar.ControlStatus.Controls
 		}
+
+		if ar.Evaluation != nil {
+			result.RecomputedLevels = slsa.ControlNamesToStrings(ar.Evaluation.VerifiedLevels)
+			result.Overclaims = slsa.ControlNamesToStrings(ar.Overclaims)
+			result.PolicyDigest = ar.Evaluation.PolicyVersion.DigestSet()
+			result.PolicyMismatch = ar.PolicyMismatch
+			result.PolicyLatest = ar.Evaluation.PolicyVersion != nil && ar.Evaluation.PolicyVersion.Latest
+		}
 	}
 
 	return result
@@ -20,12 +20,16 @@ import (
 type checkLevelOpts struct {
 	revisionOpts
 	allowMergeCommitsOptions
+	policySourceOptions
+	signingOptions
 	outputVsa, outputUnsignedVsa, useLocalPolicy string
 }
 
 func (clo *checkLevelOpts) Validate() error {
 	errs := []error{
 		clo.revisionOpts.Validate(),
+		clo.policySourceOptions.Validate(),
+		clo.signingOptions.Validate(),
 	}
 
 	return errors.Join(errs...)
@@ -34,6 +38,8 @@ func (clo *checkLevelOpts) Validate() error {
 func (clo *checkLevelOpts) AddFlags(cmd *cobra.Command) {
 	clo.commitOptions.AddFlags(cmd)
 	clo.allowMergeCommitsOptions.AddFlags(cmd)
+	clo.policySourceOptions.AddFlags(cmd)
+	clo.signingOptions.AddFlags(cmd)
 	cmd.PersistentFlags().StringVar(&clo.outputVsa, "output_vsa", "", "The path to write a signed VSA with the determined level.")
 	cmd.PersistentFlags().StringVar(&clo.outputUnsignedVsa, "output_unsigned_vsa", "", "The path to write an unsigned vsa with the determined level.")
 	cmd.PersistentFlags().StringVar(&clo.useLocalPolicy, "use_local_policy", "", "UNSAFE: Use the policy at this local path instead of the official one.")
@@ -80,7 +86,7 @@ This is meant to be run within the corresponding GitHub Actions workflow.`,
 			// Create a new sourcetool object
 			srctool, err := sourcetool.New(
 				sourcetool.WithAuthenticator(authenticator),
-				sourcetool.WithAllowMergeCommits(opts.allowMergeCommits),
+				sourcetool.WithGitLabHosts(gitlabHosts...),
 			)
 			if err != nil {
 				return err
@@ -93,6 +99,10 @@ This is meant to be run within the corresponding GitHub Actions workflow.`,
 
 			pe := policy.NewPolicyEvaluator()
 			pe.UseLocalPolicy = opts.useLocalPolicy
+			pe.Source, err = opts.PolicySource(authenticator)
+			if err != nil {
+				return err
+			}
 			result, err := pe.EvaluateControl(cmd.Context(), opts.GetRepository(), opts.GetBranch(), controlStatus)
 			if err != nil {
 				return err
@@ -110,9 +120,10 @@ This is meant to be run within the corresponding GitHub Actions workflow.`,
 				fmt.Fprintf(os.Stderr, "\nwarning: policy target level %s not met; achieved %s: %s\n",
 					result.Shortfall.TargetLevel, result.Shortfall.AchievedLevel, result.Shortfall.Reason)
 			}
+			warnFailedRules(os.Stderr, result.Rules)
 
 			unsignedVsa, err := attest.CreateUnsignedSourceVsa(
-				opts.GetBranch(), opts.GetCommit(), result.VerifiedLevels, result.PolicyPath,
+				opts.GetBranch(), opts.GetCommit(), result.VerifiedLevels, result.PolicyPath, result.PolicyVersion.DigestSet(),
 			)
 			if err != nil {
 				return err
@@ -125,7 +136,10 @@ This is meant to be run within the corresponding GitHub Actions workflow.`,
 
 			if opts.outputVsa != "" {
 				// This will output in the sigstore bundle format.
-				signedVsa, err := attest.Sign(unsignedVsa)
+				signedVsa, err := attest.NewBndSigner(attest.SigningOptions{
+					SigningConfig: opts.signingConfig,
+					Key:           opts.signingKey,
+				}).Sign(unsignedVsa)
 				if err != nil {
 					return err
 				}
@@ -60,8 +60,11 @@ func (po *pushOptions) AddFlags(cmd *cobra.Command) {
 type checkLevelProvOpts struct {
 	revisionOpts
 	verifierOptions
+	signingOptions
 	pushOptions
 	allowMergeCommitsOptions
+	policyVersionOptions
+	policySourceOptions
 	prevBundlePath       string
 	prevCommit           string
 	outputUnsignedBundle string
@@ -74,15 +77,21 @@ func (clp *checkLevelProvOpts) Validate() error {
 	return errors.Join([]error{
 		clp.revisionOpts.Validate(),
 		clp.verifierOptions.Validate(),
+		clp.signingOptions.Validate(),
 		clp.pushOptions.Validate(),
+		clp.policyVersionOptions.Validate(),
+		clp.policySourceOptions.Validate(),
 	}...)
 }
 
 func (clp *checkLevelProvOpts) AddFlags(cmd *cobra.Command) {
 	clp.revisionOpts.AddFlags(cmd)
 	clp.verifierOptions.AddFlags(cmd)
+	clp.signingOptions.AddFlags(cmd)
 	clp.pushOptions.AddFlags(cmd)
 	clp.allowMergeCommitsOptions.AddFlags(cmd)
+	clp.policyVersionOptions.AddFlags(cmd)
+	clp.policySourceOptions.AddFlags(cmd)
 	cmd.PersistentFlags().StringVar(&clp.prevBundlePath, "prev_bundle_path", "", "Path to the file with the attestations for the previous commit (as an in-toto bundle).")
 	cmd.PersistentFlags().StringVar(&clp.prevCommit, "prev_commit", "", "The commit to check.")
 	cmd.PersistentFlags().StringVar(&clp.outputUnsignedBundle, "output_unsigned_bundle", "", "The path to write a bundle of unsigned attestations.")
@@ -108,9 +117,15 @@ Based on the verification, the subcommand generates the commit's provenance
 attestation and a verification summary attestation which can optionally be
 signed using Sigstore.
 
+The policy is read from the latest version in the policy repository unless
+--policy-time or --policy-commit select an earlier one. The version used is
+recorded in the VSA policy field (its sha256 digest and commit).
+
 The signed attestations can be pushed to a storage repository: either to the
 GitHub attestations API (--push=github) or stored and in the commit's git notes
-and pushed to its remote (--push=note).
+and pushed to its remote (--push=note). Notes of GitLab repositories are
+pushed with the token in GITLAB_TOKEN, the GitHub attestations API is only
+available for GitHub repositories.
 `,
 		PreRunE: func(cmd *cobra.Command, args []string) error {
 			if len(args) > 0 {
@@ -161,7 +176,7 @@ and pushed to its remote (--push=note).
 			}
 
 			// Create the authenticator
-			authenticator, err := CheckAuth()
+			authenticator, err := opts.checkAuth()
 			if err != nil {
 				return err
 			}
@@ -169,10 +184,17 @@ and pushed to its remote (--push=note).
 			// Initialize sourcetool
 			srctool, err := sourcetool.New(
 				sourcetool.WithAuthenticator(authenticator),
+				sourcetool.WithGitLabHosts(gitlabHosts...),
+				sourcetool.WithLocalBackend(opts.localBackend),
 				sourcetool.WithExpectedIdentity(opts.expectedIssuer, opts.expectedSan),
-				sourcetool.WithAllowMergeCommits(opts.allowMergeCommits),
+				sourcetool.WithTrustPolicy(opts.trustPolicy),
+				sourcetool.WithTrustedRoot(opts.trustedRoot),
+				sourcetool.WithRequireTlog(opts.requireTlog),
+				sourcetool.WithSigningConfig(opts.signingConfig),
+				sourcetool.WithSigningKey(opts.signingKey),
 				sourcetool.WithNotesStorer(notesStorer),
 				sourcetool.WithGithubStorer(githubStorer),
+				sourcetool.WithPolicySources(opts.policySources...),
 			)
 			if err != nil {
 				return fmt.Errorf("creating sourcetool: %w", err)
@@ -182,6 +204,7 @@ and pushed to its remote (--push=note).
 			result, err := srctool.AttestRevision(
 				cmd.Context(), opts.GetBranch(), opts.GetRevision(),
 				sourcetool.WithLocalPolicy(opts.useLocalPolicy),
+				sourcetool.WithPolicyAt(opts.PolicyPoint()),
 				sourcetool.WithOutputPath(outputPath),
 				sourcetool.WithSign(signAttestation),
 				sourcetool.WithUseStdout(true),
@@ -192,6 +215,7 @@ and pushed to its remote (--push=note).
 			}
 
 			fmt.Print(result.VerifiedLevels.Levels())
+			warnFailedRules(os.Stderr, result.Rules)
 
 			// The attestations are generated (and optionally pushed) regardless
 			// of the policy outcome. If the achieved level is below the policy
@@ -6,6 +6,7 @@ package cmd
 import (
 	"errors"
 	"fmt"
+	"os"
 	"slices"
 
 	"github.com/spf13/cobra"
@@ -16,9 +17,11 @@ import (
 
 type checkTagOptions struct {
 	verifierOptions
+	signingOptions
 	revisionOpts
 	pushOptions
 	allowMergeCommitsOptions
+	policySourceOptions
 	actor                string
 	outputSignedBundle   string
 	outputUnsignedBundle string
@@ -30,7 +33,9 @@ func (cto *checkTagOptions) Validate() error {
 	errs := []error{
 		cto.revisionOpts.Validate(),
 		cto.verifierOptions.Validate(),
+		cto.signingOptions.Validate(),
 		cto.pushOptions.Validate(),
+		cto.policySourceOptions.Validate(),
 	}
 	return errors.Join(errs...)
 }
@@ -38,8 +43,10 @@ func (cto *checkTagOptions) Validate() error {
 func (cto *checkTagOptions) AddFlags(cmd *cobra.Command) {
 	cto.revisionOpts.AddFlags(cmd)
 	cto.verifierOptions.AddFlags(cmd)
+	cto.signingOptions.AddFlags(cmd)
 	cto.pushOptions.AddFlags(cmd)
 	cto.allowMergeCommitsOptions.AddFlags(cmd)
+	cto.policySourceOptions.AddFlags(cmd)
 	cmd.PersistentFlags().StringVar(&cto.actor, "actor", "", "The username of the actor that pushed the tag.")
 	cmd.PersistentFlags().StringVar(&cto.outputSignedBundle, "output_signed_bundle", "", "The path to write a bundle of signed attestations.")
 	cmd.PersistentFlags().StringVar(&cto.outputUnsignedBundle, "output_unsigned_bundle", "", "The path to write a bundle of unsigned attestations.")
@@ -115,7 +122,7 @@ func addCheckTag(parentCmd *cobra.Command) {
 			}
 
 			// Create the authenticator
-			authenticator, err := CheckAuth()
+			authenticator, err := opts.checkAuth()
 			if err != nil {
 				return err
 			}
@@ -123,10 +130,17 @@ func addCheckTag(parentCmd *cobra.Command) {
 			// Initialize sourcetool
 			srctool, err := sourcetool.New(
 				sourcetool.WithAuthenticator(authenticator),
+				sourcetool.WithGitLabHosts(gitlabHosts...),
+				sourcetool.WithLocalBackend(opts.localBackend),
 				sourcetool.WithExpectedIdentity(opts.expectedIssuer, opts.expectedSan),
-				sourcetool.WithAllowMergeCommits(opts.allowMergeCommits),
+				sourcetool.WithTrustPolicy(opts.trustPolicy),
+				sourcetool.WithTrustedRoot(opts.trustedRoot),
+				sourcetool.WithRequireTlog(opts.requireTlog),
+				sourcetool.WithSigningConfig(opts.signingConfig),
+				sourcetool.WithSigningKey(opts.signingKey),
 				sourcetool.WithNotesStorer(notesStorer),
 				sourcetool.WithGithubStorer(githubStorer),
+				sourcetool.WithPolicySources(opts.policySources...),
 			)
 			if err != nil {
 				return fmt.Errorf("creating sourcetool: %w", err)
@@ -146,6 +160,7 @@ func addCheckTag(parentCmd *cobra.Command) {
 			}
 
 			fmt.Print(result.VerifiedLevels.Levels())
+			warnFailedRules(os.Stderr, result.Rules)
 			return nil
 		},
 	}
@@ -0,0 +1,9 @@
+// SPDX-FileCopyrightText: Copyright 2026 The SLSA Authors
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build kms_azure
+
+package cmd
+
+// Registers the Azure Key Vault key URI scheme accepted by --signing-key
+import _ "github.com/sigstore/sigstore/pkg/signature/kms/azure"
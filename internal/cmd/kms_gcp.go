@@ -0,0 +1,9 @@
+// SPDX-FileCopyrightText: Copyright 2026 The SLSA Authors
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build kms_gcp
+
+package cmd
+
+// Registers the Google Cloud KMS key URI scheme accepted by --signing-key
+import _ "github.com/sigstore/sigstore/pkg/signature/kms/gcp"
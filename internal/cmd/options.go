@@ -8,19 +8,23 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/carabiner-dev/vcslocator"
 	"github.com/spf13/cobra"
 
 	"github.com/slsa-framework/source-tool/pkg/auth"
 	"github.com/slsa-framework/source-tool/pkg/ghcontrol"
+	"github.com/slsa-framework/source-tool/pkg/policy"
 	"github.com/slsa-framework/source-tool/pkg/sourcetool"
 	"github.com/slsa-framework/source-tool/pkg/sourcetool/models"
 )
 
 type repoOptions struct {
-	owner      string
-	repository string
+	hostname     string
+	owner        string
+	repository   string
+	localBackend string
 }
 
 func (ro *repoOptions) Validate() error {
@@ -43,6 +47,14 @@ func (ro *repoOptions) AddFlags(cmd *cobra.Command) {
 	cmd.PersistentFlags().StringVar(
 		&ro.owner, "owner", "", "user or oganization that owns the repo",
 	)
+
+	cmd.PersistentFlags().StringVar(
+		&ro.hostname, "hostname", githubHostname, "hostname of the system hosting the repository",
+	)
+
+	cmd.PersistentFlags().StringVar(
+		&ro.localBackend, "local-backend", "", "path to a local backend config to read the repository from disk",
+	)
 }
 
 func (ro *repoOptions) ParseSlug(lString string) error {
@@ -55,9 +67,26 @@ func (ro *repoOptions) ParseSlug(lString string) error {
 	return nil
 }
 
+// GetHostname returns the repository hostname, defaulting to GitHub
+func (ro *repoOptions) GetHostname() string {
+	if ro.hostname == "" {
+		return githubHostname
+	}
+	return ro.hostname
+}
+
+// checkAuth returns the authenticator, ensuring the user is logged in
+// unless running on a local repository, which needs no token.
+func (ro *repoOptions) checkAuth() (*auth.Authenticator, error) {
+	if ro.localBackend != "" {
+		return auth.New(), nil
+	}
+	return CheckAuth()
+}
+
 func (ro *repoOptions) GetRepository() *models.Repository {
 	return &models.Repository{
-		Hostname: "github.com",
+		Hostname: ro.GetHostname(),
 		Path:     fmt.Sprintf("%s/%s", ro.owner, ro.repository),
 	}
 }
@@ -83,11 +112,8 @@ func (bo *branchOptions) AddFlags(cmd *cobra.Command) {
 
 func (bo *branchOptions) GetBranch() *models.Branch {
 	return &models.Branch{
-		Name: bo.branch,
-		Repository: &models.Repository{
-			Hostname: "github.com",
-			Path:     fmt.Sprintf("%s/%s", bo.owner, bo.repository),
-		},
+		Name:       bo.branch,
+		Repository: bo.GetRepository(),
 	}
 }
 
@@ -108,6 +134,12 @@ func (bo *branchOptions) ParseLocator(lString string) error {
 		return err
 	}
 
+	// Bare slugs default to github.com in the locator, only honor
+	// the hostname when it is explicit in the locator string.
+	if components.Hostname != "" && strings.Contains(lString, "://") {
+		bo.hostname = components.Hostname
+	}
+
 	if components.Branch != "" {
 		bo.branch = components.Branch
 	}
@@ -127,6 +159,8 @@ func (bo *branchOptions) EnsureDefaults() error {
 	// Create a new sourcetool object
 	srctool, err := sourcetool.New(
 		sourcetool.WithAuthenticator(auth.New()),
+		sourcetool.WithGitLabHosts(gitlabHosts...),
+		sourcetool.WithLocalBackend(bo.localBackend),
 	)
 	if err != nil {
 		return err
@@ -173,6 +207,12 @@ func (co *commitOptions) ParseLocator(lString string) error {
 		return err
 	}
 
+	// Bare slugs default to github.com in the locator, only honor
+	// the hostname when it is explicit in the locator string.
+	if components.Hostname != "" && strings.Contains(lString, "://") {
+		co.hostname = components.Hostname
+	}
+
 	if components.Commit != "" {
 		co.commit = components.Commit
 	}
@@ -189,6 +229,22 @@ func (co *commitOptions) EnsureDefaults() error {
 		return fmt.Errorf("fetching default branch of %s/%s: %w", co.owner, co.repository, err)
 	}
 
+	if co.commit == "" && (co.GetHostname() != githubHostname || co.localBackend != "") {
+		srctool, err := sourcetool.New(
+			sourcetool.WithAuthenticator(auth.New()),
+			sourcetool.WithGitLabHosts(gitlabHosts...),
+			sourcetool.WithLocalBackend(co.localBackend),
+		)
+		if err != nil {
+			return err
+		}
+		commit, err := srctool.Backend().GetLatestCommit(context.Background(), co.GetRepository(), co.GetBranch())
+		if err != nil {
+			return fmt.Errorf("fetching last commit from %q: %w", co.branch, err)
+		}
+		co.commit = commit.SHA
+	}
+
 	if co.commit == "" {
 		t := githubToken
 		var err error
@@ -215,18 +271,52 @@ func (co *commitOptions) GetCommit() *models.Commit {
 	}
 }
 
+// githubHostname is the default hostname of repositories
+const githubHostname = "github.com"
+
 type verifierOptions struct {
 	expectedIssuer string
 	expectedSan    string
+	trustPolicy    string
+	trustedRoot    string
+	requireTlog    bool
 }
 
 func (vo *verifierOptions) Validate() error {
+	if vo.trustPolicy != "" && (vo.expectedIssuer != "" || vo.expectedSan != "") {
+		return errors.New("--trust-policy cannot be combined with --expected_issuer or --expected_san")
+	}
+	if vo.requireTlog && vo.trustedRoot == "" {
+		return errors.New("--require-tlog needs a --trusted-root to verify the log entries offline")
+	}
 	return nil
 }
 
 func (vo *verifierOptions) AddFlags(cmd *cobra.Command) {
 	cmd.PersistentFlags().StringVar(&vo.expectedIssuer, "expected_issuer", "", "The expected issuer of the attestation signer certificate")
 	cmd.PersistentFlags().StringVar(&vo.expectedSan, "expected_san", "", "The expected SAN string in the attestation signer certificate")
+	cmd.PersistentFlags().StringVar(&vo.trustPolicy, "trust-policy", "", "path to a trust policy file listing the accepted attestation signers")
+	cmd.PersistentFlags().StringVar(&vo.trustedRoot, "trusted-root", "", "path to the sigstore trusted root to verify attestations with, defaults to the public sigstore instance")
+	cmd.PersistentFlags().BoolVar(&vo.requireTlog, "require-tlog", false, "require attestations to have a transparency log entry, verified offline against the --trusted-root")
+}
+
+// signingOptions configure the sigstore instance or key attestations are
+// signed with
+type signingOptions struct {
+	signingConfig string
+	signingKey    string
+}
+
+func (so *signingOptions) Validate() error {
+	if so.signingConfig != "" && so.signingKey != "" {
+		return errors.New("--signing-config and --signing-key cannot be used together")
+	}
+	return nil
+}
+
+func (so *signingOptions) AddFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVar(&so.signingConfig, "signing-config", "", "path to the sigstore signing config of a private instance to sign attestations with")
+	cmd.PersistentFlags().StringVar(&so.signingKey, "signing-key", "", "path to a private key, a KMS key URI (awskms://, gcpkms://, azurekms://, hashivault://) or a PKCS#11 URI (pkcs11:) to sign attestations with")
 }
 
 type tagOptions struct {
@@ -318,6 +408,8 @@ func (ro *revisionOpts) EnsureDefaults() error {
 
 		srctool, err := sourcetool.New(
 			sourcetool.WithAuthenticator(auth.New()),
+			sourcetool.WithGitLabHosts(gitlabHosts...),
+			sourcetool.WithLocalBackend(ro.localBackend),
 		)
 		if err != nil {
 			return err
@@ -344,6 +436,8 @@ func (ro *revisionOpts) EnsureDefaults() error {
 	if ro.commit == "" && ro.tag == "" {
 		srctool, err := sourcetool.New(
 			sourcetool.WithAuthenticator(auth.New()),
+			sourcetool.WithGitLabHosts(gitlabHosts...),
+			sourcetool.WithLocalBackend(ro.localBackend),
 		)
 		if err != nil {
 			return err
@@ -365,5 +459,67 @@ type allowMergeCommitsOptions struct {
 
 // AddFlags adds the subcommands flags
 func (o *allowMergeCommitsOptions) AddFlags(cmd *cobra.Command) {
-	cmd.PersistentFlags().BoolVar(&o.allowMergeCommits, "allow-merge-commits", false, "[EXPERIMENTAL] Allow merge commits in branch.")
+	cmd.PersistentFlags().BoolVar(&o.allowMergeCommits, "allow-merge-commits", false, "Allow merge commits in branch.")
+	// Merge commits are always supported now, the flag is kept to not
+	// break existing scripts.
+	cmd.PersistentFlags().MarkDeprecated("allow-merge-commits", "merge commits are always supported") //nolint:errcheck,gosec
+}
+
+// policyVersionOptions select the version of the policy to evaluate against
+type policyVersionOptions struct {
+	policyTime   string
+	policyCommit string
+}
+
+// AddFlags adds the subcommands flags
+func (o *policyVersionOptions) AddFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVar(&o.policyTime, "policy-time", "", "Evaluate against the policy as it was at this time (RFC 3339). Sources without history (https://) use their latest policy.")
+	cmd.PersistentFlags().StringVar(&o.policyCommit, "policy-commit", "", "Evaluate against the policy at this commit of the policy repository.")
+}
+
+func (o *policyVersionOptions) Validate() error {
+	if o.policyTime == "" {
+		return nil
+	}
+	if _, err := time.Parse(time.RFC3339, o.policyTime); err != nil {
+		return fmt.Errorf("invalid policy time: %w", err)
+	}
+	return nil
+}
+
+// PolicyPoint returns the version of the policy selected in the flags
+func (o *policyVersionOptions) PolicyPoint() policy.PolicyPoint {
+	at := policy.PolicyPoint{Commit: o.policyCommit}
+	if t, err := time.Parse(time.RFC3339, o.policyTime); err == nil {
+		at.Time = &t
+	}
+	return at
+}
+
+// policySourceOptions configure the locations policies are read from
+type policySourceOptions struct {
+	policySources []string
+}
+
+// AddFlags adds the subcommands flags
+func (o *policySourceOptions) AddFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringSliceVar(
+		&o.policySources, "policy-source", []string{},
+		"Read policies from these locations, in order of precedence (github:owner/repo, repo:[path], file:path or an https:// URL).",
+	)
+}
+
+func (o *policySourceOptions) Validate() error {
+	if _, err := policy.NewSourceChain(o.policySources, nil); err != nil {
+		return fmt.Errorf("invalid policy source: %w", err)
+	}
+	return nil
+}
+
+// PolicySource returns the source chain set in the flags, nil if none was set
+func (o *policySourceOptions) PolicySource(a *auth.Authenticator) (policy.PolicySource, error) {
+	if len(o.policySources) == 0 {
+		return nil, nil
+	}
+	return policy.NewSourceChain(o.policySources, a.GetGitHubClient)
 }
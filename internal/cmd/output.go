@@ -10,6 +10,8 @@ import (
 	"os"
 
 	"github.com/spf13/cobra"
+
+	"github.com/slsa-framework/source-tool/pkg/policy"
 )
 
 const (
@@ -75,3 +77,18 @@ func (oo *outputOptions) writeResult(v interface{}) error {
 	fmt.Fprintf(oo.getWriter(), "%v\n", v)
 	return nil
 }
+
+// warnFailedRules prints a warning for each policy rule that did not pass.
+// Failing required rules stop the evaluation, so these are informational.
+func warnFailedRules(w io.Writer, rules []*policy.RuleResult) {
+	for _, r := range rules {
+		if r.Passed {
+			continue
+		}
+		if r.Error != "" {
+			fmt.Fprintf(w, "\nwarning: policy rule %q could not be evaluated: %s\n", r.Name, r.Error)
+			continue
+		}
+		fmt.Fprintf(w, "\nwarning: policy rule %q failed\n", r.Name)
+	}
+}
@@ -8,19 +8,218 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/url"
 	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
-	"google.golang.org/protobuf/proto"
 	"sigs.k8s.io/release-utils/helpers"
 
+	"github.com/slsa-framework/source-tool/pkg/audit"
 	"github.com/slsa-framework/source-tool/pkg/policy"
+	"github.com/slsa-framework/source-tool/pkg/slsa"
 	"github.com/slsa-framework/source-tool/pkg/sourcetool"
 	"github.com/slsa-framework/source-tool/pkg/sourcetool/models"
 )
 
 type policyViewOpts struct {
 	repoOptions
+	policySourceOptions
+}
+
+func (pvo *policyViewOpts) Validate() error {
+	return errors.Join(
+		pvo.repoOptions.Validate(),
+		pvo.policySourceOptions.Validate(),
+	)
+}
+
+// AddFlags adds the subcommands flags
+func (pvo *policyViewOpts) AddFlags(cmd *cobra.Command) {
+	pvo.repoOptions.AddFlags(cmd)
+	pvo.policySourceOptions.AddFlags(cmd)
+}
+
+type policyLintOpts struct {
+	repoOptions
+	policySourceOptions
+	outputOptions
+	file    string
+	offline bool
+}
+
+func (plo *policyLintOpts) Validate() error {
+	errs := []error{
+		plo.policySourceOptions.Validate(),
+		plo.outputOptions.Validate(),
+	}
+	// Linting a file offline needs no repository
+	if plo.file == "" || !plo.offline {
+		errs = append(errs, plo.repoOptions.Validate())
+	}
+	return errors.Join(errs...)
+}
+
+// AddFlags adds the subcommands flags
+func (plo *policyLintOpts) AddFlags(cmd *cobra.Command) {
+	plo.repoOptions.AddFlags(cmd)
+	plo.policySourceOptions.AddFlags(cmd)
+	plo.outputOptions.AddFlags(cmd)
+	cmd.PersistentFlags().StringVarP(&plo.file, "file", "f", "", "lint the policy in this file instead of the published one")
+	cmd.PersistentFlags().BoolVar(&plo.offline, "offline", false, "only check the policy itself, not against the live repository")
+}
+
+// ParseCanonicalRepo sets the repository from the canonical_repo of a policy
+func (plo *policyLintOpts) ParseCanonicalRepo(pcy *policy.RepoPolicy) error {
+	u, err := url.Parse(pcy.GetCanonicalRepo())
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("policy canonical_repo %q is not a repository URL", pcy.GetCanonicalRepo())
+	}
+	plo.hostname = u.Host
+	return plo.ParseSlug(strings.TrimSuffix(u.Path, ".git"))
+}
+
+type policySimulateOpts struct {
+	branchOptions
+	verifierOptions
+	policySourceOptions
+	outputOptions
+	file  string
+	from  string
+	to    string
+	depth int
+}
+
+func (pso *policySimulateOpts) Validate() error {
+	errs := []error{
+		pso.branchOptions.Validate(),
+		pso.verifierOptions.Validate(),
+		pso.policySourceOptions.Validate(),
+		pso.outputOptions.Validate(),
+	}
+	if pso.file == "" {
+		errs = append(errs, errors.New("the candidate policy file is required (--file)"))
+	}
+	if pso.depth < 0 {
+		errs = append(errs, errors.New("depth cannot be negative"))
+	}
+	return errors.Join(errs...)
+}
+
+// AddFlags adds the subcommands flags
+func (pso *policySimulateOpts) AddFlags(cmd *cobra.Command) {
+	pso.branchOptions.AddFlags(cmd)
+	pso.verifierOptions.AddFlags(cmd)
+	pso.policySourceOptions.AddFlags(cmd)
+	pso.outputOptions.AddFlags(cmd)
+	cmd.PersistentFlags().StringVarP(&pso.file, "file", "f", "", "the candidate policy file to simulate")
+	cmd.PersistentFlags().StringVar(&pso.to, "to", "", "latest commit to simulate (defaults to the head of the branch)")
+	cmd.PersistentFlags().StringVar(&pso.from, "from", "", "oldest commit to simulate")
+	cmd.PersistentFlags().IntVar(&pso.depth, "depth", 50, "max number of commits to simulate (0 for no limit)")
+}
+
+// PolicyOutcomeJSON is the evaluation of a commit under a policy
+type PolicyOutcomeJSON struct {
+	Passed         bool     `json:"passed"`
+	VerifiedLevels []string `json:"verified_levels,omitempty"`
+	Shortfall      string   `json:"shortfall,omitempty"`
+	Error          string   `json:"error,omitempty"`
+}
+
+// SimulationCommitJSON compares the evaluation of a commit under the
+// current and the candidate policies
+type SimulationCommitJSON struct {
+	Commit    string             `json:"commit"`
+	Status    string             `json:"status"`
+	Current   *PolicyOutcomeJSON `json:"current,omitempty"`
+	Candidate *PolicyOutcomeJSON `json:"candidate,omitempty"`
+}
+
+// SimulationSummary counts the simulated commits by outcome
+type SimulationSummary struct {
+	TotalCommits     int `json:"total_commits"`
+	NoProvenance     int `json:"no_provenance"`
+	FailingCurrent   int `json:"failing_current"`
+	FailingCandidate int `json:"failing_candidate"`
+	Regressed        int `json:"regressed"`
+}
+
+// SimulationResultJSON is the result of a policy simulation
+type SimulationResultJSON struct {
+	Repository string                 `json:"repository"`
+	Branch     string                 `json:"branch"`
+	Candidate  string                 `json:"candidate"`
+	Commits    []SimulationCommitJSON `json:"commits"`
+	Summary    SimulationSummary      `json:"summary"`
+}
+
+func (sr *SimulationResultJSON) add(res *audit.SimulationResult) {
+	sr.Commits = append(sr.Commits, SimulationCommitJSON{
+		Commit:    res.Commit,
+		Status:    string(res.Status()),
+		Current:   convertPolicyOutcome(res.Current),
+		Candidate: convertPolicyOutcome(res.Candidate),
+	})
+	sr.Summary.TotalCommits++
+	if res.ProvPred == nil {
+		sr.Summary.NoProvenance++
+		return
+	}
+	if res.Status() == audit.SimulationRegressed {
+		sr.Summary.Regressed++
+	}
+	if !res.Current.Passed() {
+		sr.Summary.FailingCurrent++
+	}
+	if !res.Candidate.Passed() {
+		sr.Summary.FailingCandidate++
+	}
+}
+
+func convertPolicyOutcome(po *audit.PolicyOutcome) *PolicyOutcomeJSON {
+	if po == nil {
+		return nil
+	}
+	ret := &PolicyOutcomeJSON{Passed: po.Passed()}
+	if po.Error != nil {
+		ret.Error = po.Error.Error()
+		return ret
+	}
+	ret.VerifiedLevels = slsa.ControlNamesToStrings(po.Evaluation.VerifiedLevels)
+	if po.Evaluation.Shortfall != nil {
+		ret.Shortfall = po.Evaluation.Shortfall.Reason
+	}
+	return ret
+}
+
+func (sr *SimulationResultJSON) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Simulating %s on %s branch %s\n", sr.Candidate, sr.Repository, sr.Branch)
+	for _, c := range sr.Commits {
+		fmt.Fprintf(&sb, "commit: %s - %s\n", c.Commit, c.Status)
+		if c.Current == nil {
+			continue
+		}
+		writeOutcome(&sb, "current", c.Current)
+		writeOutcome(&sb, "candidate", c.Candidate)
+	}
+	fmt.Fprintf(&sb,
+		"\n%d commits simulated, %d without provenance, %d fail the current policy, %d fail the candidate, %d regressed\n",
+		sr.Summary.TotalCommits, sr.Summary.NoProvenance, sr.Summary.FailingCurrent,
+		sr.Summary.FailingCandidate, sr.Summary.Regressed,
+	)
+	return sb.String()
+}
+
+func writeOutcome(sb *strings.Builder, name string, po *PolicyOutcomeJSON) {
+	if po.Error != "" {
+		fmt.Fprintf(sb, "\t%s: error: %s\n", name, po.Error)
+		return
+	}
+	fmt.Fprintf(sb, "\t%s: %v\n", name, po.VerifiedLevels)
+	if po.Shortfall != "" {
+		fmt.Fprintf(sb, "\t\tshortfall: %s\n", po.Shortfall)
+	}
 }
 
 type policyCreateOpts struct {
@@ -28,6 +227,20 @@ type policyCreateOpts struct {
 	interactive     bool
 	openPullRequest bool
 	update          bool
+	branchPattern   string
+	extraBranches   []string
+}
+
+// GetBranches returns the branch in the locator and the extra branches
+func (pco *policyCreateOpts) GetBranches() []*models.Branch {
+	branches := []*models.Branch{pco.GetBranch()}
+	for _, name := range pco.extraBranches {
+		if name == pco.branch {
+			continue
+		}
+		branches = append(branches, &models.Branch{Name: name, Repository: pco.GetRepository()})
+	}
+	return branches
 }
 
 func (pco *policyCreateOpts) AddFlags(cmd *cobra.Command) {
@@ -35,6 +248,8 @@ func (pco *policyCreateOpts) AddFlags(cmd *cobra.Command) {
 	cmd.PersistentFlags().BoolVar(&pco.openPullRequest, "pr", true, "Open a pull request to check-in the policy")
 	cmd.PersistentFlags().BoolVar(&pco.interactive, "interactive", true, "confirm before performing changes")
 	cmd.PersistentFlags().BoolVar(&pco.update, "update", false, "update if existing policy found")
+	cmd.PersistentFlags().StringVar(&pco.branchPattern, "branch-pattern", "", "protect all branches matching this pattern (eg release/*), using the branch as reference")
+	cmd.PersistentFlags().StringSliceVar(&pco.extraBranches, "branches", []string{}, "additional branches to protect in the policy")
 }
 
 func addPolicy(parentCmd *cobra.Command) {
@@ -55,8 +270,16 @@ policy repo.
 Creates a new policy for a repository and, optionally, check it into the
 SLSA community repository.
 
+%s
+Checks a policy for errors and verifies the repository controls meet it.
+
+%s
+Compares how the history of a branch evaluates under a candidate policy and
+under the current one.
+
 `, w("sourcetool policy:"), w2("configure SLSA source policies for a repo"),
-			w("sourcetool policy view"), w("sourcetool policy create")),
+			w("sourcetool policy view"), w("sourcetool policy create"),
+			w("sourcetool policy lint"), w("sourcetool policy simulate")),
 		Use:           "policy",
 		SilenceUsage:  true,
 		SilenceErrors: true,
@@ -64,6 +287,8 @@ SLSA community repository.
 
 	addPolicyView(policyCmd)
 	addPolicyCreate(policyCmd)
+	addPolicyLint(policyCmd)
+	addPolicySimulate(policyCmd)
 	parentCmd.AddCommand(policyCmd)
 }
 
@@ -73,6 +298,14 @@ func addPolicyView(parent *cobra.Command) {
 		Short: "view the policy of a repository",
 		Long: `The view subcommand retrieves the policy stored in the SLSA community
 repository for a repository and displays it.
+
+Policies can be read from other locations with --policy-source. The flag can
+be repeated, the first source with a policy for the repository wins:
+
+  github:owner/repo   a policy repository with the community repo layout
+  repo:[path]         a file in the repository (.slsa/source-policy.json)
+  file:path           a local policy file or a clone of a policy repository
+  https://host/path   an endpoint serving the policy repository layout
 `,
 		Use:           "view owner/repo",
 		SilenceUsage:  false,
@@ -108,7 +341,9 @@ repository for a repository and displays it.
 			// Create a new sourcetool object
 			srctool, err := sourcetool.New(
 				sourcetool.WithAuthenticator(authenticator),
+				sourcetool.WithGitLabHosts(gitlabHosts...),
 				// sourcetool.WithPolicyRepo(opts.policyRepo),
+				sourcetool.WithPolicySources(opts.policySources...),
 			)
 			if err != nil {
 				return err
@@ -140,6 +375,16 @@ and creates a new policy for it. By default it will create a pull request
 in the community source policy repository. If you choose not to, it will
 just print the generated policy.
 
+To protect a family of branches, pass --branch-pattern with a glob (eg
+'release/*'). The controls of the branch in the command line are used as
+the reference to compute the policy for all the branches matching it.
+
+More branches can be protected at once with --branches. When the repository
+already has a policy, the new branch entries are merged into it: branches
+not being updated are kept as they are and existing entries are only
+changed when their target level goes up. The level of an existing entry is
+never lowered. The changes to the policy are shown before opening the pull
+request.
 `,
 		Use:           "create owner/repo@branch",
 		SilenceUsage:  false,
@@ -177,10 +422,15 @@ just print the generated policy.
 			}
 
 			fmt.Println()
-			fmt.Println(w(fmt.Sprintf("Creating source policy for %s#%s", opts.GetRepository().GetHttpURL(), opts.GetBranch().Name)))
+			names := []string{}
+			for _, b := range opts.GetBranches() {
+				names = append(names, b.Name)
+			}
+			fmt.Println(w(fmt.Sprintf("Creating source policy for %s#%s", opts.GetRepository().GetHttpURL(), strings.Join(names, ","))))
 			// Create a new sourcetool object
 			srctool, err := sourcetool.New(
 				sourcetool.WithAuthenticator(authenticator),
+				sourcetool.WithGitLabHosts(gitlabHosts...),
 				// Uncomment when we want to support custom policy repos
 				// sourcetool.WithPolicyRepo(opts.policyRepo),
 				sourcetool.WithCreatePolicyPR(opts.openPullRequest),
@@ -216,22 +466,40 @@ just print the generated policy.
 				}
 			}
 
+			// New branch entries are merged into the existing policy
+			policyOpts := []sourcetool.PolicyOpFn{
+				sourcetool.WithBranchPattern(opts.branchPattern),
+				sourcetool.WithBasePolicy(epcy),
+			}
+
 			// If there is an existing policy, check if something changed.
 			var pcy *policy.RepoPolicy
 			pcy, err = srctool.CreateBranchPolicy(
-				context.Background(), opts.GetRepository(), []*models.Branch{opts.GetBranch()},
+				context.Background(), opts.GetRepository(), opts.GetBranches(), policyOpts...,
 			)
 			if err != nil {
 				return fmt.Errorf("creating new source policy: %w", err)
 			}
+
+			diff, err := policy.DiffPolicies(epcy, pcy)
+			if err != nil {
+				return fmt.Errorf("comparing policies: %w", err)
+			}
+			if len(diff) == 0 {
+				fmt.Println()
+				fmt.Printf("Repository policy has not changed. All done.")
+				fmt.Println()
+				return nil
+			}
+
+			fmt.Println()
 			if epcy != nil {
-				if proto.Equal(pcy, epcy) {
-					fmt.Println()
-					fmt.Printf("Repository policy has not changed. All done.")
-					fmt.Println()
-					return nil
-				}
+				fmt.Println(w("Changes to the repository policy:"))
+			} else {
+				fmt.Println(w("New repository policy:"))
 			}
+			fmt.Println()
+			fmt.Print(diff.String())
 
 			if opts.openPullRequest && opts.interactive {
 				if err := ensureOrCreatePolicyFork(srctool); err != nil {
@@ -262,13 +530,13 @@ open the pull request from there.
 				}
 			}
 
-			// Create the policy, this will open the pull request in the community
-			// repo if the options say so.
-			_, pr, err := srctool.CreateRepositoryPolicy(
-				context.Background(), opts.GetRepository(), []*models.Branch{opts.GetBranch()},
-			)
-			if err != nil {
-				return err
+			// Open the pull request with the same policy shown in the diff
+			var pr *models.PullRequest
+			if opts.openPullRequest {
+				pr, err = srctool.CreatePolicyPR(opts.GetRepository(), pcy)
+				if err != nil {
+					return err
+				}
 			}
 
 			if err := displayPolicy(opts.repoOptions, pcy); err != nil {
@@ -287,6 +555,231 @@ open the pull request from there.
 	parent.AddCommand(policyViewCmd)
 }
 
+// addPolicyLint adds the lint subcommand
+func addPolicyLint(parent *cobra.Command) {
+	opts := &policyLintOpts{}
+	policyLintCmd := &cobra.Command{
+		Short: "checks a source policy for problems",
+		Long: `The lint subcommand validates a source policy and reports its problems,
+each with a severity (error, warning or info).
+
+The policy is checked for malformed entries (invalid levels, property names
+not starting with ORG_SOURCE_, since dates in the future, bad patterns and
+rules). Unless --offline is set, it is also checked against the live
+repository: the branches in the policy must exist, the required checks must
+be required by the repository rulesets and the controls must have been in
+force since the dates in the policy.
+
+By default the published policy of the repository is linted. To lint a policy
+before merging it, pass the file with --file. When no repository is given, the
+canonical_repo of the policy is checked.
+
+The command exits with code 2 when the policy has errors.
+`,
+		Use:           "lint [owner/repo]",
+		SilenceUsage:  false,
+		SilenceErrors: true,
+		PreRunE: func(_ *cobra.Command, args []string) error {
+			if len(args) > 0 {
+				if err := opts.ParseSlug(args[0]); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			var pcy *policy.RepoPolicy
+			if opts.file != "" {
+				data, err := os.ReadFile(opts.file)
+				if err != nil {
+					return fmt.Errorf("reading policy: %w", err)
+				}
+				pcy, err = policy.ParsePolicy(data)
+				if err != nil {
+					return &exitError{code: 2, err: fmt.Errorf("parsing policy %s: %w", opts.file, err)}
+				}
+				if len(args) == 0 && !opts.offline {
+					if err := opts.ParseCanonicalRepo(pcy); err != nil {
+						return err
+					}
+				}
+			}
+
+			if err := opts.Validate(); err != nil {
+				return err
+			}
+
+			// At this point options are valid, no help needed.
+			cmd.SilenceUsage = true
+
+			var findings policy.Findings
+			if opts.file == "" || !opts.offline {
+				authenticator, err := opts.checkAuth()
+				if err != nil {
+					return err
+				}
+
+				// Create a new sourcetool object
+				srctool, err := sourcetool.New(
+					sourcetool.WithAuthenticator(authenticator),
+					sourcetool.WithGitLabHosts(gitlabHosts...),
+					sourcetool.WithLocalBackend(opts.localBackend),
+					sourcetool.WithPolicySources(opts.policySources...),
+				)
+				if err != nil {
+					return err
+				}
+
+				if pcy == nil {
+					pcy, err = srctool.GetRepositoryPolicy(cmd.Context(), opts.GetRepository())
+					if err != nil {
+						return err
+					}
+					if pcy == nil {
+						return fmt.Errorf("no source policy found for %s", opts.GetRepository().Path)
+					}
+				}
+
+				if !opts.offline {
+					findings, err = srctool.LintPolicy(cmd.Context(), opts.GetRepository(), pcy)
+					if err != nil {
+						return err
+					}
+				}
+			}
+			if findings == nil {
+				findings = policy.Validate(pcy)
+			}
+
+			if err := opts.writeResult(findings); err != nil {
+				return err
+			}
+			if findings.HasErrors() {
+				return &exitError{code: 2, err: errors.New("the policy has errors")}
+			}
+			if !opts.outputFormatIsJSON() && len(findings) == 0 {
+				opts.writeTextf("No problems found in the policy\n")
+			}
+			return nil
+		},
+	}
+	opts.AddFlags(policyLintCmd)
+	parent.AddCommand(policyLintCmd)
+}
+
+// addPolicySimulate adds the simulate subcommand
+func addPolicySimulate(parent *cobra.Command) {
+	opts := &policySimulateOpts{}
+	policySimulateCmd := &cobra.Command{
+		Short: "simulates a candidate policy on the branch history",
+		Long: `The simulate subcommand is a dry run of a policy change. It reads the
+provenance stored for the commits in the branch history and evaluates it
+against a candidate policy file and against the current policy of the
+repository. No attestations are created or pushed.
+
+For each commit the levels achieved, the shortfall from the target level and
+any hard errors (evaluations that would not issue a VSA) are reported under
+both policies. Commits that pass the current policy but not the candidate are
+reported as regressed.
+
+The history is walked from --to (the head of the branch by default) following
+the first parent of each commit, down to the --from commit or until --depth
+commits have been simulated.
+
+The command exits with code 2 when any commit regressed.
+`,
+		Use:           "simulate [owner/repo[@branch]]",
+		SilenceUsage:  false,
+		SilenceErrors: true,
+		PreRunE: func(_ *cobra.Command, args []string) error {
+			if len(args) > 0 {
+				if err := opts.ParseLocator(args[0]); err != nil {
+					return err
+				}
+			}
+			if err := opts.repoOptions.Validate(); err != nil {
+				return err
+			}
+			return opts.EnsureDefaults()
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := opts.Validate(); err != nil {
+				return err
+			}
+
+			// At this point options are valid, no help needed.
+			cmd.SilenceUsage = true
+
+			// Check the candidate before walking the history
+			data, err := os.ReadFile(opts.file)
+			if err != nil {
+				return fmt.Errorf("reading policy: %w", err)
+			}
+			if _, err := policy.ParsePolicy(data); err != nil {
+				return &exitError{code: 2, err: fmt.Errorf("parsing policy %s: %w", opts.file, err)}
+			}
+
+			authenticator, err := opts.checkAuth()
+			if err != nil {
+				return err
+			}
+
+			srctool, err := sourcetool.New(
+				sourcetool.WithAuthenticator(authenticator),
+				sourcetool.WithGitLabHosts(gitlabHosts...),
+				sourcetool.WithLocalBackend(opts.localBackend),
+				sourcetool.WithExpectedIdentity(opts.expectedIssuer, opts.expectedSan),
+				sourcetool.WithTrustPolicy(opts.trustPolicy),
+				sourcetool.WithTrustedRoot(opts.trustedRoot),
+				sourcetool.WithRequireTlog(opts.requireTlog),
+			)
+			if err != nil {
+				return err
+			}
+
+			current := policy.NewPolicyEvaluator()
+			current.Source, err = opts.PolicySource(authenticator)
+			if err != nil {
+				return err
+			}
+			candidate := policy.NewPolicyEvaluator()
+			candidate.UseLocalPolicy = opts.file
+
+			auditor, err := audit.NewAuditor(
+				audit.WithAttester(srctool.Attester()),
+				audit.WithBackend(srctool.Backend()),
+			)
+			if err != nil {
+				return err
+			}
+
+			result := &SimulationResultJSON{
+				Repository: opts.GetRepository().Path,
+				Branch:     opts.branch,
+				Candidate:  opts.file,
+				Commits:    []SimulationCommitJSON{},
+			}
+			r := audit.SimulationRange{From: opts.from, To: opts.to, Depth: opts.depth}
+			for res, err := range auditor.SimulatePolicy(cmd.Context(), opts.GetBranch(), current, candidate, r) {
+				if err != nil {
+					return err
+				}
+				result.add(res)
+			}
+
+			if err := opts.writeResult(result); err != nil {
+				return err
+			}
+			if result.Summary.Regressed > 0 {
+				return &exitError{code: 2, err: fmt.Errorf("%d commits regressed under the candidate policy", result.Summary.Regressed)}
+			}
+			return nil
+		},
+	}
+	opts.AddFlags(policySimulateCmd)
+	parent.AddCommand(policySimulateCmd)
+}
+
 func displayPolicy(opts repoOptions, pcy *policy.RepoPolicy) error {
 	if pcy == nil {
 		fmt.Println("\n" + w(fmt.Sprintf("✖️  No source policy found for %s/%s", opts.owner, opts.repository)))
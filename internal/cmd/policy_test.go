@@ -0,0 +1,65 @@
+// SPDX-FileCopyrightText: Copyright 2026 The SLSA Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/slsa-framework/source-tool/pkg/audit"
+	"github.com/slsa-framework/source-tool/pkg/policy"
+	"github.com/slsa-framework/source-tool/pkg/provenance"
+	"github.com/slsa-framework/source-tool/pkg/slsa"
+)
+
+func TestSimulationResultJSON(t *testing.T) {
+	t.Parallel()
+	passed := &audit.PolicyOutcome{Evaluation: &policy.EvaluationResult{
+		VerifiedLevels: slsa.SourceVerifiedLevels{slsa.ControlName(slsa.SlsaSourceLevel2)},
+	}}
+	shortfall := &audit.PolicyOutcome{Evaluation: &policy.EvaluationResult{
+		VerifiedLevels: slsa.SourceVerifiedLevels{slsa.ControlName(slsa.SlsaSourceLevel2)},
+		Shortfall:      &policy.PolicyShortfall{Reason: "missing review"},
+	}}
+	failed := &audit.PolicyOutcome{Error: errors.New("check not enforced")}
+	prov := &provenance.SourceProvenancePred{}
+
+	result := &SimulationResultJSON{Commits: []SimulationCommitJSON{}}
+	result.add(&audit.SimulationResult{Commit: "a", ProvPred: prov, Current: passed, Candidate: shortfall})
+	result.add(&audit.SimulationResult{Commit: "b", ProvPred: prov, Current: passed, Candidate: failed})
+	result.add(&audit.SimulationResult{Commit: "c", ProvPred: prov, Current: shortfall, Candidate: passed})
+	result.add(&audit.SimulationResult{Commit: "d"})
+
+	require.Equal(t, SimulationSummary{
+		TotalCommits:     4,
+		NoProvenance:     1,
+		FailingCurrent:   1,
+		FailingCandidate: 2,
+		Regressed:        2,
+	}, result.Summary)
+	require.Equal(t, []SimulationCommitJSON{
+		{
+			Commit:    "a",
+			Status:    string(audit.SimulationRegressed),
+			Current:   &PolicyOutcomeJSON{Passed: true, VerifiedLevels: []string{"SLSA_SOURCE_LEVEL_2"}},
+			Candidate: &PolicyOutcomeJSON{VerifiedLevels: []string{"SLSA_SOURCE_LEVEL_2"}, Shortfall: "missing review"},
+		},
+		{
+			Commit:    "b",
+			Status:    string(audit.SimulationRegressed),
+			Current:   &PolicyOutcomeJSON{Passed: true, VerifiedLevels: []string{"SLSA_SOURCE_LEVEL_2"}},
+			Candidate: &PolicyOutcomeJSON{Error: "check not enforced"},
+		},
+		{
+			Commit:    "c",
+			Status:    string(audit.SimulationImproved),
+			Current:   &PolicyOutcomeJSON{VerifiedLevels: []string{"SLSA_SOURCE_LEVEL_2"}, Shortfall: "missing review"},
+			Candidate: &PolicyOutcomeJSON{Passed: true, VerifiedLevels: []string{"SLSA_SOURCE_LEVEL_2"}},
+		},
+		{Commit: "d", Status: string(audit.SimulationNoProvenance)},
+	}, result.Commits)
+	require.Contains(t, result.String(), "4 commits simulated, 1 without provenance, 1 fail the current policy, 2 fail the candidate, 2 regressed")
+}
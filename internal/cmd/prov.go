@@ -66,7 +66,7 @@ func addProv(parentCmd *cobra.Command) {
 				return fmt.Errorf("validating options: %w", err)
 			}
 
-			authenticator, err := CheckAuth()
+			authenticator, err := opts.checkAuth()
 			if err != nil {
 				return err
 			}
@@ -74,8 +74,12 @@ func addProv(parentCmd *cobra.Command) {
 			// Create a new sourcetool object
 			srctool, err := sourcetool.New(
 				sourcetool.WithAuthenticator(authenticator),
+				sourcetool.WithGitLabHosts(gitlabHosts...),
+				sourcetool.WithLocalBackend(opts.localBackend),
 				sourcetool.WithExpectedIdentity(opts.expectedIssuer, opts.expectedSan),
-				sourcetool.WithAllowMergeCommits(opts.allowMergeCommits),
+				sourcetool.WithTrustPolicy(opts.trustPolicy),
+				sourcetool.WithTrustedRoot(opts.trustedRoot),
+				sourcetool.WithRequireTlog(opts.requireTlog),
 			)
 			if err != nil {
 				return err
@@ -11,9 +11,15 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/slsa-framework/source-tool/pkg/auth"
+	"github.com/slsa-framework/source-tool/pkg/sourcetool/backends/vcs/gitlab"
 )
 
-var githubToken string
+var (
+	githubToken string
+
+	// gitlabHosts are the hostnames of the GitLab instances to talk to
+	gitlabHosts []string
+)
 
 // Command group IDs used to organize the subcommands in the help screen.
 const (
@@ -51,6 +57,9 @@ controls and much more.
 	}
 
 	rootCmd.PersistentFlags().StringVar(&githubToken, "github_token", "", "the github token to use for auth")
+	rootCmd.PersistentFlags().StringSliceVar(
+		&gitlabHosts, "gitlab-host", []string{gitlab.Hostname}, "hostnames of the GitLab instances hosting repositories (repeatable)",
+	)
 
 	// Define command groups for better organization
 	rootCmd.AddGroup(
@@ -74,6 +83,7 @@ controls and much more.
 
 	// Verification commands
 	addVerifyCommit(rootCmd)
+	addVerifyBundle(rootCmd)
 	addAudit(rootCmd)
 
 	// Assessment commands
@@ -4,9 +4,14 @@
 package cmd
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"os"
 	"slices"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"sigs.k8s.io/release-utils/helpers"
@@ -19,15 +24,17 @@ type setupOpts struct {
 	branchOptions
 	policyRepo  string
 	userForkOrg string
+	planFile    string
 	enforce     bool
 	interactive bool
+	plan        bool
 }
 
 func (so *setupOpts) AddFlags(cmd *cobra.Command) {
 	so.branchOptions.AddFlags(cmd)
 
 	cmd.PersistentFlags().BoolVar(
-		&so.enforce, "enforce", false, "create enforcement rules",
+		&so.enforce, "enforce", true, "create enforcement rules, set to false to stage them in evaluate mode",
 	)
 	cmd.PersistentFlags().StringVar(
 		&so.userForkOrg, "user-fork", "", "GitHub organization to look for forks of repos (for pull requests)",
@@ -40,6 +47,14 @@ func (so *setupOpts) AddFlags(cmd *cobra.Command) {
 	cmd.PersistentFlags().BoolVar(
 		&so.interactive, "interactive", true, "confirm before performing changes",
 	)
+
+	cmd.PersistentFlags().BoolVar(
+		&so.plan, "plan", false, "print the changes to the repository without making them",
+	)
+
+	cmd.PersistentFlags().StringVar(
+		&so.planFile, "plan-file", "", "save the plan to a file to run it later with sourcetool setup apply",
+	)
 }
 
 // Validate checks the options in context with arguments
@@ -47,6 +62,9 @@ func (so *setupOpts) Validate() error {
 	errs := []error{
 		so.branchOptions.Validate(),
 	}
+	if so.planFile != "" && !so.plan {
+		errs = append(errs, errors.New("--plan-file can only be used with --plan"))
+	}
 	return errors.Join(errs...)
 }
 
@@ -61,7 +79,7 @@ The setup subcommands can be used to protect a repository with the
 SLSA Source tooling by automatically configuring the required security
 controls in a repository.
 
-The setup family has two subcommands:
+The setup family has five subcommands:
 
 %s
 A "one shot" setup process enabling all the security controls required
@@ -73,8 +91,21 @@ Enables fine-grained control when configuring security features in a
 repository. The setup control subcommand can configure each security
 control individually.
 
+%s
+Enforces the rules staged with --enforce=false after reviewing the pushes
+they would have blocked.
+
+%s
+Removes the rules and the provenance workflow set up by sourcetool from
+a repository.
+
+%s
+Makes the changes saved in a plan file created by running setup repo or
+setup controls with --plan.
+
 `, w("sourcetool setup:"), w2("configure SLSA source controls on a repository"),
-			w("sourcetool setup repo"), w("sourcetool setup controls")),
+			w("sourcetool setup repo"), w("sourcetool setup controls"), w("sourcetool setup promote"),
+			w("sourcetool setup remove"), w("sourcetool setup apply")),
 		Use:           "setup",
 		SilenceUsage:  true,
 		SilenceErrors: true,
@@ -82,6 +113,9 @@ control individually.
 
 	AddSetupRepo(setupCmd)
 	AddSetupControls(setupCmd)
+	AddSetupPromote(setupCmd)
+	AddSetupRemove(setupCmd)
+	AddSetupApply(setupCmd)
 	parentCmd.AddCommand(setupCmd)
 }
 
@@ -101,7 +135,15 @@ variable called GITHUB_TOKEN. The token needs admin permissions on the repo
 to configure the branch rules.
 
 If the SLSA controls are already enforce in the repository they will be left
-untouched.
+untouched. If configuring a control fails, the controls set up by the command
+are removed to leave the repository as it was.
+
+To roll out the rules without disrupting the repository, run with --enforce=false
+to stage them in evaluate mode. Staged rules don't block pushes, once you are
+confident they will not disrupt your workflows run: sourcetool setup promote.
+
+To review the exact changes before making them, run with --plan. Add
+--plan-file to save the plan and apply it later with: sourcetool setup apply.
 
 Alternatively, to enable each control individually use: sourcetool setup controls.
 
@@ -144,6 +186,7 @@ Alternatively, to enable each control individually use: sourcetool setup control
 			// Create a new sourcetool object
 			srctool, err := sourcetool.New(
 				sourcetool.WithAuthenticator(authenticator),
+				sourcetool.WithGitLabHosts(gitlabHosts...),
 				sourcetool.WithEnforce(opts.enforce),
 				sourcetool.WithUserForkOrg(opts.userForkOrg),
 				sourcetool.WithPolicyRepo(opts.policyRepo),
@@ -152,43 +195,18 @@ Alternatively, to enable each control individually use: sourcetool setup control
 				return err
 			}
 
-			// Check the control prerequisites
-			preReqOut := false
-			for _, cc := range []models.ControlConfiguration{
-				models.CONFIG_TAG_RULES, models.CONFIG_GEN_PROVENANCE, models.CONFIG_BRANCH_RULES,
-			} {
-				ok, actionDescr, remediateFn, err := srctool.ControlPrecheck(
-					cmd.Context(), opts.GetBranch().Repository, []*models.Branch{opts.GetBranch()}, cc,
-				)
-				if err != nil {
-					return fmt.Errorf("checking prerequisites for %s: %w", cc, err)
-				}
-
-				if !ok {
-					if !preReqOut {
-						fmt.Println()
-						fmt.Println("🟠 " + w("Prerequisites Check:"))
-						preReqOut = true
-					}
-					fmt.Println(">> " + actionDescr)
-					fmt.Println()
-
-					_, s, err := helpers.Ask("Type 'yes' if you want to continue", "yes|no|no", 3)
-					if err != nil {
-						return err
-					}
-
-					if !s {
-						return fmt.Errorf("prerequisites for %s not met", cc)
-					}
-
-					msg, err := remediateFn()
-					if err != nil {
-						return err
-					}
+			if opts.plan {
+				return runPlan(cmd.Context(), srctool, opts.GetBranch(), sourcetool.OnboardConfigurations, opts.planFile)
+			}
 
-					fmt.Printf("☑️  %s\n", msg)
-				}
+			// Check the control prerequisites
+			if err := checkPrerequisites(
+				cmd.Context(), srctool, opts.GetBranch().Repository, []*models.Branch{opts.GetBranch()},
+				[]models.ControlConfiguration{
+					models.CONFIG_TAG_RULES, models.CONFIG_GEN_PROVENANCE, models.CONFIG_BRANCH_RULES,
+				}, true,
+			); err != nil {
+				return err
 			}
 
 			if opts.interactive {
@@ -292,6 +310,11 @@ SLSA source level 2+.
 Configures udpate, push and delete protection for all tags in the repository,
 this is required to reach SLSA source level 2+. 
 
+%s
+Requires pull requests to the branch to be approved by a code owner other than
+the last pusher, this is required to reach SLSA source level 4. The branch needs
+a CODEOWNERS file assigning owners to all the files in the repository.
+
 %s
 Opens a pull request in the repository to add the provenance generation workflow
 after every push. 
@@ -300,6 +323,12 @@ after every push.
 Opens a pull request on the SLSA policy repository to check in a SLSA Source 
 policy for the repository.
 
+Planning the changes
+
+Run with --plan to print the exact rulesets, workflow and policy that
+sourcetool would create or modify, without touching the repository. Add
+--plan-file to save the plan and apply it later with: sourcetool setup apply.
+
 Setting up repository forks
 
 The controls that open pull requests require that you have a fork of the
@@ -307,7 +336,7 @@ repositories. Make sure you have a fork of the SLSA source policy repo and
 a fork of the repository you want to protect.
 
 `, w("sourcetool setup controls"), w2("configure a repository for SLSA source"),
-			w2(models.CONFIG_BRANCH_RULES), w2(models.CONFIG_TAG_RULES),
+			w2(models.CONFIG_BRANCH_RULES), w2(models.CONFIG_TAG_RULES), w2(models.CONFIG_REVIEW_RULES),
 			w2(models.CONFIG_GEN_PROVENANCE), w2(models.CONFIG_POLICY)),
 		Use:           "controls owner/repo --config=CONTROL1 --config=CONTROL2",
 		SilenceUsage:  false,
@@ -347,6 +376,7 @@ a fork of the repository you want to protect.
 			// Create a new sourcetool object
 			srctool, err := sourcetool.New(
 				sourcetool.WithAuthenticator(authenticator),
+				sourcetool.WithGitLabHosts(gitlabHosts...),
 				// Uncomment when we support other policy repo
 				// sourcetool.WithPolicyRepo(opts.policyRepo),
 				sourcetool.WithUserForkOrg(opts.userForkOrg),
@@ -355,6 +385,15 @@ a fork of the repository you want to protect.
 			if err != nil {
 				return err
 			}
+
+			if opts.plan {
+				cs := []models.ControlConfiguration{}
+				for _, c := range opts.configs {
+					cs = append(cs, models.ControlConfiguration(c))
+				}
+				return runPlan(cmd.Context(), srctool, opts.GetBranch(), cs, opts.planFile)
+			}
+
 			cs := []models.ControlConfiguration{}
 			if opts.interactive {
 				// Check if we need the policy fork
@@ -378,6 +417,9 @@ a fork of the repository you want to protect.
 					}
 
 					if !ok {
+						if remediateFn == nil {
+							return fmt.Errorf("prerequisites for %s not met:\n%s", cc, actionDescr)
+						}
 						if !preReqOut {
 							fmt.Println()
 							fmt.Println("🟠 " + w("Prerequisites Check:"))
@@ -426,13 +468,16 @@ a fork of the repository you want to protect.
 				for _, c := range opts.configs {
 					cc := models.ControlConfiguration(c)
 					// Run the prerequisites and run any remediations
-					ok, _, remediateFn, err := srctool.ControlPrecheck(
+					ok, actionDescr, remediateFn, err := srctool.ControlPrecheck(
 						cmd.Context(), opts.GetBranch().Repository, []*models.Branch{opts.GetBranch()}, cc,
 					)
 					if err != nil {
 						return fmt.Errorf("checking prerequisites for %q: %w", cc, err)
 					}
 					if !ok {
+						if remediateFn == nil {
+							return fmt.Errorf("prerequisites for %s not met:\n%s", cc, actionDescr)
+						}
 						msg, err := remediateFn()
 						if err != nil {
 							return fmt.Errorf("running remedaition for %q prereqs: %w", cc, err)
@@ -468,3 +513,522 @@ a fork of the repository you want to protect.
 	opts.AddFlags(setupControlsCmd)
 	parent.AddCommand(setupControlsCmd)
 }
+
+type setupPromoteOpts struct {
+	branchOptions
+	interactive bool
+}
+
+func (so *setupPromoteOpts) AddFlags(cmd *cobra.Command) {
+	so.branchOptions.AddFlags(cmd)
+
+	cmd.PersistentFlags().BoolVar(
+		&so.interactive, "interactive", true, "confirm before performing changes",
+	)
+}
+
+// Validate checks the options in context with arguments
+func (so *setupPromoteOpts) Validate() error {
+	return so.repoOptions.Validate()
+}
+
+func AddSetupPromote(parent *cobra.Command) {
+	opts := &setupPromoteOpts{}
+	setupPromoteCmd := &cobra.Command{
+		Short: "enforce the SLSA rules staged in evaluate mode",
+		Long: fmt.Sprintf(`
+%s %s
+
+The setup promote subcommand switches the rulesets staged by running setup
+with --enforce=false from evaluate mode to active.
+
+Before promoting, sourcetool lists the pushes of the last month that the
+staged rules would have blocked if they were active. Review them to make
+sure enforcing the rules will not disrupt the repository workflows.
+
+`, w("sourcetool setup promote"), w2("enforce the rules staged in a repository")),
+		Use:           "promote owner/repo",
+		SilenceUsage:  false,
+		SilenceErrors: true,
+		PreRunE: func(_ *cobra.Command, args []string) error {
+			if len(args) > 0 {
+				if err := opts.ParseLocator(args[0]); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			if err := opts.Validate(); err != nil {
+				return err
+			}
+
+			// At this point options are valid, no help needed.
+			cmd.SilenceUsage = true
+
+			authenticator, err := CheckAuth()
+			if err != nil {
+				return err
+			}
+
+			srctool, err := sourcetool.New(
+				sourcetool.WithAuthenticator(authenticator),
+				sourcetool.WithGitLabHosts(gitlabHosts...),
+			)
+			if err != nil {
+				return err
+			}
+
+			failures, err := srctool.GetStagedRuleFailures(cmd.Context(), opts.GetRepository())
+			if err != nil {
+				return err
+			}
+
+			fmt.Println()
+			if len(failures) == 0 {
+				fmt.Println("✅ The staged rules would not have blocked any push in the last month.")
+			} else {
+				fmt.Printf("🟠 %s\n", w(fmt.Sprintf("The staged rules would have blocked %d pushes in the last month:", len(failures))))
+				fmt.Println()
+				for _, f := range failures {
+					fmt.Printf("  %s %s %s..%s by %s\n", f.PushedAt.Format(time.RFC3339), f.Ref, f.Before, f.After, f.Actor)
+					for _, r := range f.Rules {
+						fmt.Printf("    - %s\n", r)
+					}
+				}
+			}
+			fmt.Println()
+
+			if opts.interactive {
+				fmt.Printf("sourcetool is about to enforce the rules staged in %s.\n\n", opts.GetRepository().Path)
+				_, s, err := helpers.Ask("Type 'yes' if you want to continue", "yes|no|no", 3)
+				if err != nil {
+					return err
+				}
+
+				if !s {
+					fmt.Println("Cancelled.")
+					return nil
+				}
+			}
+
+			promoted, err := srctool.PromoteControls(cmd.Context(), opts.GetRepository())
+			for _, name := range promoted {
+				fmt.Printf("✅ Ruleset %q is now enforced\n", name)
+			}
+			if err != nil {
+				if errors.Is(err, models.ErrRepositoryAccessDenied) {
+					fmt.Printf("\n   🔐 %s sourcetool does not have access to %s\n\n", colorHiRed("Error:"), opts.GetRepository().Path)
+					return nil
+				}
+				return err
+			}
+
+			if len(promoted) == 0 {
+				fmt.Printf("ℹ️  No staged rules found in %s\n", opts.GetRepository().Path)
+			}
+			fmt.Println()
+			return nil
+		},
+	}
+	opts.AddFlags(setupPromoteCmd)
+	parent.AddCommand(setupPromoteCmd)
+}
+
+type setupRemoveOpts struct {
+	branchOptions
+	configs     []string
+	interactive bool
+}
+
+func (so *setupRemoveOpts) AddFlags(cmd *cobra.Command) {
+	so.branchOptions.AddFlags(cmd)
+
+	cmd.PersistentFlags().StringSliceVar(
+		&so.configs, "config", []string{}, "control to remove, defaults to all the controls set up by sourcetool",
+	)
+	cmd.PersistentFlags().BoolVar(
+		&so.interactive, "interactive", true, "confirm before performing changes",
+	)
+}
+
+// Validate checks the options in context with arguments
+func (so *setupRemoveOpts) Validate() error {
+	errs := []error{
+		so.repoOptions.Validate(),
+	}
+	for _, c := range so.configs {
+		if c == string(models.CONFIG_POLICY) || !slices.Contains(sourcetool.ControlConfigurations, models.ControlConfiguration(c)) {
+			errs = append(errs, fmt.Errorf("unknown configuration: %q", c))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// removalDescr describes what removing a control configuration does
+func removalDescr(config models.ControlConfiguration) string {
+	//nolint:exhaustive // The policy is not removed
+	switch config {
+	case models.CONFIG_BRANCH_RULES:
+		return "Delete the branch rules created by sourcetool"
+	case models.CONFIG_TAG_RULES:
+		return "Delete the tag rules created by sourcetool"
+	case models.CONFIG_REVIEW_RULES:
+		return "Delete the review rules created by sourcetool"
+	case models.CONFIG_GEN_PROVENANCE:
+		return "Close the provenance workflow pull request or open a pull request removing the workflow"
+	default:
+		return string(config)
+	}
+}
+
+func AddSetupRemove(parent *cobra.Command) {
+	opts := &setupRemoveOpts{}
+	setupRemoveCmd := &cobra.Command{
+		Short: "remove the SLSA controls set up by sourcetool",
+		Long: fmt.Sprintf(`
+%s %s
+
+The setup remove subcommand undoes what setup repo and setup controls did
+in a repository. It deletes the rulesets created by sourcetool and, if the
+provenance workflow pull request is still open, closes it. When the workflow
+was already merged, a pull request to remove it is opened.
+
+Rules not created by sourcetool are never removed. Use --config to only
+remove some of the controls. The repository policy is not removed, to
+stop protecting the repository delete it from the policy repository.
+
+`, w("sourcetool setup remove"), w2("remove the SLSA controls from a repository")),
+		Use:           "remove owner/repo [--config=CONTROL]",
+		SilenceUsage:  false,
+		SilenceErrors: true,
+		PreRunE: func(_ *cobra.Command, args []string) error {
+			if len(args) > 0 {
+				if err := opts.ParseLocator(args[0]); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			if err := opts.Validate(); err != nil {
+				return err
+			}
+
+			// At this point options are valid, no help needed.
+			cmd.SilenceUsage = true
+
+			authenticator, err := CheckAuth()
+			if err != nil {
+				return err
+			}
+
+			srctool, err := sourcetool.New(
+				sourcetool.WithAuthenticator(authenticator),
+				sourcetool.WithGitLabHosts(gitlabHosts...),
+			)
+			if err != nil {
+				return err
+			}
+
+			managed, err := srctool.GetManagedControls(cmd.Context(), opts.GetRepository())
+			if err != nil {
+				return err
+			}
+
+			cs := []models.ControlConfiguration{}
+			for _, cc := range managed {
+				if len(opts.configs) == 0 || slices.Contains(opts.configs, string(cc)) {
+					cs = append(cs, cc)
+				}
+			}
+
+			if len(cs) == 0 {
+				fmt.Printf("\nℹ️  No controls set up by sourcetool found in %s\n\n", opts.GetRepository().Path)
+				return nil
+			}
+
+			if opts.interactive {
+				fmt.Println()
+				fmt.Println("sourcetool is about to perform the following actions on your behalf:")
+				fmt.Println()
+				for _, cc := range cs {
+					fmt.Printf("  - %s.\n", removalDescr(cc))
+				}
+				fmt.Println()
+
+				_, s, err := helpers.Ask("Type 'yes' if you want to continue", "yes|no|no", 3)
+				if err != nil {
+					return err
+				}
+
+				if !s {
+					fmt.Println("Cancelled.")
+					return nil
+				}
+			}
+
+			if err := srctool.RemoveControls(cmd.Context(), opts.GetRepository(), cs); err != nil {
+				if errors.Is(err, models.ErrRepositoryAccessDenied) {
+					fmt.Printf("\n   🔐 %s sourcetool does not have access to %s\n\n", colorHiRed("Error:"), opts.GetRepository().Path)
+					return nil
+				}
+				return err
+			}
+
+			fmt.Println()
+			fmt.Println(w("✅ Controls have been removed successfully."))
+			fmt.Println()
+			return nil
+		},
+	}
+	opts.AddFlags(setupRemoveCmd)
+	parent.AddCommand(setupRemoveCmd)
+}
+
+// checkPrerequisites runs the prechecks of the control configurations and
+// the remediations they return. When interactive, the user is asked before
+// running each remediation.
+func checkPrerequisites(
+	ctx context.Context, srctool *sourcetool.Tool, r *models.Repository, branches []*models.Branch,
+	configs []models.ControlConfiguration, interactive bool,
+) error {
+	preReqOut := false
+	for _, cc := range configs {
+		ok, actionDescr, remediateFn, err := srctool.ControlPrecheck(ctx, r, branches, cc)
+		if err != nil {
+			return fmt.Errorf("checking prerequisites for %s: %w", cc, err)
+		}
+		if ok {
+			continue
+		}
+		if remediateFn == nil {
+			return fmt.Errorf("prerequisites for %s not met:\n%s", cc, actionDescr)
+		}
+
+		if interactive {
+			if !preReqOut {
+				fmt.Println()
+				fmt.Println("🟠 " + w("Prerequisites Check:"))
+				preReqOut = true
+			}
+			fmt.Println(">> " + actionDescr)
+			fmt.Println()
+
+			_, s, err := helpers.Ask("Type 'yes' if you want to continue", "yes|no|no", 3)
+			if err != nil {
+				return err
+			}
+
+			if !s {
+				return fmt.Errorf("prerequisites for %s not met", cc)
+			}
+		}
+
+		msg, err := remediateFn()
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("☑️  %s\n", msg)
+	}
+	return nil
+}
+
+// runPlan prints the changes that configuring the controls would make and
+// optionally saves the plan to a file.
+func runPlan(
+	ctx context.Context, srctool *sourcetool.Tool, branch *models.Branch,
+	configs []models.ControlConfiguration, planFile string,
+) error {
+	plan, err := srctool.PlanControls(ctx, branch.Repository, []*models.Branch{branch}, configs)
+	if err != nil {
+		return fmt.Errorf("planning changes: %w", err)
+	}
+
+	if err := printPlan(plan); err != nil {
+		return err
+	}
+
+	if planFile == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling plan: %w", err)
+	}
+	if err := os.WriteFile(planFile, data, 0o644); err != nil { //nolint:gosec
+		return fmt.Errorf("writing plan file: %w", err)
+	}
+	fmt.Printf("Plan saved to %s, to make the changes run:\n\n", planFile)
+	fmt.Printf("  %s\n\n", w2("sourcetool setup apply --plan-file="+planFile))
+	return nil
+}
+
+// printPlan prints the changes in a plan with the diffs of the resources
+func printPlan(plan *models.ControlPlan) error {
+	fmt.Println()
+	fmt.Printf("%s %s (%s)\n", w("Changes planned for"), plan.Repository, strings.Join(plan.Branches, ", "))
+	fmt.Println()
+
+	for _, c := range plan.Changes {
+		switch c.Action {
+		case models.PlanActionNoop:
+			fmt.Printf("  = %s: no changes, %s\n\n", c.Config, c.Reason)
+			continue
+		case models.PlanActionUpdate:
+			fmt.Printf("  ~ update %s %q (%s)\n", c.Resource, c.Name, c.Config)
+		default:
+			if c.Resource == models.PlanResourceFile {
+				fmt.Printf("  + create %s %s in a pull request (%s)\n", c.Resource, c.Name, c.Config)
+			} else {
+				fmt.Printf("  + create %s %q (%s)\n", c.Resource, c.Name, c.Config)
+			}
+		}
+
+		diff, err := c.Diff()
+		if err != nil {
+			return fmt.Errorf("diffing %s: %w", c.Config, err)
+		}
+		for l := range strings.SplitSeq(strings.TrimSuffix(diff, "\n"), "\n") {
+			fmt.Println("      " + l)
+		}
+		fmt.Println()
+	}
+	return nil
+}
+
+type setupApplyOpts struct {
+	planFile    string
+	interactive bool
+}
+
+func (so *setupApplyOpts) AddFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVar(
+		&so.planFile, "plan-file", "", "plan file created by running setup with --plan",
+	)
+	cmd.PersistentFlags().BoolVar(
+		&so.interactive, "interactive", true, "confirm before performing changes",
+	)
+}
+
+// Validate checks the options in context with arguments
+func (so *setupApplyOpts) Validate() error {
+	if so.planFile == "" {
+		return errors.New("a plan file must be specified with --plan-file")
+	}
+	return nil
+}
+
+func AddSetupApply(parent *cobra.Command) {
+	opts := &setupApplyOpts{}
+	setupApplyCmd := &cobra.Command{
+		Short: "make the changes saved in a plan file",
+		Long: fmt.Sprintf(`
+%s %s
+
+The setup apply subcommand makes the changes in a plan created by running
+setup repo or setup controls with --plan and --plan-file. The rulesets,
+workflow and policy are created exactly as they are in the plan.
+
+Before changing anything, sourcetool checks the repository has not changed
+since the plan was made. If it did, nothing is applied and the changes need
+to be planned again. If a change fails, the rulesets created and the
+workflow pull request opened by the plan are removed.
+
+`, w("sourcetool setup apply"), w2("apply a plan to a repository")),
+		Use:           "apply --plan-file=plan.json",
+		SilenceUsage:  false,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			if err := opts.Validate(); err != nil {
+				return err
+			}
+
+			// At this point options are valid, no help needed.
+			cmd.SilenceUsage = true
+
+			data, err := os.ReadFile(opts.planFile)
+			if err != nil {
+				return fmt.Errorf("reading plan file: %w", err)
+			}
+			plan := &models.ControlPlan{}
+			if err := json.Unmarshal(data, plan); err != nil {
+				return fmt.Errorf("parsing plan file: %w", err)
+			}
+
+			authenticator, err := CheckAuth()
+			if err != nil {
+				return err
+			}
+
+			srctool, err := sourcetool.New(
+				sourcetool.WithAuthenticator(authenticator),
+				sourcetool.WithGitLabHosts(gitlabHosts...),
+			)
+			if err != nil {
+				return err
+			}
+
+			if err := printPlan(plan); err != nil {
+				return err
+			}
+
+			cs := []models.ControlConfiguration{}
+			for _, c := range plan.Changes {
+				if c.Action != models.PlanActionNoop {
+					cs = append(cs, c.Config)
+				}
+			}
+			if len(cs) == 0 {
+				fmt.Println("ℹ️  The plan has no changes to make.")
+				fmt.Println()
+				return nil
+			}
+
+			if opts.interactive && slices.Contains(cs, models.CONFIG_POLICY) {
+				if err := ensureOrCreatePolicyFork(srctool); err != nil {
+					return err
+				}
+			}
+
+			if err := checkPrerequisites(
+				cmd.Context(), srctool, plan.GetRepository(), plan.GetBranches(), cs, opts.interactive,
+			); err != nil {
+				return err
+			}
+
+			if opts.interactive {
+				fmt.Printf("sourcetool is about to make the changes above in %s.\n\n", plan.Repository)
+				_, s, err := helpers.Ask("Type 'yes' if you want to continue", "yes|no|no", 3)
+				if err != nil {
+					return err
+				}
+
+				if !s {
+					fmt.Println("Cancelled.")
+					return nil
+				}
+			}
+
+			if err := srctool.ApplyPlan(cmd.Context(), plan); err != nil {
+				if errors.Is(err, models.ErrRepositoryAccessDenied) {
+					fmt.Printf("\n   🔐 %s sourcetool does not have access to %s\n\n", colorHiRed("Error:"), plan.Repository)
+					return nil
+				}
+				if errors.Is(err, models.ErrPlanOutdated) {
+					return fmt.Errorf("%w, run setup with --plan again to review the changes", err)
+				}
+				return fmt.Errorf("applying plan: %w", err)
+			}
+
+			fmt.Println()
+			fmt.Println(w("✅ The plan has been applied successfully."))
+			fmt.Println()
+			return nil
+		},
+	}
+	opts.AddFlags(setupApplyCmd)
+	parent.AddCommand(setupApplyCmd)
+}
@@ -104,6 +104,7 @@ sourcetool status myorg/myrepo@mybranch
 			// Create a new sourcetool object
 			srctool, err := sourcetool.New(
 				sourcetool.WithAuthenticator(authenticator),
+				sourcetool.WithGitLabHosts(gitlabHosts...),
 			)
 			if err != nil {
 				return err
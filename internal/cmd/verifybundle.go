@@ -0,0 +1,157 @@
+// SPDX-FileCopyrightText: Copyright 2026 The SLSA Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/slsa-framework/source-tool/pkg/attest"
+	"github.com/slsa-framework/source-tool/pkg/slsa"
+	"github.com/slsa-framework/source-tool/pkg/sourcetool/models"
+)
+
+type verifyBundleOptions struct {
+	verifierOptions
+	outputOptions
+	bundle  string
+	commit  string
+	repoURI string
+	require []string
+}
+
+// VerifyBundleResult represents the result of verifying a commit against
+// an attestation bundle
+type VerifyBundleResult struct {
+	Success        bool     `json:"success"`
+	Commit         string   `json:"commit"`
+	Repository     string   `json:"repository"`
+	Bundle         string   `json:"bundle"`
+	VerifiedLevels []string `json:"verified_levels,omitempty"`
+	Message        string   `json:"message,omitempty"`
+}
+
+// String implements fmt.Stringer for text output
+func (v VerifyBundleResult) String() string {
+	if !v.Success {
+		return fmt.Sprintf("FAILED: %s\n", v.Message)
+	}
+	return fmt.Sprintf("SUCCESS: commit %s of %s verified with %v\n", v.Commit, v.Repository, v.VerifiedLevels)
+}
+
+func (vbo *verifyBundleOptions) Validate() error {
+	errs := []error{
+		vbo.verifierOptions.Validate(),
+		vbo.outputOptions.Validate(),
+	}
+	if vbo.bundle == "" {
+		errs = append(errs, errors.New("path to the attestation bundle not set"))
+	}
+	if vbo.commit == "" {
+		errs = append(errs, errors.New("commit digest must be set"))
+	}
+	if vbo.repoURI == "" {
+		errs = append(errs, errors.New("repository URI must be set"))
+	}
+	if vbo.trustedRoot == "" {
+		errs = append(errs, errors.New("a trusted root is required to verify offline"))
+	}
+	return errors.Join(errs...)
+}
+
+func (vbo *verifyBundleOptions) AddFlags(cmd *cobra.Command) {
+	vbo.verifierOptions.AddFlags(cmd)
+	vbo.outputOptions.AddFlags(cmd)
+	cmd.PersistentFlags().StringVarP(&vbo.bundle, "bundle", "b", "", "path to a JSONL bundle of signed attestations")
+	cmd.PersistentFlags().StringVarP(&vbo.commit, "commit", "c", "", "commit digest (sha1)")
+	cmd.PersistentFlags().StringVar(&vbo.repoURI, "repo", "", "URI of the repository, eg https://github.com/owner/repo")
+	cmd.PersistentFlags().StringSliceVar(&vbo.require, "require", []string{}, "SLSA levels or controls the VSA must verify, a level is met by higher ones")
+}
+
+// verificationOptions returns the attestation verifier options, honoring the
+// identity overrides and the trust policy
+func (vbo *verifyBundleOptions) verificationOptions() (attest.VerificationOptions, error) {
+	vo := attest.DefaultVerifierOptions
+	vo.TrustedRoot = vbo.trustedRoot
+	vo.RequireTlog = vbo.requireTlog
+	if vbo.expectedIssuer != "" {
+		vo.ExpectedIssuer = vbo.expectedIssuer
+	}
+	if vbo.expectedSan != "" {
+		vo.ExpectedSan = vbo.expectedSan
+		vo.AlternateSans = nil
+	}
+	if vbo.trustPolicy != "" {
+		tp, err := attest.LoadTrustPolicy(vbo.trustPolicy)
+		if err != nil {
+			return vo, err
+		}
+		vo.TrustPolicy = tp
+	}
+	return vo, nil
+}
+
+func addVerifyBundle(cmd *cobra.Command) {
+	opts := verifyBundleOptions{}
+	verifyBundleCmd := &cobra.Command{
+		Use:     "verify-bundle --bundle attestations.jsonl --commit SHA --repo URI --trusted-root trusted_root.json",
+		GroupID: cmdGroupVerification,
+		Short:   "Verifies a commit offline using a local bundle of attestations",
+		Long: `Verifies a commit offline using a local bundle of attestations.
+
+verify-bundle looks for a VSA of the commit in a JSONL file of signed
+attestations. The signatures and signer identity are checked using the
+sigstore trusted root passed in --trusted-root, so no data is read from the
+network. The VSA must be issued for the repository URI, carry the commit
+digest in its subject and verify all the levels and controls passed in
+--require.
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := opts.Validate(); err != nil {
+				return fmt.Errorf("validating options: %w", err)
+			}
+
+			vo, err := opts.verificationOptions()
+			if err != nil {
+				return err
+			}
+
+			envs, err := attest.ReadBundle(opts.bundle)
+			if err != nil {
+				return err
+			}
+
+			required := slsa.SourceVerifiedLevels{}
+			for _, r := range opts.require {
+				required = append(required, slsa.ControlName(r))
+			}
+
+			result := VerifyBundleResult{
+				Commit:     opts.commit,
+				Repository: opts.repoURI,
+				Bundle:     opts.bundle,
+			}
+
+			vsaPred, err := attest.VerifyBundleVSA(
+				attest.NewBndVerifier(vo), envs, opts.repoURI,
+				&models.Commit{SHA: opts.commit}, required,
+			)
+			if err != nil {
+				result.Message = err.Error()
+				if err := opts.writeResult(result); err != nil {
+					return err
+				}
+				return &exitError{code: 2, err: fmt.Errorf("commit %s could not be verified", opts.commit)}
+			}
+
+			result.Success = true
+			result.VerifiedLevels = vsaPred.GetVerifiedLevels()
+			return opts.writeResult(result)
+		},
+	}
+	opts.AddFlags(verifyBundleCmd)
+	cmd.AddCommand(verifyBundleCmd)
+}
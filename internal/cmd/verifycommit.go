@@ -9,6 +9,7 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/slsa-framework/source-tool/pkg/attest"
 	"github.com/slsa-framework/source-tool/pkg/sourcetool"
 )
 
@@ -33,7 +34,9 @@ type VerifyCommitResult struct {
 	Owner          string   `json:"owner"`
 	Repository     string   `json:"repository"`
 	VerifiedLevels []string `json:"verified_levels,omitempty"`
-	Message        string   `json:"message,omitempty"`
+	// TlogEntries are the transparency log entries of the VSA
+	TlogEntries []*attest.TlogEntry `json:"tlog_entries,omitempty"`
+	Message     string              `json:"message,omitempty"`
 }
 
 // String implements fmt.Stringer for text output
@@ -41,7 +44,11 @@ func (v VerifyCommitResult) String() string {
 	if !v.Success {
 		return fmt.Sprintf("FAILED: %s\n", v.Message)
 	}
-	return fmt.Sprintf("SUCCESS: commit %s on %s verified with %v\n", v.Commit, v.Ref, v.VerifiedLevels)
+	ret := fmt.Sprintf("SUCCESS: commit %s on %s verified with %v\n", v.Commit, v.Ref, v.VerifiedLevels)
+	for _, e := range v.TlogEntries {
+		ret += fmt.Sprintf("  tlog: %s\n", e)
+	}
+	return ret
 }
 
 func (vco *verifyCommitOptions) Validate() error {
@@ -97,7 +104,11 @@ func addVerifyCommit(cmd *cobra.Command) {
 			// Create a new sourcetool object
 			srctool, err := sourcetool.New(
 				sourcetool.WithAuthenticator(authenticator),
+				sourcetool.WithGitLabHosts(gitlabHosts...),
 				sourcetool.WithExpectedIdentity(opts.expectedIssuer, opts.expectedSan),
+				sourcetool.WithTrustPolicy(opts.trustPolicy),
+				sourcetool.WithTrustedRoot(opts.trustedRoot),
+				sourcetool.WithRequireTlog(opts.requireTlog),
 			)
 			if err != nil {
 				return err
@@ -116,7 +127,7 @@ func addVerifyCommit(cmd *cobra.Command) {
 				return fmt.Errorf("must specify either branch or tag")
 			}
 
-			_, vsaPred, err := srctool.Attester().GetRevisionVSA(cmd.Context(), opts.GetBranch(), opts.GetCommit())
+			vsaEnv, vsaPred, err := srctool.Attester().GetRevisionVSA(cmd.Context(), opts.GetBranch(), opts.GetCommit())
 			if err != nil {
 				return err
 			}
@@ -139,6 +150,10 @@ func addVerifyCommit(cmd *cobra.Command) {
 			}
 
 			result.VerifiedLevels = vsaPred.GetVerifiedLevels()
+			result.TlogEntries, err = srctool.Attester().TlogEntries(vsaEnv)
+			if err != nil {
+				return err
+			}
 			return opts.writeResult(result)
 		},
 	}
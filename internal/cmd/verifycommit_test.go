@@ -5,6 +5,9 @@ package cmd
 
 import (
 	"testing"
+	"time"
+
+	"github.com/slsa-framework/source-tool/pkg/attest"
 )
 
 func TestVerifyCommitResult_JSONMarshaling(t *testing.T) {
@@ -55,6 +58,29 @@ func TestVerifyCommitResult_JSONMarshaling(t *testing.T) {
 				Message:    "no VSA matching commit 'def456' on branch 'develop' found in github.com/test-owner/test-repo",
 			},
 		},
+		{
+			name: "verification with transparency log entries",
+			result: VerifyCommitResult{
+				Success:        true,
+				Commit:         "abc123",
+				Ref:            "main",
+				RefType:        "branch",
+				Owner:          "test-owner",
+				Repository:     "test-repo",
+				VerifiedLevels: []string{"SLSA_SOURCE_LEVEL_3"},
+				TlogEntries:    []*attest.TlogEntry{{LogIndex: 42, LogID: "c0d23d6a", IntegratedTime: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)}},
+			},
+			want: VerifyCommitResult{
+				Success:        true,
+				Commit:         "abc123",
+				Ref:            "main",
+				RefType:        "branch",
+				Owner:          "test-owner",
+				Repository:     "test-repo",
+				VerifiedLevels: []string{"SLSA_SOURCE_LEVEL_3"},
+				TlogEntries:    []*attest.TlogEntry{{LogIndex: 42, LogID: "c0d23d6a", IntegratedTime: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)}},
+			},
+		},
 		{
 			name: "tag verification",
 			result: VerifyCommitResult{
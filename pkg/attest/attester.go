@@ -11,6 +11,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/carabiner-dev/attestation"
 	"github.com/carabiner-dev/collector"
 	intoto "github.com/in-toto/attestation/go/v1"
 	"google.golang.org/protobuf/encoding/protojson"
@@ -49,6 +50,9 @@ type Attester struct {
 	Options       AttesterOptions
 	authenticator *auth.Authenticator
 
+	// repositories are additional read repositories passed as objects
+	repositories []attestation.Repository
+
 	// collectorMtx guards the memoized collector agents.
 	collectorMtx sync.Mutex
 	// collectors memoizes one collector agent per repository (keyed by its
@@ -72,6 +76,15 @@ func WithRepository(repos ...string) optFn {
 	}
 }
 
+// WithAttestationRepository adds already initialized repositories to the
+// collector agents used to read attestations.
+func WithAttestationRepository(repos ...attestation.Repository) optFn {
+	return func(a *Attester) error {
+		a.repositories = append(a.repositories, repos...)
+		return nil
+	}
+}
+
 func WithRetries(r uint8) optFn {
 	return func(a *Attester) error {
 		a.Options.Retries = r
@@ -114,6 +127,12 @@ func WithNotesCollector(yesno bool) optFn {
 	}
 }
 
+// TlogEntries returns the transparency log entries of an envelope, checked
+// with the attester verifier
+func (a *Attester) TlogEntries(env attestation.Envelope) ([]*TlogEntry, error) {
+	return a.verifier.TlogEntries(env)
+}
+
 // Validate checks that the attester configuration is complete
 func (a *Attester) Validate() error {
 	errs := []error{}
@@ -124,7 +143,7 @@ func (a *Attester) Validate() error {
 	}
 
 	// Check we have attestation repos to read
-	if len(a.Options.Repos) == 0 && !a.Options.InitGHCollector && !a.Options.InitNotesCollector {
+	if len(a.Options.Repos) == 0 && len(a.repositories) == 0 && !a.Options.InitGHCollector && !a.Options.InitNotesCollector {
 		errs = append(errs, errors.New("no attestation repository configured"))
 	}
 
@@ -157,8 +176,9 @@ func NewAttester(fn ...optFn) (*Attester, error) {
 }
 
 // createCurrentProvenance creates the provenance statement for the specified commit
-// without any context from the previous provenance (if any).
-func (a *Attester) createCurrentProvenance(ctx context.Context, branch *models.Branch, commit, prevCommit *models.Commit) (*intoto.Statement, error) {
+// without any context from the previous provenance (if any). The first parent
+// is recorded as the previous commit.
+func (a *Attester) createCurrentProvenance(ctx context.Context, branch *models.Branch, commit *models.Commit, parents []*models.Commit) (*intoto.Statement, error) {
 	// Get the active controls
 	controlStatus, err := a.backend.GetBranchControlsAtCommit(ctx, branch, commit)
 	if err != nil {
@@ -169,9 +189,15 @@ func (a *Attester) createCurrentProvenance(ctx context.Context, branch *models.B
 		return nil, errors.New("VCS backend returned a nil controlset")
 	}
 
+	parentSHAs := make([]string, 0, len(parents))
+	for _, p := range parents {
+		parentSHAs = append(parentSHAs, p.SHA)
+	}
+
 	// Build the provenance predicate
 	curProvPred := provenance.SourceProvenancePred{
-		PrevCommit:   prevCommit.SHA,
+		PrevCommit:   parentSHAs[0],
+		Parents:      parentSHAs,
 		RepoUri:      branch.Repository.GetHttpURL(),
 		ActivityType: controlStatus.ActivityType,
 		Actor:        controlStatus.ActorLogin,
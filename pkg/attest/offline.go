@@ -0,0 +1,129 @@
+// SPDX-FileCopyrightText: Copyright 2026 The SLSA Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package attest
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/carabiner-dev/attestation"
+	"github.com/carabiner-dev/collector/envelope"
+	vsa "github.com/in-toto/attestation/go/predicates/vsa/v1"
+
+	"github.com/slsa-framework/source-tool/pkg/slsa"
+	"github.com/slsa-framework/source-tool/pkg/sourcetool/models"
+)
+
+// checkVSA checks a VSA was issued by the source tool for the repository
+// and records a passed verification. An empty repository URL is not checked.
+func checkVSA(vsaPred *vsa.VerificationSummary, repoURL string) error {
+	if vsaPred.GetVerifier().GetId() != VsaVerifierId {
+		return fmt.Errorf("VSA verifier ID is %q but must be %s", vsaPred.GetVerifier().GetId(), VsaVerifierId)
+	}
+
+	// Check the VSA resource to ensure it is our repo
+	if repoURL != "" && normalizeRepoURI(vsaPred.GetResourceUri()) != normalizeRepoURI(repoURL) {
+		return fmt.Errorf("resourceUri is %s but we want %s", vsaPred.GetResourceUri(), repoURL)
+	}
+
+	if vsaPred.GetVerificationResult() != "PASSED" {
+		return fmt.Errorf("verificationResult is %s but must be 'PASSED'", vsaPred.GetVerificationResult())
+	}
+	return nil
+}
+
+// normalizeRepoURI strips the VCS prefix and .git suffix from a repository URI
+func normalizeRepoURI(uri string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(uri, "git+"), ".git")
+}
+
+// ReadBundle reads the attestations in a JSONL bundle file
+func ReadBundle(path string) ([]attestation.Envelope, error) {
+	envs, err := envelope.NewJSONL().ParseFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading bundle: %w", err)
+	}
+	return envs, nil
+}
+
+// VerifyBundleVSA looks for a VSA of a commit among the attestations of a
+// bundle, without reading any data from the network when the verifier has
+// a trusted root. The VSA signature and signer must check out with the
+// verifier, its subject must carry the commit digest, its resource must be
+// the repository and it must verify the required levels and controls.
+//
+// The VSA found is returned. If none qualifies, the error lists why each of
+// the VSAs in the bundle was rejected.
+func VerifyBundleVSA(
+	verifier Verifier, envs []attestation.Envelope, repoURL string, commit *models.Commit, required slsa.SourceVerifiedLevels,
+) (*vsa.VerificationSummary, error) {
+	errs := []error{}
+	for i, env := range envs {
+		if env.GetStatement().GetPredicateType() != VsaPredicateType {
+			continue
+		}
+
+		if GetSubjectForCommit(env, commit) == nil {
+			errs = append(errs, fmt.Errorf("attestation #%d: no subject matches commit %s", i, commit.SHA))
+			continue
+		}
+
+		if err := verifier.VerifyEnvelope(env); err != nil {
+			errs = append(errs, fmt.Errorf("attestation #%d: %w", i, err))
+			continue
+		}
+
+		vsaPred, ok := env.GetPredicate().GetParsed().(*vsa.VerificationSummary)
+		if !ok {
+			errs = append(errs, fmt.Errorf("attestation #%d: unable to parse VSA predicate", i))
+			continue
+		}
+
+		if err := checkVSA(vsaPred, repoURL); err != nil {
+			errs = append(errs, fmt.Errorf("attestation #%d: %w", i, err))
+			continue
+		}
+
+		if missing := missingLevels(required, vsaPred.GetVerifiedLevels()); len(missing) > 0 {
+			errs = append(errs, fmt.Errorf("attestation #%d: VSA does not verify %v", i, missing))
+			continue
+		}
+
+		return vsaPred, nil
+	}
+
+	if len(errs) == 0 {
+		return nil, fmt.Errorf("no VSA for commit %s found in the bundle", commit.SHA)
+	}
+	return nil, errors.Join(errs...)
+}
+
+// missingLevels returns the required levels and controls not verified. A
+// SLSA source level is met by a higher level.
+func missingLevels(required slsa.SourceVerifiedLevels, verified []string) slsa.SourceVerifiedLevels {
+	verifiedLevel := slsa.SlsaSourceLevel0
+	verifiedSet := slsa.SourceVerifiedLevels{}
+	for _, v := range verified {
+		verifiedSet = append(verifiedSet, slsa.ControlName(v))
+	}
+	if levels := verifiedSet.Levels(); len(levels) > 0 {
+		verifiedLevel = slsa.SlsaSourceLevel(levels[0])
+	}
+
+	missing := slsa.SourceVerifiedLevels{}
+	for _, r := range required {
+		if slsa.IsSlsaSourceLevel(r) {
+			if !slsa.IsLevelHigherOrEqualTo(verifiedLevel, slsa.SlsaSourceLevel(r)) {
+				missing = append(missing, r)
+			}
+			continue
+		}
+		if !slices.Contains(verifiedSet, r) {
+			missing = append(missing, r)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,122 @@
+// SPDX-FileCopyrightText: Copyright 2026 The SLSA Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package attest
+
+import (
+	"testing"
+
+	"github.com/carabiner-dev/attestation"
+	"github.com/carabiner-dev/collector/statement/intoto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slsa-framework/source-tool/pkg/slsa"
+	"github.com/slsa-framework/source-tool/pkg/sourcetool/models"
+)
+
+// statementEnvelope is an envelope wrapping a parsed statement
+type statementEnvelope struct {
+	fakeEnvelope
+	statement attestation.Statement
+}
+
+func (s *statementEnvelope) GetStatement() attestation.Statement { return s.statement }
+func (s *statementEnvelope) GetPredicate() attestation.Predicate { return s.statement.GetPredicate() }
+
+func newVSAEnvelope(t *testing.T, branch *models.Branch, commit *models.Commit, levels slsa.SourceVerifiedLevels, signer string) attestation.Envelope {
+	t.Helper()
+	data, err := CreateUnsignedSourceVsa(branch, commit, levels, "policy", nil)
+	require.NoError(t, err)
+	statement, err := (&intoto.Parser{}).Parse([]byte(data))
+	require.NoError(t, err)
+	return &statementEnvelope{
+		fakeEnvelope: fakeEnvelope{verification: signedBy(ExpectedIssuer, signer)},
+		statement:    statement,
+	}
+}
+
+func TestVerifyBundleVSA(t *testing.T) {
+	t.Parallel()
+	branch := newTestBranch("github.com", "example/repo", "main")
+	commit := newTestCommit("abc123")
+	other := newTestBranch("github.com", "example/other", "main")
+	attacker := "https://github.com/attacker/repo/.github/workflows/fake.yml@refs/heads/main"
+	l3 := slsa.SourceVerifiedLevels{slsa.ControlName(slsa.SlsaSourceLevel3), "ORG_SOURCE_TESTED"}
+
+	for _, tc := range []struct {
+		name     string
+		envs     []attestation.Envelope
+		repoURL  string
+		required slsa.SourceVerifiedLevels
+		mustErr  bool
+	}{
+		{
+			name:     "valid",
+			envs:     []attestation.Envelope{newVSAEnvelope(t, branch, commit, l3, ExpectedSan)},
+			repoURL:  "https://github.com/example/repo",
+			required: slsa.SourceVerifiedLevels{slsa.ControlName(slsa.SlsaSourceLevel2), "ORG_SOURCE_TESTED"},
+		},
+		{
+			name:    "vcs-uri",
+			envs:    []attestation.Envelope{newVSAEnvelope(t, branch, commit, l3, ExpectedSan)},
+			repoURL: "git+https://github.com/example/repo.git",
+		},
+		{
+			name: "skips-rejected",
+			envs: []attestation.Envelope{
+				newVSAEnvelope(t, branch, commit, l3, attacker),
+				newVSAEnvelope(t, other, commit, l3, ExpectedSan),
+				newVSAEnvelope(t, branch, commit, l3, ExpectedSan),
+			},
+			repoURL: "https://github.com/example/repo",
+		},
+		{
+			name:    "wrong-signer",
+			envs:    []attestation.Envelope{newVSAEnvelope(t, branch, commit, l3, attacker)},
+			repoURL: "https://github.com/example/repo",
+			mustErr: true,
+		},
+		{
+			name:    "wrong-repository",
+			envs:    []attestation.Envelope{newVSAEnvelope(t, other, commit, l3, ExpectedSan)},
+			repoURL: "https://github.com/example/repo",
+			mustErr: true,
+		},
+		{
+			name:    "wrong-commit",
+			envs:    []attestation.Envelope{newVSAEnvelope(t, branch, newTestCommit("def456"), l3, ExpectedSan)},
+			repoURL: "https://github.com/example/repo",
+			mustErr: true,
+		},
+		{
+			name:     "level-too-low",
+			envs:     []attestation.Envelope{newVSAEnvelope(t, branch, commit, l3, ExpectedSan)},
+			repoURL:  "https://github.com/example/repo",
+			required: slsa.SourceVerifiedLevels{slsa.ControlName(slsa.SlsaSourceLevel4)},
+			mustErr:  true,
+		},
+		{
+			name:     "missing-control",
+			envs:     []attestation.Envelope{newVSAEnvelope(t, branch, commit, l3, ExpectedSan)},
+			repoURL:  "https://github.com/example/repo",
+			required: slsa.SourceVerifiedLevels{"ORG_SOURCE_REVIEWED"},
+			mustErr:  true,
+		},
+		{
+			name:    "empty-bundle",
+			repoURL: "https://github.com/example/repo",
+			mustErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			vsaPred, err := VerifyBundleVSA(GetDefaultVerifier(), tc.envs, tc.repoURL, commit, tc.required)
+			if tc.mustErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, slsa.ControlNamesToStrings(l3), vsaPred.GetVerifiedLevels())
+		})
+	}
+}
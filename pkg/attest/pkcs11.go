@@ -0,0 +1,136 @@
+// SPDX-FileCopyrightText: Copyright 2026 The SLSA Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package attest
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+
+	sdsse "github.com/sigstore/protobuf-specs/gen/pb-go/dsse"
+)
+
+const (
+	// pkcs11Scheme prefixes the URIs (RFC 7512) of keys in PKCS#11 tokens
+	pkcs11Scheme = "pkcs11:"
+
+	// pkcs11PinEnvVar is the environment variable read for the token PIN
+	// when the URI has no pin-value attribute.
+	pkcs11PinEnvVar = "SOURCE_TOOL_PKCS11_PIN"
+)
+
+// pkcs11Ref is a key in a PKCS#11 token
+type pkcs11Ref struct {
+	// ModulePath is the path of the PKCS#11 module library of the token
+	ModulePath string
+	// Token is the label of the token holding the key
+	Token string
+	// Label and ID identify the key in the token, at least one is set
+	Label string
+	ID    []byte
+	// Pin logs into the token
+	Pin string
+}
+
+// pkcs11Opener opens a key in a PKCS#11 token. The closer releases the
+// token session once the key is no longer used.
+type pkcs11Opener func(*pkcs11Ref) (crypto.Signer, io.Closer, error)
+
+// openPKCS11Key opens keys in PKCS#11 tokens. It is only set when
+// sourcetool is built with the pkcs11 tag, which requires cgo.
+var openPKCS11Key pkcs11Opener
+
+// isPKCS11Key returns true when the key reference is a PKCS#11 URI
+func isPKCS11Key(ref string) bool {
+	return strings.HasPrefix(ref, pkcs11Scheme)
+}
+
+// parsePKCS11Ref parses a PKCS#11 URI of the form:
+//
+//	pkcs11:token=<label>;object=<label>;id=<id>?module-path=<path>&pin-value=<pin>
+//
+// Other attributes are ignored. The PIN is read from SOURCE_TOOL_PKCS11_PIN
+// when the URI has none.
+func parsePKCS11Ref(uri string) (*pkcs11Ref, error) {
+	rest, ok := strings.CutPrefix(uri, pkcs11Scheme)
+	if !ok {
+		return nil, fmt.Errorf("%q is not a PKCS#11 URI", uri)
+	}
+	path, query, _ := strings.Cut(rest, "?")
+
+	ref := &pkcs11Ref{}
+	for attrs, sep := range map[string]string{path: ";", query: "&"} {
+		for attr := range strings.SplitSeq(attrs, sep) {
+			if attr == "" {
+				continue
+			}
+			name, value, ok := strings.Cut(attr, "=")
+			if !ok {
+				return nil, fmt.Errorf("invalid attribute %q in PKCS#11 URI", attr)
+			}
+			value, err := url.PathUnescape(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value of %s in PKCS#11 URI: %w", name, err)
+			}
+			switch name {
+			case "token":
+				ref.Token = value
+			case "object":
+				ref.Label = value
+			case "id":
+				ref.ID = []byte(value)
+			case "module-path":
+				ref.ModulePath = value
+			case "pin-value":
+				ref.Pin = value
+			}
+		}
+	}
+
+	switch {
+	case ref.ModulePath == "":
+		return nil, errors.New("PKCS#11 URI has no module-path")
+	case ref.Token == "":
+		return nil, errors.New("PKCS#11 URI has no token")
+	case ref.Label == "" && len(ref.ID) == 0:
+		return nil, errors.New("PKCS#11 URI must identify the key by object or id")
+	}
+	if ref.Pin == "" {
+		ref.Pin = os.Getenv(pkcs11PinEnvVar)
+	}
+	return ref, nil
+}
+
+// signWithPKCS11 signs the payload with a key in a PKCS#11 token. The key
+// never leaves the token, only the digest of the PAE encoded payload is
+// sent to be signed.
+func signWithPKCS11(uri string, open pkcs11Opener, payload []byte) (*sdsse.Envelope, error) {
+	if open == nil {
+		return nil, errors.New("PKCS#11 keys are not supported in this build, sourcetool must be built with the pkcs11 tag")
+	}
+	ref, err := parsePKCS11Ref(uri)
+	if err != nil {
+		return nil, err
+	}
+	signer, closer, err := open(ref)
+	if err != nil {
+		return nil, fmt.Errorf("loading PKCS#11 key: %w", err)
+	}
+	defer closer.Close() //nolint:errcheck
+
+	env, err := signDSSE(signer.Public(), payload, func(pae []byte) ([]byte, error) {
+		digest := sha256.Sum256(pae)
+		return signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("signing with PKCS#11 key: %w", err)
+	}
+	return env, nil
+}
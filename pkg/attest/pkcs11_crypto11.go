@@ -0,0 +1,43 @@
+// SPDX-FileCopyrightText: Copyright 2026 The SLSA Authors
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build pkcs11
+
+package attest
+
+import (
+	"crypto"
+	"fmt"
+	"io"
+
+	"github.com/ThalesIgnite/crypto11"
+)
+
+func init() {
+	openPKCS11Key = openCrypto11Key
+}
+
+// openCrypto11Key opens the key with the PKCS#11 module of the token
+func openCrypto11Key(ref *pkcs11Ref) (crypto.Signer, io.Closer, error) {
+	ctx, err := crypto11.Configure(&crypto11.Config{
+		Path: ref.ModulePath, TokenLabel: ref.Token, Pin: ref.Pin,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("opening PKCS#11 token %s: %w", ref.Token, err)
+	}
+
+	var label []byte
+	if ref.Label != "" {
+		label = []byte(ref.Label)
+	}
+	signer, err := ctx.FindKeyPair(ref.ID, label)
+	if err != nil {
+		ctx.Close() //nolint:errcheck,gosec
+		return nil, nil, fmt.Errorf("looking up key: %w", err)
+	}
+	if signer == nil {
+		ctx.Close() //nolint:errcheck,gosec
+		return nil, nil, fmt.Errorf("key not found in PKCS#11 token %s", ref.Token)
+	}
+	return signer, ctx, nil
+}
@@ -7,7 +7,6 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"strings"
 	"time"
 
 	"github.com/carabiner-dev/attestation"
@@ -79,6 +78,9 @@ func GetTagProvPred(statement *intoto.Statement) (*provenance.TagProvenancePred,
 	return &predStruct, nil
 }
 
+// githubHostname is the host where the authenticator token is valid
+const githubHostname = "github.com"
+
 func (a *Attester) getCollector(branch *models.Branch) (*collector.Agent, error) {
 	// Reuse a single agent per repository so its attestation cache is shared
 	// across the several reads we do for a revision (provenance, VSA, parent
@@ -101,8 +103,12 @@ func (a *Attester) getCollector(branch *models.Branch) (*collector.Agent, error)
 		return nil, err
 	}
 
+	// The authenticator token is a GitHub token, never send it to
+	// repositories hosted elsewhere.
+	onGitHub := branch.Repository.Hostname == "" || branch.Repository.Hostname == githubHostname
+
 	var token string
-	if a.Options.InitNotesCollector || a.Options.InitGHCollector {
+	if onGitHub && (a.Options.InitNotesCollector || a.Options.InitGHCollector) {
 		token, err = a.authenticator.ReadToken()
 		if err != nil {
 			return nil, fmt.Errorf("fetching token from authenticator: %w", err)
@@ -111,10 +117,11 @@ func (a *Attester) getCollector(branch *models.Branch) (*collector.Agent, error)
 
 	// These two require the authenticator token
 	if a.Options.InitNotesCollector {
-		repo, err := note.NewDynamic(
-			note.DynamicRepoURL(branch.Repository.GetHttpURL()),
-			note.WithHttpAuth("github", token),
-		)
+		noteOpts := []func(*note.Options){note.DynamicRepoURL(branch.Repository.GetHttpURL())}
+		if onGitHub {
+			noteOpts = append(noteOpts, note.WithHttpAuth("github", token))
+		}
+		repo, err := note.NewDynamic(noteOpts...)
 		if err != nil {
 			return nil, fmt.Errorf("creating notes collector: %w", err)
 		}
@@ -124,7 +131,7 @@ func (a *Attester) getCollector(branch *models.Branch) (*collector.Agent, error)
 		}
 	}
 
-	if a.Options.InitGHCollector {
+	if onGitHub && a.Options.InitGHCollector {
 		repo, err := github.New(
 			github.WithRepo(branch.Repository.Path),
 			github.WithToken(token),
@@ -137,6 +144,12 @@ func (a *Attester) getCollector(branch *models.Branch) (*collector.Agent, error)
 		}
 	}
 
+	if len(a.repositories) > 0 {
+		if err := agent.AddRepository(a.repositories...); err != nil {
+			return nil, err
+		}
+	}
+
 	for _, initString := range a.Options.Repos {
 		if err := agent.AddRepositoryFromString(initString); err != nil {
 			return nil, err
@@ -207,23 +220,8 @@ func (a *Attester) GetRevisionVSA(ctx context.Context, branch *models.Branch, re
 			continue
 		}
 
-		// Check the verifier ID matches
-		if vsaPred.GetVerifier().GetId() != VsaVerifierId {
-			Debugf("VSA verfier ID does not match %s", VsaVerifierId)
-			continue
-		}
-
-		// Check the VSA resource to ensure it is our repo
-		cleanResourceUri := strings.TrimPrefix(vsaPred.GetResourceUri(), "git+")
-		if branch.Repository.GetHttpURL() != "" && cleanResourceUri != branch.Repository.GetHttpURL() {
-			Debugf("ResourceUri is %s but we want %s", cleanResourceUri, branch.Repository.GetHttpURL())
-			continue
-		}
-
-		// And the verification is passed
-		// 	// Is the result PASSED?
-		if vsaPred.GetVerificationResult() != "PASSED" {
-			Debugf("verificationResult is %s but must be 'PASSED'", vsaPred.GetVerificationResult())
+		if err := checkVSA(vsaPred, branch.Repository.GetHttpURL()); err != nil {
+			Debugf("discarding VSA attestation: %v", err)
 			continue
 		}
 
@@ -292,18 +290,31 @@ func (a *Attester) GetRevisionProvenance(ctx context.Context, branch *models.Bra
 	return nil, fmt.Errorf("unable to parse predicate: %w", err)
 }
 
-// prevAttPath string
+// CreateSourceProvenance creates the provenance statement for a commit in a
+// branch, carrying over the control history from the provenance of its
+// parents.
+//
+// The provenance chain follows the first parent. For merge commits, the
+// merged-in parents that have provenance of their own (eg a merge from
+// another protected branch) are also taken into account: a control is only
+// considered enforced since it was in place on both sides. Controls not
+// recorded on the merged side and parents without provenance don't affect
+// the result, the merge itself is covered by the controls on the branch.
 func (a *Attester) CreateSourceProvenance(ctx context.Context, branch *models.Branch, commit *models.Commit) (*intoto.Statement, error) {
-	// Get the previous commit
-	prevCommit, err := a.backend.GetPreviousCommit(ctx, branch, commit)
+	// Get the parents of the commit
+	parents, err := a.backend.GetCommitParents(ctx, branch, commit)
 	if err != nil {
-		return nil, fmt.Errorf("getting previous commit: %w", err)
+		return nil, fmt.Errorf("getting commit parents: %w", err)
 	}
+	if len(parents) == 0 {
+		return nil, fmt.Errorf("getting previous commit: there is no commit earlier than %s", commit.SHA)
+	}
+	prevCommit := parents[0]
 
 	// Source provenance is based on
 	// 1. The current control situation (we assume 'commit' has _just_ occurred).
 	// 2. How long the properties have been enforced according to the previous provenance.
-	curProv, err := a.createCurrentProvenance(ctx, branch, commit, prevCommit)
+	curProv, err := a.createCurrentProvenance(ctx, branch, commit, parents)
 	if err != nil {
 		return nil, fmt.Errorf("creating provenance predicate: %w", err)
 	}
@@ -325,15 +336,34 @@ func (a *Attester) CreateSourceProvenance(ctx context.Context, branch *models.Br
 		return nil, err
 	}
 
-	// There was prior provenance, so update the Since field for each property
-	// to the oldest encountered.
+	// Read the provenance of the merged-in parents
+	mergedPreds := []*provenance.SourceProvenancePred{}
+	for _, parent := range parents[1:] {
+		pred, err := a.GetRevisionProvenance(ctx, branch, parent)
+		if err != nil {
+			return nil, fmt.Errorf("reading provenance of merged commit %s: %w", parent.SHA, err)
+		}
+		if pred == nil {
+			Debugf("Merged commit %s has no provenance\n", parent.SHA)
+			continue
+		}
+		mergedPreds = append(mergedPreds, pred)
+	}
+
+	// There was prior provenance, so carry over the enforcement windows
+	// of each property recorded in it.
 	for i, curControl := range curProvPred.GetControls() {
 		prevControl := prevProvPred.GetControl(curControl.GetName())
 		// No prior version of this control
 		if prevControl == nil {
 			continue
 		}
-		curControl.Since = timestamppb.New(slsa.EarlierTime(curControl.GetSince().AsTime(), prevControl.GetSince().AsTime()))
+		mergeControlIntervals(curControl, prevControl)
+		for _, pred := range mergedPreds {
+			if mergedControl := pred.GetControl(curControl.GetName()); mergedControl != nil {
+				intersectControlIntervals(curControl, mergedControl)
+			}
+		}
 		// Update the value.
 		curProvPred.Controls[i] = curControl
 	}
@@ -341,6 +371,66 @@ func (a *Attester) CreateSourceProvenance(ctx context.Context, branch *models.Br
 	return addPredToStatement(curProvPred, provenance.SourceProvPredicateType, commit.SHA)
 }
 
+// controlIntervals returns the enforcement windows recorded in a provenance
+// control. Older provenance only has the since date.
+func controlIntervals(ctl *provenance.Control) slsa.Intervals {
+	if ivs := slsa.IntervalsFromProvenance(ctl.GetIntervals()); ivs != nil {
+		return ivs
+	}
+	if ctl.GetSince() == nil {
+		return nil
+	}
+	return slsa.Intervals{{Start: ctl.GetSince().AsTime()}}
+}
+
+// setControlIntervals records the windows in the control. The since date
+// is the start of the last window, the intervals are only recorded when the
+// control was interrupted.
+func setControlIntervals(ctl *provenance.Control, ivs slsa.Intervals) {
+	last := ivs.Last()
+	if last == nil {
+		return
+	}
+	ctl.Since = timestamppb.New(last.Start)
+	ctl.Intervals = nil
+	if len(ivs) > 1 || last.End != nil {
+		ctl.Intervals = ivs.Normalize().ToProvenance()
+	}
+}
+
+// mergeControlIntervals merges the enforcement windows recorded in the
+// previous provenance into the current control.
+//
+// When the current control has intervals, the backend knows its history and
+// only the windows before that history are taken from the previous
+// provenance. Otherwise the control is assumed to be continuously enforced
+// and it is extended back to the oldest since date encountered.
+func mergeControlIntervals(cur, prev *provenance.Control) {
+	curIvs := slsa.IntervalsFromProvenance(cur.GetIntervals())
+	prevIvs := controlIntervals(prev)
+
+	var merged slsa.Intervals
+	switch {
+	case curIvs != nil:
+		merged = curIvs.Union(prevIvs.ClipBefore(curIvs[0].Start))
+	case cur.GetSince() != nil:
+		merged = prevIvs.Union(slsa.Intervals{{Start: cur.GetSince().AsTime()}})
+	default:
+		merged = prevIvs
+	}
+	setControlIntervals(cur, merged)
+}
+
+// intersectControlIntervals restricts the windows of the current control to
+// those where the control was also enforced on a merged-in parent.
+func intersectControlIntervals(cur, merged *provenance.Control) {
+	curIvs, mergedIvs := controlIntervals(cur), controlIntervals(merged)
+	if curIvs == nil || mergedIvs == nil {
+		return
+	}
+	setControlIntervals(cur, curIvs.Intersect(mergedIvs))
+}
+
 // CreateTagProvenance creates a provenance statement for a tag.
 func (a *Attester) CreateTagProvenance(ctx context.Context, branch *models.Branch, tag *models.Tag, actor string) (*intoto.Statement, error) {
 	if tag.Commit == nil {
@@ -377,6 +467,12 @@ func (a *Attester) CreateTagProvenance(ctx context.Context, branch *models.Branc
 		return nil, fmt.Errorf("error getting source refs from vsa %w", err)
 	}
 
+	// 4. Record whether the tag is annotated and signed
+	tagInfo, err := a.backend.GetTagInfo(ctx, branch.Repository, tag)
+	if err != nil {
+		return nil, fmt.Errorf("reading tag object: %w", err)
+	}
+
 	curProvPred := provenance.TagProvenancePred{
 		RepoUri:   branch.Repository.GetHttpURL(),
 		Actor:     actor,
@@ -389,6 +485,8 @@ func (a *Attester) CreateTagProvenance(ctx context.Context, branch *models.Branc
 				VerifiedLevels: vsaPred.GetVerifiedLevels(),
 			},
 		},
+		Annotated:         tagInfo.Annotated,
+		SignatureVerified: tagInfo.SignatureVerified,
 	}
 
 	return addPredToStatement(&curProvPred, provenance.TagProvPredicateType, tag.Commit.SHA)
@@ -0,0 +1,120 @@
+// SPDX-FileCopyrightText: Copyright 2026 The SLSA Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package attest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/slsa-framework/source-tool/pkg/provenance"
+	"github.com/slsa-framework/source-tool/pkg/slsa"
+)
+
+func TestMergeControlIntervals(t *testing.T) {
+	t.Parallel()
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(h int) time.Time { return base.Add(time.Duration(h) * time.Hour) }
+	end := func(h int) *time.Time { t := at(h); return &t }
+	control := func(since int, ivs slsa.Intervals) *provenance.Control {
+		c := &provenance.Control{Name: "test", Since: timestamppb.New(at(since))}
+		if ivs != nil {
+			c.Intervals = ivs.ToProvenance()
+		}
+		return c
+	}
+
+	for _, tc := range []struct {
+		name      string
+		cur       *provenance.Control
+		prev      *provenance.Control
+		since     time.Time
+		intervals slsa.Intervals
+	}{
+		{
+			name:  "since-only-keeps-oldest",
+			cur:   control(5, nil),
+			prev:  control(1, nil),
+			since: at(1),
+		},
+		{
+			name:      "prev-gaps-preserved",
+			cur:       control(5, nil),
+			prev:      control(3, slsa.Intervals{{Start: at(0), End: end(2)}, {Start: at(3)}}),
+			since:     at(3),
+			intervals: slsa.Intervals{{Start: at(0), End: end(2)}, {Start: at(3)}},
+		},
+		{
+			name:      "cur-history-wins",
+			cur:       control(6, slsa.Intervals{{Start: at(2), End: end(4)}, {Start: at(6)}}),
+			prev:      control(1, nil),
+			since:     at(6),
+			intervals: slsa.Intervals{{Start: at(1), End: end(4)}, {Start: at(6)}},
+		},
+		{
+			name:  "cur-history-extended",
+			cur:   control(2, slsa.Intervals{{Start: at(2)}}),
+			prev:  control(0, nil),
+			since: at(0),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			mergeControlIntervals(tc.cur, tc.prev)
+			require.True(t, tc.since.Equal(tc.cur.GetSince().AsTime()), "since %s", tc.cur.GetSince().AsTime())
+			if tc.intervals == nil {
+				require.Empty(t, tc.cur.GetIntervals())
+				return
+			}
+			got := slsa.IntervalsFromProvenance(tc.cur.GetIntervals())
+			require.Len(t, got, len(tc.intervals))
+			for i := range got {
+				require.True(t, tc.intervals[i].Start.Equal(got[i].Start))
+				require.Equal(t, tc.intervals[i].End == nil, got[i].End == nil)
+			}
+		})
+	}
+}
+
+func TestIntersectControlIntervals(t *testing.T) {
+	t.Parallel()
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(h int) time.Time { return base.Add(time.Duration(h) * time.Hour) }
+	end := func(h int) *time.Time { t := at(h); return &t }
+	control := func(since int, ivs slsa.Intervals) *provenance.Control {
+		c := &provenance.Control{Name: "test", Since: timestamppb.New(at(since))}
+		if ivs != nil {
+			c.Intervals = ivs.ToProvenance()
+		}
+		return c
+	}
+
+	for _, tc := range []struct {
+		name      string
+		cur       *provenance.Control
+		merged    *provenance.Control
+		since     time.Time
+		intervals int
+	}{
+		{name: "merged-side-later", cur: control(1, nil), merged: control(4, nil), since: at(4)},
+		{name: "merged-side-earlier", cur: control(4, nil), merged: control(1, nil), since: at(4)},
+		{
+			name:      "merged-side-gap",
+			cur:       control(0, nil),
+			merged:    control(5, slsa.Intervals{{Start: at(1), End: end(2)}, {Start: at(5)}}),
+			since:     at(5),
+			intervals: 2,
+		},
+		{name: "merged-side-no-since", cur: control(3, nil), merged: &provenance.Control{Name: "test"}, since: at(3)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			intersectControlIntervals(tc.cur, tc.merged)
+			require.True(t, tc.since.Equal(tc.cur.GetSince().AsTime()), "since %s", tc.cur.GetSince().AsTime())
+			require.Len(t, tc.cur.GetIntervals(), tc.intervals)
+		})
+	}
+}
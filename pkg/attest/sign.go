@@ -5,14 +5,100 @@ package attest
 
 import (
 	"bytes"
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
 
 	"github.com/carabiner-dev/signer"
+	"github.com/carabiner-dev/signer/dsse"
+	"github.com/carabiner-dev/signer/key"
 	"github.com/carabiner-dev/signer/options"
+	"github.com/carabiner-dev/signer/sts"
+	sdsse "github.com/sigstore/protobuf-specs/gen/pb-go/dsse"
+	sgbundle "github.com/sigstore/sigstore-go/pkg/bundle"
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/sign"
+	"github.com/sigstore/sigstore/pkg/signature/kms"
+	sigopts "github.com/sigstore/sigstore/pkg/signature/options"
 )
 
+// SigningOptions configure the sigstore instance attestations are signed
+// with. The zero value signs with the public sigstore instance.
+type SigningOptions struct {
+	// SigningConfig is the path to a sigstore signing config
+	// (application/vnd.dev.sigstore.signingconfig.v0.2+json) listing the
+	// Fulcio, Rekor and TSA services of a private sigstore instance.
+	SigningConfig string
+
+	// TrustedRoot is the path to the trusted root of the instance. When
+	// set, signed bundles are verified against it before returning them.
+	TrustedRoot string
+
+	// Key signs attestations with a key instead of a keyless sigstore
+	// identity. It is either the path to a private key file, a KMS key
+	// URI of a registered sigstore KMS provider (awskms://, gcpkms://,
+	// azurekms:// or hashivault://) or the pkcs11: URI of a key in a
+	// PKCS#11 token. Key signed attestations are written as DSSE envelopes.
+	Key string
+}
+
+const (
+	// idTokenEnvVar is the environment variable read for an OIDC token to
+	// exchange for a signing certificate in private sigstore instances.
+	idTokenEnvVar = "SIGSTORE_ID_TOKEN"
+
+	// keyPassphraseEnvVar is the environment variable read for the
+	// passphrase of encrypted signing key files.
+	keyPassphraseEnvVar = "SOURCE_TOOL_KEY_PASSPHRASE"
+
+	intotoPayloadType = "application/vnd.in-toto+json"
+)
+
+type BndSigner struct {
+	Options SigningOptions
+}
+
+func NewBndSigner(opts SigningOptions) *BndSigner {
+	return &BndSigner{Options: opts}
+}
+
+// Sign signs an in-toto statement and returns the sigstore bundle
 func Sign(data string) (string, error) {
-	artifact, err := signer.NewSigner().SignStatement(
-		[]byte(data), options.WithPayloadType("application/vnd.in-toto+json"),
+	return NewBndSigner(SigningOptions{}).Sign(data)
+}
+
+// Sign signs an in-toto statement and returns the sigstore bundle
+func (bs *BndSigner) Sign(data string) (string, error) {
+	if bs.Options.Key != "" {
+		return bs.signWithKey(data)
+	}
+
+	s := signer.NewSigner()
+	if bs.Options.SigningConfig != "" {
+		sc, err := root.NewSigningConfigFromPath(bs.Options.SigningConfig)
+		if err != nil {
+			return "", fmt.Errorf("reading signing config: %w", err)
+		}
+
+		// Setting the credentials keeps the signer from bootstrapping the
+		// public sigstore instance from TUF.
+		s.Options.SigningConfig = sc
+		s.Options.Timestamp = len(sc.TimestampAuthorityURLs()) > 0
+		s.Options.AppendToRekor = len(sc.RekorLogURLs()) > 0
+		s.Credentials = &fulcioCredentials{
+			signingConfig: sc,
+			clientID:      s.Options.OIDCConfig.ClientID,
+		}
+	}
+
+	artifact, err := s.SignStatement(
+		[]byte(data), options.WithPayloadType(intotoPayloadType),
 	)
 	if err != nil {
 		return "", err
@@ -25,5 +111,191 @@ func Sign(data string) (string, error) {
 		return "", err
 	}
 
+	bndl := &sgbundle.Bundle{}
+	if err := bndl.UnmarshalJSON(buf.Bytes()); err != nil {
+		return "", fmt.Errorf("parsing signed bundle: %w", err)
+	}
+
+	// Make sure the signature was recorded in the transparency log
+	if s.Options.AppendToRekor {
+		entries, err := bndl.TlogEntries()
+		if err != nil {
+			return "", fmt.Errorf("reading transparency log entries: %w", err)
+		}
+		if len(entries) == 0 {
+			return "", errors.New("signed bundle has no transparency log entry")
+		}
+	}
+
+	if bs.Options.TrustedRoot != "" {
+		if _, err := verifySigstoreBundle(bndl, bs.Options.TrustedRoot); err != nil {
+			return "", fmt.Errorf("verifying signed bundle against the trusted root: %w", err)
+		}
+	}
+
 	return buf.String(), nil
 }
+
+// signWithKey signs the statement with the configured key and returns the
+// DSSE envelope
+func (bs *BndSigner) signWithKey(data string) (string, error) {
+	var env *sdsse.Envelope
+	var err error
+	switch {
+	case isPKCS11Key(bs.Options.Key):
+		env, err = signWithPKCS11(bs.Options.Key, openPKCS11Key, []byte(data))
+	case isKMSKey(bs.Options.Key):
+		env, err = signWithKMS(context.Background(), bs.Options.Key, []byte(data))
+	default:
+		env, err = signWithKeyFile(bs.Options.Key, []byte(data))
+	}
+	if err != nil {
+		return "", err
+	}
+
+	out, err := json.Marshal(env)
+	if err != nil {
+		return "", fmt.Errorf("marshaling signed envelope: %w", err)
+	}
+	return string(out), nil
+}
+
+// isKMSKey returns true when the key reference is a KMS URI rather than
+// the path to a key file
+func isKMSKey(ref string) bool {
+	return strings.Contains(ref, "://")
+}
+
+// signWithKeyFile signs the payload with a private key read from a file
+func signWithKeyFile(path string, payload []byte) (*sdsse.Envelope, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading signing key: %w", err)
+	}
+	pk, err := key.NewParser().ParsePrivateKeyProvider(data, key.WithPassphrase(os.Getenv(keyPassphraseEnvVar)))
+	if err != nil {
+		return nil, fmt.Errorf("parsing signing key: %w", err)
+	}
+
+	env, err := signer.NewSigner().SignMessageToDSSE(
+		payload, options.WithKey(pk), options.WithPayloadType(intotoPayloadType),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("signing with key: %w", err)
+	}
+	return env, nil
+}
+
+// signWithKMS signs the payload with a KMS key. The key never leaves the
+// KMS, only the PAE encoded payload is sent to be signed.
+func signWithKMS(ctx context.Context, ref string, payload []byte) (*sdsse.Envelope, error) {
+	sv, err := kms.Get(ctx, ref, crypto.SHA256)
+	if err != nil {
+		return nil, fmt.Errorf("loading KMS key: %w", err)
+	}
+	pub, err := sv.PublicKey(sigopts.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("reading KMS public key: %w", err)
+	}
+
+	env, err := signDSSE(pub, payload, func(pae []byte) ([]byte, error) {
+		return sv.SignMessage(bytes.NewReader(pae), sigopts.WithContext(ctx))
+	})
+	if err != nil {
+		return nil, fmt.Errorf("signing with KMS key: %w", err)
+	}
+	return env, nil
+}
+
+// signDSSE assembles a DSSE envelope of the payload signed by a key held
+// elsewhere. The sign function gets the PAE encoded envelope and the
+// signature key ID is computed from the public key.
+func signDSSE(pub crypto.PublicKey, payload []byte, sign func([]byte) ([]byte, error)) (*sdsse.Envelope, error) {
+	keyID := (&key.Public{Key: pub}).ID()
+	if keyID == "" {
+		return nil, fmt.Errorf("unsupported key type %T", pub)
+	}
+
+	ds := &dsse.DefaultSigner{}
+	env, err := ds.WrapPayload(intotoPayloadType, payload)
+	if err != nil {
+		return nil, err
+	}
+	pae, err := ds.PaeEncode(env)
+	if err != nil {
+		return nil, fmt.Errorf("encoding envelope: %w", err)
+	}
+
+	sig, err := sign(pae)
+	if err != nil {
+		return nil, err
+	}
+	env.Signatures = append(env.Signatures, &sdsse.Signature{Sig: sig, Keyid: keyID})
+	return env, nil
+}
+
+// fulcioCredentials get a signing certificate from the Fulcio instance of a
+// signing config. The OIDC token is read from the environment or from the
+// ambient credentials of the CI system.
+type fulcioCredentials struct {
+	signingConfig *root.SigningConfig
+	clientID      string
+
+	keypair *sign.EphemeralKeypair
+	cp      sign.CertificateProvider
+	token   string
+}
+
+func (fc *fulcioCredentials) Prepare(ctx context.Context) error {
+	if fc.cp != nil {
+		return nil
+	}
+
+	fulcio, err := root.SelectService(fc.signingConfig.FulcioCertificateAuthorityURLs(), sign.FulcioAPIVersions, time.Now())
+	if err != nil {
+		return fmt.Errorf("selecting fulcio service: %w", err)
+	}
+
+	fc.token = os.Getenv(idTokenEnvVar)
+	for _, provider := range sts.DefaultProviders {
+		if fc.token != "" {
+			break
+		}
+		tok, err := provider.Provide(ctx, fc.clientID)
+		if err != nil {
+			return fmt.Errorf("reading ambient credentials: %w", err)
+		}
+		if tok != nil {
+			fc.token = tok.RawString
+		}
+	}
+	if fc.token == "" {
+		return fmt.Errorf("no OIDC token found to sign, set %s or run in a CI system with ambient credentials", idTokenEnvVar)
+	}
+
+	fc.keypair, err = sign.NewEphemeralKeypair(nil)
+	if err != nil {
+		return fmt.Errorf("generating ephemeral keypair: %w", err)
+	}
+	fc.cp = sign.NewFulcio(&sign.FulcioOptions{
+		BaseURL: fulcio.URL,
+		Timeout: 30 * time.Second,
+		Retries: 1,
+	})
+	return nil
+}
+
+func (fc *fulcioCredentials) Keypair() sign.Keypair {
+	if fc.keypair == nil {
+		return nil
+	}
+	return fc.keypair
+}
+
+func (fc *fulcioCredentials) CertificateProvider() (sign.CertificateProvider, *sign.CertificateProviderOptions) {
+	return fc.cp, &sign.CertificateProviderOptions{IDToken: fc.token}
+}
+
+// Intermediates returns nil, the chain is read from the trusted root when
+// verifying.
+func (fc *fulcioCredentials) Intermediates() []*x509.Certificate { return nil }
@@ -0,0 +1,333 @@
+// SPDX-FileCopyrightText: Copyright 2026 The SLSA Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package attest
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/carabiner-dev/collector/envelope"
+	"github.com/carabiner-dev/collector/envelope/bundle"
+	"github.com/carabiner-dev/signer/key"
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore/pkg/signature/kms/fake"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slsa-framework/source-tool/pkg/slsa"
+)
+
+const (
+	fakeIssuer = "https://oidc.example.com"
+	fakeSan    = "https://git.example.com/org/source-actions/.github/workflows/compute_slsa_source.yml@refs/heads/main"
+)
+
+// fakeSigstore is a private sigstore instance with only a certificate
+// authority, served locally.
+type fakeSigstore struct {
+	signingConfig string
+	trustedRoot   string
+}
+
+// newFakeSigstore starts a fake Fulcio issuing certificates for fakeSan and
+// writes the signing config and trusted root of the instance.
+func newFakeSigstore(t *testing.T) *fakeSigstore {
+	t.Helper()
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "fake-fulcio"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	require.NoError(t, err)
+	caCert, err := x509.ParseCertificate(caDER)
+	require.NoError(t, err)
+
+	issuerV2, err := asn1.MarshalWithParams(fakeIssuer, "utf8")
+	require.NoError(t, err)
+	sanURL, err := url.Parse(fakeSan)
+	require.NoError(t, err)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := struct {
+			PublicKeyRequest struct {
+				PublicKey struct {
+					Content string `json:"content"`
+				} `json:"publicKey"`
+			} `json:"publicKeyRequest"`
+		}{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		block, _ := pem.Decode([]byte(req.PublicKeyRequest.PublicKey.Content))
+		if block == nil {
+			http.Error(w, "no public key", http.StatusBadRequest)
+			return
+		}
+		pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		leafDER, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+			SerialNumber: big.NewInt(2),
+			NotBefore:    time.Now().Add(-time.Minute),
+			NotAfter:     time.Now().Add(10 * time.Minute),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+			URIs:         []*url.URL{sanURL},
+			ExtraExtensions: []pkix.Extension{
+				{Id: asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 8}, Value: issuerV2},
+			},
+		}, caCert, pub, caKey)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"signedCertificateEmbeddedSct": {"chain": {"certificates": [%q, %q]}}}`,
+			pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: leafDER}),
+			pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER}),
+		)
+	}))
+	t.Cleanup(srv.Close)
+
+	dir := t.TempDir()
+	fs := &fakeSigstore{
+		signingConfig: filepath.Join(dir, "signing_config.json"),
+		trustedRoot:   filepath.Join(dir, "trusted_root.json"),
+	}
+	require.NoError(t, os.WriteFile(fs.signingConfig, fmt.Appendf(nil, `{
+		"mediaType": "application/vnd.dev.sigstore.signingconfig.v0.2+json",
+		"caUrls": [{"url": %q, "majorApiVersion": 1, "validFor": {"start": "2020-01-01T00:00:00Z"}}]
+	}`, srv.URL), 0o600))
+
+	tr, err := root.NewTrustedRoot(root.TrustedRootMediaType01, []root.CertificateAuthority{
+		&root.FulcioCertificateAuthority{Root: caCert, ValidityPeriodStart: caCert.NotBefore, URI: srv.URL},
+	}, nil, nil, nil)
+	require.NoError(t, err)
+	data, err := tr.MarshalJSON()
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(fs.trustedRoot, data, 0o600))
+	return fs
+}
+
+// fakeIDToken returns an unsigned JWT, the fake Fulcio does not check it
+func fakeIDToken() string {
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString([]byte(`{"alg":"none"}`)) + "." +
+		enc.EncodeToString([]byte(`{"sub":"test","iss":"`+fakeIssuer+`"}`)) + ".sig"
+}
+
+func TestSignPrivateInstance(t *testing.T) {
+	t.Setenv(idTokenEnvVar, fakeIDToken())
+	instance := newFakeSigstore(t)
+	other := newFakeSigstore(t)
+
+	statement, err := CreateUnsignedSourceVsa(
+		newTestBranch("github.com", "example/repo", "main"), newTestCommit("abc123"),
+		slsa.SourceVerifiedLevels{slsa.ControlName(slsa.SlsaSourceLevel3)}, "policy", nil,
+	)
+	require.NoError(t, err)
+
+	// Bundles are checked against the trusted root after signing
+	_, err = NewBndSigner(SigningOptions{
+		SigningConfig: instance.signingConfig, TrustedRoot: other.trustedRoot,
+	}).Sign(statement)
+	require.ErrorContains(t, err, "trusted root")
+
+	signed, err := NewBndSigner(SigningOptions{
+		SigningConfig: instance.signingConfig, TrustedRoot: instance.trustedRoot,
+	}).Sign(statement)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name    string
+		opts    VerificationOptions
+		mustErr bool
+	}{
+		{
+			name: "trusted-root",
+			opts: VerificationOptions{ExpectedIssuer: fakeIssuer, ExpectedSan: fakeSan, TrustedRoot: instance.trustedRoot},
+		},
+		{
+			name:    "other-trusted-root",
+			opts:    VerificationOptions{ExpectedIssuer: fakeIssuer, ExpectedSan: fakeSan, TrustedRoot: other.trustedRoot},
+			mustErr: true,
+		},
+		{
+			name:    "default-identity",
+			opts:    VerificationOptions{ExpectedIssuer: ExpectedIssuer, ExpectedSan: ExpectedSan, TrustedRoot: instance.trustedRoot},
+			mustErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			envs, err := (&bundle.Parser{}).Parse([]byte(signed))
+			require.NoError(t, err)
+			require.Len(t, envs, 1)
+
+			err = NewBndVerifier(tc.opts).VerifyEnvelope(envs[0])
+			if tc.mustErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestSignWithKey(t *testing.T) {
+	t.Parallel()
+	statement, err := CreateUnsignedSourceVsa(
+		newTestBranch("github.com", "example/repo", "main"), newTestCommit("abc123"),
+		slsa.SourceVerifiedLevels{slsa.ControlName(slsa.SlsaSourceLevel3)}, "policy", nil,
+	)
+	require.NoError(t, err)
+
+	// newKey generates an ECDSA key and returns it with its PEM encoded
+	// public key
+	newKey := func() (*ecdsa.PrivateKey, string) {
+		priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		der, err := x509.MarshalPKIXPublicKey(&priv.PublicKey)
+		require.NoError(t, err)
+		return priv, string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	}
+	priv, pub := newKey()
+	_, otherPub := newKey()
+
+	dir := t.TempDir()
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	require.NoError(t, err)
+	keyPath := filepath.Join(dir, "signer.key")
+	require.NoError(t, os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "bad.key"), []byte("not a key"), 0o600))
+
+	signedFile, err := NewBndSigner(SigningOptions{Key: keyPath}).Sign(statement)
+	require.NoError(t, err)
+
+	// The fake KMS signs with the key in the context
+	env, err := signWithKMS(
+		context.WithValue(t.Context(), fake.KmsCtxKey{}, priv), fake.ReferenceScheme+"key", []byte(statement),
+	)
+	require.NoError(t, err)
+	require.Len(t, env.GetSignatures(), 1)
+	require.Equal(t, (&key.Public{Key: &priv.PublicKey}).ID(), env.GetSignatures()[0].GetKeyid())
+	signedKMS, err := json.Marshal(env)
+	require.NoError(t, err)
+
+	// The fake token holds the key in memory
+	env, err = signWithPKCS11(
+		"pkcs11:token=tok;object=signer?module-path=/usr/lib/fake.so",
+		func(*pkcs11Ref) (crypto.Signer, io.Closer, error) { return priv, io.NopCloser(nil), nil },
+		[]byte(statement),
+	)
+	require.NoError(t, err)
+	require.Equal(t, (&key.Public{Key: &priv.PublicKey}).ID(), env.GetSignatures()[0].GetKeyid())
+	signedPKCS11, err := json.Marshal(env)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name    string
+		signed  string
+		pub     string
+		mustErr bool
+	}{
+		{name: "key-file", signed: signedFile, pub: pub},
+		{name: "key-file-other-key", signed: signedFile, pub: otherPub, mustErr: true},
+		{name: "kms", signed: string(signedKMS), pub: pub},
+		{name: "kms-other-key", signed: string(signedKMS), pub: otherPub, mustErr: true},
+		{name: "pkcs11", signed: string(signedPKCS11), pub: pub},
+		{name: "pkcs11-other-key", signed: string(signedPKCS11), pub: otherPub, mustErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			envs, err := envelope.Parsers.Parse(bytes.NewReader([]byte(tc.signed)))
+			require.NoError(t, err)
+			require.Len(t, envs, 1)
+
+			err = NewBndVerifier(VerificationOptions{
+				TrustPolicy: &TrustPolicy{Identities: []*TrustedIdentity{{PublicKey: tc.pub}}},
+			}).VerifyEnvelope(envs[0])
+			if tc.mustErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+
+	for _, ref := range []string{filepath.Join(dir, "missing.key"), filepath.Join(dir, "bad.key")} {
+		_, err := NewBndSigner(SigningOptions{Key: ref}).Sign(statement)
+		require.Error(t, err)
+	}
+
+	// Builds without the pkcs11 tag can't open tokens
+	_, err = signWithPKCS11("pkcs11:token=tok;object=signer?module-path=/usr/lib/fake.so", nil, []byte(statement))
+	require.Error(t, err)
+}
+
+func TestParsePKCS11Ref(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		name     string
+		uri      string
+		expected *pkcs11Ref
+		mustErr  bool
+	}{
+		{
+			name:     "label",
+			uri:      "pkcs11:token=my%20token;object=signer?module-path=/usr/lib/softhsm/libsofthsm2.so&pin-value=1234",
+			expected: &pkcs11Ref{ModulePath: "/usr/lib/softhsm/libsofthsm2.so", Token: "my token", Label: "signer", Pin: "1234"},
+		},
+		{
+			name:     "id",
+			uri:      "pkcs11:token=tok;id=%01%02;type=private?module-path=/lib/p11.so&pin-value=1",
+			expected: &pkcs11Ref{ModulePath: "/lib/p11.so", Token: "tok", ID: []byte{1, 2}, Pin: "1"},
+		},
+		{name: "no-module", uri: "pkcs11:token=tok;object=signer?pin-value=1", mustErr: true},
+		{name: "no-token", uri: "pkcs11:object=signer?module-path=/lib/p11.so", mustErr: true},
+		{name: "no-key", uri: "pkcs11:token=tok?module-path=/lib/p11.so", mustErr: true},
+		{name: "bad-attr", uri: "pkcs11:token?module-path=/lib/p11.so", mustErr: true},
+		{name: "bad-escape", uri: "pkcs11:token=%zz;object=a?module-path=/lib/p11.so", mustErr: true},
+		{name: "not-pkcs11", uri: "awskms:///key", mustErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ref, err := parsePKCS11Ref(tc.uri)
+			if tc.mustErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, ref)
+		})
+	}
+}
@@ -0,0 +1,114 @@
+// SPDX-FileCopyrightText: Copyright 2026 The SLSA Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package attest
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/carabiner-dev/attestation"
+	"github.com/carabiner-dev/collector/envelope/bundle"
+	sgbundle "github.com/sigstore/sigstore-go/pkg/bundle"
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/tlog"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+)
+
+// TlogEntry is the transparency log entry recording the signature of an
+// attestation. Auditors can look it up in the log by its index.
+type TlogEntry struct {
+	LogIndex       int64     `json:"log_index"`
+	LogID          string    `json:"log_id"`
+	IntegratedTime time.Time `json:"integrated_time"`
+
+	// Verified is true when the entry was checked against the trusted root
+	Verified bool `json:"verified"`
+}
+
+func (te *TlogEntry) String() string {
+	s := fmt.Sprintf("log index %d integrated at %s", te.LogIndex, te.IntegratedTime.UTC().Format(time.RFC3339))
+	if !te.Verified {
+		s += " (unverified)"
+	}
+	return s
+}
+
+// TlogEntries returns the transparency log entries of a sigstore bundle.
+// Other envelopes have no entries. The entries are read as found in the
+// bundle and are not verified, use the verifier TlogEntries to check them.
+func TlogEntries(env attestation.Envelope) ([]*TlogEntry, error) {
+	bndl, ok := env.(*bundle.Envelope)
+	if !ok {
+		return nil, nil
+	}
+	entries, err := (&sgbundle.Bundle{Bundle: &bndl.Bundle}).TlogEntries()
+	if err != nil {
+		return nil, fmt.Errorf("reading transparency log entries: %w", err)
+	}
+	return toTlogEntries(entries, false), nil
+}
+
+// TlogEntries returns the transparency log entries of an envelope. When the
+// verifier requires a transparency log, the entries are verified against
+// the trusted root. Otherwise they are returned unverified.
+func (bv *BndVerifier) TlogEntries(env attestation.Envelope) ([]*TlogEntry, error) {
+	if !bv.Options.RequireTlog {
+		return TlogEntries(env)
+	}
+	return bv.verifyTlog(env)
+}
+
+// verifyTlog checks the transparency log entries of an envelope against
+// the trusted root. The inclusion proofs and signed entry timestamps are
+// verified offline, neither the log nor the sigstore TUF repository are
+// queried so the trusted root must be set.
+func (bv *BndVerifier) verifyTlog(env attestation.Envelope) ([]*TlogEntry, error) {
+	bndl, ok := env.(*bundle.Envelope)
+	if !ok {
+		return nil, errors.New("only sigstore bundles are recorded in a transparency log")
+	}
+	if bv.Options.TrustedRoot == "" {
+		return nil, errors.New("a trusted root is required to verify transparency log entries")
+	}
+
+	tr, err := root.NewTrustedRootFromPath(bv.Options.TrustedRoot)
+	if err != nil {
+		return nil, fmt.Errorf("reading trusted root: %w", err)
+	}
+	return verifyTlogEntries(&sgbundle.Bundle{Bundle: &bndl.Bundle}, tr)
+}
+
+// verifyTlogEntries checks a signed entity has at least one transparency
+// log entry that verifies against the trusted material and returns them
+func verifyTlogEntries(entity verify.SignedEntity, tm root.TrustedMaterial) ([]*TlogEntry, error) {
+	if len(tm.RekorLogs()) == 0 {
+		return nil, errors.New("trusted root lists no transparency logs")
+	}
+	entries, err := entity.TlogEntries()
+	if err != nil {
+		return nil, fmt.Errorf("reading transparency log entries: %w", err)
+	}
+	if len(entries) == 0 {
+		return nil, errors.New("attestation has no transparency log entry")
+	}
+	if _, err := verify.VerifyTlogEntry(entity, tm, 1, true); err != nil {
+		return nil, err
+	}
+	return toTlogEntries(entries, true), nil
+}
+
+func toTlogEntries(entries []*tlog.Entry, verified bool) []*TlogEntry {
+	ret := make([]*TlogEntry, 0, len(entries))
+	for _, e := range entries {
+		ret = append(ret, &TlogEntry{
+			LogIndex:       e.LogIndex(),
+			LogID:          hex.EncodeToString([]byte(e.LogKeyID())),
+			IntegratedTime: e.IntegratedTime(),
+			Verified:       verified,
+		})
+	}
+	return ret
+}
@@ -0,0 +1,100 @@
+// SPDX-FileCopyrightText: Copyright 2026 The SLSA Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package attest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/carabiner-dev/collector/envelope/bundle"
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/testing/ca"
+	"github.com/sigstore/sigstore-go/pkg/tlog"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slsa-framework/source-tool/pkg/slsa"
+)
+
+// unloggedEntity is a signed entity without transparency log entries
+type unloggedEntity struct {
+	verify.SignedEntity
+}
+
+func (u *unloggedEntity) TlogEntries() ([]*tlog.Entry, error) { return nil, nil }
+
+func TestVerifyTlogEntries(t *testing.T) {
+	t.Parallel()
+	statement, err := CreateUnsignedSourceVsa(
+		newTestBranch("github.com", "example/repo", "main"), newTestCommit("abc123"),
+		slsa.SourceVerifiedLevels{slsa.ControlName(slsa.SlsaSourceLevel3)}, "policy", nil,
+	)
+	require.NoError(t, err)
+
+	instance, err := ca.NewVirtualSigstore()
+	require.NoError(t, err)
+	other, err := ca.NewVirtualSigstore()
+	require.NoError(t, err)
+
+	// The leaf certificates of the virtual instance are valid from now
+	integrated := time.Now().Add(5 * time.Minute).Truncate(time.Second)
+	withSET, err := instance.AttestAtTime(fakeSan, fakeIssuer, []byte(statement), integrated, false)
+	require.NoError(t, err)
+	withProof, err := instance.AttestAtTime(fakeSan, fakeIssuer, []byte(statement), integrated, true)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name    string
+		entity  verify.SignedEntity
+		tm      root.TrustedMaterial
+		mustErr bool
+	}{
+		{name: "signed-entry-timestamp", entity: withSET, tm: instance},
+		{name: "inclusion-proof", entity: withProof, tm: instance},
+		{name: "other-log", entity: withSET, tm: other, mustErr: true},
+		{name: "no-entries", entity: &unloggedEntity{withSET}, tm: instance, mustErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			entries, err := verifyTlogEntries(tc.entity, tc.tm)
+			if tc.mustErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, entries, 1)
+			require.True(t, integrated.Equal(entries[0].IntegratedTime))
+			require.NotEmpty(t, entries[0].LogID)
+			require.True(t, entries[0].Verified)
+		})
+	}
+}
+
+func TestVerifyEnvelopeRequireTlog(t *testing.T) {
+	t.Parallel()
+	env := &fakeEnvelope{verification: signedBy(ExpectedIssuer, ExpectedSan)}
+
+	entries, err := TlogEntries(env)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+
+	opts := DefaultVerifierOptions
+	require.NoError(t, NewBndVerifier(opts).VerifyEnvelope(env))
+
+	// Envelopes that are not sigstore bundles carry no log entry
+	opts.RequireTlog = true
+	require.ErrorContains(t, NewBndVerifier(opts).VerifyEnvelope(env), "transparency log")
+
+	// The entries are only verified offline, against a trusted root
+	_, err = NewBndVerifier(opts).TlogEntries(&bundle.Envelope{})
+	require.ErrorContains(t, err, "trusted root is required")
+}
+
+func TestTlogEntryString(t *testing.T) {
+	t.Parallel()
+	e := &TlogEntry{LogIndex: 42, IntegratedTime: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	require.Equal(t, "log index 42 integrated at 2025-01-01T00:00:00Z (unverified)", e.String())
+	e.Verified = true
+	require.Equal(t, "log index 42 integrated at 2025-01-01T00:00:00Z", e.String())
+}
@@ -0,0 +1,218 @@
+// SPDX-FileCopyrightText: Copyright 2026 The SLSA Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package attest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+
+	"github.com/carabiner-dev/attestation"
+	sapi "github.com/carabiner-dev/signer/api/v1"
+	"github.com/carabiner-dev/signer/key"
+)
+
+// TrustPolicy lists the signer identities accepted when verifying
+// attestations. An attestation is trusted when its signer matches any of
+// the identities that apply to its predicate type.
+type TrustPolicy struct {
+	Identities []*TrustedIdentity `json:"identities"`
+}
+
+// TrustedIdentity is a signer accepted by a trust policy. It is either a
+// keyless (sigstore) identity, matched by issuer and SAN, or a key based
+// signer.
+type TrustedIdentity struct {
+	// PredicateTypes limits the identity to attestations of these predicate
+	// types. When empty, the identity is trusted for all attestations.
+	PredicateTypes []string `json:"predicate_types,omitempty"`
+
+	// Issuer is the OIDC issuer of the signer certificate
+	Issuer string `json:"issuer,omitempty"`
+
+	// The signer SAN is matched with exactly one of San (exact match),
+	// SanRegex (anchored regular expression) or SanPrefix.
+	San       string `json:"san,omitempty"`
+	SanRegex  string `json:"san_regex,omitempty"`
+	SanPrefix string `json:"san_prefix,omitempty"`
+
+	// PublicKey is the PEM encoded public key of a key based signer
+	PublicKey string `json:"public_key,omitempty"`
+
+	// PublicKeyFile is the path to the public key of a key based signer,
+	// relative to the trust policy file. It is read into PublicKey when
+	// loading the policy.
+	PublicKeyFile string `json:"public_key_file,omitempty"`
+}
+
+// LoadTrustPolicy reads and validates a trust policy file
+func LoadTrustPolicy(path string) (*TrustPolicy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading trust policy: %w", err)
+	}
+
+	tp := &TrustPolicy{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(tp); err != nil {
+		return nil, fmt.Errorf("parsing trust policy: %w", err)
+	}
+
+	for i, ti := range tp.Identities {
+		if ti.PublicKeyFile == "" {
+			continue
+		}
+		keyPath := ti.PublicKeyFile
+		if !filepath.IsAbs(keyPath) {
+			keyPath = filepath.Join(filepath.Dir(path), keyPath)
+		}
+		keyData, err := os.ReadFile(keyPath)
+		if err != nil {
+			return nil, fmt.Errorf("identity #%d: reading public key: %w", i, err)
+		}
+		ti.PublicKey = string(keyData)
+	}
+
+	if err := tp.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid trust policy: %w", err)
+	}
+	return tp, nil
+}
+
+// Validate checks the identities of the trust policy are well formed
+func (tp *TrustPolicy) Validate() error {
+	if len(tp.Identities) == 0 {
+		return errors.New("trust policy lists no identities")
+	}
+	errs := []error{}
+	for i, ti := range tp.Identities {
+		if err := ti.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("identity #%d: %w", i, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Validate checks the identity is either a complete keyless identity or a
+// key based one
+func (ti *TrustedIdentity) Validate() error {
+	sanMatchers := 0
+	for _, s := range []string{ti.San, ti.SanRegex, ti.SanPrefix} {
+		if s != "" {
+			sanMatchers++
+		}
+	}
+
+	if ti.PublicKey != "" {
+		if ti.Issuer != "" || sanMatchers > 0 {
+			return errors.New("key based identities cannot set an issuer or SAN")
+		}
+		if _, err := key.NewParser().ParsePublicKeyProvider([]byte(ti.PublicKey)); err != nil {
+			return fmt.Errorf("parsing public key: %w", err)
+		}
+		return nil
+	}
+
+	errs := []error{}
+	if ti.Issuer == "" {
+		errs = append(errs, errors.New("identity has no issuer"))
+	}
+	if sanMatchers != 1 {
+		errs = append(errs, errors.New("exactly one of san, san_regex or san_prefix must be set"))
+	}
+	if ti.SanRegex != "" {
+		if _, err := regexp.Compile(ti.SanRegex); err != nil {
+			errs = append(errs, fmt.Errorf("invalid san_regex: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// AppliesTo returns true if the identity is trusted for attestations of
+// the predicate type
+func (ti *TrustedIdentity) AppliesTo(predicateType string) bool {
+	return len(ti.PredicateTypes) == 0 || slices.Contains(ti.PredicateTypes, predicateType)
+}
+
+// identity returns the identity as matched by the signer library
+func (ti *TrustedIdentity) identity() *sapi.Identity {
+	if ti.PublicKey != "" {
+		return &sapi.Identity{Key: &sapi.IdentityKey{Data: ti.PublicKey}}
+	}
+
+	sanMatch := &sapi.StringMatcher{}
+	switch {
+	case ti.SanRegex != "":
+		sanMatch.Kind = &sapi.StringMatcher_Regex{Regex: ti.SanRegex}
+	case ti.SanPrefix != "":
+		sanMatch.Kind = &sapi.StringMatcher_Prefix{Prefix: ti.SanPrefix}
+	default:
+		sanMatch.Kind = &sapi.StringMatcher_Exact{Exact: ti.San}
+	}
+	return &sapi.Identity{Sigstore: &sapi.IdentitySigstore{
+		IssuerMatch:   &sapi.StringMatcher{Kind: &sapi.StringMatcher_Exact{Exact: ti.Issuer}},
+		IdentityMatch: sanMatch,
+	}}
+}
+
+// String returns a short description of the identity for error messages
+func (ti *TrustedIdentity) String() string {
+	switch {
+	case ti.PublicKey != "":
+		return "public key"
+	case ti.SanRegex != "":
+		return fmt.Sprintf("issuer %q identity matching %q", ti.Issuer, ti.SanRegex)
+	case ti.SanPrefix != "":
+		return fmt.Sprintf("issuer %q identity starting with %q", ti.Issuer, ti.SanPrefix)
+	default:
+		return fmt.Sprintf("issuer %q identity %q", ti.Issuer, ti.San)
+	}
+}
+
+// Matches returns true if the signer in the verification data matches any
+// of the identities trusted for the predicate type
+func (tp *TrustPolicy) Matches(verification attestation.Verification, predicateType string) bool {
+	for _, ti := range tp.Identities {
+		if ti.AppliesTo(predicateType) && verification.MatchesIdentity(ti.identity()) {
+			return true
+		}
+	}
+	return false
+}
+
+// PublicKeys returns the keys of the key based identities, they are needed
+// to check the signatures of key signed envelopes.
+func (tp *TrustPolicy) PublicKeys() ([]key.PublicKeyProvider, error) {
+	keys := []key.PublicKeyProvider{}
+	for _, ti := range tp.Identities {
+		if ti.PublicKey == "" {
+			continue
+		}
+		k, err := key.NewParser().ParsePublicKeyProvider([]byte(ti.PublicKey))
+		if err != nil {
+			return nil, fmt.Errorf("parsing public key: %w", err)
+		}
+		keys = append(keys, k)
+	}
+	return keys, nil
+}
+
+// trustPolicyFromIdentity returns a trust policy accepting the issuer and
+// any of the SANs for all attestations
+func trustPolicyFromIdentity(issuer string, sans ...string) *TrustPolicy {
+	tp := &TrustPolicy{Identities: []*TrustedIdentity{}}
+	for _, san := range sans {
+		if san == "" {
+			continue
+		}
+		tp.Identities = append(tp.Identities, &TrustedIdentity{Issuer: issuer, San: san})
+	}
+	return tp
+}
@@ -0,0 +1,202 @@
+// SPDX-FileCopyrightText: Copyright 2026 The SLSA Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package attest
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/carabiner-dev/attestation"
+	cdsse "github.com/carabiner-dev/collector/envelope/dsse"
+	"github.com/carabiner-dev/signer"
+	"github.com/carabiner-dev/signer/key"
+	"github.com/carabiner-dev/signer/options"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slsa-framework/source-tool/pkg/provenance"
+	"github.com/slsa-framework/source-tool/pkg/slsa"
+)
+
+const forkSan = "https://github.com/example/source-actions/.github/workflows/compute_slsa_source.yml@refs/tags/v1.2.0"
+
+func TestLoadTrustPolicy(t *testing.T) {
+	t.Parallel()
+	priv, err := key.NewGenerator().GenerateKeyPair()
+	require.NoError(t, err)
+	pub, err := priv.PublicKey()
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "signer.pub"), []byte(pub.Data), 0o600))
+
+	for _, tc := range []struct {
+		name    string
+		data    string
+		mustErr bool
+	}{
+		{
+			name: "valid",
+			data: `{"identities": [
+				{"issuer": "https://token.actions.githubusercontent.com", "san_prefix": "https://github.com/example/"},
+				{"issuer": "https://token.actions.githubusercontent.com", "san_regex": "https://github\\.com/example/.*", "predicate_types": ["https://slsa.dev/verification_summary/v1"]},
+				{"public_key_file": "signer.pub"}
+			]}`,
+		},
+		{name: "no-identities", data: `{"identities": []}`, mustErr: true},
+		{name: "unknown-field", data: `{"identities": [{"issuer": "a", "san": "b", "subject": "c"}]}`, mustErr: true},
+		{name: "no-issuer", data: `{"identities": [{"san": "b"}]}`, mustErr: true},
+		{name: "no-san", data: `{"identities": [{"issuer": "a"}]}`, mustErr: true},
+		{name: "two-san-matchers", data: `{"identities": [{"issuer": "a", "san": "b", "san_prefix": "c"}]}`, mustErr: true},
+		{name: "bad-regex", data: `{"identities": [{"issuer": "a", "san_regex": "("}]}`, mustErr: true},
+		{name: "key-with-san", data: `{"identities": [{"public_key_file": "signer.pub", "san": "b"}]}`, mustErr: true},
+		{name: "missing-key-file", data: `{"identities": [{"public_key_file": "missing.pub"}]}`, mustErr: true},
+		{name: "bad-key", data: `{"identities": [{"public_key": "not a key"}]}`, mustErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			path := filepath.Join(dir, tc.name+".json")
+			require.NoError(t, os.WriteFile(path, []byte(tc.data), 0o600))
+			tp, err := LoadTrustPolicy(path)
+			if tc.mustErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, tp.Identities, 3)
+			require.Equal(t, pub.Data, tp.Identities[2].PublicKey)
+		})
+	}
+}
+
+func TestVerifyEnvelopeTrustPolicy(t *testing.T) {
+	t.Parallel()
+	branch := newTestBranch("github.com", "example/repo", "main")
+	commit := newTestCommit("abc123")
+	levels := slsa.SourceVerifiedLevels{slsa.ControlName(slsa.SlsaSourceLevel3)}
+
+	verifier := NewBndVerifier(VerificationOptions{TrustPolicy: &TrustPolicy{Identities: []*TrustedIdentity{
+		{Issuer: ExpectedIssuer, SanPrefix: "https://github.com/example/source-actions/.github/workflows/compute_slsa_source.yml@refs/tags/"},
+		{
+			Issuer:         ExpectedIssuer,
+			SanRegex:       `https://github\.com/example/vsa-signer/\.github/workflows/.+@refs/heads/.+`,
+			PredicateTypes: []string{VsaPredicateType},
+		},
+	}}})
+
+	for _, tc := range []struct {
+		name    string
+		env     attestation.Envelope
+		mustErr bool
+	}{
+		{
+			name: "prefix-match",
+			env:  &fakeEnvelope{verification: signedBy(ExpectedIssuer, forkSan)},
+		},
+		{
+			name:    "prefix-other-issuer",
+			env:     &fakeEnvelope{verification: signedBy("https://accounts.google.com", forkSan)},
+			mustErr: true,
+		},
+		{
+			// The default identity is not trusted when a policy is set
+			name:    "default-identity",
+			env:     &fakeEnvelope{verification: signedBy(ExpectedIssuer, ExpectedSan)},
+			mustErr: true,
+		},
+		{
+			name: "regex-match-vsa",
+			env: newVSAEnvelope(t, branch, commit, levels,
+				"https://github.com/example/vsa-signer/.github/workflows/vsa.yml@refs/heads/release"),
+		},
+		{
+			// The regex is anchored, a SAN with extra data must not match
+			name: "regex-anchored",
+			env: newVSAEnvelope(t, branch, commit, levels,
+				"https://github.com/attacker/x?https://github.com/example/vsa-signer/.github/workflows/vsa.yml@refs/heads/release"),
+			mustErr: true,
+		},
+		{
+			// The VSA signer is not trusted for other predicate types
+			name: "regex-other-predicate",
+			env: &fakeEnvelope{verification: signedBy(
+				ExpectedIssuer, "https://github.com/example/vsa-signer/.github/workflows/vsa.yml@refs/heads/release",
+			)},
+			mustErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			err := verifier.VerifyEnvelope(tc.env)
+			if tc.mustErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestVerifyEnvelopeKeySigner(t *testing.T) {
+	t.Parallel()
+	branch := newTestBranch("github.com", "example/repo", "main")
+	statement, err := CreateUnsignedSourceVsa(
+		branch, newTestCommit("abc123"), slsa.SourceVerifiedLevels{slsa.ControlName(slsa.SlsaSourceLevel3)}, "policy", nil,
+	)
+	require.NoError(t, err)
+
+	newKey := func() (*key.Private, *key.Public) {
+		priv, err := key.NewGenerator().GenerateKeyPair()
+		require.NoError(t, err)
+		pub, err := priv.PublicKey()
+		require.NoError(t, err)
+		return priv, pub
+	}
+	priv, pub := newKey()
+	_, otherPub := newKey()
+
+	signed, err := signer.NewSigner().SignStatementToDSSE([]byte(statement), options.WithKey(priv))
+	require.NoError(t, err)
+	data, err := json.Marshal(signed)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name    string
+		tp      *TrustPolicy
+		mustErr bool
+	}{
+		{
+			name: "trusted-key",
+			tp:   &TrustPolicy{Identities: []*TrustedIdentity{{PublicKey: pub.Data}}},
+		},
+		{
+			name:    "other-key",
+			tp:      &TrustPolicy{Identities: []*TrustedIdentity{{PublicKey: otherPub.Data}}},
+			mustErr: true,
+		},
+		{
+			name: "key-for-other-predicate",
+			tp: &TrustPolicy{Identities: []*TrustedIdentity{
+				{PublicKey: pub.Data, PredicateTypes: []string{provenance.SourceProvPredicateType}},
+			}},
+			mustErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			envs, err := (&cdsse.Parser{}).ParseStream(bytes.NewReader(data))
+			require.NoError(t, err)
+			require.Len(t, envs, 1)
+
+			err = NewBndVerifier(VerificationOptions{TrustPolicy: tc.tp}).VerifyEnvelope(envs[0])
+			if tc.mustErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
@@ -8,10 +8,14 @@ import (
 	"fmt"
 
 	"github.com/carabiner-dev/attestation"
+	"github.com/carabiner-dev/collector/envelope/bundle"
 	"github.com/carabiner-dev/signer"
 	sapi "github.com/carabiner-dev/signer/api/v1"
 	"github.com/carabiner-dev/signer/options"
+	sgbundle "github.com/sigstore/sigstore-go/pkg/bundle"
+	"github.com/sigstore/sigstore-go/pkg/root"
 	"github.com/sigstore/sigstore-go/pkg/verify"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type VerificationOptions struct {
@@ -24,6 +28,28 @@ type VerificationOptions struct {
 	//
 	// See https://github.com/slsa-framework/source-tool/issues/255
 	AlternateSans []string
+
+	// TrustedRoot is the path to a sigstore trusted root (trusted_root.json).
+	// When set, sigstore bundles are verified offline against it instead of
+	// the trusted root fetched from the sigstore TUF repository.
+	TrustedRoot string
+
+	// TrustPolicy lists the signer identities accepted. When set, it
+	// replaces the expected issuer and SANs.
+	TrustPolicy *TrustPolicy
+
+	// RequireTlog requires attestations to be recorded in a transparency
+	// log. The inclusion proof or signed entry timestamp of the log entry
+	// is verified offline against the trusted root, which must be set.
+	RequireTlog bool
+}
+
+// trustPolicy returns the identities accepted by the verifier
+func (vo *VerificationOptions) trustPolicy() *TrustPolicy {
+	if vo.TrustPolicy != nil {
+		return vo.TrustPolicy
+	}
+	return trustPolicyFromIdentity(vo.ExpectedIssuer, append([]string{vo.ExpectedSan}, vo.AlternateSans...)...)
 }
 
 const (
@@ -42,8 +68,8 @@ const (
 	OldExpectedSan = "https://github.com/slsa-framework/slsa-source-poc/.github/workflows/compute_slsa_source.yml@refs/heads/main"
 )
 
-// TODO: Update ExpectedSan to support regex so we can get the branches/tags we really think
-// folks should be using (they won't all run from main).
+// DefaultVerifierOptions accept the SLSA source-actions workflow identity.
+// To accept other identities or SAN patterns, set a TrustPolicy.
 var DefaultVerifierOptions = VerificationOptions{
 	ExpectedIssuer: ExpectedIssuer,
 	ExpectedSan:    ExpectedSan,
@@ -58,6 +84,10 @@ type Verifier interface {
 	// identity. Envelopes that carry no verifiable signature (eg bare
 	// statements) must return an error.
 	VerifyEnvelope(env attestation.Envelope) error
+
+	// TlogEntries returns the transparency log entries of an envelope,
+	// flagged as verified only when they were checked.
+	TlogEntries(env attestation.Envelope) ([]*TlogEntry, error)
 }
 
 type BndVerifier struct {
@@ -84,16 +114,32 @@ func (bv *BndVerifier) Verify(data string) (*verify.VerificationResult, error) {
 
 // VerifyEnvelope verifies the signature of an attestation envelope fetched
 // by the collector and checks that the signer matches the expected identity
-// (issuer + SAN) or one of the accepted alternate identities.
+// (issuer + SAN), one of the accepted alternate identities or, when set, an
+// identity of the trust policy.
 func (bv *BndVerifier) VerifyEnvelope(env attestation.Envelope) error {
 	if env == nil {
 		return errors.New("unable to verify, envelope is nil")
 	}
+	tp := bv.Options.trustPolicy()
+
+	// With a trusted root, the signatures are checked offline. This records
+	// the verification data in the envelope, so Verify below does not check
+	// the signatures again.
+	if bv.Options.TrustedRoot != "" {
+		if err := bv.verifyWithTrustedRoot(env); err != nil {
+			return fmt.Errorf("verifying envelope signature: %w", err)
+		}
+	}
 
 	// Verify the envelope signatures. Note that this call only checks the
 	// cryptographic integrity of the envelope, identity verification is
-	// done below by matching the verification data.
-	if err := env.Verify(); err != nil {
+	// done below by matching the verification data. The keys of the trust
+	// policy are passed to check envelopes signed with keys.
+	keys, err := tp.PublicKeys()
+	if err != nil {
+		return err
+	}
+	if err := env.Verify(keys); err != nil {
 		return fmt.Errorf("verifying envelope signature: %w", err)
 	}
 
@@ -104,27 +150,98 @@ func (bv *BndVerifier) VerifyEnvelope(env attestation.Envelope) error {
 		return errors.New("envelope carries no verified signature")
 	}
 
-	// Check the signer identity against the expected SANs
-	for _, san := range append([]string{bv.Options.ExpectedSan}, bv.Options.AlternateSans...) {
-		if san == "" {
-			continue
-		}
-		if verification.MatchesIdentity(&sapi.Identity{
-			Sigstore: &sapi.IdentitySigstore{
-				Issuer:   bv.Options.ExpectedIssuer,
-				Identity: san,
-			},
-		}) {
-			return nil
+	if bv.Options.RequireTlog {
+		if _, err := bv.verifyTlog(env); err != nil {
+			return fmt.Errorf("verifying transparency log entry: %w", err)
 		}
 	}
 
+	// Check the signer identity against the accepted identities
+	predicateType := ""
+	if s := env.GetStatement(); s != nil {
+		predicateType = string(s.GetPredicateType())
+	}
+	if tp.Matches(verification, predicateType) {
+		return nil
+	}
+
+	if bv.Options.TrustPolicy != nil {
+		return fmt.Errorf("envelope signer does not match any identity of the trust policy for %q", predicateType)
+	}
 	return fmt.Errorf(
 		"envelope signer does not match the expected identity (issuer %q identity %q)",
 		bv.Options.ExpectedIssuer, bv.Options.ExpectedSan,
 	)
 }
 
+// verifyWithTrustedRoot checks the signatures of a sigstore bundle against
+// the configured trusted root and records the signer identity in the
+// envelope, as the collector does when verifying online.
+func (bv *BndVerifier) verifyWithTrustedRoot(env attestation.Envelope) error {
+	bndl, ok := env.(*bundle.Envelope)
+	if !ok {
+		return errors.New("only sigstore bundles can be verified with a trusted root")
+	}
+	if bndl.GetPredicate() == nil {
+		return errors.New("bundle has no attestation")
+	}
+
+	// The identity is matched by VerifyEnvelope against all accepted SANs
+	res, err := verifySigstoreBundle(&sgbundle.Bundle{Bundle: &bndl.Bundle}, bv.Options.TrustedRoot)
+	if err != nil {
+		return err
+	}
+	if res.Signature == nil || res.Signature.Certificate == nil {
+		return errors.New("bundle is not signed with a certificate")
+	}
+
+	bndl.GetPredicate().SetVerification(&sapi.Verification{
+		Signature: &sapi.SignatureVerification{
+			Date:     timestamppb.Now(),
+			Verified: true,
+			Identities: []*sapi.Identity{{
+				Sigstore: &sapi.IdentitySigstore{
+					Issuer:              res.Signature.Certificate.Issuer,
+					Identity:            res.Signature.Certificate.SubjectAlternativeName,
+					SourceRepositoryUri: res.Signature.Certificate.SourceRepositoryURI,
+				},
+			}},
+		},
+	})
+	return nil
+}
+
+// verifySigstoreBundle checks the signatures of a sigstore bundle against a
+// trusted root, without checking the signer identity. Transparency log
+// entries and certificate timestamps are required when the trusted root
+// lists services to check them, private instances may not run them all.
+func verifySigstoreBundle(bndl *sgbundle.Bundle, trustedRootPath string) (*verify.VerificationResult, error) {
+	tr, err := root.NewTrustedRootFromPath(trustedRootPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading trusted root: %w", err)
+	}
+
+	opts := []verify.VerifierOption{}
+	if len(tr.CTLogs()) > 0 {
+		opts = append(opts, verify.WithSignedCertificateTimestamps(1))
+	}
+	if len(tr.RekorLogs()) > 0 {
+		opts = append(opts, verify.WithTransparencyLog(1))
+	}
+	if len(tr.RekorLogs()) > 0 || len(tr.TimestampingAuthorities()) > 0 {
+		opts = append(opts, verify.WithObserverTimestamps(1))
+	} else {
+		// Without a log or TSA the certificate is checked at the current time
+		opts = append(opts, verify.WithCurrentTime())
+	}
+
+	sv, err := verify.NewVerifier(tr, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("creating verifier: %w", err)
+	}
+	return sv.Verify(bndl, verify.NewPolicy(verify.WithoutArtifactUnsafe(), verify.WithoutIdentitiesUnsafe()))
+}
+
 func NewBndVerifier(opts VerificationOptions) *BndVerifier {
 	return &BndVerifier{Options: opts}
 }
@@ -21,12 +21,17 @@ const (
 	VsaVerifierId    = "https://github.com/slsa-framework/source-actions"
 )
 
-func CreateUnsignedSourceVsa(branch *models.Branch, commit *models.Commit, verifiedLevels slsa.SourceVerifiedLevels, policy string) (string, error) {
-	return createUnsignedSourceVsaAllParams(branch, commit, verifiedLevels, policy, VsaVerifierId, "PASSED")
+// CreateUnsignedSourceVsa generates a VSA for a commit. The policy digest
+// identifies the version of the policy used and may be nil.
+func CreateUnsignedSourceVsa(branch *models.Branch, commit *models.Commit, verifiedLevels slsa.SourceVerifiedLevels, policy string, policyDigest map[string]string) (string, error) {
+	return createUnsignedSourceVsaAllParams(branch, commit, verifiedLevels, policy, policyDigest, VsaVerifierId, "PASSED")
 }
 
 // createUnsignedSourceVsaAllParams generates a VSA
-func createUnsignedSourceVsaAllParams(branch *models.Branch, commit *models.Commit, verifiedLevels slsa.SourceVerifiedLevels, policy, verifiedId, result string) (string, error) {
+func createUnsignedSourceVsaAllParams(
+	branch *models.Branch, commit *models.Commit, verifiedLevels slsa.SourceVerifiedLevels,
+	policy string, policyDigest map[string]string, verifiedId, result string,
+) (string, error) {
 	// The attestation records a VCS locator
 	resourceUri := fmt.Sprintf("git+%s", branch.Repository.GetHttpURL())
 	vsaPred := &vpb.VerificationSummary{
@@ -35,7 +40,7 @@ func createUnsignedSourceVsaAllParams(branch *models.Branch, commit *models.Comm
 		},
 		TimeVerified:       timestamppb.Now(),
 		ResourceUri:        resourceUri,
-		Policy:             &vpb.VerificationSummary_Policy{Uri: policy},
+		Policy:             &vpb.VerificationSummary_Policy{Uri: policy, Digest: policyDigest},
 		VerificationResult: result,
 		VerifiedLevels:     slsa.ControlNamesToStrings(verifiedLevels),
 	}
@@ -33,7 +33,10 @@ func TestCreateUnsignedSourceVsa(t *testing.T) {
 	branch := newTestBranch("github.com", "owner/repo", "main")
 	commit := newTestCommit("de9395302d14b24c0a42685cf27315d93c88ff79")
 
-	vsaJSON, err := CreateUnsignedSourceVsa(branch, commit, slsa.SourceVerifiedLevels{"TEST_LEVEL"}, "test-policy")
+	vsaJSON, err := CreateUnsignedSourceVsa(
+		branch, commit, slsa.SourceVerifiedLevels{"TEST_LEVEL"}, "test-policy",
+		map[string]string{"sha256": "abc", "gitCommit": "def"},
+	)
 	require.NoError(t, err)
 	require.NotEmpty(t, vsaJSON)
 
@@ -51,6 +54,9 @@ func TestCreateUnsignedSourceVsa(t *testing.T) {
 	require.Equal(t, VsaVerifierId, predFields["verifier"].GetStructValue().GetFields()["id"].GetStringValue())
 	require.Equal(t, "PASSED", predFields["verificationResult"].GetStringValue())
 	require.Equal(t, "test-policy", predFields["policy"].GetStructValue().GetFields()["uri"].GetStringValue())
+	policyDigest := predFields["policy"].GetStructValue().GetFields()["digest"].GetStructValue().GetFields()
+	require.Equal(t, "abc", policyDigest["sha256"].GetStringValue())
+	require.Equal(t, "def", policyDigest["gitCommit"].GetStringValue())
 
 	// Check verified levels
 	levels := predFields["verifiedLevels"].GetListValue().GetValues()
@@ -65,7 +71,7 @@ func TestCreateUnsignedSourceVsaMultipleLevels(t *testing.T) {
 	branch := newTestBranch("github.com", "owner/repo", "main")
 	commit := newTestCommit("73f0a864c2c9af12e03dae433a6ff5f5e719d7aa")
 
-	vsaJSON, err := CreateUnsignedSourceVsa(branch, commit, slsa.SourceVerifiedLevels{"LEVEL_1", "LEVEL_2", "LEVEL_3"}, "test-policy")
+	vsaJSON, err := CreateUnsignedSourceVsa(branch, commit, slsa.SourceVerifiedLevels{"LEVEL_1", "LEVEL_2", "LEVEL_3"}, "test-policy", nil)
 	require.NoError(t, err)
 
 	var stmt spb.Statement
@@ -98,7 +104,7 @@ func TestCreateUnsignedSourceVsaAllParams(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			vsaJSON, err := createUnsignedSourceVsaAllParams(branch, commit, slsa.SourceVerifiedLevels{}, "test-policy", tt.verifierID, tt.result)
+			vsaJSON, err := createUnsignedSourceVsaAllParams(branch, commit, slsa.SourceVerifiedLevels{}, "test-policy", nil, tt.verifierID, tt.result)
 			require.NoError(t, err)
 
 			var stmt spb.Statement
@@ -116,7 +122,7 @@ func TestCreateUnsignedSourceVsaSubjectAnnotation(t *testing.T) {
 	branch := newTestBranch("github.com", "owner/repo", "main")
 	commit := newTestCommit("abc123")
 
-	vsaJSON, err := CreateUnsignedSourceVsa(branch, commit, slsa.SourceVerifiedLevels{}, "test-policy")
+	vsaJSON, err := CreateUnsignedSourceVsa(branch, commit, slsa.SourceVerifiedLevels{}, "test-policy", nil)
 	require.NoError(t, err)
 
 	var stmt spb.Statement
@@ -9,18 +9,36 @@ import (
 	"fmt"
 	"iter"
 	"os"
+	"slices"
+	"sync"
 
 	vpb "github.com/in-toto/attestation/go/predicates/vsa/v1"
 
 	"github.com/slsa-framework/source-tool/pkg/attest"
+	"github.com/slsa-framework/source-tool/pkg/policy"
 	"github.com/slsa-framework/source-tool/pkg/provenance"
 	"github.com/slsa-framework/source-tool/pkg/slsa"
 	"github.com/slsa-framework/source-tool/pkg/sourcetool/models"
 )
 
+const (
+	// defaultWorkers is the number of commits audited concurrently
+	defaultWorkers = 8
+
+	// historyBatchSize is the number of commits read from the branch
+	// history on each call to the backend.
+	historyBatchSize = 100
+)
+
 type Auditor struct {
 	attester *attest.Attester
 	backend  models.VcsBackend
+	// workers is the number of commits audited concurrently
+	workers int
+	// checkpointPath is the file where the audit progress is recorded
+	checkpointPath string
+	// evaluator re-evaluates the provenance in deep audits
+	evaluator *policy.PolicyEvaluator
 }
 
 type optFn func(*Auditor) error
@@ -39,13 +57,74 @@ func WithBackend(b models.VcsBackend) optFn {
 	}
 }
 
+// WithWorkers sets the number of commits audited concurrently
+func WithWorkers(n int) optFn {
+	return func(au *Auditor) error {
+		if n < 1 {
+			return fmt.Errorf("invalid number of workers: %d", n)
+		}
+		au.workers = n
+		return nil
+	}
+}
+
+// WithCheckpointFile records the progress of branch audits in a file. If the
+// file exists when the audit starts, the audit resumes from the commit
+// recorded in it. The file is removed once the audit reaches the root commit.
+func WithCheckpointFile(path string) optFn {
+	return func(au *Auditor) error {
+		au.checkpointPath = path
+		return nil
+	}
+}
+
+// WithPolicyEvaluator enables the deep audit: the provenance of each commit
+// is evaluated again against the repository policy and the VSA is checked
+// not to claim more than the evaluation supports. A nil evaluator disables
+// the deep audit.
+func WithPolicyEvaluator(pe *policy.PolicyEvaluator) optFn {
+	return func(au *Auditor) error {
+		au.evaluator = pe
+		return nil
+	}
+}
+
 type AuditCommitResult struct {
 	Commit   string
 	VsaPred  *vpb.VerificationSummary
 	ProvPred *provenance.SourceProvenancePred
-	// The previous commit reported by the VCS backend.
-	PriorCommit   string
+	// VsaTlogEntries are the transparency log entries of the VSA
+	VsaTlogEntries []*attest.TlogEntry
+	// The previous commit reported by the VCS backend. For merge commits
+	// this is the first parent.
+	PriorCommit string
+	// The commits merged into the branch when Commit is a merge commit.
+	MergedParents []*MergedParentResult
 	ControlStatus *slsa.ControlSet
+	// Evaluation is the result of evaluating the provenance against the
+	// policy again. Only set in deep audits.
+	Evaluation *policy.EvaluationResult
+	// Overclaims lists the levels and controls in the VSA that the
+	// evaluation does not support.
+	Overclaims slsa.SourceVerifiedLevels
+	// PolicyMismatch is the policy URI recorded in the VSA when it was not
+	// read from the source of the evaluator. The recorded version can't be
+	// looked up then, the policy is read as it was when the provenance was
+	// created.
+	PolicyMismatch string
+}
+
+// MergedParentResult captures the attestations found for a commit merged
+// into the audited branch.
+type MergedParentResult struct {
+	Commit   string
+	VsaPred  *vpb.VerificationSummary
+	ProvPred *provenance.SourceProvenancePred
+}
+
+// IsAttested returns true if the merged commit has a VSA or provenance
+func (mp *MergedParentResult) IsAttested() bool {
+	return mp.VsaPred != nil || mp.ProvPred != nil
 }
 
 func (ar *AuditCommitResult) IsGood() bool {
@@ -58,13 +137,41 @@ func (ar *AuditCommitResult) IsGood() bool {
 	} else if ar.ProvPred.GetPrevCommit() != ar.PriorCommit {
 		// Commits need to be the same.
 		good = false
+	} else if !ar.ParentsMatch() {
+		good = false
+	}
+
+	// The merged-in side must have been attested itself
+	for _, mp := range ar.MergedParents {
+		if !mp.IsAttested() {
+			good = false
+		}
+	}
+
+	// The VSA must not claim more than the provenance justifies
+	if len(ar.Overclaims) > 0 {
+		good = false
 	}
 
 	return good
 }
 
+// ParentsMatch checks the parents recorded in the provenance against those
+// reported by the VCS backend. Older provenance doesn't record the parents
+// so only the previous commit can be checked.
+func (ar *AuditCommitResult) ParentsMatch() bool {
+	if ar.ProvPred == nil || len(ar.ProvPred.GetParents()) == 0 {
+		return true
+	}
+	parents := []string{ar.PriorCommit}
+	for _, mp := range ar.MergedParents {
+		parents = append(parents, mp.Commit)
+	}
+	return slices.Equal(parents, ar.ProvPred.GetParents())
+}
+
 func NewAuditor(fn ...optFn) (*Auditor, error) {
-	a := &Auditor{}
+	a := &Auditor{workers: defaultWorkers}
 	for _, f := range fn {
 		if err := f(a); err != nil {
 			return nil, err
@@ -87,11 +194,15 @@ func NewAuditor(fn ...optFn) (*Auditor, error) {
 func (a *Auditor) AuditCommit(ctx context.Context, branch *models.Branch, commit *models.Commit) (ar *AuditCommitResult, err error) {
 	ar = &AuditCommitResult{Commit: commit.SHA}
 
-	_, vsa, err := a.attester.GetRevisionVSA(ctx, branch, commit)
+	vsaEnv, vsa, err := a.attester.GetRevisionVSA(ctx, branch, commit)
 	if err != nil {
 		return nil, fmt.Errorf("getting vsa for revision %s: %w", commit, err)
 	}
 	ar.VsaPred = vsa
+	ar.VsaTlogEntries, err = a.attester.TlogEntries(vsaEnv)
+	if err != nil {
+		return nil, fmt.Errorf("reading vsa log entries for revision %s: %w", commit, err)
+	}
 
 	prov, err := a.attester.GetRevisionProvenance(ctx, branch, commit)
 	if err != nil {
@@ -99,11 +210,49 @@ func (a *Auditor) AuditCommit(ctx context.Context, branch *models.Branch, commit
 	}
 	ar.ProvPred = prov
 
-	prior, err := a.backend.GetPreviousCommit(ctx, branch, commit)
+	parents, err := a.commitParents(ctx, branch, commit)
 	if err != nil {
 		return nil, fmt.Errorf("could not get prior commit for revision %s: %w", commit, err)
 	}
-	ar.PriorCommit = prior.SHA
+	if len(parents) > 0 {
+		ar.PriorCommit = parents[0].SHA
+	}
+
+	// Check the merged-in parents were attested
+	for _, parent := range parents[min(1, len(parents)):] {
+		mp := &MergedParentResult{Commit: parent.SHA}
+		_, mp.VsaPred, err = a.attester.GetRevisionVSA(ctx, branch, parent)
+		if err != nil {
+			return nil, fmt.Errorf("getting vsa for merged revision %s: %w", parent.SHA, err)
+		}
+		mp.ProvPred, err = a.attester.GetRevisionProvenance(ctx, branch, parent)
+		if err != nil {
+			return nil, fmt.Errorf("getting prov for merged revision %s: %w", parent.SHA, err)
+		}
+		ar.MergedParents = append(ar.MergedParents, mp)
+	}
+
+	if prov != nil && a.evaluator != nil {
+		// Evaluate against the policy version recorded in the VSA unless the
+		// evaluator is pinned to one. The recorded commit only identifies a
+		// version in the source the VSA policy was read from.
+		at := a.evaluator.At
+		if at.IsLatest() && vsa != nil {
+			uri := vsa.GetPolicy().GetUri()
+			switch {
+			case a.evaluator.IsSourceURI(branch.Repository, uri):
+				at.Commit = vsa.GetPolicy().GetDigest()[models.DigestTypeGitCommit]
+			case uri != "":
+				ar.PolicyMismatch = uri
+			}
+		}
+		ar.Evaluation, err = a.evaluator.ReevaluateSourceProv(ctx, branch.Repository, branch, prov, at)
+		if err != nil {
+			// Still return ar so callers can continue if they want.
+			return ar, fmt.Errorf("evaluating provenance of %s: %w", commit.SHA, err)
+		}
+		ar.Overclaims = overclaims(vsa.GetVerifiedLevels(), ar.Evaluation.VerifiedLevels)
+	}
 
 	if prov == nil {
 		// If there's no provenance, check the controls to see how they're looking.
@@ -120,25 +269,211 @@ func (a *Auditor) AuditCommit(ctx context.Context, branch *models.Branch, commit
 	return ar, nil
 }
 
-func (a *Auditor) AuditBranch(ctx context.Context, branch *models.Branch) iter.Seq2[*AuditCommitResult, error] {
-	latestCommit, err := a.backend.GetLatestCommit(ctx, branch.Repository, branch)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "error fetching latest commit: %v", err)
-		return nil
+// overclaims returns the levels and controls claimed in a VSA that are not
+// in the verified levels. A lower SLSA source level than the one verified
+// is not an overclaim.
+func overclaims(claimed []string, verified slsa.SourceVerifiedLevels) slsa.SourceVerifiedLevels {
+	verifiedLevel := slsa.SlsaSourceLevel0
+	if levels := verified.Levels(); len(levels) > 0 {
+		verifiedLevel = slsa.SlsaSourceLevel(levels[0])
+	}
+
+	ret := slsa.SourceVerifiedLevels{}
+	for _, c := range claimed {
+		name := slsa.ControlName(c)
+		if len(slsa.SourceVerifiedLevels{name}.Levels()) > 0 {
+			if !slsa.IsLevelHigherOrEqualTo(verifiedLevel, slsa.SlsaSourceLevel(c)) {
+				ret = append(ret, name)
+			}
+			continue
+		}
+		if !slices.Contains(verified, name) {
+			ret = append(ret, name)
+		}
 	}
+	return ret
+}
 
+// commitParents returns the parents of a commit, reusing those read with
+// the branch history when available.
+func (a *Auditor) commitParents(ctx context.Context, branch *models.Branch, commit *models.Commit) ([]*models.Commit, error) {
+	if commit.Parents == nil {
+		return a.backend.GetCommitParents(ctx, branch, commit)
+	}
+	parents := make([]*models.Commit, 0, len(commit.Parents))
+	for _, sha := range commit.Parents {
+		parents = append(parents, &models.Commit{SHA: sha})
+	}
+	return parents, nil
+}
+
+// auditJob is a commit queued for auditing. The result is delivered in the
+// buffered channel once a worker is done with it.
+type auditJob struct {
+	commit *models.Commit
+	result chan auditOutcome
+}
+
+type auditOutcome struct {
+	ar  *AuditCommitResult
+	err error
+}
+
+// AuditBranch audits the branch history walking the first parent of each
+// commit, from the latest commit down to the root. The sides merged into the
+// branch are checked for attestations but not walked.
+//
+// Commits are audited concurrently but the results are yielded in history
+// order. When a checkpoint file is configured, the progress is saved after
+// each result and a later audit of the same branch resumes from it. Commits
+// that failed to audit are recorded in the checkpoint and retried first when
+// resuming.
+func (a *Auditor) AuditBranch(ctx context.Context, branch *models.Branch) iter.Seq2[*AuditCommitResult, error] {
 	return func(yield func(*AuditCommitResult, error) bool) {
+		start, checkpoint, err := a.startingPoint(ctx, branch)
 		if err != nil {
 			yield(nil, err)
 			return
 		}
-		nextCommit := latestCommit
-		for ok := true; ok; ok = (nextCommit.SHA != "") {
-			ar, err := a.AuditCommit(ctx, branch, nextCommit)
-			if !yield(ar, err) {
-				return
+
+		// Commits that failed in a previous run are audited again first
+		if checkpoint != nil {
+			for _, sha := range slices.Clone(checkpoint.Failed) {
+				ar, err := a.AuditCommit(ctx, branch, &models.Commit{SHA: sha})
+				checkpoint.record(sha, err)
+				if err := checkpoint.Save(a.checkpointPath); err != nil {
+					yield(nil, err)
+					return
+				}
+				if !yield(ar, err) || ar == nil {
+					return
+				}
+			}
+		}
+
+		var wg sync.WaitGroup
+		defer wg.Wait()
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		if start != nil {
+			for job := range a.dispatch(ctx, &wg, branch, start) {
+				var res auditOutcome
+				select {
+				case res = <-job.result:
+				case <-ctx.Done():
+					yield(nil, ctx.Err())
+					return
+				}
+				if res.ar != nil && checkpoint != nil {
+					checkpoint.Next = res.ar.PriorCommit
+					checkpoint.record(job.commit.SHA, res.err)
+					if err := checkpoint.Save(a.checkpointPath); err != nil {
+						yield(nil, err)
+						return
+					}
+				}
+				if !yield(res.ar, res.err) || res.ar == nil {
+					return
+				}
+			}
+		}
+
+		// The whole history was audited, there is nothing left to resume
+		if checkpoint != nil && checkpoint.Done() {
+			if err := os.Remove(a.checkpointPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+				yield(nil, fmt.Errorf("removing checkpoint: %w", err))
 			}
-			nextCommit = &models.Commit{SHA: ar.PriorCommit}
 		}
 	}
 }
+
+// startingPoint returns the commit where the audit starts: the latest commit
+// in the branch or the next commit recorded in the checkpoint file.
+func (a *Auditor) startingPoint(ctx context.Context, branch *models.Branch) (*models.Commit, *Checkpoint, error) {
+	var checkpoint *Checkpoint
+	if a.checkpointPath != "" {
+		cp, err := LoadCheckpoint(a.checkpointPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		if cp != nil {
+			if !cp.Matches(branch) {
+				return nil, nil, fmt.Errorf(
+					"checkpoint %s was recorded auditing %s in %s", a.checkpointPath, cp.Branch, cp.Repository,
+				)
+			}
+			if cp.Next != "" {
+				return &models.Commit{SHA: cp.Next}, cp, nil
+			}
+			// Only failed commits are left to audit
+			if len(cp.Failed) > 0 {
+				return nil, cp, nil
+			}
+		}
+		checkpoint = newCheckpoint(branch)
+	}
+
+	latestCommit, err := a.backend.GetLatestCommit(ctx, branch.Repository, branch)
+	if err != nil {
+		return nil, nil, fmt.Errorf("fetching latest commit: %w", err)
+	}
+	return latestCommit, checkpoint, nil
+}
+
+// dispatch reads the branch history starting at a commit and feeds it to a
+// pool of workers. The returned channel lists the jobs in history order,
+// its capacity bounds how far ahead of the consumer the workers can get.
+func (a *Auditor) dispatch(ctx context.Context, wg *sync.WaitGroup, branch *models.Branch, start *models.Commit) <-chan *auditJob {
+	jobs := make(chan *auditJob)
+	pending := make(chan *auditJob, 2*a.workers)
+
+	for range a.workers {
+		wg.Go(func() {
+			for job := range jobs {
+				ar, err := a.AuditCommit(ctx, branch, job.commit)
+				job.result <- auditOutcome{ar: ar, err: err}
+			}
+		})
+	}
+
+	wg.Go(func() {
+		defer close(pending)
+		defer close(jobs)
+
+		next := start
+		for next != nil {
+			commits, err := a.backend.GetCommitHistory(ctx, branch, next, historyBatchSize)
+			if err != nil {
+				job := &auditJob{result: make(chan auditOutcome, 1)}
+				job.result <- auditOutcome{err: fmt.Errorf("reading history from %s: %w", next.SHA, err)}
+				select {
+				case pending <- job:
+				case <-ctx.Done():
+				}
+				return
+			}
+
+			next = nil
+			for _, commit := range commits {
+				job := &auditJob{commit: commit, result: make(chan auditOutcome, 1)}
+				select {
+				case pending <- job:
+				case <-ctx.Done():
+					return
+				}
+				select {
+				case jobs <- job:
+				case <-ctx.Done():
+					return
+				}
+				next = nil
+				if len(commit.Parents) > 0 {
+					next = &models.Commit{SHA: commit.Parents[0]}
+				}
+			}
+		}
+	})
+
+	return pending
+}
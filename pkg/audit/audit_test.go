@@ -0,0 +1,229 @@
+// SPDX-FileCopyrightText: Copyright 2026 The SLSA Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync/atomic"
+	"testing"
+
+	"github.com/carabiner-dev/attestation"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slsa-framework/source-tool/pkg/attest"
+	"github.com/slsa-framework/source-tool/pkg/slsa"
+	"github.com/slsa-framework/source-tool/pkg/sourcetool/models"
+	"github.com/slsa-framework/source-tool/pkg/sourcetool/models/modelsfakes"
+)
+
+// emptyRepository is an attestation repository with no attestations
+type emptyRepository struct{}
+
+func (emptyRepository) Fetch(context.Context, attestation.FetchOptions) ([]attestation.Envelope, error) {
+	return nil, nil
+}
+
+func (emptyRepository) FetchBySubject(context.Context, attestation.FetchOptions, []attestation.Subject) ([]attestation.Envelope, error) {
+	return nil, nil
+}
+
+// newTestAuditor returns an auditor over a linear history of n commits,
+// the first one in the list is the latest.
+func newTestAuditor(t *testing.T, n int, fn ...optFn) (*Auditor, []string) {
+	t.Helper()
+	history := []*models.Commit{}
+	for i := n; i > 0; i-- {
+		c := &models.Commit{SHA: fmt.Sprintf("%040d", i), Parents: []string{}}
+		if i > 1 {
+			c.Parents = []string{fmt.Sprintf("%040d", i-1)}
+		}
+		history = append(history, c)
+	}
+
+	backend := &modelsfakes.FakeVcsBackend{}
+	backend.GetLatestCommitReturns(history[0], nil)
+	backend.GetBranchControlsAtCommitReturns(&slsa.ControlSet{}, nil)
+	backend.GetCommitHistoryCalls(func(_ context.Context, _ *models.Branch, start *models.Commit, limit int) ([]*models.Commit, error) {
+		i := slices.IndexFunc(history, func(c *models.Commit) bool { return c.SHA == start.SHA })
+		if i == -1 {
+			return nil, fmt.Errorf("commit %s not found", start.SHA)
+		}
+		return history[i:min(len(history), i+limit)], nil
+	})
+
+	attester, err := attest.NewAttester(
+		attest.WithBackend(backend),
+		attest.WithAttestationRepository(emptyRepository{}),
+		attest.WithNotesCollector(false),
+		attest.WithVerifier(attest.GetDefaultVerifier()),
+	)
+	require.NoError(t, err)
+
+	auditor, err := NewAuditor(append([]optFn{WithAttester(attester), WithBackend(backend)}, fn...)...)
+	require.NoError(t, err)
+
+	shas := []string{}
+	for _, c := range history {
+		shas = append(shas, c.SHA)
+	}
+	return auditor, shas
+}
+
+func testBranch() *models.Branch {
+	return &models.Branch{
+		Name:       "main",
+		Repository: &models.Repository{Hostname: "github.com", Path: "example/repo"},
+	}
+}
+
+func TestAuditBranch(t *testing.T) {
+	t.Parallel()
+	for _, workers := range []int{1, 3, 16} {
+		t.Run(fmt.Sprintf("workers-%d", workers), func(t *testing.T) {
+			t.Parallel()
+			auditor, shas := newTestAuditor(t, 25, WithWorkers(workers))
+
+			got := []string{}
+			for ar, err := range auditor.AuditBranch(t.Context(), testBranch()) {
+				require.NoError(t, err)
+				require.NotNil(t, ar)
+				require.False(t, ar.IsGood())
+				got = append(got, ar.Commit)
+			}
+			require.Equal(t, shas, got)
+		})
+	}
+}
+
+func TestAuditBranchCheckpoint(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+	auditor, shas := newTestAuditor(t, 5, WithCheckpointFile(path))
+
+	// Stop the audit after two commits
+	got := []string{}
+	for ar, err := range auditor.AuditBranch(t.Context(), testBranch()) {
+		require.NoError(t, err)
+		got = append(got, ar.Commit)
+		if len(got) == 2 {
+			break
+		}
+	}
+
+	cp, err := LoadCheckpoint(path)
+	require.NoError(t, err)
+	require.NotNil(t, cp)
+	require.Equal(t, shas[2], cp.Next)
+	require.Equal(t, 2, cp.Audited)
+
+	// Auditing another branch with the same checkpoint fails
+	other := testBranch()
+	other.Name = "dev"
+	for ar, err := range auditor.AuditBranch(t.Context(), other) {
+		require.Nil(t, ar)
+		require.Error(t, err)
+	}
+
+	// Resume and run to the end
+	for ar, err := range auditor.AuditBranch(t.Context(), testBranch()) {
+		require.NoError(t, err)
+		got = append(got, ar.Commit)
+	}
+	require.Equal(t, shas, got)
+
+	_, err = os.Stat(path)
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestAuditBranchCheckpointFailed(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+	auditor, shas := newTestAuditor(t, 4, WithCheckpointFile(path))
+
+	// Reading the controls of the second commit fails in the first run
+	var failing atomic.Bool
+	failing.Store(true)
+	backend, ok := auditor.backend.(*modelsfakes.FakeVcsBackend)
+	require.True(t, ok)
+	backend.GetBranchControlsAtCommitCalls(func(_ context.Context, _ *models.Branch, commit *models.Commit) (*slsa.ControlSet, error) {
+		if commit.SHA == shas[1] && failing.Load() {
+			return nil, errors.New("synthetic error")
+		}
+		return &slsa.ControlSet{}, nil
+	})
+
+	// Stop the first run after the third commit
+	got := []string{}
+	for ar, err := range auditor.AuditBranch(t.Context(), testBranch()) {
+		require.NotNil(t, ar)
+		require.Equal(t, ar.Commit == shas[1], err != nil)
+		got = append(got, ar.Commit)
+		if len(got) == 3 {
+			break
+		}
+	}
+
+	cp, err := LoadCheckpoint(path)
+	require.NoError(t, err)
+	require.Equal(t, shas[3], cp.Next)
+	require.Equal(t, 2, cp.Audited)
+	require.Equal(t, []string{shas[1]}, cp.Failed)
+
+	// Resuming retries the failed commit before moving on
+	failing.Store(false)
+	got = []string{}
+	for ar, err := range auditor.AuditBranch(t.Context(), testBranch()) {
+		require.NoError(t, err)
+		got = append(got, ar.Commit)
+	}
+	require.Equal(t, []string{shas[1], shas[3]}, got)
+
+	_, err = os.Stat(path)
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestOverclaims(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		name     string
+		claimed  []string
+		verified slsa.SourceVerifiedLevels
+		expected slsa.SourceVerifiedLevels
+	}{
+		{
+			name:     "match",
+			claimed:  []string{"SLSA_SOURCE_LEVEL_3", "SLSA_SOURCE_SCS_TWO_PARTY_REVIEW"},
+			verified: slsa.SourceVerifiedLevels{"SLSA_SOURCE_LEVEL_3", "SLSA_SOURCE_SCS_TWO_PARTY_REVIEW"},
+			expected: slsa.SourceVerifiedLevels{},
+		},
+		{
+			name:     "lower-level-claimed",
+			claimed:  []string{"SLSA_SOURCE_LEVEL_2"},
+			verified: slsa.SourceVerifiedLevels{"SLSA_SOURCE_LEVEL_3"},
+			expected: slsa.SourceVerifiedLevels{},
+		},
+		{
+			name:     "higher-level-claimed",
+			claimed:  []string{"SLSA_SOURCE_LEVEL_3"},
+			verified: slsa.SourceVerifiedLevels{"SLSA_SOURCE_LEVEL_2"},
+			expected: slsa.SourceVerifiedLevels{"SLSA_SOURCE_LEVEL_3"},
+		},
+		{
+			name:     "control-not-supported",
+			claimed:  []string{"SLSA_SOURCE_LEVEL_2", "ORG_SOURCE_TESTED"},
+			verified: slsa.SourceVerifiedLevels{"SLSA_SOURCE_LEVEL_2"},
+			expected: slsa.SourceVerifiedLevels{"ORG_SOURCE_TESTED"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tc.expected, overclaims(tc.claimed, tc.verified))
+		})
+	}
+}
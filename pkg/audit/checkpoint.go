@@ -0,0 +1,106 @@
+// SPDX-FileCopyrightText: Copyright 2026 The SLSA Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package audit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/slsa-framework/source-tool/pkg/sourcetool/models"
+)
+
+// Checkpoint records the progress of a branch audit so that an interrupted
+// audit can be resumed where it left off.
+type Checkpoint struct {
+	Repository string `json:"repository"`
+	Branch     string `json:"branch"`
+	// Next is the first commit that has not been audited yet
+	Next string `json:"next"`
+	// Audited is the number of commits audited so far
+	Audited int `json:"audited"`
+	// Failed lists the commits whose audit failed, they are audited again
+	// when resuming.
+	Failed []string `json:"failed,omitempty"`
+}
+
+// newCheckpoint returns a checkpoint for the branch with no progress
+func newCheckpoint(branch *models.Branch) *Checkpoint {
+	cp := &Checkpoint{Branch: branch.FullRef()}
+	if branch.Repository != nil {
+		cp.Repository = branch.Repository.GetHttpURL()
+	}
+	return cp
+}
+
+// record notes the outcome of a commit audit. Failed commits are kept to be
+// retried, they are only counted as audited once they succeed.
+func (cp *Checkpoint) record(sha string, err error) {
+	cp.Failed = slices.DeleteFunc(cp.Failed, func(s string) bool { return s == sha })
+	if err != nil {
+		cp.Failed = append(cp.Failed, sha)
+		return
+	}
+	cp.Audited++
+}
+
+// Done returns true when there is nothing left to resume
+func (cp *Checkpoint) Done() bool {
+	return cp.Next == "" && len(cp.Failed) == 0
+}
+
+// Matches returns true if the checkpoint was recorded auditing the branch
+func (cp *Checkpoint) Matches(branch *models.Branch) bool {
+	other := newCheckpoint(branch)
+	return cp.Repository == other.Repository && cp.Branch == other.Branch
+}
+
+// LoadCheckpoint reads a checkpoint file. Returns nil if the file does not
+// exist.
+func LoadCheckpoint(path string) (*Checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading checkpoint: %w", err)
+	}
+	cp := &Checkpoint{}
+	if err := json.Unmarshal(data, cp); err != nil {
+		return nil, fmt.Errorf("parsing checkpoint %s: %w", path, err)
+	}
+	return cp, nil
+}
+
+// Save writes the checkpoint to a file. The data is written to a temporary
+// file first and then moved in place so an interrupted write never leaves
+// a truncated checkpoint behind.
+func (cp *Checkpoint) Save(path string) error {
+	data, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling checkpoint: %w", err)
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), ".audit-checkpoint-*")
+	if err != nil {
+		return fmt.Errorf("creating checkpoint file: %w", err)
+	}
+	defer os.Remove(f.Name()) //nolint:errcheck
+
+	if _, err := f.Write(data); err != nil {
+		f.Close() //nolint:errcheck,gosec
+		return fmt.Errorf("writing checkpoint: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("closing checkpoint: %w", err)
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		return fmt.Errorf("moving checkpoint in place: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+// SPDX-FileCopyrightText: Copyright 2026 The SLSA Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package audit
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"slices"
+
+	"github.com/slsa-framework/source-tool/pkg/policy"
+	"github.com/slsa-framework/source-tool/pkg/provenance"
+	"github.com/slsa-framework/source-tool/pkg/slsa"
+	"github.com/slsa-framework/source-tool/pkg/sourcetool/models"
+)
+
+// SimulationStatus summarizes how a candidate policy changes the evaluation
+// of a commit.
+type SimulationStatus string
+
+const (
+	// SimulationNoProvenance commits have no provenance to evaluate
+	SimulationNoProvenance SimulationStatus = "no-provenance"
+	// SimulationUnchanged commits evaluate the same under both policies
+	SimulationUnchanged SimulationStatus = "unchanged"
+	// SimulationChanged commits pass both policies with different levels
+	SimulationChanged SimulationStatus = "changed"
+	// SimulationRegressed commits pass the current policy but not the candidate
+	SimulationRegressed SimulationStatus = "regressed"
+	// SimulationImproved commits pass the candidate policy but not the current one
+	SimulationImproved SimulationStatus = "improved"
+)
+
+// SimulationRange selects the commits in a policy simulation. The branch
+// history is walked following the first parent of each commit.
+type SimulationRange struct {
+	// To is the latest commit simulated, defaults to the head of the branch
+	To string
+	// From is the oldest commit simulated. When not set, the simulation
+	// runs to the root commit or the depth limit.
+	From string
+	// Depth is the maximum number of commits simulated, 0 for no limit
+	Depth int
+}
+
+// PolicyOutcome is the result of evaluating the provenance of a commit
+// against a policy.
+type PolicyOutcome struct {
+	Evaluation *policy.EvaluationResult
+	// Error is set when the evaluation failed hard, the commit would not
+	// get a VSA under the policy.
+	Error error
+}
+
+// Passed returns true if the evaluation succeeded and met the policy target
+func (po *PolicyOutcome) Passed() bool {
+	return po != nil && po.Error == nil && po.Evaluation.Shortfall == nil
+}
+
+// SimulationResult compares the evaluation of the provenance of a commit
+// under the current policy and a candidate one.
+type SimulationResult struct {
+	Commit    string
+	ProvPred  *provenance.SourceProvenancePred
+	Current   *PolicyOutcome
+	Candidate *PolicyOutcome
+}
+
+// Status returns how the candidate policy changes the evaluation of the commit
+func (sr *SimulationResult) Status() SimulationStatus {
+	switch {
+	case sr.ProvPred == nil:
+		return SimulationNoProvenance
+	case sr.Current.Passed() && !sr.Candidate.Passed():
+		return SimulationRegressed
+	case !sr.Current.Passed() && sr.Candidate.Passed():
+		return SimulationImproved
+	case !slices.Equal(sr.Current.levels(), sr.Candidate.levels()):
+		return SimulationChanged
+	}
+	return SimulationUnchanged
+}
+
+// levels returns the sorted verified levels of the outcome
+func (po *PolicyOutcome) levels() []string {
+	if po.Evaluation == nil {
+		return nil
+	}
+	return slices.Sorted(slices.Values(slsa.ControlNamesToStrings(po.Evaluation.VerifiedLevels)))
+}
+
+// SimulatePolicy evaluates the provenance stored for the commits in a
+// branch against the current policy and a candidate one, without issuing
+// any attestations. Results are yielded in history order, from the latest
+// commit in the range.
+func (a *Auditor) SimulatePolicy(
+	ctx context.Context, branch *models.Branch, current, candidate *policy.PolicyEvaluator, r SimulationRange,
+) iter.Seq2[*SimulationResult, error] {
+	return func(yield func(*SimulationResult, error) bool) {
+		next := &models.Commit{SHA: r.To}
+		if r.To == "" {
+			latestCommit, err := a.backend.GetLatestCommit(ctx, branch.Repository, branch)
+			if err != nil {
+				yield(nil, fmt.Errorf("fetching latest commit: %w", err))
+				return
+			}
+			next = latestCommit
+		}
+
+		count := 0
+		for next != nil {
+			limit := historyBatchSize
+			if r.Depth > 0 {
+				limit = min(limit, r.Depth-count)
+			}
+			commits, err := a.backend.GetCommitHistory(ctx, branch, next, limit)
+			if err != nil {
+				yield(nil, fmt.Errorf("reading history from %s: %w", next.SHA, err))
+				return
+			}
+
+			next = nil
+			for _, commit := range commits {
+				sr, err := a.SimulateCommit(ctx, branch, commit, current, candidate)
+				if !yield(sr, err) || sr == nil {
+					return
+				}
+				count++
+				if commit.SHA == r.From || (r.Depth > 0 && count >= r.Depth) {
+					return
+				}
+				next = nil
+				if len(commit.Parents) > 0 {
+					next = &models.Commit{SHA: commit.Parents[0]}
+				}
+			}
+		}
+	}
+}
+
+// SimulateCommit evaluates the provenance stored for a commit against the
+// current policy and a candidate one.
+func (a *Auditor) SimulateCommit(
+	ctx context.Context, branch *models.Branch, commit *models.Commit, current, candidate *policy.PolicyEvaluator,
+) (*SimulationResult, error) {
+	prov, err := a.attester.GetRevisionProvenance(ctx, branch, commit)
+	if err != nil {
+		return nil, fmt.Errorf("getting prov for revision %s: %w", commit.SHA, err)
+	}
+	return simulateProv(ctx, branch, commit, prov, current, candidate), nil
+}
+
+// simulateProv evaluates a provenance predicate against both policies
+func simulateProv(
+	ctx context.Context, branch *models.Branch, commit *models.Commit, prov *provenance.SourceProvenancePred,
+	current, candidate *policy.PolicyEvaluator,
+) *SimulationResult {
+	sr := &SimulationResult{Commit: commit.SHA, ProvPred: prov}
+	if prov == nil {
+		return sr
+	}
+
+	sr.Current = &PolicyOutcome{}
+	sr.Current.Evaluation, sr.Current.Error = current.EvaluateSourceProvPred(ctx, branch.Repository, branch, prov)
+	sr.Candidate = &PolicyOutcome{}
+	sr.Candidate.Evaluation, sr.Candidate.Error = candidate.EvaluateSourceProvPred(ctx, branch.Repository, branch, prov)
+	return sr
+}
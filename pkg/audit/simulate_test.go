@@ -0,0 +1,131 @@
+// SPDX-FileCopyrightText: Copyright 2026 The SLSA Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/slsa-framework/source-tool/pkg/policy"
+	"github.com/slsa-framework/source-tool/pkg/provenance"
+	"github.com/slsa-framework/source-tool/pkg/slsa"
+	"github.com/slsa-framework/source-tool/pkg/sourcetool/models"
+)
+
+func TestSimulatePolicy(t *testing.T) {
+	t.Parallel()
+	auditor, shas := newTestAuditor(t, 10)
+	pe := &policy.PolicyEvaluator{UseLocalPolicy: filepath.Join(t.TempDir(), "missing.json")}
+
+	for _, tc := range []struct {
+		name     string
+		r        SimulationRange
+		expected []string
+	}{
+		{name: "whole-history", expected: shas},
+		{name: "depth", r: SimulationRange{Depth: 3}, expected: shas[:3]},
+		{name: "from-to", r: SimulationRange{To: shas[2], From: shas[5]}, expected: shas[2:6]},
+		{name: "depth-before-from", r: SimulationRange{To: shas[2], From: shas[8], Depth: 2}, expected: shas[2:4]},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			got := []string{}
+			for sr, err := range auditor.SimulatePolicy(t.Context(), testBranch(), pe, pe, tc.r) {
+				require.NoError(t, err)
+				require.Equal(t, SimulationNoProvenance, sr.Status())
+				require.Nil(t, sr.Current)
+				require.Nil(t, sr.Candidate)
+				got = append(got, sr.Commit)
+			}
+			require.Equal(t, tc.expected, got)
+		})
+	}
+}
+
+func TestSimulateProv(t *testing.T) {
+	t.Parallel()
+	since := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	controls := []*provenance.Control{}
+	for _, name := range slsa.GetRequiredControlsForLevel(slsa.SlsaSourceLevel2) {
+		controls = append(controls, &provenance.Control{Name: name.String(), Since: timestamppb.New(since)})
+	}
+	prov := &provenance.SourceProvenancePred{
+		Branch:    "refs/heads/main",
+		CreatedOn: timestamppb.New(since.Add(24 * time.Hour)),
+		Controls:  controls,
+	}
+
+	dir := t.TempDir()
+	writePolicy := func(name string, pb *policy.ProtectedBranch) *policy.PolicyEvaluator {
+		pb.Name = "main"
+		pb.Since = timestamppb.New(since)
+		data, err := protojson.Marshal(&policy.RepoPolicy{
+			CanonicalRepo:     "https://github.com/example/repo",
+			ProtectedBranches: []*policy.ProtectedBranch{pb},
+		})
+		require.NoError(t, err)
+		path := filepath.Join(dir, name+".json")
+		require.NoError(t, os.WriteFile(path, data, 0o600))
+		return &policy.PolicyEvaluator{UseLocalPolicy: path}
+	}
+
+	current := writePolicy("current", &policy.ProtectedBranch{TargetSlsaSourceLevel: string(slsa.SlsaSourceLevel2)})
+	for _, tc := range []struct {
+		name      string
+		candidate *policy.ProtectedBranch
+		status    SimulationStatus
+		shortfall bool
+		mustErr   bool
+	}{
+		{
+			name:      "same-policy",
+			candidate: &policy.ProtectedBranch{TargetSlsaSourceLevel: string(slsa.SlsaSourceLevel2)},
+			status:    SimulationUnchanged,
+		},
+		{
+			name:      "lower-target",
+			candidate: &policy.ProtectedBranch{TargetSlsaSourceLevel: string(slsa.SlsaSourceLevel1)},
+			status:    SimulationChanged,
+		},
+		{
+			name:      "higher-target",
+			candidate: &policy.ProtectedBranch{TargetSlsaSourceLevel: string(slsa.SlsaSourceLevel3)},
+			status:    SimulationRegressed,
+			shortfall: true,
+		},
+		{
+			name: "missing-org-check",
+			candidate: &policy.ProtectedBranch{
+				TargetSlsaSourceLevel: string(slsa.SlsaSourceLevel2),
+				OrgStatusCheckControls: []*policy.OrgStatusCheckControl{
+					{CheckName: "test", PropertyName: "ORG_SOURCE_TESTED", Since: timestamppb.New(since)},
+				},
+			},
+			status:  SimulationRegressed,
+			mustErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			candidate := writePolicy(tc.name, tc.candidate)
+			sr := simulateProv(t.Context(), testBranch(), &models.Commit{SHA: "abc"}, prov, current, candidate)
+			require.Equal(t, "abc", sr.Commit)
+			require.NoError(t, sr.Current.Error)
+			require.True(t, sr.Current.Passed())
+			require.Equal(t, tc.status, sr.Status())
+			if tc.mustErr {
+				require.Error(t, sr.Candidate.Error)
+				return
+			}
+			require.NoError(t, sr.Candidate.Error)
+			require.Equal(t, tc.shortfall, sr.Candidate.Evaluation.Shortfall != nil)
+		})
+	}
+}
@@ -0,0 +1,217 @@
+// SPDX-FileCopyrightText: Copyright 2026 The SLSA Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package ghcontrol
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ErrActivityNotFound is returned when the push of a commit cannot be found
+// in the repository activity.
+var ErrActivityNotFound = errors.New("could not find repo activity")
+
+// activityClockSkew is the tolerance applied to commit dates when bounding
+// the activity search, committer clocks are not always right.
+const activityClockSkew = 24 * time.Hour
+
+// monitoredActivityTypes are the activities that can put a commit on a branch
+var monitoredActivityTypes = []string{"push", "force_push", "pr_merge"}
+
+type actor struct {
+	Login string `json:"login"`
+}
+
+type activity struct {
+	Id           int
+	Before       string
+	After        string
+	Ref          string
+	Timestamp    time.Time
+	ActivityType string `json:"activity_type"`
+	Actor        actor  `json:"actor"`
+}
+
+// activityFeed is a cached, partially read listing of the repository
+// activity. The API returns the newest entries first so the pages read
+// so far cover everything after the oldest entry seen.
+type activityFeed struct {
+	// mtx guards the fields below. fetchMtx serializes the page reads, it
+	// is held during the API calls so lookups of activities already read
+	// don't wait on the network.
+	mtx      sync.Mutex
+	fetchMtx sync.Mutex
+
+	activities []*activity
+	// cursor to the next page, empty when the feed is done
+	next string
+	done bool
+}
+
+// oldest returns the time of the oldest activity read so far
+func (f *activityFeed) oldest() *time.Time {
+	if len(f.activities) == 0 {
+		return nil
+	}
+	return &f.activities[len(f.activities)-1].Timestamp
+}
+
+// scan looks for a matching activity in the pages read, starting at index
+// i. It returns the index scanned up to and whether the feed has nothing
+// left to read after notBefore.
+func (f *activityFeed) scan(i int, match func(*activity) bool, notBefore *time.Time) (*activity, int, bool) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	for ; i < len(f.activities); i++ {
+		if match(f.activities[i]) {
+			return f.activities[i], i, true
+		}
+	}
+	exhausted := f.done || (notBefore != nil && f.oldest() != nil && f.oldest().Before(*notBefore))
+	return nil, i, exhausted
+}
+
+// activityTimePeriod returns the smallest time_period accepted by the
+// activity API that includes the date, empty if it is older than a year.
+func activityTimePeriod(since, now time.Time) string {
+	age := now.Sub(since)
+	for _, p := range []struct {
+		name string
+		d    time.Duration
+	}{
+		{"day", 24 * time.Hour},
+		{"week", 7 * 24 * time.Hour},
+		{"month", 28 * 24 * time.Hour},
+		{"quarter", 90 * 24 * time.Hour},
+		{"year", 365 * 24 * time.Hour},
+	} {
+		if age < p.d {
+			return p.name
+		}
+	}
+	return ""
+}
+
+// commitDate returns the committer date of a commit, used to bound the
+// activity search. The push that introduced a commit can't predate it.
+func (ghc *GitHubConnection) commitDate(ctx context.Context, commit string) (*time.Time, error) {
+	c, _, err := ghc.Client().Git.GetCommit(ctx, ghc.Owner(), ghc.Repo(), commit)
+	if err != nil {
+		return nil, err
+	}
+	if c.GetCommitter().Date == nil {
+		return nil, errors.New("commit has no committer date")
+	}
+	t := c.GetCommitter().GetDate().Time.Add(-activityClockSkew)
+	return &t, nil
+}
+
+// commitActivity finds the activity (push, merge) that put a commit on the
+// ref. The activity feed is paged through filtering by ref and activity
+// type on the server, stopping at the commit date. Pages are cached in the
+// connection so looking up many commits reads each page only once, lookups
+// running concurrently share the page reads.
+func (ghc *GitHubConnection) commitActivity(ctx context.Context, commit, targetRef string) (*activity, error) {
+	var notBefore *time.Time
+	period := ""
+	if t, err := ghc.commitDate(ctx, commit); err == nil {
+		notBefore = t
+		period = activityTimePeriod(*t, time.Now())
+	} else {
+		log.Printf("unable to read date of %s, searching all repository activity: %v", commit, err)
+	}
+
+	match := func(a *activity) bool {
+		return a.After == commit && a.Ref == targetRef && slices.Contains(monitoredActivityTypes, a.ActivityType)
+	}
+
+	for _, activityType := range monitoredActivityTypes {
+		feed := ghc.activityFeed(targetRef, activityType, period)
+		i := 0
+		for {
+			a, scanned, exhausted := feed.scan(i, match, notBefore)
+			if a != nil {
+				// Found it
+				return a, nil
+			}
+			if exhausted {
+				break
+			}
+			i = scanned
+
+			if err := ghc.readActivityPage(ctx, feed, scanned, targetRef, activityType, period); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("%w for %s on %s", ErrActivityNotFound, commit, targetRef)
+}
+
+// activityFeed returns the cached feed for the query, creating it if needed
+func (ghc *GitHubConnection) activityFeed(ref, activityType, period string) *activityFeed {
+	ghc.activityMtx.Lock()
+	defer ghc.activityMtx.Unlock()
+	key := ref + "|" + activityType + "|" + period
+	if ghc.activityFeeds == nil {
+		ghc.activityFeeds = map[string]*activityFeed{}
+	}
+	if _, ok := ghc.activityFeeds[key]; !ok {
+		ghc.activityFeeds[key] = &activityFeed{}
+	}
+	return ghc.activityFeeds[key]
+}
+
+// readActivityPage fetches the next page of the feed. The page is not read
+// if the feed grew past the seen activities while waiting for another read.
+func (ghc *GitHubConnection) readActivityPage(ctx context.Context, feed *activityFeed, seen int, ref, activityType, period string) error {
+	feed.fetchMtx.Lock()
+	defer feed.fetchMtx.Unlock()
+
+	feed.mtx.Lock()
+	stale := feed.done || len(feed.activities) > seen
+	next := feed.next
+	feed.mtx.Unlock()
+	if stale {
+		return nil
+	}
+
+	// Unfortunately the gh_client doesn't have native support for this...'
+	q := url.Values{}
+	q.Set("ref", ref)
+	q.Set("activity_type", activityType)
+	q.Set("per_page", "100")
+	if period != "" {
+		q.Set("time_period", period)
+	}
+	if next != "" {
+		q.Set("after", next)
+	}
+
+	reqUrl := fmt.Sprintf("repos/%s/%s/activity?%s", ghc.Owner(), ghc.Repo(), q.Encode())
+	req, err := ghc.Client().NewRequest(ctx, http.MethodGet, reqUrl, nil)
+	if err != nil {
+		return err
+	}
+
+	var result []*activity
+	resp, err := ghc.Client().Do(req, &result)
+	if err != nil {
+		return fmt.Errorf("reading repository activity: %w", err)
+	}
+
+	feed.mtx.Lock()
+	defer feed.mtx.Unlock()
+	feed.activities = append(feed.activities, result...)
+	feed.next = resp.After
+	feed.done = resp.After == "" || len(result) == 0
+	return nil
+}
@@ -0,0 +1,162 @@
+// SPDX-FileCopyrightText: Copyright 2026 The SLSA Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package ghcontrol
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v88/github"
+	"github.com/migueleliasweb/go-github-mock/src/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommitActivity(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+
+	// Four pages of pushes, one every 12 hours going back in time
+	pages := [][]activity{}
+	n := 0
+	for range 4 {
+		page := []activity{}
+		for range 2 {
+			n++
+			page = append(page, activity{
+				Id: n, After: fmt.Sprintf("sha%d", n), Ref: "refs/heads/main",
+				Timestamp: now.Add(-time.Duration(n) * 12 * time.Hour), ActivityType: "push",
+			})
+		}
+		pages = append(pages, page)
+	}
+
+	var requests atomic.Int32
+	activityHandler := func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		require.Equal(t, "refs/heads/main", q.Get("ref"))
+		require.Equal(t, "week", q.Get("time_period"))
+		if q.Get("activity_type") != "push" {
+			_, err := w.Write([]byte("[]"))
+			require.NoError(t, err)
+			return
+		}
+		requests.Add(1)
+
+		i := 0
+		if after := q.Get("after"); after != "" {
+			var err error
+			i, err = strconv.Atoi(after)
+			require.NoError(t, err)
+		}
+		if i+1 < len(pages) {
+			w.Header().Set("Link", fmt.Sprintf(`<https://api.github.com/repos/owner/repo/activity?after=%d>; rel="next"`, i+1))
+		}
+		data, err := json.Marshal(pages[i])
+		require.NoError(t, err)
+		_, err = w.Write(data)
+		require.NoError(t, err)
+	}
+
+	commitHandler := func(w http.ResponseWriter, r *http.Request) {
+		// All commits are dated 30 hours ago
+		data, err := json.Marshal(github.Commit{Committer: &github.CommitAuthor{
+			Date: &github.Timestamp{Time: now.Add(-30 * time.Hour)},
+		}})
+		require.NoError(t, err)
+		_, err = w.Write(data)
+		require.NoError(t, err)
+	}
+
+	client, err := github.NewClient(github.WithHTTPClient(mock.NewMockedHTTPClient(
+		mock.WithRequestMatchHandler(mock.GetReposActivityByOwnerByRepo, http.HandlerFunc(activityHandler)),
+		mock.WithRequestMatchHandler(mock.GetReposGitCommitsByOwnerByRepoByCommitSha, http.HandlerFunc(commitHandler)),
+	)))
+	require.NoError(t, err)
+	ghc := NewGhConnectionWithClient("owner", "repo", "refs/heads/main", client)
+
+	// The push is in the second page
+	a, err := ghc.commitActivity(t.Context(), "sha3", "refs/heads/main")
+	require.NoError(t, err)
+	require.Equal(t, 3, a.Id)
+	require.Equal(t, int32(2), requests.Load())
+
+	// Cached pages are not read again
+	a, err = ghc.commitActivity(t.Context(), "sha1", "refs/heads/main")
+	require.NoError(t, err)
+	require.Equal(t, 1, a.Id)
+	require.Equal(t, int32(2), requests.Load())
+
+	// The third page has pushes older than the commit date (minus the
+	// skew tolerance) so the search stops before the last one.
+	_, err = ghc.commitActivity(t.Context(), "unknown", "refs/heads/main")
+	require.ErrorIs(t, err, ErrActivityNotFound)
+	require.Equal(t, int32(3), requests.Load())
+}
+
+func TestCommitActivityConcurrent(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	pages := [][]activity{
+		{{Id: 1, After: "sha1", Ref: "refs/heads/main", Timestamp: now.Add(-time.Hour), ActivityType: "push"}},
+		{{Id: 2, After: "sha2", Ref: "refs/heads/main", Timestamp: now.Add(-2 * time.Hour), ActivityType: "push"}},
+	}
+
+	// The read of the second page blocks until released
+	fetching := make(chan struct{})
+	release := make(chan struct{})
+	activityHandler := func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		page := pages[0]
+		switch {
+		case q.Get("activity_type") != "push":
+			page = nil
+		case q.Get("after") != "":
+			close(fetching)
+			<-release
+			page = pages[1]
+		default:
+			w.Header().Set("Link", `<https://api.github.com/repos/owner/repo/activity?after=1>; rel="next"`)
+		}
+		writeJSON(page)(w, r)
+	}
+
+	commitHandler := writeJSON(github.Commit{Committer: &github.CommitAuthor{
+		Date: &github.Timestamp{Time: now.Add(-3 * time.Hour)},
+	}})
+
+	client, err := github.NewClient(github.WithHTTPClient(mock.NewMockedHTTPClient(
+		mock.WithRequestMatchHandler(mock.GetReposActivityByOwnerByRepo, http.HandlerFunc(activityHandler)),
+		mock.WithRequestMatchHandler(mock.GetReposGitCommitsByOwnerByRepoByCommitSha, http.HandlerFunc(commitHandler)),
+	)))
+	require.NoError(t, err)
+	ghc := NewGhConnectionWithClient("owner", "repo", "refs/heads/main", client)
+
+	a, err := ghc.commitActivity(t.Context(), "sha1", "refs/heads/main")
+	require.NoError(t, err)
+	require.Equal(t, 1, a.Id)
+
+	// While the second page is being read, the cached push is still found
+	done := make(chan *activity)
+	go func() {
+		a, err := ghc.commitActivity(t.Context(), "sha2", "refs/heads/main")
+		if err != nil {
+			a = nil
+		}
+		done <- a
+	}()
+	<-fetching
+	a, err = ghc.commitActivity(t.Context(), "sha1", "refs/heads/main")
+	require.NoError(t, err)
+	require.Equal(t, 1, a.Id)
+
+	close(release)
+	a = <-done
+	require.NotNil(t, a)
+	require.Equal(t, 2, a.Id)
+}
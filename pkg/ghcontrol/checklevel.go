@@ -7,7 +7,6 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"net/http"
 	"slices"
 	"time"
 
@@ -21,48 +20,6 @@ const (
 	EnforcementActive = "active"
 )
 
-type actor struct {
-	Login string `json:"login"`
-}
-
-type activity struct {
-	Id           int
-	Before       string
-	After        string
-	Ref          string
-	Timestamp    time.Time
-	ActivityType string `json:"activity_type"`
-	Actor        actor  `json:"actor"`
-}
-
-func (ghc *GitHubConnection) commitActivity(ctx context.Context, commit, targetRef string) (*activity, error) {
-	// Unfortunately the gh_client doesn't have native support for this...'
-	reqUrl := fmt.Sprintf("repos/%s/%s/activity", ghc.Owner(), ghc.Repo())
-	req, err := ghc.Client().NewRequest(ctx, "GET", reqUrl, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	var result []*activity
-	_, err = ghc.Client().Do(req, &result)
-	if err != nil {
-		return nil, err
-	}
-
-	monitoredTypes := []string{"push", "force_push", "pr_merge"}
-	for _, activity := range result {
-		if !slices.Contains(monitoredTypes, activity.ActivityType) {
-			continue
-		}
-		if activity.After == commit && activity.Ref == targetRef {
-			// Found it
-			return activity, nil
-		}
-	}
-
-	return nil, fmt.Errorf("could not find repo activity for %s on %s", commit, targetRef)
-}
-
 type RequiredCheck struct {
 	// The name of the required status check as reported in the GitHub UI/API.
 	Name string
@@ -131,6 +88,7 @@ func enforcesTagHygiene(ruleset *github.RepositoryRuleset) bool {
 		ruleset.Rules.Deletion != nil &&
 		ruleset.Rules.NonFastForward != nil &&
 		ruleset.Conditions != nil &&
+		ruleset.Conditions.RefName != nil &&
 		len(ruleset.Conditions.RefName.Exclude) == 0 &&
 		slices.Contains(ruleset.Conditions.RefName.Include, "~ALL") {
 		return true
@@ -232,101 +190,123 @@ func (ghc *GitHubConnection) computeRequiredChecks(ctx context.Context, ghCheckR
 
 // EnableBranchRules adds a ruleset to the repo to enforce delete and push
 // protection if one of them is missing. We check first so if other rules
-// already protect the branch, this function noops.
-func (ghc *GitHubConnection) EnableBranchRules(ctx context.Context) error {
+// already protect the branch, this function noops. Creating the ruleset with
+// evaluate enforcement stages it without blocking any pushes.
+func (ghc *GitHubConnection) EnableBranchRules(ctx context.Context, enforcement github.RulesetEnforcement) error {
+	plan, err := ghc.PlanBranchRules(ctx, enforcement)
+	if err != nil {
+		return err
+	}
+	return ghc.ApplyRulesetPlan(ctx, plan)
+}
+
+// PlanBranchRules returns the ruleset EnableBranchRules creates without
+// touching the repository. It returns ErrProtectionAlreadyInPlace when there
+// is nothing to do.
+func (ghc *GitHubConnection) PlanBranchRules(ctx context.Context, enforcement github.RulesetEnforcement) (*RulesetPlan, error) {
 	branchRules, _, err := ghc.Client().Repositories.ListRulesForBranch(
 		ctx, ghc.Owner(), ghc.Repo(), GetBranchFromRef(ghc.ref), nil,
 	)
 	if err != nil {
-		return fmt.Errorf("fetching branch rules: %w", err)
+		return nil, fmt.Errorf("fetching branch rules: %w", err)
 	}
 
 	oldestDeletion, err := ghc.getOldestActiveRule(ctx, branchRules.Deletion)
 	if err != nil {
-		return fmt.Errorf("reading branch delete protection status: %w", err)
+		return nil, fmt.Errorf("reading branch delete protection status: %w", err)
 	}
 
 	oldestNoFf, err := ghc.getOldestActiveRule(ctx, branchRules.NonFastForward)
 	if err != nil {
-		return fmt.Errorf("reading branch push protection: %w", err)
+		return nil, fmt.Errorf("reading branch push protection: %w", err)
 	}
 
 	// Check if they are both enabled and noop if they are
 	if oldestDeletion != nil && oldestNoFf != nil {
-		return models.ErrProtectionAlreadyInPlace
+		return nil, models.ErrProtectionAlreadyInPlace
+	}
+
+	if err := ghc.checkStagedRuleset(ctx, BranchRulesetName, enforcement); err != nil {
+		return nil, err
 	}
 
 	// Create the SLSA ruleset
-	if _, resp, err := ghc.Client().Repositories.CreateRuleset(ctx, ghc.Owner(), ghc.Repo(), github.RepositoryRuleset{
-		Name:         "SLSA Branch Controls",
-		Target:       github.Ptr(github.RulesetTargetBranch),
-		Enforcement:  EnforcementActive,
-		BypassActors: []*github.BypassActor{},
-		Conditions: &github.RepositoryRulesetConditions{
-			RefName: &github.RepositoryRulesetRefConditionParameters{
-				Include: []string{ghc.GetFullRef()},
-				Exclude: []string{},
+	return &RulesetPlan{
+		Desired: &github.RepositoryRuleset{
+			Name:         BranchRulesetName,
+			Target:       github.Ptr(github.RulesetTargetBranch),
+			Enforcement:  enforcement,
+			BypassActors: []*github.BypassActor{},
+			Conditions: &github.RepositoryRulesetConditions{
+				RefName: &github.RepositoryRulesetRefConditionParameters{
+					Include: []string{ghc.GetFullRef()},
+					Exclude: []string{},
+				},
+			},
+			Rules: &github.RepositoryRulesetRules{
+				Deletion:       &github.EmptyRuleParameters{},
+				NonFastForward: &github.EmptyRuleParameters{},
 			},
 		},
-		Rules: &github.RepositoryRulesetRules{
-			Deletion:       &github.EmptyRuleParameters{},
-			NonFastForward: &github.EmptyRuleParameters{},
-		},
-	}); err != nil {
-		if resp.StatusCode == http.StatusNotFound {
-			return models.ErrRepositoryAccessDenied
-		}
-		return fmt.Errorf("creating branch protection ruleset: %w", err)
-	}
-
-	return nil
+	}, nil
 }
 
 // EnableTagRules adds a ruleset to the repo to enforce delete and push and update
-// protection on all branches.
-func (ghc *GitHubConnection) EnableTagRules(ctx context.Context) error {
+// protection on all branches. Creating the ruleset with evaluate enforcement
+// stages it without blocking any pushes.
+func (ghc *GitHubConnection) EnableTagRules(ctx context.Context, enforcement github.RulesetEnforcement) error {
+	plan, err := ghc.PlanTagRules(ctx, enforcement)
+	if err != nil {
+		return err
+	}
+	return ghc.ApplyRulesetPlan(ctx, plan)
+}
+
+// PlanTagRules returns the ruleset EnableTagRules creates without touching
+// the repository. It returns ErrProtectionAlreadyInPlace when there is
+// nothing to do.
+func (ghc *GitHubConnection) PlanTagRules(ctx context.Context, enforcement github.RulesetEnforcement) (*RulesetPlan, error) {
 	allRules, _, err := ghc.Client().Repositories.GetAllRulesets(
 		ctx, ghc.Owner(), ghc.Repo(), &github.RepositoryListRulesetsOptions{IncludesParents: github.Ptr(true)},
 	)
 	if err != nil {
-		return fmt.Errorf("fetching tag rules: %w", err)
+		return nil, fmt.Errorf("fetching tag rules: %w", err)
 	}
 	ctl, err := ghc.computeTagHygieneControl(ctx, allRules)
 	if err != nil {
-		return fmt.Errorf("checking tag controls: %w", err)
+		return nil, fmt.Errorf("checking tag controls: %w", err)
 	}
 	if ctl != nil {
 		// Tag controls are in place, noop
-		return models.ErrProtectionAlreadyInPlace
+		return nil, models.ErrProtectionAlreadyInPlace
+	}
+
+	if err := ghc.checkStagedRuleset(ctx, TagRulesetName, enforcement); err != nil {
+		return nil, err
 	}
 
 	// Create the SLSA ruleset
-	if _, resp, err := ghc.Client().Repositories.CreateRuleset(ctx, ghc.Owner(), ghc.Repo(), github.RepositoryRuleset{
-		Name:         "SLSA Tag Controls",
-		Target:       github.Ptr(github.RulesetTargetTag),
-		Enforcement:  EnforcementActive,
-		BypassActors: []*github.BypassActor{},
-		Conditions: &github.RepositoryRulesetConditions{
-			RefName: &github.RepositoryRulesetRefConditionParameters{
-				Exclude: []string{},
-				Include: []string{"~ALL"},
+	return &RulesetPlan{
+		Desired: &github.RepositoryRuleset{
+			Name:         TagRulesetName,
+			Target:       github.Ptr(github.RulesetTargetTag),
+			Enforcement:  enforcement,
+			BypassActors: []*github.BypassActor{},
+			Conditions: &github.RepositoryRulesetConditions{
+				RefName: &github.RepositoryRulesetRefConditionParameters{
+					Exclude: []string{},
+					Include: []string{"~ALL"},
+				},
 			},
-		},
-		Rules: &github.RepositoryRulesetRules{
-			Deletion:       &github.EmptyRuleParameters{},
-			NonFastForward: &github.EmptyRuleParameters{},
-			Update: &github.UpdateRuleParameters{
-				UpdateAllowsFetchAndMerge: false,
+			Rules: &github.RepositoryRulesetRules{
+				Deletion:       &github.EmptyRuleParameters{},
+				NonFastForward: &github.EmptyRuleParameters{},
+				Update: &github.UpdateRuleParameters{
+					UpdateAllowsFetchAndMerge: false,
+				},
 			},
 		},
-	}); err != nil {
-		if resp.StatusCode == http.StatusNotFound {
-			return models.ErrRepositoryAccessDenied
-		}
-		return fmt.Errorf("creating tag protection ruleset: %w", err)
-	}
-
-	return nil
+	}, nil
 }
 
 func (ghc *GitHubConnection) getOldestActiveRule(ctx context.Context, rules []*github.BranchRuleMetadata) (*github.RepositoryRuleset, error) {
@@ -392,12 +372,41 @@ func (ghc *GitHubConnection) GetBranchControls(ctx context.Context, ref string)
 	}
 	controls.AddControl(TagHygieneControl)
 
+	ghc.refineSinceFromHistory(ctx, ref, controls)
+
 	return controls, nil
 }
 
+// refineSinceFromHistory replaces the since dates of the active controls
+// (taken from the last time a ruleset was modified) with the start of their
+// continuous enforcement window and records their enforcement intervals,
+// when the ruleset history is available.
+func (ghc *GitHubConnection) refineSinceFromHistory(ctx context.Context, ref string, controls *slsa.ControlSet) {
+	history, err := ghc.GetControlHistory(ctx, ref)
+	if err != nil {
+		log.Printf("unable to read ruleset history, using ruleset update times: %v", err)
+		return
+	}
+
+	now := time.Now()
+	for _, c := range controls.Controls {
+		if iv := history.Intervals[c.Name].At(now); iv != nil {
+			since := iv.Start
+			c.Since = &since
+			c.Intervals = history.Intervals[c.Name].ClipBefore(now)
+			c.Intervals[len(c.Intervals)-1].End = nil
+		}
+	}
+}
+
 // GetBranchControlsAtCommit determines the controls that are in place for a branch
 // at a specific commit using GitHub's APIs. This is necessarily only as good as
 // GitHub's controls and existing APIs.
+//
+// The controls are computed from the ruleset history so a ruleset that was
+// disabled when the commit was pushed is not counted, even if it is enabled
+// now. If the history cannot be read an error is returned, the controls
+// active now say nothing about those in place when the commit was pushed.
 func (ghc *GitHubConnection) GetBranchControlsAtCommit(ctx context.Context, commit, ref string) (*GhControlStatus, error) {
 	// We want to know when this commit was pushed to ensure the rules were active _then_.
 	activity, err := ghc.commitActivity(ctx, commit, ref)
@@ -412,16 +421,11 @@ func (ghc *GitHubConnection) GetBranchControlsAtCommit(ctx context.Context, comm
 		Controls:       &slsa.ControlSet{},
 	}
 
-	activeControls, err := ghc.GetBranchControls(ctx, ref)
+	history, err := ghc.GetControlHistory(ctx, ref)
 	if err != nil {
-		return nil, fmt.Errorf("reading active controls: %w", err)
-	}
-
-	// Add the controls to the control status object. This will
-	// discard any that were not active when the commit merged.
-	for _, c := range activeControls.Controls {
-		controlStatus.AddControl(c)
+		return nil, fmt.Errorf("reading control history: %w", err)
 	}
+	controlStatus.Controls = history.ControlsAt(activity.Timestamp)
 
 	return &controlStatus, nil
 }
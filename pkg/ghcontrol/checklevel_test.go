@@ -182,11 +182,16 @@ func newMockedGitHubClient(rulesetResponse *github.RepositoryRuleset, activityRe
 	// as needed.  In the future we might want to support testing different rules being enabled in different
 	// rulesets, but that's a problem for the future.
 	client, err := github.NewClient(github.WithHTTPClient(mock.NewMockedHTTPClient(
+		// The rulesets are listed once to read their history (which is not
+		// mocked here) and once to compute the current controls.
 		mock.WithRequestMatch(
 			mock.GetReposRulesetsByOwnerByRepo,
 			[]*github.RepositoryRuleset{
 				rulesetResponse,
 			},
+			[]*github.RepositoryRuleset{
+				rulesetResponse,
+			},
 		),
 		mock.WithRequestMatch(
 			mock.GetReposRulesetsByOwnerByRepoByRulesetId,
@@ -202,6 +207,19 @@ func newMockedGitHubClient(rulesetResponse *github.RepositoryRuleset, activityRe
 			mock.GetReposRulesBranchesByOwnerByRepoByBranch,
 			*branchRulesResponse,
 		),
+		// The ruleset history has a single version, created when the
+		// ruleset was last updated.
+		mock.WithRequestMatchHandler(
+			mock.GetReposRulesetsHistoryByOwnerByRepoByRulesetId,
+			writeJSON([]map[string]any{{"version_id": 1, "updated_at": rulesetResponse.UpdatedAt}}),
+		),
+		mock.WithRequestMatchHandler(
+			mock.GetReposRulesetsHistoryByOwnerByRepoByRulesetIdByVersionId,
+			writeJSON(map[string]any{
+				"version_id": 1, "updated_at": rulesetResponse.UpdatedAt,
+				"state": versionState(rulesetResponse, *branchRulesResponse),
+			}),
+		),
 	)))
 	if err != nil {
 		panic(fmt.Sprintf("creating mocked github client: %v", err))
@@ -209,6 +227,43 @@ func newMockedGitHubClient(rulesetResponse *github.RepositoryRuleset, activityRe
 	return client
 }
 
+// versionState returns the ruleset state served by the mocked history API.
+// Except for tag rulesets, the rules are those in the branch rules response
+// so the history matches the rules applying to the branch.
+func versionState(rs *github.RepositoryRuleset, branchRules []branchRuleRawResponse) *github.RepositoryRuleset {
+	if enforcesTagHygiene(rs) {
+		return rs
+	}
+
+	rules := &github.RepositoryRulesetRules{}
+	for _, br := range branchRules {
+		var err error
+		//nolint:exhaustive // Only the rules used in the tests
+		switch br.Type {
+		case github.RulesetRuleTypeDeletion:
+			rules.Deletion = &github.EmptyRuleParameters{}
+		case github.RulesetRuleTypeNonFastForward:
+			rules.NonFastForward = &github.EmptyRuleParameters{}
+		case github.RulesetRuleTypeUpdate:
+			rules.Update = &github.UpdateRuleParameters{}
+		case github.RulesetRuleTypePullRequest:
+			rules.PullRequest = &github.PullRequestRuleParameters{}
+			err = json.Unmarshal(br.Parameters, rules.PullRequest)
+		case github.RulesetRuleTypeRequiredStatusChecks:
+			rules.RequiredStatusChecks = &github.RequiredStatusChecksRuleParameters{}
+			err = json.Unmarshal(br.Parameters, rules.RequiredStatusChecks)
+		}
+		if err != nil {
+			panic(fmt.Sprintf("unmarshaling rule parameters: %v", err))
+		}
+	}
+
+	state := *rs
+	state.Target = github.Ptr(github.RulesetTargetBranch)
+	state.Rules = rules
+	return &state
+}
+
 // Helper to create a test GH Branch connection with no client.
 func newTestGhConnection(owner, repo, branch string, rulesetResponse *github.RepositoryRuleset, activityResponse []activity, branchRulesResponse *[]branchRuleRawResponse) *GitHubConnection {
 	return NewGhConnectionWithClient(
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"sync"
 
 	"github.com/google/go-github/v88/github"
 	"github.com/hashicorp/go-retryablehttp"
@@ -22,6 +23,19 @@ type GitHubConnection struct {
 	client           *github.Client
 	Options          Options
 	owner, repo, ref string
+
+	// historyMtx guards the caches of the ruleset history
+	historyMtx sync.Mutex
+	// histories caches the reconstructed control history by ref
+	histories map[string]*historyResult
+	// rulesetVersions caches the ruleset states by version URL
+	rulesetVersions map[string]*github.RepositoryRuleset
+
+	// activityMtx guards the map of activity feeds, each feed has its own
+	// locks for the pages read.
+	activityMtx sync.Mutex
+	// activityFeeds caches the activity pages read by query
+	activityFeeds map[string]*activityFeed
 }
 
 func NewGhConnection(owner, repo, ref string) *GitHubConnection {
@@ -99,29 +113,80 @@ func (ghc *GitHubConnection) GetRepoUri() string {
 	return fmt.Sprintf("https://github.com/%s/%s", ghc.Owner(), ghc.Repo())
 }
 
-// Gets the previous commit to 'sha' if it has one.
-// If there are more than one parents this fails with an error.
-// (This tool generally operates in an environment of linear history)
+// Gets the previous commit to 'sha' if it has one. For merge commits this
+// is the first parent, the tip of the branch the merge was made into.
 func (ghc *GitHubConnection) GetPriorCommit(ctx context.Context, sha string) (string, error) {
-	commit, _, err := ghc.Client().Git.GetCommit(ctx, ghc.Owner(), ghc.Repo(), sha)
+	parents, err := ghc.GetCommitParents(ctx, sha)
 	if err != nil {
-		return "", fmt.Errorf("cannot get commit data for %s: %w", sha, err)
+		return "", err
 	}
 
-	if len(commit.Parents) == 0 {
+	if len(parents) == 0 {
 		return "", fmt.Errorf("there is no commit earlier than %s, that isn't yet supported", sha)
 	}
 
-	if len(commit.Parents) > 1 && !ghc.Options.AllowMergeCommits {
-		return "", fmt.Errorf("commit %s has more than one parent (%v), which is not supported", sha, commit.Parents)
+	return parents[0], nil
+}
+
+// GetCommitParents returns the SHAs of the parents of a commit, first
+// parent first.
+func (ghc *GitHubConnection) GetCommitParents(ctx context.Context, sha string) ([]string, error) {
+	commit, _, err := ghc.Client().Git.GetCommit(ctx, ghc.Owner(), ghc.Repo(), sha)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get commit data for %s: %w", sha, err)
+	}
+
+	parents := make([]string, 0, len(commit.Parents))
+	for _, p := range commit.Parents {
+		parents = append(parents, p.GetSHA())
 	}
+	return parents, nil
+}
+
+// GetFirstParentHistory returns up to limit commits (all if limit <= 0) of
+// the first-parent history starting at sha, newest first. The commits are
+// read in pages from the repository commit listing, which includes the
+// merged-in sides, following the first parent of each commit.
+func (ghc *GitHubConnection) GetFirstParentHistory(ctx context.Context, sha string, limit int) ([]*github.RepositoryCommit, error) {
+	seen := map[string]*github.RepositoryCommit{}
+	opts := &github.CommitsListOptions{SHA: sha, ListOptions: github.ListOptions{PerPage: 100}}
+	exhausted := false
+
+	ret := []*github.RepositoryCommit{}
+	next := sha
+	for next != "" && (limit <= 0 || len(ret) < limit) {
+		c, ok := seen[next]
+		if !ok {
+			if exhausted {
+				return nil, fmt.Errorf("commit %s not found in the history of %s", next, sha)
+			}
+			page, resp, err := ghc.Client().Repositories.ListCommits(ctx, ghc.Owner(), ghc.Repo(), opts)
+			if err != nil {
+				return nil, fmt.Errorf("listing commits: %w", err)
+			}
+			for _, rc := range page {
+				seen[rc.GetSHA()] = rc
+			}
+			exhausted = resp.NextPage == 0
+			opts.Page = resp.NextPage
+			continue
+		}
 
-	return *commit.Parents[0].SHA, nil
+		ret = append(ret, c)
+		next = ""
+		if len(c.Parents) > 0 {
+			next = c.Parents[0].GetSHA()
+		}
+	}
+	return ret, nil
 }
 
 func (ghc *GitHubConnection) GetLatestCommit(ctx context.Context, targetBranch string) (string, error) {
-	branch, _, err := ghc.Client().Repositories.GetBranch(ctx, ghc.Owner(), ghc.Repo(), targetBranch, 1)
+	branch, resp, err := ghc.Client().Repositories.GetBranch(ctx, ghc.Owner(), ghc.Repo(), targetBranch, 1)
 	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return "", fmt.Errorf("%w: %s", models.ErrBranchNotFound, targetBranch)
+		}
 		return "", fmt.Errorf("could not get info on specified branch %s: %w", targetBranch, err)
 	}
 	return *branch.Commit.SHA, nil
@@ -166,3 +231,27 @@ func (ghc *GitHubConnection) GetTagCommit(ctx context.Context, tagName string) (
 		Message: tag.GetMessage(),
 	}, nil
 }
+
+// GetTagInfo reads the tag object of a tag. Signatures are only reported as
+// verified when GitHub verified them.
+func (ghc *GitHubConnection) GetTagInfo(ctx context.Context, tagName string) (*models.TagInfo, error) {
+	ref, _, err := ghc.Client().Git.GetRef(ctx, ghc.owner, ghc.repo, "tags/"+tagName)
+	if err != nil {
+		return nil, fmt.Errorf("fetching ref tags/%s: %w", tagName, err)
+	}
+
+	obj := ref.GetObject()
+	if obj.GetType() != "tag" {
+		return &models.TagInfo{}, nil
+	}
+
+	tag, _, err := ghc.Client().Git.GetTag(ctx, ghc.owner, ghc.repo, obj.GetSHA())
+	if err != nil {
+		return nil, fmt.Errorf("fetching tag object %s: %w", obj.GetSHA(), err)
+	}
+
+	return &models.TagInfo{
+		Annotated:         true,
+		SignatureVerified: tag.GetVerification().GetVerified(),
+	}, nil
+}
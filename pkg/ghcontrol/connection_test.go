@@ -6,6 +6,8 @@ package ghcontrol
 import (
 	"testing"
 
+	"github.com/google/go-github/v88/github"
+	"github.com/migueleliasweb/go-github-mock/src/mock"
 	"github.com/stretchr/testify/require"
 )
 
@@ -28,3 +30,45 @@ func TestWithAuthToken(t *testing.T) {
 		require.Equal(t, "abc1234", ghc.Options.accessToken)
 	})
 }
+
+func TestGetFirstParentHistory(t *testing.T) {
+	t.Parallel()
+	commit := func(sha string, parents ...string) *github.RepositoryCommit {
+		rc := &github.RepositoryCommit{SHA: github.Ptr(sha)}
+		for _, p := range parents {
+			rc.Parents = append(rc.Parents, &github.Commit{SHA: github.Ptr(p)})
+		}
+		return rc
+	}
+
+	for _, tc := range []struct {
+		name     string
+		limit    int
+		expected []string
+	}{
+		{name: "all", limit: 0, expected: []string{"merge", "second", "first"}},
+		{name: "limited", limit: 2, expected: []string{"merge", "second"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			// The listing includes the merged-in side and spans two pages
+			client, err := github.NewClient(github.WithHTTPClient(mock.NewMockedHTTPClient(
+				mock.WithRequestMatchPages(
+					mock.GetReposCommitsByOwnerByRepo,
+					[]*github.RepositoryCommit{commit("merge", "second", "feature"), commit("feature", "first")},
+					[]*github.RepositoryCommit{commit("second", "first"), commit("first")},
+				),
+			)))
+			require.NoError(t, err)
+			ghc := NewGhConnectionWithClient("owner", "repo", "refs/heads/main", client)
+
+			history, err := ghc.GetFirstParentHistory(t.Context(), "merge", tc.limit)
+			require.NoError(t, err)
+			got := []string{}
+			for _, c := range history {
+				got = append(got, c.GetSHA())
+			}
+			require.Equal(t, tc.expected, got)
+		})
+	}
+}
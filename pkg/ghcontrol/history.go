@@ -0,0 +1,430 @@
+// SPDX-FileCopyrightText: Copyright 2026 The SLSA Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package ghcontrol
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v88/github"
+
+	"github.com/slsa-framework/source-tool/pkg/slsa"
+)
+
+// ErrHistoryUnavailable is returned when the history of a ruleset cannot be
+// read, usually because the token lacks permissions to read the ruleset
+// versions. The controls enforced in the past are unknown then, the current
+// ruleset state says nothing about them.
+var ErrHistoryUnavailable = errors.New("ruleset history not available")
+
+// rulesetDestroyAction is the audit log action recorded when a ruleset is
+// deleted from a repository.
+const rulesetDestroyAction = "repository_ruleset.destroy"
+
+// Keys of the rules tracked in the ruleset history that don't map directly
+// to a control.
+const (
+	historyKeyDeletion       = string(github.RulesetRuleTypeDeletion)
+	historyKeyNonFastForward = string(github.RulesetRuleTypeNonFastForward)
+)
+
+// rulesetVersion is an entry in the ruleset history API. The State is only
+// returned when fetching a single version.
+type rulesetVersion struct {
+	VersionID int64                     `json:"version_id"`
+	UpdatedAt time.Time                 `json:"updated_at"`
+	State     *github.RepositoryRuleset `json:"state,omitempty"`
+}
+
+// ControlHistory captures the windows during which each control was enforced
+// on a branch, reconstructed from the versions of the repository rulesets.
+type ControlHistory struct {
+	Ref       string
+	Intervals map[slsa.ControlName]slsa.Intervals
+}
+
+// ControlsAt returns the controls that were enforced at a point in time. The
+// since date of each control is the start of its continuous enforcement
+// window, not the last time the ruleset was modified. The control intervals
+// record the history up to that time, the current window is left open.
+func (ch *ControlHistory) ControlsAt(t time.Time) *slsa.ControlSet {
+	set := &slsa.ControlSet{Controls: []*slsa.Control{}}
+	names := []slsa.ControlName{}
+	for name := range ch.Intervals {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		iv := ch.Intervals[name].At(t)
+		if iv == nil {
+			continue
+		}
+		since := iv.Start
+		ivs := ch.Intervals[name].ClipBefore(t)
+		ivs[len(ivs)-1].End = nil
+		set.AddControl(&slsa.Control{Name: name, Since: &since, Intervals: ivs})
+	}
+	return set
+}
+
+type historyResult struct {
+	history *ControlHistory
+	err     error
+}
+
+// GetControlHistory reconstructs the enforcement windows of the controls on
+// a branch from the history of all the rulesets in the repository (including
+// disabled ones and those inherited from the organization) and of the
+// rulesets deleted from it, found in the organization audit log.
+//
+// The result is cached in the connection as versions never change. Only
+// the history and ErrHistoryUnavailable are cached, other errors may be
+// transient.
+func (ghc *GitHubConnection) GetControlHistory(ctx context.Context, ref string) (*ControlHistory, error) {
+	ghc.historyMtx.Lock()
+	res, ok := ghc.histories[ref]
+	ghc.historyMtx.Unlock()
+	if ok {
+		return res.history, res.err
+	}
+
+	history, err := ghc.buildControlHistory(ctx, ref)
+	if err != nil && !errors.Is(err, ErrHistoryUnavailable) {
+		return nil, err
+	}
+
+	ghc.historyMtx.Lock()
+	defer ghc.historyMtx.Unlock()
+	if ghc.histories == nil {
+		ghc.histories = map[string]*historyResult{}
+	}
+	ghc.histories[ref] = &historyResult{history: history, err: err}
+	return history, err
+}
+
+// deletedRuleset is a ruleset deleted from the repository
+type deletedRuleset struct {
+	id        int64
+	deletedAt time.Time
+}
+
+// getDeletedRulesets reads the rulesets deleted from the repository from the
+// organization audit log. The audit log is only available to organizations
+// on GitHub Enterprise Cloud, when it can't be read no rulesets are returned:
+// missing a deleted ruleset can only shorten the enforcement windows, never
+// extend them.
+func (ghc *GitHubConnection) getDeletedRulesets(ctx context.Context) ([]*deletedRuleset, error) {
+	opts := &github.GetAuditLogOptions{
+		Phrase:            github.Ptr(fmt.Sprintf("action:%s repo:%s/%s", rulesetDestroyAction, ghc.Owner(), ghc.Repo())),
+		ListCursorOptions: github.ListCursorOptions{PerPage: 100},
+	}
+
+	deleted := []*deletedRuleset{}
+	for {
+		entries, resp, err := ghc.Client().Organizations.GetAuditLog(ctx, ghc.Owner(), opts)
+		if err != nil {
+			if resp != nil && (resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusForbidden) {
+				log.Printf("audit log not available, deleted rulesets are not part of the control history: %v", err)
+				return nil, nil
+			}
+			return nil, fmt.Errorf("reading audit log: %w", err)
+		}
+
+		for _, e := range entries {
+			if e.GetAction() != rulesetDestroyAction || e.Timestamp == nil {
+				continue
+			}
+			id, ok := e.AdditionalFields["ruleset_id"].(float64)
+			if !ok {
+				continue
+			}
+			deleted = append(deleted, &deletedRuleset{id: int64(id), deletedAt: e.Timestamp.Time})
+		}
+
+		if resp.After == "" {
+			break
+		}
+		opts.After = resp.After
+	}
+	return deleted, nil
+}
+
+func (ghc *GitHubConnection) buildControlHistory(ctx context.Context, ref string) (*ControlHistory, error) {
+	allRulesets, _, err := ghc.Client().Repositories.GetAllRulesets(
+		ctx, ghc.Owner(), ghc.Repo(), &github.RepositoryListRulesetsOptions{IncludesParents: github.Ptr(true)},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("listing rulesets: %w", err)
+	}
+
+	deleted, err := ghc.getDeletedRulesets(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// The last version of a deleted ruleset was enforced until it was
+	// deleted. If its versions are gone, its windows are lost.
+	deletedAt := map[int64]time.Time{}
+	for _, d := range deleted {
+		deletedAt[d.id] = d.deletedAt
+		allRulesets = append(allRulesets, &github.RepositoryRuleset{ID: github.Ptr(d.id)})
+	}
+
+	getDefaultBranch := ghc.lazyDefaultBranch(ctx)
+	// Collect the windows of each rule across all rulesets
+	windows := map[string]slsa.Intervals{}
+	for _, rs := range allRulesets {
+		versions, err := ghc.getRulesetVersions(ctx, rs)
+		if err != nil {
+			if _, ok := deletedAt[rs.GetID()]; ok && errors.Is(err, ErrHistoryUnavailable) {
+				log.Printf("history of deleted ruleset %d not available: %v", rs.GetID(), err)
+				continue
+			}
+			return nil, err
+		}
+
+		for i, v := range versions {
+			iv := slsa.Interval{Start: v.UpdatedAt}
+			if i < len(versions)-1 {
+				end := versions[i+1].UpdatedAt
+				iv.End = &end
+			} else if end, ok := deletedAt[rs.GetID()]; ok {
+				iv.End = &end
+			}
+
+			keys, err := ghc.enforcedRules(v.State, ref, getDefaultBranch)
+			if err != nil {
+				return nil, err
+			}
+			for _, k := range keys {
+				windows[k] = append(windows[k], iv)
+			}
+		}
+	}
+
+	history := &ControlHistory{
+		Ref:       ref,
+		Intervals: map[slsa.ControlName]slsa.Intervals{},
+	}
+	for k, ivs := range windows {
+		if k == historyKeyDeletion || k == historyKeyNonFastForward {
+			continue
+		}
+		history.Intervals[slsa.ControlName(k)] = ivs.Normalize()
+	}
+
+	// Continuity requires both delete and force push protection, possibly
+	// from different rulesets.
+	if continuity := windows[historyKeyDeletion].Intersect(windows[historyKeyNonFastForward]); len(continuity) > 0 {
+		history.Intervals[slsa.SLSA_SOURCE_SCS_CONTINUITY] = continuity
+	}
+
+	return history, nil
+}
+
+// lazyDefaultBranch returns a function that reads the default branch of
+// the repository the first time it is called.
+func (ghc *GitHubConnection) lazyDefaultBranch(ctx context.Context) func() (string, error) {
+	var defaultBranch *string
+	return func() (string, error) {
+		if defaultBranch == nil {
+			b, err := ghc.GetDefaultBranch(ctx)
+			if err != nil {
+				return "", err
+			}
+			defaultBranch = &b
+		}
+		return *defaultBranch, nil
+	}
+}
+
+// getRulesetVersions returns the versions of a ruleset sorted from oldest
+// to newest, with their full state.
+func (ghc *GitHubConnection) getRulesetVersions(ctx context.Context, rs *github.RepositoryRuleset) ([]*rulesetVersion, error) {
+	base := fmt.Sprintf("repos/%s/%s/rulesets/%d/history", ghc.Owner(), ghc.Repo(), rs.GetID())
+	if rs.SourceType != nil && *rs.SourceType == github.RulesetSourceTypeOrganization {
+		base = fmt.Sprintf("orgs/%s/rulesets/%d/history", rs.Source, rs.GetID())
+	}
+
+	versions := []*rulesetVersion{}
+	page := 1
+	for page != 0 {
+		req, err := ghc.Client().NewRequest(ctx, http.MethodGet, fmt.Sprintf("%s?per_page=100&page=%d", base, page), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var result []*rulesetVersion
+		resp, err := ghc.Client().Do(req, &result)
+		if err != nil {
+			if resp != nil && (resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusForbidden) {
+				return nil, fmt.Errorf("%w: ruleset %d: %w", ErrHistoryUnavailable, rs.GetID(), err)
+			}
+			return nil, fmt.Errorf("listing versions of ruleset %d: %w", rs.GetID(), err)
+		}
+		versions = append(versions, result...)
+		page = resp.NextPage
+	}
+
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("%w: ruleset %d has no versions", ErrHistoryUnavailable, rs.GetID())
+	}
+
+	slices.SortFunc(versions, func(a, b *rulesetVersion) int {
+		return a.UpdatedAt.Compare(b.UpdatedAt)
+	})
+
+	for _, v := range versions {
+		state, err := ghc.getRulesetVersionState(ctx, base, v.VersionID)
+		if err != nil {
+			return nil, fmt.Errorf("reading version %d of ruleset %d: %w", v.VersionID, rs.GetID(), err)
+		}
+		v.State = state
+	}
+	return versions, nil
+}
+
+// getRulesetVersionState fetches the full ruleset as it was in a version
+func (ghc *GitHubConnection) getRulesetVersionState(ctx context.Context, base string, versionID int64) (*github.RepositoryRuleset, error) {
+	key := fmt.Sprintf("%s/%d", base, versionID)
+	ghc.historyMtx.Lock()
+	state, ok := ghc.rulesetVersions[key]
+	ghc.historyMtx.Unlock()
+	if ok {
+		return state, nil
+	}
+
+	req, err := ghc.Client().NewRequest(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	version := &rulesetVersion{}
+	if _, err := ghc.Client().Do(req, version); err != nil {
+		return nil, err
+	}
+	if version.State == nil {
+		return nil, errors.New("version has no ruleset state")
+	}
+
+	ghc.historyMtx.Lock()
+	defer ghc.historyMtx.Unlock()
+	if ghc.rulesetVersions == nil {
+		ghc.rulesetVersions = map[string]*github.RepositoryRuleset{}
+	}
+	ghc.rulesetVersions[key] = version.State
+	return version.State, nil
+}
+
+// enforcedRules returns the keys of the rules enforced on the ref by a
+// ruleset version. Keys are the names of the controls they implement or
+// one of the historyKey* constants.
+func (ghc *GitHubConnection) enforcedRules(rs *github.RepositoryRuleset, ref string, defaultBranch func() (string, error)) ([]string, error) {
+	if rs == nil || rs.Enforcement != github.RulesetEnforcementActive || rs.Rules == nil {
+		return nil, nil
+	}
+
+	// Tag protection applies to the whole repository
+	if rs.Target != nil && *rs.Target == github.RulesetTargetTag {
+		if enforcesTagHygiene(rs) {
+			return []string{slsa.SLSA_SOURCE_SCS_PROTECTED_REFS.String()}, nil
+		}
+		return nil, nil
+	}
+
+	if rs.Target != nil && *rs.Target != github.RulesetTargetBranch {
+		return nil, nil
+	}
+
+	applies, err := rulesetAppliesToRef(rs, ref, defaultBranch)
+	if err != nil || !applies {
+		return nil, err
+	}
+
+	keys := []string{}
+	if rs.Rules.Deletion != nil {
+		keys = append(keys, historyKeyDeletion)
+	}
+	if rs.Rules.NonFastForward != nil {
+		keys = append(keys, historyKeyNonFastForward)
+	}
+	if rs.Rules.PullRequest != nil && ghc.ruleMeetsRequiresReview(&github.PullRequestBranchRule{Parameters: *rs.Rules.PullRequest}) {
+		keys = append(keys, slsa.SLSA_SOURCE_SCS_TWO_PARTY_REVIEW.String())
+	}
+	if rs.Rules.RequiredStatusChecks != nil {
+		for _, check := range rs.Rules.RequiredStatusChecks.RequiredStatusChecks {
+			if check.IntegrationID == nil || *check.IntegrationID != GitHubActionsIntegrationId {
+				continue
+			}
+			keys = append(keys, CheckNameToControlName(check.Context).String())
+		}
+	}
+	return keys, nil
+}
+
+// rulesetAppliesToRef evaluates the ref name conditions of a ruleset
+func rulesetAppliesToRef(rs *github.RepositoryRuleset, ref string, defaultBranch func() (string, error)) (bool, error) {
+	if rs.Conditions == nil || rs.Conditions.RefName == nil {
+		return true, nil
+	}
+
+	matches := func(patterns []string) (bool, error) {
+		for _, p := range patterns {
+			switch p {
+			case "~ALL":
+				return true, nil
+			case "~DEFAULT_BRANCH":
+				b, err := defaultBranch()
+				if err != nil {
+					return false, fmt.Errorf("reading default branch: %w", err)
+				}
+				if BranchToFullRef(b) == ref {
+					return true, nil
+				}
+			default:
+				if refPatternToRegexp(p).MatchString(ref) {
+					return true, nil
+				}
+			}
+		}
+		return false, nil
+	}
+
+	excluded, err := matches(rs.Conditions.RefName.Exclude)
+	if err != nil || excluded {
+		return false, err
+	}
+	return matches(rs.Conditions.RefName.Include)
+}
+
+// refPatternToRegexp converts a ruleset fnmatch pattern to a regular
+// expression. `*` does not cross slashes while `**` does.
+func refPatternToRegexp(pattern string) *regexp.Regexp {
+	var sb strings.Builder
+	sb.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				sb.WriteString(".*")
+				i++
+				continue
+			}
+			sb.WriteString("[^/]*")
+		case '?':
+			sb.WriteString("[^/]")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	sb.WriteString("$")
+	return regexp.MustCompile(sb.String())
+}
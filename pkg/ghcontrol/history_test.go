@@ -0,0 +1,278 @@
+// SPDX-FileCopyrightText: Copyright 2026 The SLSA Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package ghcontrol
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v88/github"
+	"github.com/migueleliasweb/go-github-mock/src/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slsa-framework/source-tool/pkg/slsa"
+)
+
+// testVersion is a ruleset version served by the mocked history API
+type testVersion struct {
+	id          int64
+	updated     time.Time
+	enforcement github.RulesetEnforcement
+	rules       *github.RepositoryRulesetRules
+}
+
+func reviewRules() *github.RepositoryRulesetRules {
+	return &github.RepositoryRulesetRules{
+		PullRequest: &github.PullRequestRuleParameters{
+			DismissStaleReviewsOnPush:    true,
+			RequireCodeOwnerReview:       true,
+			RequireLastPushApproval:      true,
+			RequiredApprovingReviewCount: 1,
+		},
+	}
+}
+
+// newHistoryConnection returns a connection to a mocked API serving the
+// rulesets and their versions. Deleted rulesets are only found in the audit
+// log and rulesets without versions have no history available.
+func newHistoryConnection(
+	t *testing.T, pushTime time.Time, rulesets map[int64][]testVersion, deleted map[int64]time.Time,
+) *GitHubConnection {
+	t.Helper()
+	state := func(id int64, v testVersion) *github.RepositoryRuleset {
+		return &github.RepositoryRuleset{
+			ID:          github.Ptr(id),
+			Target:      github.Ptr(github.RulesetTargetBranch),
+			Enforcement: v.enforcement,
+			UpdatedAt:   github.Ptr(github.Timestamp{Time: v.updated}),
+			Rules:       v.rules,
+			Conditions:  conditionsForRuleset("~DEFAULT_BRANCH"),
+		}
+	}
+
+	list := []*github.RepositoryRuleset{}
+	for id, versions := range rulesets {
+		if _, ok := deleted[id]; ok {
+			continue
+		}
+		if len(versions) == 0 {
+			list = append(list, state(id, testVersion{enforcement: github.RulesetEnforcementActive}))
+			continue
+		}
+		list = append(list, state(id, versions[len(versions)-1]))
+	}
+
+	auditLog := []map[string]any{}
+	for id, when := range deleted {
+		auditLog = append(auditLog, map[string]any{
+			"action": rulesetDestroyAction, "@timestamp": when.UnixMilli(), "ruleset_id": id,
+		})
+	}
+
+	historyHandler := func(w http.ResponseWriter, r *http.Request) {
+		// /repos/owner/repo/rulesets/{id}/history[/{version}]
+		parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+		id, err := strconv.ParseInt(parts[4], 10, 64)
+		require.NoError(t, err)
+		versions, ok := rulesets[id]
+		if !ok || len(versions) == 0 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		var resp any
+		if len(parts) == 6 {
+			entries := []map[string]any{}
+			for _, v := range versions {
+				entries = append(entries, map[string]any{"version_id": v.id, "updated_at": v.updated})
+			}
+			resp = entries
+		} else {
+			vid, err := strconv.ParseInt(parts[6], 10, 64)
+			require.NoError(t, err)
+			for _, v := range versions {
+				if v.id == vid {
+					resp = map[string]any{"version_id": v.id, "updated_at": v.updated, "state": state(id, v)}
+				}
+			}
+		}
+		data, err := json.Marshal(resp)
+		require.NoError(t, err)
+		_, err = w.Write(data)
+		require.NoError(t, err)
+	}
+
+	client, err := github.NewClient(github.WithHTTPClient(mock.NewMockedHTTPClient(
+		mock.WithRequestMatch(mock.GetReposRulesetsByOwnerByRepo, list),
+		mock.WithRequestMatch(mock.GetReposByOwnerByRepo, github.Repository{DefaultBranch: github.Ptr("main")}),
+		mock.WithRequestMatchHandler(mock.GetReposRulesetsHistoryByOwnerByRepoByRulesetId, http.HandlerFunc(historyHandler)),
+		mock.WithRequestMatchHandler(mock.GetReposRulesetsHistoryByOwnerByRepoByRulesetIdByVersionId, http.HandlerFunc(historyHandler)),
+		mock.WithRequestMatch(mock.GetOrgsAuditLogByOrg, auditLog),
+		mock.WithRequestMatch(mock.GetReposActivityByOwnerByRepo, []activity{{
+			After: "abc123", Ref: "refs/heads/main", Timestamp: pushTime, ActivityType: "push",
+		}}),
+	)))
+	require.NoError(t, err)
+	return NewGhConnectionWithClient("owner", "repo", "refs/heads/main", client)
+}
+
+func TestGetBranchControlsAtCommitHistory(t *testing.T) {
+	t.Parallel()
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	disabled := t0.Add(30 * 24 * time.Hour)
+	reenabled := disabled.Add(time.Hour)
+	edited := t0.Add(10 * 24 * time.Hour)
+
+	rulesets := map[int64][]testVersion{
+		// Continuity enabled, briefly disabled and then re-enabled
+		1: {
+			{id: 1, updated: t0, enforcement: github.RulesetEnforcementActive, rules: rulesForBranchContinuity()},
+			{id: 2, updated: disabled, enforcement: github.RulesetEnforcementDisabled, rules: rulesForBranchContinuity()},
+			{id: 3, updated: reenabled, enforcement: github.RulesetEnforcementActive, rules: rulesForBranchContinuity()},
+		},
+		// Review always enforced, the ruleset was edited without turning it off
+		2: {
+			{id: 4, updated: t0, enforcement: github.RulesetEnforcementActive, rules: reviewRules()},
+			{id: 5, updated: edited, enforcement: github.RulesetEnforcementActive, rules: reviewRules()},
+		},
+	}
+
+	for _, tc := range []struct {
+		name     string
+		pushTime time.Time
+		expected map[slsa.ControlName]time.Time
+		// Number of continuity windows recorded up to the push
+		continuityWindows int
+	}{
+		{
+			name:     "before-edit",
+			pushTime: t0.Add(time.Hour),
+			expected: map[slsa.ControlName]time.Time{
+				slsa.SLSA_SOURCE_SCS_CONTINUITY:       t0,
+				slsa.SLSA_SOURCE_SCS_TWO_PARTY_REVIEW: t0,
+			},
+			continuityWindows: 1,
+		},
+		{
+			name:     "in-gap",
+			pushTime: disabled.Add(time.Minute),
+			expected: map[slsa.ControlName]time.Time{
+				slsa.SLSA_SOURCE_SCS_TWO_PARTY_REVIEW: t0,
+			},
+		},
+		{
+			name:     "after-reenable",
+			pushTime: reenabled.Add(time.Minute),
+			expected: map[slsa.ControlName]time.Time{
+				slsa.SLSA_SOURCE_SCS_CONTINUITY:       reenabled,
+				slsa.SLSA_SOURCE_SCS_TWO_PARTY_REVIEW: t0,
+			},
+			continuityWindows: 2,
+		},
+		{
+			name:     "before-rulesets",
+			pushTime: t0.Add(-time.Hour),
+			expected: map[slsa.ControlName]time.Time{},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ghc := newHistoryConnection(t, tc.pushTime, rulesets, nil)
+			status, err := ghc.GetBranchControlsAtCommit(t.Context(), "abc123", "refs/heads/main")
+			require.NoError(t, err)
+			require.Len(t, status.Controls.Controls, len(tc.expected), fmt.Sprintf("%+v", status.Controls.Names()))
+			for name, since := range tc.expected {
+				ctrl := status.Controls.GetControl(name)
+				require.NotNil(t, ctrl, name)
+				require.True(t, since.Equal(*ctrl.GetSince()), "%s: expected %s got %s", name, since, ctrl.GetSince())
+			}
+			if tc.continuityWindows > 0 {
+				ivs := status.Controls.GetControl(slsa.SLSA_SOURCE_SCS_CONTINUITY).GetIntervals()
+				require.Len(t, ivs, tc.continuityWindows)
+				require.Nil(t, ivs[len(ivs)-1].End)
+			}
+		})
+	}
+}
+
+func TestGetBranchControlsAtCommitDeletedRuleset(t *testing.T) {
+	t.Parallel()
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	deletedAt := t0.Add(10 * 24 * time.Hour)
+	rulesets := map[int64][]testVersion{
+		1: {{id: 1, updated: t0, enforcement: github.RulesetEnforcementActive, rules: rulesForBranchContinuity()}},
+	}
+
+	for _, tc := range []struct {
+		name     string
+		pushTime time.Time
+		enforced bool
+	}{
+		{name: "before-delete", pushTime: t0.Add(time.Hour), enforced: true},
+		{name: "after-delete", pushTime: deletedAt.Add(time.Hour), enforced: false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ghc := newHistoryConnection(t, tc.pushTime, rulesets, map[int64]time.Time{1: deletedAt})
+			status, err := ghc.GetBranchControlsAtCommit(t.Context(), "abc123", "refs/heads/main")
+			require.NoError(t, err)
+			ctrl := status.Controls.GetControl(slsa.SLSA_SOURCE_SCS_CONTINUITY)
+			if !tc.enforced {
+				require.Nil(t, ctrl)
+				return
+			}
+			require.NotNil(t, ctrl)
+			require.True(t, t0.Equal(*ctrl.GetSince()))
+
+			// The window ends when the ruleset was deleted
+			history, err := ghc.GetControlHistory(t.Context(), "refs/heads/main")
+			require.NoError(t, err)
+			ivs := history.Intervals[slsa.SLSA_SOURCE_SCS_CONTINUITY]
+			require.Len(t, ivs, 1)
+			require.NotNil(t, ivs[0].End)
+			require.True(t, deletedAt.Equal(*ivs[0].End))
+		})
+	}
+}
+
+func TestGetBranchControlsAtCommitHistoryUnavailable(t *testing.T) {
+	t.Parallel()
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	ghc := newHistoryConnection(t, t0.Add(time.Hour), map[int64][]testVersion{
+		1: {{id: 1, updated: t0, enforcement: github.RulesetEnforcementActive, rules: reviewRules()}},
+		2: nil,
+	}, nil)
+
+	// Without the history, the controls at the commit are unknown. They
+	// must not be read from the current rulesets.
+	_, err := ghc.GetBranchControlsAtCommit(t.Context(), "abc123", "refs/heads/main")
+	require.ErrorIs(t, err, ErrHistoryUnavailable)
+}
+
+func TestRefPatternToRegexp(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		pattern string
+		ref     string
+		matches bool
+	}{
+		{"refs/heads/main", "refs/heads/main", true},
+		{"refs/heads/main", "refs/heads/main2", false},
+		{"refs/heads/release/*", "refs/heads/release/v1", true},
+		{"refs/heads/release/*", "refs/heads/release/v1/hotfix", false},
+		{"refs/heads/release/**", "refs/heads/release/v1/hotfix", true},
+		{"refs/heads/v?", "refs/heads/v1", true},
+		{"refs/heads/v.1", "refs/heads/vx1", false},
+	} {
+		t.Run(tc.pattern+"_"+tc.ref, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tc.matches, refPatternToRegexp(tc.pattern).MatchString(tc.ref))
+		})
+	}
+}
@@ -9,8 +9,9 @@ var defaultOptions = Options{
 }
 
 type Options struct {
-	// AllowMergeCommits causes the GitHub connector to reject merge
-	// commits when set to false.
+	// AllowMergeCommits is kept for compatibility.
+	//
+	// Deprecated: merge commits are always supported.
 	AllowMergeCommits bool
 
 	// accessToken is the token we will use to connect to the GitHub API
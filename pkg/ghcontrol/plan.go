@@ -0,0 +1,129 @@
+// SPDX-FileCopyrightText: Copyright 2026 The SLSA Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package ghcontrol
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/go-github/v88/github"
+
+	"github.com/slsa-framework/source-tool/pkg/sourcetool/models"
+)
+
+// RulesetPlan is a change to one of the rulesets managed by sourcetool
+type RulesetPlan struct {
+	// Current is the ruleset in the repository when the plan was made, nil
+	// when the ruleset will be created.
+	Current *github.RepositoryRuleset
+
+	// Desired is the ruleset as it will be created or updated
+	Desired *github.RepositoryRuleset
+}
+
+// copyRuleset returns a deep copy of a ruleset
+func copyRuleset(rs *github.RepositoryRuleset) (*github.RepositoryRuleset, error) {
+	data, err := json.Marshal(rs)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling ruleset: %w", err)
+	}
+	c := &github.RepositoryRuleset{}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("unmarshaling ruleset: %w", err)
+	}
+	return c, nil
+}
+
+// writableRuleset returns a copy of the ruleset without the read-only fields
+// set by the API, which can't be sent when creating or updating it.
+func writableRuleset(rs *github.RepositoryRuleset) github.RepositoryRuleset {
+	w := *rs
+	w.ID = nil
+	w.NodeID = nil
+	w.Links = nil
+	w.Source = ""
+	w.SourceType = nil
+	w.CurrentUserCanBypass = nil
+	w.CreatedAt = nil
+	w.UpdatedAt = nil
+	return w
+}
+
+// CheckRulesetPlan verifies the ruleset in the repository has not changed
+// since the plan was made. It returns an error wrapping ErrPlanOutdated if
+// it did.
+func (ghc *GitHubConnection) CheckRulesetPlan(ctx context.Context, plan *RulesetPlan) error {
+	if plan == nil || plan.Desired == nil {
+		return errors.New("ruleset plan has no ruleset defined")
+	}
+	name := plan.Desired.Name
+
+	existing, err := ghc.findManagedRuleset(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	if plan.Current == nil {
+		if existing != nil {
+			return fmt.Errorf("ruleset %q was created: %w", name, models.ErrPlanOutdated)
+		}
+		return nil
+	}
+
+	if existing == nil || existing.GetID() != plan.Current.GetID() {
+		return fmt.Errorf("ruleset %q was deleted: %w", name, models.ErrPlanOutdated)
+	}
+
+	current, _, err := ghc.Client().Repositories.GetRuleset(ctx, ghc.Owner(), ghc.Repo(), existing.GetID(), false)
+	if err != nil {
+		return fmt.Errorf("fetching ruleset %q: %w", name, err)
+	}
+	// Only the writable fields are compared, the rest depend on who reads
+	// the ruleset and the plan may have been made by someone else.
+	currentData, err := json.Marshal(writableRuleset(current))
+	if err != nil {
+		return fmt.Errorf("marshaling ruleset: %w", err)
+	}
+	plannedData, err := json.Marshal(writableRuleset(plan.Current))
+	if err != nil {
+		return fmt.Errorf("marshaling planned ruleset: %w", err)
+	}
+	if !bytes.Equal(currentData, plannedData) {
+		return fmt.Errorf("ruleset %q was modified: %w", name, models.ErrPlanOutdated)
+	}
+	return nil
+}
+
+// ApplyRulesetPlan creates or updates the ruleset exactly as planned. The
+// read-only fields in the planned ruleset are not sent.
+func (ghc *GitHubConnection) ApplyRulesetPlan(ctx context.Context, plan *RulesetPlan) error {
+	if plan == nil || plan.Desired == nil {
+		return errors.New("ruleset plan has no ruleset defined")
+	}
+	desired := writableRuleset(plan.Desired)
+
+	if plan.Current == nil {
+		if _, resp, err := ghc.Client().Repositories.CreateRuleset(ctx, ghc.Owner(), ghc.Repo(), desired); err != nil {
+			if resp != nil && resp.StatusCode == http.StatusNotFound {
+				return models.ErrRepositoryAccessDenied
+			}
+			return fmt.Errorf("creating ruleset %q: %w", plan.Desired.Name, err)
+		}
+		return nil
+	}
+
+	if _, resp, err := ghc.Client().Repositories.UpdateRuleset(
+		ctx, ghc.Owner(), ghc.Repo(), plan.Current.GetID(), desired,
+	); err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return models.ErrRepositoryAccessDenied
+		}
+		return fmt.Errorf("updating ruleset %q: %w", plan.Desired.Name, err)
+	}
+	return nil
+}
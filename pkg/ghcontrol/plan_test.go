@@ -0,0 +1,102 @@
+// SPDX-FileCopyrightText: Copyright 2026 The SLSA Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package ghcontrol
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-github/v88/github"
+	"github.com/migueleliasweb/go-github-mock/src/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slsa-framework/source-tool/pkg/sourcetool/models"
+)
+
+func TestCheckRulesetPlan(t *testing.T) {
+	t.Parallel()
+	review := stagedRuleset(1, ReviewRulesetName, github.RulesetTargetBranch, github.RulesetEnforcementActive, "refs/heads/dev", reviewRules())
+	modified := stagedRuleset(1, ReviewRulesetName, github.RulesetTargetBranch, github.RulesetEnforcementEvaluate, "refs/heads/dev", reviewRules())
+	// Planned by another user, the fields that depend on the reader differ
+	otherUser := stagedRuleset(1, ReviewRulesetName, github.RulesetTargetBranch, github.RulesetEnforcementActive, "refs/heads/dev", reviewRules())
+	otherUser.CurrentUserCanBypass = github.Ptr(github.BypassModeAlways)
+	for _, tc := range []struct {
+		name     string
+		rulesets []*github.RepositoryRuleset
+		current  *github.RepositoryRuleset
+		outdated bool
+	}{
+		{name: "create"},
+		{name: "created-since", rulesets: []*github.RepositoryRuleset{review}, outdated: true},
+		{name: "update", rulesets: []*github.RepositoryRuleset{review}, current: review},
+		{name: "planned-by-other-user", rulesets: []*github.RepositoryRuleset{review}, current: otherUser},
+		{name: "modified-since", rulesets: []*github.RepositoryRuleset{modified}, current: review, outdated: true},
+		{name: "deleted-since", current: review, outdated: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ghc := newRolloutConnection(t, tc.rulesets)
+			plan := &RulesetPlan{Desired: &github.RepositoryRuleset{Name: ReviewRulesetName}}
+			if tc.current != nil {
+				// The plan is read back from a file
+				current, err := copyRuleset(tc.current)
+				require.NoError(t, err)
+				plan.Current = current
+			}
+
+			err := ghc.CheckRulesetPlan(t.Context(), plan)
+			if tc.outdated {
+				require.ErrorIs(t, err, models.ErrPlanOutdated)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestApplyRulesetPlan(t *testing.T) {
+	t.Parallel()
+	current := stagedRuleset(1, ReviewRulesetName, github.RulesetTargetBranch, github.RulesetEnforcementEvaluate, "refs/heads/dev", reviewRules())
+	current.NodeID = github.Ptr("RRS_1")
+	current.Source = "owner/repo"
+	current.SourceType = github.Ptr(github.RulesetSourceTypeRepository)
+	current.CreatedAt = github.Ptr(github.Timestamp{Time: curTime})
+	current.Links = &github.RepositoryRulesetLinks{Self: &github.RepositoryRulesetLink{HRef: github.Ptr("https://example.com")}}
+
+	// The planned ruleset is read from the API, it carries all the fields
+	desired, err := copyRuleset(current)
+	require.NoError(t, err)
+	desired.Enforcement = github.RulesetEnforcementActive
+
+	for _, tc := range []struct {
+		name     string
+		current  *github.RepositoryRuleset
+		endpoint mock.EndpointPattern
+	}{
+		{name: "create", endpoint: mock.PostReposRulesetsByOwnerByRepo},
+		{name: "update", current: current, endpoint: mock.PutReposRulesetsByOwnerByRepoByRulesetId},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			var sent map[string]any
+			ghc := newRolloutConnection(t, nil, mock.WithRequestMatchHandler(tc.endpoint, http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					data, err := io.ReadAll(r.Body)
+					require.NoError(t, err)
+					require.NoError(t, json.Unmarshal(data, &sent))
+					writeJSON(desired)(w, r)
+				},
+			)))
+
+			require.NoError(t, ghc.ApplyRulesetPlan(t.Context(), &RulesetPlan{Current: tc.current, Desired: desired}))
+			require.Equal(t, string(github.RulesetEnforcementActive), sent["enforcement"])
+			for _, field := range []string{"id", "node_id", "_links", "source_type", "created_at", "updated_at"} {
+				require.NotContains(t, sent, field)
+			}
+			require.Empty(t, sent["source"])
+		})
+	}
+}
@@ -0,0 +1,217 @@
+// SPDX-FileCopyrightText: Copyright 2026 The SLSA Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package ghcontrol
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/google/go-github/v88/github"
+
+	"github.com/slsa-framework/source-tool/pkg/sourcetool/models"
+)
+
+// codeownersPaths are the locations GitHub reads the CODEOWNERS file from,
+// in order of precedence.
+var codeownersPaths = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+// catchAllPatterns are the CODEOWNERS patterns matching every file
+var catchAllPatterns = []string{"*", "/*", "**", "/**"}
+
+var (
+	// ErrCodeownersNotFound is returned when the branch has no CODEOWNERS file
+	ErrCodeownersNotFound = errors.New("no CODEOWNERS file found")
+
+	// ErrCodeownersIncomplete is returned when the CODEOWNERS file does not
+	// assign owners to all the files in the repository.
+	ErrCodeownersIncomplete = errors.New("CODEOWNERS file does not cover the whole repository")
+
+	// ErrCodeownersInvalid is returned when GitHub reports errors in the
+	// CODEOWNERS file.
+	ErrCodeownersInvalid = errors.New("invalid CODEOWNERS file")
+)
+
+// requireReview sets the pull request parameters checked by
+// ruleMeetsRequiresReview, the rest of the settings are kept.
+func requireReview(params *github.PullRequestRuleParameters) *github.PullRequestRuleParameters {
+	if params == nil {
+		params = &github.PullRequestRuleParameters{}
+	}
+	params.RequiredApprovingReviewCount = max(params.RequiredApprovingReviewCount, 1)
+	params.DismissStaleReviewsOnPush = true
+	params.RequireCodeOwnerReview = true
+	params.RequireLastPushApproval = true
+	return params
+}
+
+// EnableReviewRules adds a ruleset to the repo requiring pull requests to be
+// approved by a code owner other than the last pusher. If another active
+// ruleset already requires it, this function noops. When the ruleset created
+// by sourcetool exists but does not meet the requirements, it is amended.
+func (ghc *GitHubConnection) EnableReviewRules(ctx context.Context, enforcement github.RulesetEnforcement) error {
+	plan, err := ghc.PlanReviewRules(ctx, enforcement)
+	if err != nil {
+		return err
+	}
+	return ghc.ApplyRulesetPlan(ctx, plan)
+}
+
+// PlanReviewRules returns the ruleset EnableReviewRules creates or amends
+// without touching the repository. It returns ErrProtectionAlreadyInPlace
+// when there is nothing to do.
+func (ghc *GitHubConnection) PlanReviewRules(ctx context.Context, enforcement github.RulesetEnforcement) (*RulesetPlan, error) {
+	branchRules, _, err := ghc.Client().Repositories.ListRulesForBranch(
+		ctx, ghc.Owner(), ghc.Repo(), GetBranchFromRef(ghc.ref), nil,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("fetching branch rules: %w", err)
+	}
+
+	reviewControl, err := ghc.computeReviewControl(ctx, branchRules.PullRequest)
+	if err != nil {
+		return nil, fmt.Errorf("reading branch review rules: %w", err)
+	}
+	if reviewControl != nil {
+		return nil, models.ErrProtectionAlreadyInPlace
+	}
+
+	if err := ghc.checkStagedRuleset(ctx, ReviewRulesetName, enforcement); err != nil {
+		return nil, err
+	}
+
+	existing, err := ghc.findManagedRuleset(ctx, ReviewRulesetName)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing != nil {
+		current, _, err := ghc.Client().Repositories.GetRuleset(ctx, ghc.Owner(), ghc.Repo(), existing.GetID(), false)
+		if err != nil {
+			return nil, fmt.Errorf("fetching review ruleset: %w", err)
+		}
+		rs, err := copyRuleset(current)
+		if err != nil {
+			return nil, err
+		}
+		if rs.Rules == nil {
+			rs.Rules = &github.RepositoryRulesetRules{}
+		}
+		rs.Rules.PullRequest = requireReview(rs.Rules.PullRequest)
+		if rs.Conditions == nil || rs.Conditions.RefName == nil {
+			rs.Conditions = &github.RepositoryRulesetConditions{
+				RefName: &github.RepositoryRulesetRefConditionParameters{Include: []string{}, Exclude: []string{}},
+			}
+		}
+		if !slices.Contains(rs.Conditions.RefName.Include, ghc.GetFullRef()) {
+			rs.Conditions.RefName.Include = append(rs.Conditions.RefName.Include, ghc.GetFullRef())
+		}
+		return &RulesetPlan{Current: current, Desired: rs}, nil
+	}
+
+	// Create the SLSA ruleset
+	return &RulesetPlan{
+		Desired: &github.RepositoryRuleset{
+			Name:         ReviewRulesetName,
+			Target:       github.Ptr(github.RulesetTargetBranch),
+			Enforcement:  enforcement,
+			BypassActors: []*github.BypassActor{},
+			Conditions: &github.RepositoryRulesetConditions{
+				RefName: &github.RepositoryRulesetRefConditionParameters{
+					Include: []string{ghc.GetFullRef()},
+					Exclude: []string{},
+				},
+			},
+			Rules: &github.RepositoryRulesetRules{
+				PullRequest: requireReview(nil),
+			},
+		},
+	}, nil
+}
+
+// CheckCodeowners verifies the branch has a valid CODEOWNERS file assigning
+// owners to all the files in the repository. Code owner review can't be
+// required otherwise. It returns the path of the file.
+func (ghc *GitHubConnection) CheckCodeowners(ctx context.Context) (string, error) {
+	var path, data string
+	for _, p := range codeownersPaths {
+		contents, _, resp, err := ghc.Client().Repositories.GetContents(
+			ctx, ghc.Owner(), ghc.Repo(), p, &github.RepositoryContentGetOptions{Ref: ghc.GetFullRef()},
+		)
+		if err != nil {
+			if resp != nil && resp.StatusCode == http.StatusNotFound {
+				continue
+			}
+			return "", fmt.Errorf("reading %s: %w", p, err)
+		}
+		if contents == nil {
+			continue
+		}
+		data, err = contents.GetContent()
+		if err != nil {
+			return "", fmt.Errorf("decoding %s: %w", p, err)
+		}
+		path = p
+		break
+	}
+	if path == "" {
+		return "", ErrCodeownersNotFound
+	}
+
+	codeownersErrors, _, err := ghc.Client().Repositories.GetCodeownersErrors(
+		ctx, ghc.Owner(), ghc.Repo(), &github.GetCodeownersErrorsOptions{Ref: ghc.GetFullRef()},
+	)
+	if err != nil {
+		return "", fmt.Errorf("checking CODEOWNERS errors: %w", err)
+	}
+	if len(codeownersErrors.Errors) > 0 {
+		errs := []error{}
+		for _, e := range codeownersErrors.Errors {
+			errs = append(errs, fmt.Errorf("%s:%d: %s", e.Path, e.Line, e.Kind))
+		}
+		return "", fmt.Errorf("%w: %w", ErrCodeownersInvalid, errors.Join(errs...))
+	}
+
+	covered, unowned := codeownersCoversAll(data)
+	if !covered {
+		if len(unowned) > 0 {
+			return "", fmt.Errorf("%s: %w, files matching %s have no owners", path, ErrCodeownersIncomplete, strings.Join(unowned, ", "))
+		}
+		return "", fmt.Errorf("%s: %w", path, ErrCodeownersIncomplete)
+	}
+	return path, nil
+}
+
+// codeownersCoversAll returns true when a catch-all pattern in the
+// CODEOWNERS file assigns at least one owner and no later rule removes the
+// owners of any file. The last matching rule takes precedence, so patterns
+// without owners after the catch-all leave their files unowned. Those
+// patterns are returned.
+func codeownersCoversAll(data string) (bool, []string) {
+	covered := false
+	unowned := []string{}
+	s := bufio.NewScanner(strings.NewReader(data))
+	for s.Scan() {
+		line, _, _ := strings.Cut(s.Text(), "#")
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+			continue
+		case slices.Contains(catchAllPatterns, fields[0]):
+			// A catch-all overrides all the previous rules
+			covered = len(fields) > 1
+			unowned = []string{}
+			if !covered {
+				unowned = append(unowned, fields[0])
+			}
+		case len(fields) == 1:
+			unowned = append(unowned, fields[0])
+		}
+	}
+	return covered && len(unowned) == 0, unowned
+}
@@ -0,0 +1,233 @@
+// SPDX-FileCopyrightText: Copyright 2026 The SLSA Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package ghcontrol
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v88/github"
+	"github.com/migueleliasweb/go-github-mock/src/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slsa-framework/source-tool/pkg/sourcetool/models"
+)
+
+func TestEnableReviewRules(t *testing.T) {
+	t.Parallel()
+	reviewParams, err := json.Marshal(reviewRules().PullRequest)
+	require.NoError(t, err)
+	weakRules := &github.RepositoryRulesetRules{
+		PullRequest: &github.PullRequestRuleParameters{
+			RequiredApprovingReviewCount:   2,
+			RequiredReviewThreadResolution: true,
+		},
+	}
+
+	for _, tc := range []struct {
+		name        string
+		rulesets    []*github.RepositoryRuleset
+		branchRules []branchRuleRawResponse
+		enforcement github.RulesetEnforcement
+		expectedErr error
+		// created or updated ruleset, nil if none
+		expected *github.RepositoryRuleset
+	}{
+		{
+			name:        "create",
+			enforcement: github.RulesetEnforcementActive,
+			expected: &github.RepositoryRuleset{
+				Name:        ReviewRulesetName,
+				Enforcement: github.RulesetEnforcementActive,
+				Conditions:  conditionsForRuleset("refs/heads/main"),
+				Rules:       reviewRules(),
+			},
+		},
+		{
+			name: "amend",
+			rulesets: []*github.RepositoryRuleset{
+				stagedRuleset(1, ReviewRulesetName, github.RulesetTargetBranch, github.RulesetEnforcementActive, "refs/heads/dev", weakRules),
+			},
+			enforcement: github.RulesetEnforcementActive,
+			expected: &github.RepositoryRuleset{
+				Name:        ReviewRulesetName,
+				Enforcement: github.RulesetEnforcementActive,
+				Conditions: &github.RepositoryRulesetConditions{
+					RefName: &github.RepositoryRulesetRefConditionParameters{Include: []string{"refs/heads/dev", "refs/heads/main"}},
+				},
+				Rules: &github.RepositoryRulesetRules{
+					PullRequest: &github.PullRequestRuleParameters{
+						DismissStaleReviewsOnPush:      true,
+						RequireCodeOwnerReview:         true,
+						RequireLastPushApproval:        true,
+						RequiredApprovingReviewCount:   2,
+						RequiredReviewThreadResolution: true,
+					},
+				},
+			},
+		},
+		{
+			name: "already-in-place",
+			rulesets: []*github.RepositoryRuleset{
+				stagedRuleset(5, "Other rules", github.RulesetTargetBranch, github.RulesetEnforcementActive, "~ALL", reviewRules()),
+			},
+			branchRules: []branchRuleRawResponse{{
+				Type:               github.RulesetRuleTypePullRequest,
+				BranchRuleMetadata: github.BranchRuleMetadata{RulesetID: 5},
+				Parameters:         reviewParams,
+			}},
+			enforcement: github.RulesetEnforcementActive,
+			expectedErr: models.ErrProtectionAlreadyInPlace,
+		},
+		{
+			name: "enforce-staged",
+			rulesets: []*github.RepositoryRuleset{
+				stagedRuleset(1, ReviewRulesetName, github.RulesetTargetBranch, github.RulesetEnforcementEvaluate, "refs/heads/main", reviewRules()),
+			},
+			enforcement: github.RulesetEnforcementActive,
+			expectedErr: ErrRulesetStaged,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			var written *github.RepositoryRuleset
+			write := func(w http.ResponseWriter, r *http.Request) {
+				written = &github.RepositoryRuleset{}
+				require.NoError(t, json.NewDecoder(r.Body).Decode(written))
+				writeJSON(written)(w, r)
+			}
+			branchRules := tc.branchRules
+			if branchRules == nil {
+				branchRules = []branchRuleRawResponse{}
+			}
+			ghc := newRolloutConnection(t, tc.rulesets,
+				mock.WithRequestMatch(mock.GetReposRulesBranchesByOwnerByRepoByBranch, branchRules),
+				mock.WithRequestMatchHandler(mock.PostReposRulesetsByOwnerByRepo, http.HandlerFunc(write)),
+				mock.WithRequestMatchHandler(mock.PutReposRulesetsByOwnerByRepoByRulesetId, http.HandlerFunc(write)),
+			)
+
+			err := ghc.EnableReviewRules(t.Context(), tc.enforcement)
+			if tc.expectedErr != nil {
+				require.ErrorIs(t, err, tc.expectedErr)
+				require.Nil(t, written)
+				return
+			}
+			require.NoError(t, err)
+			require.NotNil(t, written)
+			require.Equal(t, tc.expected.Name, written.Name)
+			require.Equal(t, tc.expected.Enforcement, written.Enforcement)
+			require.Equal(t, tc.expected.Conditions.RefName.Include, written.Conditions.RefName.Include)
+			require.Equal(t, tc.expected.Rules.PullRequest, written.Rules.PullRequest)
+			require.True(t, ghc.ruleMeetsRequiresReview(&github.PullRequestBranchRule{Parameters: *written.Rules.PullRequest}))
+		})
+	}
+}
+
+func TestCheckCodeowners(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		name        string
+		files       map[string]string
+		errors      []*github.CodeownersError
+		path        string
+		expectedErr error
+	}{
+		{
+			name:  "github-dir",
+			files: map[string]string{".github/CODEOWNERS": "* @org/maintainers\n"},
+			path:  ".github/CODEOWNERS",
+		},
+		{
+			name: "precedence",
+			files: map[string]string{
+				"CODEOWNERS":      "/docs/ @org/writers\n",
+				"docs/CODEOWNERS": "* @org/maintainers\n",
+			},
+			expectedErr: ErrCodeownersIncomplete,
+		},
+		{
+			name:        "not-found",
+			files:       map[string]string{},
+			expectedErr: ErrCodeownersNotFound,
+		},
+		{
+			name:        "invalid",
+			files:       map[string]string{"CODEOWNERS": "* @org/unknown\n"},
+			errors:      []*github.CodeownersError{{Path: "CODEOWNERS", Line: 1, Kind: "Unknown owner"}},
+			expectedErr: ErrCodeownersInvalid,
+		},
+		{
+			name:        "vendor-unowned",
+			files:       map[string]string{"CODEOWNERS": "* @org/maintainers\n/vendor/\n"},
+			expectedErr: ErrCodeownersIncomplete,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			contents := func(w http.ResponseWriter, r *http.Request) {
+				path := strings.TrimPrefix(r.URL.Path, "/repos/owner/repo/contents/")
+				data, ok := tc.files[path]
+				if !ok {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				writeJSON(github.RepositoryContent{
+					Type:     github.Ptr("file"),
+					Path:     github.Ptr(path),
+					Encoding: github.Ptr("base64"),
+					Content:  github.Ptr(base64.StdEncoding.EncodeToString([]byte(data))),
+				})(w, r)
+			}
+			errs := tc.errors
+			if errs == nil {
+				errs = []*github.CodeownersError{}
+			}
+			client, err := github.NewClient(github.WithHTTPClient(mock.NewMockedHTTPClient(
+				mock.WithRequestMatchHandler(mock.GetReposContentsByOwnerByRepoByPath, http.HandlerFunc(contents)),
+				mock.WithRequestMatch(mock.GetReposCodeownersErrorsByOwnerByRepo, github.CodeownersErrors{Errors: errs}),
+			)))
+			require.NoError(t, err)
+			ghc := NewGhConnectionWithClient("owner", "repo", "refs/heads/main", client)
+
+			path, err := ghc.CheckCodeowners(t.Context())
+			switch {
+			case tc.expectedErr != nil:
+				require.ErrorIs(t, err, tc.expectedErr)
+			default:
+				require.NoError(t, err)
+				require.Equal(t, tc.path, path)
+			}
+		})
+	}
+}
+
+func TestCodeownersCoversAll(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		name     string
+		data     string
+		expected bool
+		unowned  []string
+	}{
+		{name: "catch-all", data: "* @org/maintainers", expected: true},
+		{name: "double-star", data: "/** @user", expected: true},
+		{name: "comments", data: "# owners\n* @org/maintainers # everyone\n", expected: true},
+		{name: "paths-only", data: "/src/ @org/devs\n*.md @org/writers\n"},
+		{name: "owner-removed", data: "* @org/maintainers\n*\n", unowned: []string{"*"}},
+		{name: "path-unowned", data: "* @org/maintainers\n/vendor/\n/src/ @org/devs\n", unowned: []string{"/vendor/"}},
+		{name: "unowned-overridden", data: "/vendor/\n* @org/maintainers\n", expected: true},
+		{name: "commented-out", data: "# * @org/maintainers\n"},
+		{name: "empty"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			covered, unowned := codeownersCoversAll(tc.data)
+			require.Equal(t, tc.expected, covered)
+			require.ElementsMatch(t, tc.unowned, unowned)
+		})
+	}
+}
@@ -0,0 +1,312 @@
+// SPDX-FileCopyrightText: Copyright 2026 The SLSA Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package ghcontrol
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/google/go-github/v88/github"
+
+	"github.com/slsa-framework/source-tool/pkg/slsa"
+	"github.com/slsa-framework/source-tool/pkg/sourcetool/models"
+)
+
+// Names of the rulesets sourcetool creates in the repository
+const (
+	BranchRulesetName = "SLSA Branch Controls"
+	TagRulesetName    = "SLSA Tag Controls"
+	ReviewRulesetName = "SLSA Review Controls"
+)
+
+// ManagedRulesets are the names of the rulesets created by sourcetool, only
+// these are promoted from evaluate mode.
+var ManagedRulesets = []string{BranchRulesetName, TagRulesetName, ReviewRulesetName}
+
+// ErrRulesetStaged is returned when trying to enforce a ruleset that is
+// already staged in evaluate mode, it needs to be promoted instead.
+var ErrRulesetStaged = errors.New("ruleset is staged in evaluate mode")
+
+// RuleSuite is a push evaluated by the repository rules
+type RuleSuite struct {
+	ID               int64     `json:"id"`
+	ActorName        string    `json:"actor_name"`
+	BeforeSHA        string    `json:"before_sha"`
+	AfterSHA         string    `json:"after_sha"`
+	Ref              string    `json:"ref"`
+	PushedAt         time.Time `json:"pushed_at"`
+	Result           string    `json:"result"`
+	EvaluationResult string    `json:"evaluation_result"`
+
+	// RuleEvaluations are only returned when fetching a single suite
+	RuleEvaluations []*RuleEvaluation `json:"rule_evaluations,omitempty"`
+}
+
+// RuleEvaluation is the result of a rule in a rule suite
+type RuleEvaluation struct {
+	RuleSource struct {
+		Type string `json:"type"`
+		ID   int64  `json:"id"`
+		Name string `json:"name"`
+	} `json:"rule_source"`
+	Enforcement string `json:"enforcement"`
+	Result      string `json:"result"`
+	RuleType    string `json:"rule_type"`
+	Details     string `json:"details"`
+}
+
+// findManagedRuleset returns the repository ruleset created by sourcetool
+// with the given name or nil if there is none.
+func (ghc *GitHubConnection) findManagedRuleset(ctx context.Context, name string) (*github.RepositoryRuleset, error) {
+	rulesets, _, err := ghc.Client().Repositories.GetAllRulesets(
+		ctx, ghc.Owner(), ghc.Repo(), &github.RepositoryListRulesetsOptions{IncludesParents: github.Ptr(false)},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("listing repository rulesets: %w", err)
+	}
+	for _, rs := range rulesets {
+		if rs.Name == name {
+			return rs, nil
+		}
+	}
+	return nil, nil
+}
+
+// checkStagedRuleset returns an error when the ruleset is already staged in
+// the repository. Staging it again is a noop while enforcing it requires
+// promoting the staged version.
+func (ghc *GitHubConnection) checkStagedRuleset(ctx context.Context, name string, enforcement github.RulesetEnforcement) error {
+	rs, err := ghc.findManagedRuleset(ctx, name)
+	if err != nil {
+		return err
+	}
+	if rs == nil || rs.Enforcement != github.RulesetEnforcementEvaluate {
+		return nil
+	}
+	if enforcement == github.RulesetEnforcementEvaluate {
+		return models.ErrProtectionAlreadyInPlace
+	}
+	return fmt.Errorf("%q: %w", name, ErrRulesetStaged)
+}
+
+// ListManagedRulesets returns the rulesets created by sourcetool in the
+// repository. Rulesets are listed without their rules.
+func (ghc *GitHubConnection) ListManagedRulesets(ctx context.Context) ([]*github.RepositoryRuleset, error) {
+	rulesets, _, err := ghc.Client().Repositories.GetAllRulesets(
+		ctx, ghc.Owner(), ghc.Repo(), &github.RepositoryListRulesetsOptions{IncludesParents: github.Ptr(false)},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("listing repository rulesets: %w", err)
+	}
+
+	managed := []*github.RepositoryRuleset{}
+	for _, rs := range rulesets {
+		if slices.Contains(ManagedRulesets, rs.Name) {
+			managed = append(managed, rs)
+		}
+	}
+	return managed, nil
+}
+
+// DeleteManagedRuleset deletes the ruleset created by sourcetool with the
+// given name. It returns false if the ruleset does not exist.
+func (ghc *GitHubConnection) DeleteManagedRuleset(ctx context.Context, name string) (bool, error) {
+	if !slices.Contains(ManagedRulesets, name) {
+		return false, fmt.Errorf("%q is not a ruleset managed by sourcetool", name)
+	}
+
+	rs, err := ghc.findManagedRuleset(ctx, name)
+	if err != nil {
+		return false, err
+	}
+	if rs == nil {
+		return false, nil
+	}
+
+	if resp, err := ghc.Client().Repositories.DeleteRuleset(ctx, ghc.Owner(), ghc.Repo(), rs.GetID()); err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return false, models.ErrRepositoryAccessDenied
+		}
+		return false, fmt.Errorf("deleting ruleset %q: %w", name, err)
+	}
+	return true, nil
+}
+
+// stagedRulesets returns the rulesets created by sourcetool that are in
+// evaluate mode, with their full rules.
+func (ghc *GitHubConnection) stagedRulesets(ctx context.Context) ([]*github.RepositoryRuleset, error) {
+	managed, err := ghc.ListManagedRulesets(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	staged := []*github.RepositoryRuleset{}
+	for _, summary := range managed {
+		if summary.Enforcement != github.RulesetEnforcementEvaluate {
+			continue
+		}
+		rs, _, err := ghc.Client().Repositories.GetRuleset(ctx, ghc.Owner(), ghc.Repo(), summary.GetID(), false)
+		if err != nil {
+			return nil, fmt.Errorf("fetching ruleset %d: %w", summary.GetID(), err)
+		}
+		staged = append(staged, rs)
+	}
+	return staged, nil
+}
+
+// StagedControls returns the controls that the rulesets staged in evaluate
+// mode would enforce on the ref once promoted, mapped to the name of the
+// ruleset staging them.
+func (ghc *GitHubConnection) StagedControls(ctx context.Context, ref string) (map[slsa.ControlName]string, error) {
+	staged, err := ghc.stagedRulesets(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	getDefaultBranch := ghc.lazyDefaultBranch(ctx)
+	rules := map[string]string{}
+	for _, rs := range staged {
+		// Evaluate the ruleset as if it was already active
+		promoted := *rs
+		promoted.Enforcement = github.RulesetEnforcementActive
+		keys, err := ghc.enforcedRules(&promoted, ref, getDefaultBranch)
+		if err != nil {
+			return nil, err
+		}
+		for _, k := range keys {
+			rules[k] = rs.Name
+		}
+	}
+
+	controls := map[slsa.ControlName]string{}
+	for k, name := range rules {
+		if k == historyKeyDeletion || k == historyKeyNonFastForward {
+			continue
+		}
+		controls[slsa.ControlName(k)] = name
+	}
+	if name, ok := rules[historyKeyDeletion]; ok {
+		if _, ok := rules[historyKeyNonFastForward]; ok {
+			controls[slsa.SLSA_SOURCE_SCS_CONTINUITY] = name
+		}
+	}
+	return controls, nil
+}
+
+// PromoteRulesets switches the rulesets staged by sourcetool from evaluate
+// to active enforcement. It returns the names of the promoted rulesets.
+func (ghc *GitHubConnection) PromoteRulesets(ctx context.Context) ([]string, error) {
+	staged, err := ghc.stagedRulesets(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	promoted := []string{}
+	for _, rs := range staged {
+		rs.Enforcement = github.RulesetEnforcementActive
+		if _, resp, err := ghc.Client().Repositories.UpdateRuleset(ctx, ghc.Owner(), ghc.Repo(), rs.GetID(), *rs); err != nil {
+			if resp != nil && resp.StatusCode == http.StatusNotFound {
+				return promoted, models.ErrRepositoryAccessDenied
+			}
+			return promoted, fmt.Errorf("promoting ruleset %q: %w", rs.Name, err)
+		}
+		promoted = append(promoted, rs.Name)
+	}
+	return promoted, nil
+}
+
+// StagedRuleFailures returns the pushes of the last month that the rulesets
+// staged by sourcetool would have blocked if they were active. Only the
+// failing evaluations of the staged rulesets are kept in each suite.
+func (ghc *GitHubConnection) StagedRuleFailures(ctx context.Context) ([]*RuleSuite, error) {
+	staged, err := ghc.stagedRulesets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(staged) == 0 {
+		return nil, nil
+	}
+	ids := []int64{}
+	for _, rs := range staged {
+		ids = append(ids, rs.GetID())
+	}
+
+	suites, err := ghc.listRuleSuites(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	failures := []*RuleSuite{}
+	for _, s := range suites {
+		if s.EvaluationResult != "fail" {
+			continue
+		}
+		suite, err := ghc.getRuleSuite(ctx, s.ID)
+		if err != nil {
+			return nil, err
+		}
+		failed := []*RuleEvaluation{}
+		for _, e := range suite.RuleEvaluations {
+			if e.Enforcement == string(github.RulesetEnforcementEvaluate) && e.Result == "fail" &&
+				slices.Contains(ids, e.RuleSource.ID) {
+				failed = append(failed, e)
+			}
+		}
+		if len(failed) == 0 {
+			continue
+		}
+		suite.RuleEvaluations = failed
+		failures = append(failures, suite)
+	}
+	return failures, nil
+}
+
+// listRuleSuites lists the rule suites of the repository in the last month
+func (ghc *GitHubConnection) listRuleSuites(ctx context.Context) ([]*RuleSuite, error) {
+	suites := []*RuleSuite{}
+	page := 1
+	for page != 0 {
+		q := url.Values{}
+		q.Set("time_period", "month")
+		q.Set("rule_suite_result", "all")
+		q.Set("per_page", "100")
+		q.Set("page", fmt.Sprintf("%d", page))
+
+		req, err := ghc.Client().NewRequest(
+			ctx, http.MethodGet, fmt.Sprintf("repos/%s/%s/rulesets/rule-suites?%s", ghc.Owner(), ghc.Repo(), q.Encode()), nil,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		var result []*RuleSuite
+		resp, err := ghc.Client().Do(req, &result)
+		if err != nil {
+			return nil, fmt.Errorf("listing rule suites: %w", err)
+		}
+		suites = append(suites, result...)
+		page = resp.NextPage
+	}
+	return suites, nil
+}
+
+// getRuleSuite fetches a rule suite with its rule evaluations
+func (ghc *GitHubConnection) getRuleSuite(ctx context.Context, id int64) (*RuleSuite, error) {
+	req, err := ghc.Client().NewRequest(
+		ctx, http.MethodGet, fmt.Sprintf("repos/%s/%s/rulesets/rule-suites/%d", ghc.Owner(), ghc.Repo(), id), nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+	suite := &RuleSuite{}
+	if _, err := ghc.Client().Do(req, suite); err != nil {
+		return nil, fmt.Errorf("reading rule suite %d: %w", id, err)
+	}
+	return suite, nil
+}
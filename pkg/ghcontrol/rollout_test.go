@@ -0,0 +1,300 @@
+// SPDX-FileCopyrightText: Copyright 2026 The SLSA Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package ghcontrol
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v88/github"
+	"github.com/migueleliasweb/go-github-mock/src/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slsa-framework/source-tool/pkg/slsa"
+	"github.com/slsa-framework/source-tool/pkg/sourcetool/models"
+)
+
+func stagedRuleset(id int64, name string, target github.RulesetTarget, enforcement github.RulesetEnforcement, include string, rules *github.RepositoryRulesetRules) *github.RepositoryRuleset {
+	return &github.RepositoryRuleset{
+		ID:          github.Ptr(id),
+		Name:        name,
+		Target:      github.Ptr(target),
+		Enforcement: enforcement,
+		Conditions:  conditionsForRuleset(include),
+		Rules:       rules,
+		UpdatedAt:   github.Ptr(github.Timestamp{Time: curTime}),
+	}
+}
+
+func tagRules() *github.RepositoryRulesetRules {
+	rules := rulesForBranchContinuity()
+	rules.Update = &github.UpdateRuleParameters{}
+	return rules
+}
+
+// writeJSON returns a handler that always responds with v. Handlers run
+// outside the test goroutine, failures are reported as a 500 response.
+func writeJSON(v any) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		data, err := json.Marshal(v)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write(data) //nolint:errcheck,gosec
+	}
+}
+
+// newRolloutConnection returns a connection to a mocked API serving the
+// rulesets and any other mocked endpoints.
+func newRolloutConnection(t *testing.T, rulesets []*github.RepositoryRuleset, opts ...mock.MockBackendOption) *GitHubConnection {
+	t.Helper()
+	getRuleset := func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+		id, err := strconv.ParseInt(parts[len(parts)-1], 10, 64)
+		require.NoError(t, err)
+		for _, rs := range rulesets {
+			if rs.GetID() == id {
+				writeJSON(rs)(w, r)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}
+
+	opts = append(opts,
+		mock.WithRequestMatchHandler(mock.GetReposRulesetsByOwnerByRepo, writeJSON(rulesets)),
+		mock.WithRequestMatchHandler(mock.GetReposRulesetsByOwnerByRepoByRulesetId, http.HandlerFunc(getRuleset)),
+		mock.WithRequestMatch(mock.GetReposByOwnerByRepo, github.Repository{DefaultBranch: github.Ptr("main")}),
+	)
+	client, err := github.NewClient(github.WithHTTPClient(mock.NewMockedHTTPClient(opts...)))
+	require.NoError(t, err)
+	return NewGhConnectionWithClient("owner", "repo", "refs/heads/main", client)
+}
+
+func TestStagedControls(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		name     string
+		rulesets []*github.RepositoryRuleset
+		expected map[slsa.ControlName]string
+	}{
+		{
+			name: "staged-branch-and-tags",
+			rulesets: []*github.RepositoryRuleset{
+				stagedRuleset(1, BranchRulesetName, github.RulesetTargetBranch, github.RulesetEnforcementEvaluate, "~DEFAULT_BRANCH", rulesForBranchContinuity()),
+				stagedRuleset(2, TagRulesetName, github.RulesetTargetTag, github.RulesetEnforcementEvaluate, "~ALL", tagRules()),
+			},
+			expected: map[slsa.ControlName]string{
+				slsa.SLSA_SOURCE_SCS_CONTINUITY:     BranchRulesetName,
+				slsa.SLSA_SOURCE_SCS_PROTECTED_REFS: TagRulesetName,
+			},
+		},
+		{
+			name: "active-rulesets",
+			rulesets: []*github.RepositoryRuleset{
+				stagedRuleset(1, BranchRulesetName, github.RulesetTargetBranch, github.RulesetEnforcementActive, "~DEFAULT_BRANCH", rulesForBranchContinuity()),
+			},
+			expected: map[slsa.ControlName]string{},
+		},
+		{
+			name: "not-managed",
+			rulesets: []*github.RepositoryRuleset{
+				stagedRuleset(1, "Other rules", github.RulesetTargetBranch, github.RulesetEnforcementEvaluate, "~DEFAULT_BRANCH", rulesForBranchContinuity()),
+			},
+			expected: map[slsa.ControlName]string{},
+		},
+		{
+			name: "other-branch",
+			rulesets: []*github.RepositoryRuleset{
+				stagedRuleset(1, BranchRulesetName, github.RulesetTargetBranch, github.RulesetEnforcementEvaluate, "refs/heads/dev", rulesForBranchContinuity()),
+			},
+			expected: map[slsa.ControlName]string{},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ghc := newRolloutConnection(t, tc.rulesets)
+			controls, err := ghc.StagedControls(t.Context(), "refs/heads/main")
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, controls)
+		})
+	}
+}
+
+func TestPromoteRulesets(t *testing.T) {
+	t.Parallel()
+	updated := map[int64]github.RulesetEnforcement{}
+	update := func(w http.ResponseWriter, r *http.Request) {
+		rs := &github.RepositoryRuleset{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(rs))
+		parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+		id, err := strconv.ParseInt(parts[len(parts)-1], 10, 64)
+		require.NoError(t, err)
+		updated[id] = rs.Enforcement
+		writeJSON(rs)(w, r)
+	}
+
+	ghc := newRolloutConnection(t, []*github.RepositoryRuleset{
+		stagedRuleset(1, BranchRulesetName, github.RulesetTargetBranch, github.RulesetEnforcementEvaluate, "~DEFAULT_BRANCH", rulesForBranchContinuity()),
+		stagedRuleset(2, TagRulesetName, github.RulesetTargetTag, github.RulesetEnforcementActive, "~ALL", tagRules()),
+		stagedRuleset(3, "Other rules", github.RulesetTargetBranch, github.RulesetEnforcementEvaluate, "~ALL", rulesForBranchContinuity()),
+	}, mock.WithRequestMatchHandler(mock.PutReposRulesetsByOwnerByRepoByRulesetId, http.HandlerFunc(update)))
+
+	promoted, err := ghc.PromoteRulesets(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, []string{BranchRulesetName}, promoted)
+	require.Equal(t, map[int64]github.RulesetEnforcement{1: github.RulesetEnforcementActive}, updated)
+}
+
+func TestStagedRuleFailures(t *testing.T) {
+	t.Parallel()
+	pushed := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	evaluation := func(id int64, enforcement, result string) *RuleEvaluation {
+		e := &RuleEvaluation{Enforcement: enforcement, Result: result, RuleType: "non_fast_forward"}
+		e.RuleSource.ID = id
+		e.RuleSource.Name = BranchRulesetName
+		return e
+	}
+	suites := map[int64]*RuleSuite{
+		// Force push blocked by the staged ruleset
+		10: {
+			ID: 10, Ref: "refs/heads/main", ActorName: "octocat", PushedAt: pushed, EvaluationResult: "fail",
+			RuleEvaluations: []*RuleEvaluation{
+				evaluation(1, "evaluate", "fail"),
+				evaluation(1, "evaluate", "pass"),
+			},
+		},
+		// Push failing a ruleset sourcetool does not manage
+		11: {
+			ID: 11, Ref: "refs/heads/main", ActorName: "octocat", PushedAt: pushed, EvaluationResult: "fail",
+			RuleEvaluations: []*RuleEvaluation{evaluation(3, "evaluate", "fail")},
+		},
+		// Push passing all rules
+		12: {ID: 12, Ref: "refs/heads/main", PushedAt: pushed, EvaluationResult: "pass"},
+	}
+	list := []*RuleSuite{}
+	for _, id := range []int64{10, 11, 12} {
+		s := *suites[id]
+		s.RuleEvaluations = nil
+		list = append(list, &s)
+	}
+	getSuite := func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+		id, err := strconv.ParseInt(parts[len(parts)-1], 10, 64)
+		require.NoError(t, err)
+		require.NotEqual(t, int64(12), id, "passing suites should not be fetched")
+		writeJSON(suites[id])(w, r)
+	}
+
+	ghc := newRolloutConnection(t, []*github.RepositoryRuleset{
+		stagedRuleset(1, BranchRulesetName, github.RulesetTargetBranch, github.RulesetEnforcementEvaluate, "~DEFAULT_BRANCH", rulesForBranchContinuity()),
+		stagedRuleset(3, "Other rules", github.RulesetTargetBranch, github.RulesetEnforcementEvaluate, "~ALL", rulesForBranchContinuity()),
+	},
+		mock.WithRequestMatchHandler(mock.GetReposRulesetsRuleSuitesByOwnerByRepo, writeJSON(list)),
+		mock.WithRequestMatchHandler(mock.GetReposRulesetsRuleSuitesByOwnerByRepoByRuleSuiteId, http.HandlerFunc(getSuite)),
+	)
+
+	failures, err := ghc.StagedRuleFailures(t.Context())
+	require.NoError(t, err)
+	require.Len(t, failures, 1)
+	require.Equal(t, int64(10), failures[0].ID)
+	require.Equal(t, "octocat", failures[0].ActorName)
+	require.Equal(t, pushed, failures[0].PushedAt)
+	require.Len(t, failures[0].RuleEvaluations, 1)
+	require.Equal(t, "fail", failures[0].RuleEvaluations[0].Result)
+}
+
+func TestEnableBranchRulesStaged(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		name        string
+		existing    github.RulesetEnforcement
+		enforcement github.RulesetEnforcement
+		created     bool
+		expectedErr error
+	}{
+		{name: "stage", enforcement: github.RulesetEnforcementEvaluate, created: true},
+		{name: "enforce", enforcement: github.RulesetEnforcementActive, created: true},
+		{name: "stage-staged", existing: github.RulesetEnforcementEvaluate, enforcement: github.RulesetEnforcementEvaluate, expectedErr: models.ErrProtectionAlreadyInPlace},
+		{name: "enforce-staged", existing: github.RulesetEnforcementEvaluate, enforcement: github.RulesetEnforcementActive, expectedErr: ErrRulesetStaged},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			rulesets := []*github.RepositoryRuleset{}
+			if tc.existing != "" {
+				rulesets = append(rulesets, stagedRuleset(1, BranchRulesetName, github.RulesetTargetBranch, tc.existing, "refs/heads/main", rulesForBranchContinuity()))
+			}
+
+			var created *github.RepositoryRuleset
+			create := func(w http.ResponseWriter, r *http.Request) {
+				created = &github.RepositoryRuleset{}
+				require.NoError(t, json.NewDecoder(r.Body).Decode(created))
+				writeJSON(created)(w, r)
+			}
+			ghc := newRolloutConnection(t, rulesets,
+				// Rules in evaluate mode are not returned for the branch
+				mock.WithRequestMatch(mock.GetReposRulesBranchesByOwnerByRepoByBranch, []branchRuleRawResponse{}),
+				mock.WithRequestMatchHandler(mock.PostReposRulesetsByOwnerByRepo, http.HandlerFunc(create)),
+			)
+
+			err := ghc.EnableBranchRules(t.Context(), tc.enforcement)
+			if tc.expectedErr != nil {
+				require.ErrorIs(t, err, tc.expectedErr)
+				require.Nil(t, created)
+				return
+			}
+			require.NoError(t, err)
+			require.NotNil(t, created)
+			require.Equal(t, BranchRulesetName, created.Name)
+			require.Equal(t, tc.enforcement, created.Enforcement)
+		})
+	}
+}
+
+func TestDeleteManagedRuleset(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		name     string
+		ruleset  string
+		expected bool
+		deleted  int64
+		mustErr  bool
+	}{
+		{name: "delete", ruleset: TagRulesetName, expected: true, deleted: 2},
+		{name: "not-found", ruleset: ReviewRulesetName},
+		{name: "not-managed", ruleset: "Other rules", mustErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			var deleted int64
+			del := func(w http.ResponseWriter, r *http.Request) {
+				parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+				id, err := strconv.ParseInt(parts[len(parts)-1], 10, 64)
+				require.NoError(t, err)
+				deleted = id
+				w.WriteHeader(http.StatusNoContent)
+			}
+			ghc := newRolloutConnection(t, []*github.RepositoryRuleset{
+				stagedRuleset(1, BranchRulesetName, github.RulesetTargetBranch, github.RulesetEnforcementActive, "~DEFAULT_BRANCH", rulesForBranchContinuity()),
+				stagedRuleset(2, TagRulesetName, github.RulesetTargetTag, github.RulesetEnforcementActive, "~ALL", tagRules()),
+				stagedRuleset(3, "Other rules", github.RulesetTargetBranch, github.RulesetEnforcementActive, "~ALL", rulesForBranchContinuity()),
+			}, mock.WithRequestMatchHandler(mock.DeleteReposRulesetsByOwnerByRepoByRulesetId, http.HandlerFunc(del)))
+
+			ok, err := ghc.DeleteManagedRuleset(t.Context(), tc.ruleset)
+			if tc.mustErr {
+				require.Error(t, err)
+				require.Zero(t, deleted)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, ok)
+			require.Equal(t, tc.deleted, deleted)
+		})
+	}
+}
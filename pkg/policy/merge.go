@@ -0,0 +1,184 @@
+// SPDX-FileCopyrightText: Copyright 2026 The SLSA Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/slsa-framework/source-tool/pkg/slsa"
+)
+
+// MergePolicies returns a copy of base with the branch and tag protection
+// of update merged in. It is used to add newly generated branch entries to
+// a published policy.
+//
+// Branch entries in update are added to the policy or, when base already
+// has an entry with the same name, raise its target level. An existing
+// entry is never downgraded: its level and since date are only replaced
+// when update targets a higher level, and the rest of its settings
+// (reviews, org checks, rules) are kept. Branches not in update are left
+// untouched.
+func MergePolicies(base, update *RepoPolicy) *RepoPolicy {
+	if base == nil {
+		return proto.CloneOf(update)
+	}
+	merged := proto.CloneOf(base)
+	if merged.GetCanonicalRepo() == "" {
+		merged.CanonicalRepo = update.GetCanonicalRepo()
+	}
+
+	for _, pb := range update.GetProtectedBranches() {
+		i := slices.IndexFunc(merged.GetProtectedBranches(), func(e *ProtectedBranch) bool {
+			return e.GetName() == pb.GetName()
+		})
+		if i == -1 {
+			merged.ProtectedBranches = append(merged.ProtectedBranches, proto.CloneOf(pb))
+			continue
+		}
+
+		existing := merged.GetProtectedBranches()[i]
+		if !slsa.IsLevelHigherOrEqualTo(
+			slsa.SlsaSourceLevel(existing.GetTargetSlsaSourceLevel()), slsa.SlsaSourceLevel(pb.GetTargetSlsaSourceLevel()),
+		) {
+			existing.TargetSlsaSourceLevel = pb.GetTargetSlsaSourceLevel()
+			existing.Since = proto.CloneOf(pb.GetSince())
+		}
+	}
+
+	// Tag protection is only added, never dropped from the policy
+	if tp := update.GetProtectedTag(); tp.GetTagHygiene() && !merged.GetProtectedTag().GetTagHygiene() {
+		if merged.ProtectedTag == nil {
+			merged.ProtectedTag = &ProtectedTag{}
+		}
+		merged.ProtectedTag.TagHygiene = true
+		merged.ProtectedTag.Since = proto.CloneOf(tp.GetSince())
+	}
+	return merged
+}
+
+// ChangeType is the kind of a change between two versions of a policy
+type ChangeType string
+
+const (
+	ChangeAdded    ChangeType = "+"
+	ChangeRemoved  ChangeType = "-"
+	ChangeModified ChangeType = "~"
+)
+
+// PolicyChange is a field that differs between two versions of a policy
+type PolicyChange struct {
+	Type ChangeType `json:"type"`
+	// Field is the location of the value, entries of lists are keyed by
+	// name when they have one, eg protected_branches[main].since
+	Field string `json:"field"`
+	Old   string `json:"old,omitempty"`
+	New   string `json:"new,omitempty"`
+}
+
+func (pc *PolicyChange) String() string {
+	switch pc.Type {
+	case ChangeAdded:
+		return fmt.Sprintf("%s %s: %s", pc.Type, pc.Field, pc.New)
+	case ChangeRemoved:
+		return fmt.Sprintf("%s %s: %s", pc.Type, pc.Field, pc.Old)
+	default:
+		return fmt.Sprintf("%s %s: %s -> %s", pc.Type, pc.Field, pc.Old, pc.New)
+	}
+}
+
+// PolicyDiff lists the changes between two versions of a policy
+type PolicyDiff []*PolicyChange
+
+func (pd PolicyDiff) String() string {
+	var sb strings.Builder
+	for _, c := range pd {
+		sb.WriteString(c.String() + "\n")
+	}
+	return sb.String()
+}
+
+// DiffPolicies returns the changes from the old to the new version of a
+// policy. A nil policy is handled as an empty one.
+func DiffPolicies(oldPolicy, newPolicy *RepoPolicy) (PolicyDiff, error) {
+	oldFields, err := flattenPolicy(oldPolicy)
+	if err != nil {
+		return nil, fmt.Errorf("reading old policy: %w", err)
+	}
+	newFields, err := flattenPolicy(newPolicy)
+	if err != nil {
+		return nil, fmt.Errorf("reading new policy: %w", err)
+	}
+
+	all := maps.Clone(oldFields)
+	maps.Copy(all, newFields)
+
+	diff := PolicyDiff{}
+	for _, f := range slices.Sorted(maps.Keys(all)) {
+		o, inOld := oldFields[f]
+		n, inNew := newFields[f]
+		switch {
+		case !inOld:
+			diff = append(diff, &PolicyChange{Type: ChangeAdded, Field: f, New: n})
+		case !inNew:
+			diff = append(diff, &PolicyChange{Type: ChangeRemoved, Field: f, Old: o})
+		case o != n:
+			diff = append(diff, &PolicyChange{Type: ChangeModified, Field: f, Old: o, New: n})
+		}
+	}
+	return diff, nil
+}
+
+// flattenPolicy returns the values in the policy keyed by their location
+func flattenPolicy(rp *RepoPolicy) (map[string]string, error) {
+	fields := map[string]string{}
+	if rp == nil {
+		return fields, nil
+	}
+	data, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(rp)
+	if err != nil {
+		return nil, err
+	}
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	flattenValue(fields, "", v)
+	return fields, nil
+}
+
+func flattenValue(fields map[string]string, prefix string, v any) {
+	switch val := v.(type) {
+	case map[string]any:
+		for k, e := range val {
+			key := k
+			if prefix != "" {
+				key = prefix + "." + k
+			}
+			flattenValue(fields, key, e)
+		}
+	case []any:
+		for i, e := range val {
+			key := fmt.Sprintf("%s[%d]", prefix, i)
+			if m, ok := e.(map[string]any); ok {
+				if name, ok := m["name"].(string); ok && name != "" {
+					key = fmt.Sprintf("%s[%s]", prefix, name)
+				}
+			}
+			flattenValue(fields, key, e)
+		}
+	default:
+		data, err := json.Marshal(val)
+		if err != nil {
+			data = fmt.Appendf(nil, "%v", val)
+		}
+		fields[prefix] = string(data)
+	}
+}
@@ -0,0 +1,118 @@
+// SPDX-FileCopyrightText: Copyright 2026 The SLSA Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package policy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/slsa-framework/source-tool/pkg/slsa"
+)
+
+func TestMergePolicies(t *testing.T) {
+	t.Parallel()
+	old := timestamppb.New(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	recent := timestamppb.New(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	base := &RepoPolicy{
+		CanonicalRepo: "https://github.com/example/repo",
+		ProtectedBranches: []*ProtectedBranch{
+			{
+				Name: "main", Since: old, TargetSlsaSourceLevel: string(slsa.SlsaSourceLevel2),
+				OrgStatusCheckControls: []*OrgStatusCheckControl{{CheckName: "test", PropertyName: "ORG_SOURCE_TESTED", Since: old}},
+			},
+			{Name: "develop", Since: old, TargetSlsaSourceLevel: string(slsa.SlsaSourceLevel1)},
+			{Name: "legacy", Since: old, TargetSlsaSourceLevel: string(slsa.SlsaSourceLevel1)},
+			{Name: "stable", Since: old, TargetSlsaSourceLevel: string(slsa.SlsaSourceLevel3)},
+		},
+	}
+	update := &RepoPolicy{
+		CanonicalRepo: "https://github.com/example/repo",
+		ProtectedBranches: []*ProtectedBranch{
+			{Name: "main", Since: recent, TargetSlsaSourceLevel: string(slsa.SlsaSourceLevel2)},
+			{Name: "develop", Since: recent, TargetSlsaSourceLevel: string(slsa.SlsaSourceLevel3)},
+			{Name: "stable", Since: recent, TargetSlsaSourceLevel: string(slsa.SlsaSourceLevel2)},
+			{Name: "release/*", Since: recent, TargetSlsaSourceLevel: string(slsa.SlsaSourceLevel3)},
+		},
+		ProtectedTag: &ProtectedTag{Since: recent, TagHygiene: true},
+	}
+	baseCopy := proto.CloneOf(base)
+
+	merged := MergePolicies(base, update)
+	require.True(t, proto.Equal(baseCopy, base), "base policy was modified")
+	require.Len(t, merged.GetProtectedBranches(), 5)
+
+	// Same level: the since date and the org checks are kept
+	main := merged.GetBranchPolicy("main")
+	require.True(t, proto.Equal(old, main.GetSince()))
+	require.Len(t, main.GetOrgStatusCheckControls(), 1)
+
+	// The level changed, the since date is the new one
+	develop := merged.GetBranchPolicy("develop")
+	require.Equal(t, string(slsa.SlsaSourceLevel3), develop.GetTargetSlsaSourceLevel())
+	require.True(t, proto.Equal(recent, develop.GetSince()))
+
+	// A lower level never downgrades the existing entry
+	require.True(t, proto.Equal(base.GetProtectedBranches()[3], merged.GetBranchPolicy("stable")))
+
+	// Branches not in the update are left alone and new ones are added
+	require.True(t, proto.Equal(base.GetProtectedBranches()[2], merged.GetBranchPolicy("legacy")))
+	require.Equal(t, "release/*", merged.GetProtectedBranches()[4].GetName())
+	require.True(t, merged.GetProtectedTag().GetTagHygiene())
+
+	// Tag protection is never dropped
+	merged = MergePolicies(merged, &RepoPolicy{})
+	require.True(t, merged.GetProtectedTag().GetTagHygiene())
+
+	// Merging into no policy returns the update
+	require.True(t, proto.Equal(update, MergePolicies(nil, update)))
+}
+
+func TestDiffPolicies(t *testing.T) {
+	t.Parallel()
+	since := timestamppb.New(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	oldPolicy := &RepoPolicy{
+		CanonicalRepo: "https://github.com/example/repo",
+		ProtectedBranches: []*ProtectedBranch{
+			{Name: "main", Since: since, TargetSlsaSourceLevel: string(slsa.SlsaSourceLevel2)},
+			{Name: "legacy", Since: since, TargetSlsaSourceLevel: string(slsa.SlsaSourceLevel1)},
+		},
+	}
+	newPolicy := &RepoPolicy{
+		CanonicalRepo: "https://github.com/example/repo",
+		ProtectedBranches: []*ProtectedBranch{
+			{Name: "develop", Since: since, TargetSlsaSourceLevel: string(slsa.SlsaSourceLevel1)},
+			{Name: "main", Since: since, TargetSlsaSourceLevel: string(slsa.SlsaSourceLevel3)},
+		},
+	}
+
+	diff, err := DiffPolicies(oldPolicy, newPolicy)
+	require.NoError(t, err)
+	require.Equal(t, PolicyDiff{
+		{Type: ChangeAdded, Field: "protected_branches[develop].name", New: `"develop"`},
+		{Type: ChangeAdded, Field: "protected_branches[develop].since", New: `"2024-01-01T00:00:00Z"`},
+		{Type: ChangeAdded, Field: "protected_branches[develop].target_slsa_source_level", New: `"SLSA_SOURCE_LEVEL_1"`},
+		{Type: ChangeRemoved, Field: "protected_branches[legacy].name", Old: `"legacy"`},
+		{Type: ChangeRemoved, Field: "protected_branches[legacy].since", Old: `"2024-01-01T00:00:00Z"`},
+		{Type: ChangeRemoved, Field: "protected_branches[legacy].target_slsa_source_level", Old: `"SLSA_SOURCE_LEVEL_1"`},
+		{
+			Type: ChangeModified, Field: "protected_branches[main].target_slsa_source_level",
+			Old: `"SLSA_SOURCE_LEVEL_2"`, New: `"SLSA_SOURCE_LEVEL_3"`,
+		},
+	}, diff)
+	require.Contains(t, diff.String(), `~ protected_branches[main].target_slsa_source_level: "SLSA_SOURCE_LEVEL_2" -> "SLSA_SOURCE_LEVEL_3"`)
+
+	diff, err = DiffPolicies(oldPolicy, proto.CloneOf(oldPolicy))
+	require.NoError(t, err)
+	require.Empty(t, diff)
+
+	// A nil policy is an empty one
+	diff, err = DiffPolicies(nil, oldPolicy)
+	require.NoError(t, err)
+	require.Len(t, diff, 7)
+}
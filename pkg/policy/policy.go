@@ -8,18 +8,19 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"net/http"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
 	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	git "github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/google/go-github/v88/github" // Use v88
 	spb "github.com/in-toto/attestation/go/v1"
-	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/slsa-framework/source-tool/pkg/attest"
@@ -38,17 +39,104 @@ const (
 	// DefaultPolicyPath is the policy path reported when a branch is
 	// evaluated using the default policy.
 	DefaultPolicyPath = "DEFAULT"
+
+	// BranchPatternFragment and TagPatternFragment prefix the pattern of
+	// the protected branch or tag entry applied in the fragment of the
+	// reported policy paths.
+	BranchPatternFragment = "protected_branch="
+	TagPatternFragment    = "protected_tag="
 )
 
 // Returns the policy for the branch or nil if the branch doesn't have one.
+//
+// Protected branch names can be glob patterns (see IsBranchPattern). An entry
+// named exactly as the branch always wins. When several patterns match, the
+// most specific one (the one with the most literal characters) applies and
+// ties go to the pattern listed first.
 func (rp *RepoPolicy) GetBranchPolicy(branch string) *ProtectedBranch {
-	branch = strings.TrimPrefix(branch, "refs/heads/")
-	for _, pb := range rp.GetProtectedBranches() {
-		if pb.GetName() == branch {
-			return pb
+	return matchEntry(rp.GetProtectedBranches(), strings.TrimPrefix(branch, "refs/heads/"))
+}
+
+// GetTagPolicy returns the policy for the tag or nil if the tag doesn't have
+// one. Entries in protected_tags are selected like protected branches (see
+// GetBranchPolicy), tags not matching any of them use protected_tag.
+func (rp *RepoPolicy) GetTagPolicy(tag string) *ProtectedTag {
+	if pt := matchEntry(rp.GetProtectedTags(), strings.TrimPrefix(tag, "refs/tags/")); pt != nil {
+		return pt
+	}
+	return rp.GetProtectedTag()
+}
+
+// namedEntry is a policy entry applying to the refs matching its name
+type namedEntry interface {
+	comparable
+	GetName() string
+}
+
+// matchEntry returns the entry that applies to the ref name
+func matchEntry[E namedEntry](entries []E, name string) E {
+	var match, none E
+	for _, e := range entries {
+		if e.GetName() == name {
+			return e
+		}
+		if !IsBranchPattern(e.GetName()) || !matchPattern(e.GetName(), name) {
+			continue
+		}
+		if match == none || patternSpecificity(e.GetName()) > patternSpecificity(match.GetName()) {
+			match = e
 		}
 	}
-	return nil
+	return match
+}
+
+// IsBranchPattern returns true if a protected branch or tag name is a glob
+// pattern. Patterns use the path.Match syntax: '*' matches any sequence of
+// characters except '/', '?' any single one and '[...]' a character class.
+func IsBranchPattern(name string) bool {
+	return strings.ContainsAny(name, `*?[\`)
+}
+
+// MatchBranchPattern returns true if the branch matches the pattern.
+// Malformed patterns match no branch.
+func MatchBranchPattern(pattern, branch string) bool {
+	return matchPattern(pattern, strings.TrimPrefix(branch, "refs/heads/"))
+}
+
+func matchPattern(pattern, name string) bool {
+	ok, err := path.Match(pattern, name)
+	return err == nil && ok
+}
+
+// patternSpecificity is the number of literal characters in a pattern
+func patternSpecificity(pattern string) int {
+	n := 0
+	inClass := false
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case inClass:
+			inClass = c != ']'
+		case c == '[':
+			inClass = true
+		case c == '*' || c == '?':
+		case c == '\\':
+			i++
+			n++
+		default:
+			n++
+		}
+	}
+	return n
+}
+
+// entryPolicyPath returns the policy path to report when a branch or tag
+// entry applies. When the ref matched a pattern, the pattern is appended as a
+// fragment to let consumers know which entry was applied.
+func entryPolicyPath(policyPath, fragment, entry, name string) string {
+	if entry == "" || entry == name {
+		return policyPath
+	}
+	return policyPath + "#" + fragment + url.QueryEscape(entry)
 }
 
 func createDefaultBranchPolicy(branch *models.Branch) *ProtectedBranch {
@@ -82,63 +170,14 @@ func (pe *PolicyEvaluator) getGitHubClient() (*github.Client, error) {
 	return pe.authenticator.GetGitHubClient()
 }
 
-// getRemotePolicy fetches a policy using the GitHub API
-// If we can't find a policy we return a nil policy.
-func (pe *PolicyEvaluator) getRemotePolicy(ctx context.Context, repo *models.Repository) (*RepoPolicy, string, error) {
-	path := getPolicyPath(repo)
-	client, err := pe.getGitHubClient()
-	if err != nil {
-		return nil, "", err
-	}
-
-	policyContents, _, resp, err := client.Repositories.GetContents(ctx, SourcePolicyRepoOwner, SourcePolicyRepo, path, nil)
-	if resp != nil && resp.StatusCode == http.StatusNotFound {
-		return nil, "", nil
-	}
-
-	if err != nil {
-		return nil, "", fmt.Errorf("fetching policy code: %w", err)
-	}
-
-	content, err := policyContents.GetContent()
-	if err != nil {
-		return nil, "", err
-	}
-
-	p := &RepoPolicy{}
-	err = protojson.UnmarshalOptions{
-		DiscardUnknown: false,
-	}.Unmarshal([]byte(content), p)
-	if err != nil {
-		return nil, "", fmt.Errorf("unmarshaling policy code: %w", err)
-	}
-	return p, *policyContents.HTMLURL, nil
-}
-
-func getLocalPolicy(path string) (*RepoPolicy, string, error) {
-	contents, err := os.ReadFile(path)
-	if err != nil {
-		return nil, "", err
-	}
-
-	var p RepoPolicy
-	err = protojson.Unmarshal(contents, &p)
-	if err != nil {
-		return nil, "", fmt.Errorf("unmarshaling json: %w", err)
-	}
-	return &p, path, nil
-}
-
-// GetPolicy fetches the policy for a repository from the SLSA source repo.
-// For debugging purposes, if UseLocalPolicy is defined, then the policy will
-// be read from a local file.
+// GetPolicy fetches the policy for a repository from the evaluator's policy
+// source, the SLSA source repo by default. For debugging purposes, if
+// UseLocalPolicy is defined, then the policy will be read from a local file.
+//
+// The policy is read at the evaluator's point (At), the latest policy by
+// default.
 func (pe *PolicyEvaluator) GetPolicy(ctx context.Context, repo *models.Repository) (policy *RepoPolicy, path string, err error) {
-	if pe.UseLocalPolicy == "" {
-		policy, path, err = pe.getRemotePolicy(ctx, repo)
-	} else {
-		policy, path, err = getLocalPolicy(pe.UseLocalPolicy)
-	}
-
+	policy, path, _, err = pe.GetPolicyAt(ctx, repo, pe.At)
 	return policy, path, err
 }
 
@@ -172,13 +211,14 @@ func (pe *PolicyEvaluator) checkLocalDir(ctx context.Context, repo *models.Repos
 		return fmt.Errorf("policy already exists at %s", path)
 	}
 
-	// Is there a remote policy?
-	rp, _, err := pe.getRemotePolicy(ctx, repo)
+	// Is there a policy in the source the evaluator reads from?
+	src := pe.policySource()
+	rp, _, _, err := src.GetPolicyAt(ctx, repo, PolicyPoint{})
 	if err != nil {
-		return fmt.Errorf("checking remote policy: %w", err)
+		return fmt.Errorf("checking policy in %s: %w", src, err)
 	}
 	if rp != nil {
-		return fmt.Errorf("policy already exists remotely for %s", getPolicyPath(repo))
+		return fmt.Errorf("policy already exists in %s for %s", src, getPolicyPath(repo))
 	}
 	return nil
 }
@@ -333,42 +373,57 @@ func ComputeEligibleSlsaLevel(controls *slsa.ControlSet) slsa.SlsaSourceLevel {
 	return slsa.SlsaSourceLevel1
 }
 
-func laterTime(time1, time2 time.Time) time.Time {
-	if time1.After(time2) {
-		return time1
+// Computes the time since these controls have been eligible for the level, nil if not eligible.
+//
+// Eligibility is continuous: the result is the start of the latest window
+// in which all the required controls were enforced together. If that window
+// is closed (one of the controls has been disabled since) the controls are
+// not eligible.
+func ComputeEligibleSince(controls *slsa.ControlSet, level slsa.SlsaSourceLevel) (*time.Time, error) {
+	windows, ok := eligibleIntervals(controls, level)
+	if !ok {
+		return nil, nil
+	}
+	// No control has a since date
+	if windows == nil {
+		return &time.Time{}, nil
 	}
-	return time2
+	last := windows.Last()
+	if last == nil || last.End != nil {
+		return nil, nil
+	}
+	return &last.Start, nil
 }
 
-// Computes the time since these controls have been eligible for the level, nil if not eligible.
-func ComputeEligibleSince(controls *slsa.ControlSet, level slsa.SlsaSourceLevel) (*time.Time, error) {
+// eligibleIntervals returns the windows in which all the controls required
+// for the level were enforced. Returns false if a control is missing and
+// nil intervals if none of the controls knows when it was enabled.
+func eligibleIntervals(controls *slsa.ControlSet, level slsa.SlsaSourceLevel) (slsa.Intervals, bool) {
 	// Get the required controls for the taget SLSA level
 	requiredControls := slsa.GetRequiredControlsForLevel(level)
-	var newestTime time.Time
-	// Range the controls and get the latest time. This is the time when
-	// the repo started being elegible for the target level
+	var windows slsa.Intervals
 	for _, rc := range requiredControls {
 		ac := controls.GetControl(rc)
 		if ac == nil {
 			// TODO(puerco): Here we should report which controls are missing
 			// to inform the user somehow.
-			return nil, nil
+			return nil, false
 		}
 
 		// If a control is missing it since date, then ignore it for "ElegibleSince"
 		// computation. Here we have a problem on how we compute since for provenance.
 		// See https://github.com/slsa-framework/source-tool/issues/365
-		since := ac.GetSince()
-		if since == nil {
+		ivs := ac.GetIntervals()
+		if ivs == nil {
 			continue
 		}
-		if newestTime.Equal(time.Time{}) {
-			newestTime = *since
+		if windows == nil {
+			windows = ivs
 		} else {
-			newestTime = laterTime(newestTime, *since)
+			windows = windows.Intersect(ivs)
 		}
 	}
-	return &newestTime, nil
+	return windows, true
 }
 
 // Every function that determines properties to include in the result & VSA implements this interface.
@@ -388,9 +443,14 @@ type PolicyShortfall struct {
 type EvaluationResult struct {
 	VerifiedLevels slsa.SourceVerifiedLevels
 	PolicyPath     string
+	// PolicyVersion identifies the version of the policy used, nil when
+	// evaluating against the default policy.
+	PolicyVersion *PolicyVersion
 	// Shortfall is non-nil when the achieved SLSA source level is below the
 	// policy's target level.
 	Shortfall *PolicyShortfall
+	// Rules are the results of the custom rules in the policy
+	Rules []*RuleResult
 }
 
 // computeAchievableSlsaLevel determines the highest SLSA source level the controls
@@ -455,6 +515,9 @@ func slsaLevelShortfall(target, achieved, eligibleLevel slsa.SlsaSourceLevel, br
 				"policy sets target level %s since %v, but it has only been eligible for that level since %v",
 				target, branchPolicy.GetSince().AsTime(), *eligibleSince,
 			)
+			if gap := lastGapAfter(controls, target, branchPolicy.GetSince().AsTime()); gap != nil {
+				reason += fmt.Sprintf(" (required controls were not enforced from %v to %v)", gap.Start, *gap.End)
+			}
 		}
 	}
 
@@ -465,6 +528,20 @@ func slsaLevelShortfall(target, achieved, eligibleLevel slsa.SlsaSourceLevel, br
 	}
 }
 
+// lastGapAfter returns the most recent window after t in which the controls
+// required for the level were not enforced together, nil if there was none.
+func lastGapAfter(controls *slsa.ControlSet, level slsa.SlsaSourceLevel, t time.Time) *slsa.Interval {
+	windows, ok := eligibleIntervals(controls, level)
+	if !ok {
+		return nil
+	}
+	gaps := windows.Gaps()
+	if len(gaps) == 0 || !gaps[len(gaps)-1].End.After(t) {
+		return nil
+	}
+	return &gaps[len(gaps)-1]
+}
+
 func computeReviewEnforced(branchPolicy *ProtectedBranch, _ *ProtectedTag, controls *slsa.ControlSet) ([]slsa.ControlName, error) {
 	if !branchPolicy.GetRequireReview() {
 		return []slsa.ControlName{}, nil
@@ -565,6 +642,10 @@ func evaluateBranchControls(branchPolicy *ProtectedBranch, tagPolicy *ProtectedT
 // Users provide a list of verifiedLevels that came from VSAs issued previously for the commit pointed to by this
 // tag.
 func evaluateTagProv(tagPolicy *ProtectedTag, tagProvPred *provenance.TagProvenancePred) (slsa.SourceVerifiedLevels, error) {
+	if err := checkTagRequirements(tagPolicy, tagProvPred); err != nil {
+		return slsa.SourceVerifiedLevels{}, err
+	}
+
 	// As long as all the controls for tag protection are currently in force then we'll
 	// include the verifiedLevels.
 
@@ -606,15 +687,119 @@ func evaluateTagProv(tagPolicy *ProtectedTag, tagProvPred *provenance.TagProvena
 	return verifiedLevels, nil
 }
 
+// checkTagRequirements checks the tag meets the requirements of its policy
+// entry: signed annotated tags, the minimum source level inherited from the
+// VSAs of the tagged commit and the branches it was verified on.
+func checkTagRequirements(tagPolicy *ProtectedTag, tagProvPred *provenance.TagProvenancePred) error {
+	if tagPolicy.GetRequireSigned() {
+		if !tagProvPred.GetAnnotated() {
+			return fmt.Errorf("policy requires signed annotated tags, but tag %q is not annotated", tagProvPred.GetTag())
+		}
+		if !tagProvPred.GetSignatureVerified() {
+			return fmt.Errorf("policy requires signed annotated tags, but the signature of tag %q is not verified", tagProvPred.GetTag())
+		}
+	}
+
+	if minLevel := slsa.SlsaSourceLevel(tagPolicy.GetMinSourceLevel()); minLevel != "" {
+		if !slsa.IsSlsaSourceLevel(slsa.ControlName(minLevel)) {
+			return fmt.Errorf("policy specifies an invalid min_source_level %q", minLevel)
+		}
+		if level := inheritedSourceLevel(tagProvPred); !slsa.IsLevelHigherOrEqualTo(level, minLevel) {
+			return fmt.Errorf("policy requires tagged commits at %s or above, but tag %q points to a commit at %s", minLevel, tagProvPred.GetTag(), level)
+		}
+	}
+
+	if branches := tagPolicy.GetRequiredBranches(); len(branches) > 0 && !verifiedOnBranch(tagProvPred, branches) {
+		return fmt.Errorf(
+			"policy requires tagged commits to be verified on %s, but tag %q points to a commit that was not",
+			strings.Join(branches, ", "), tagProvPred.GetTag(),
+		)
+	}
+	return nil
+}
+
+// inheritedSourceLevel returns the highest SLSA source level in the VSAs of
+// the tagged commit.
+func inheritedSourceLevel(tagProvPred *provenance.TagProvenancePred) slsa.SlsaSourceLevel {
+	highest := slsa.SlsaSourceLevel0
+	for _, summary := range tagProvPred.GetVsaSummaries() {
+		for _, level := range summary.GetVerifiedLevels() {
+			if slsa.IsSlsaSourceLevel(slsa.ControlName(level)) &&
+				slsa.IsLevelHigherOrEqualTo(slsa.SlsaSourceLevel(level), highest) {
+				highest = slsa.SlsaSourceLevel(level)
+			}
+		}
+	}
+	return highest
+}
+
+// verifiedOnBranch returns true if any of the VSAs of the tagged commit was
+// issued for one of the branches (or branch patterns).
+func verifiedOnBranch(tagProvPred *provenance.TagProvenancePred, branches []string) bool {
+	for _, summary := range tagProvPred.GetVsaSummaries() {
+		for _, ref := range summary.GetSourceRefs() {
+			if !strings.HasPrefix(ref, "refs/heads/") {
+				continue
+			}
+			for _, b := range branches {
+				if b == strings.TrimPrefix(ref, "refs/heads/") || MatchBranchPattern(b, ref) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
 // PolicyEvaluator creates a new policy evaluator
 type PolicyEvaluator struct {
 	// UNSAFE!
 	// Instead of grabbing the policy from the canonical repo, use the policy at this path instead.
 	UseLocalPolicy string
 
+	// At selects the version of the policy used in evaluations. The zero
+	// value uses the latest policy.
+	At PolicyPoint
+
+	// Source is where policies are read from. When nil, policies are read
+	// from the SLSA community policy repository.
+	Source PolicySource
+
 	authenticator *auth.Authenticator
 	reader        models.AttestationStorageReader
 	client        *github.Client
+
+	communityOnce sync.Once
+	community     *GitHubRepoSource
+}
+
+// IsSourceURI returns true when a policy URI, as recorded in a VSA, was read
+// from the source the evaluator reads policies from. Only then the policy
+// version recorded with it can be looked up in the source.
+func (pe *PolicyEvaluator) IsSourceURI(repo *models.Repository, uri string) bool {
+	uri, _, _ = strings.Cut(uri, "#")
+	return sourceHasURI(pe.policySource(), repo, uri)
+}
+
+// policySource returns the source to read policies from. UseLocalPolicy
+// overrides any configured source.
+func (pe *PolicyEvaluator) policySource() PolicySource {
+	if pe.UseLocalPolicy != "" {
+		return &FilesystemSource{Path: pe.UseLocalPolicy}
+	}
+	if pe.Source != nil {
+		return pe.Source
+	}
+	return pe.communitySource()
+}
+
+// communitySource returns the source reading from the SLSA community policy
+// repository.
+func (pe *PolicyEvaluator) communitySource() *GitHubRepoSource {
+	pe.communityOnce.Do(func() {
+		pe.community = NewGitHubRepoSource(SourcePolicyRepoOwner, SourcePolicyRepo, pe.getGitHubClient)
+	})
+	return pe.community
 }
 
 func NewPolicyEvaluator() *PolicyEvaluator {
@@ -631,7 +816,7 @@ func NewPolicyEvaluator() *PolicyEvaluator {
 func (pe *PolicyEvaluator) EvaluateControl(ctx context.Context, repo *models.Repository, branch *models.Branch, controlStatus *slsa.ControlSet) (*EvaluationResult, error) {
 	// We want to ensure the repo hasn't enabled/disabled the rules since
 	// setting the 'since' field in their policy.
-	rp, policyPath, err := pe.GetPolicy(ctx, repo)
+	rp, policyPath, version, err := pe.GetPolicyAt(ctx, repo, pe.At)
 	if err != nil {
 		return nil, err
 	}
@@ -640,6 +825,9 @@ func (pe *PolicyEvaluator) EvaluateControl(ctx context.Context, repo *models.Rep
 	if branchPolicy == nil {
 		branchPolicy = createDefaultBranchPolicy(branch)
 		policyPath = DefaultPolicyPath
+		version = nil
+	} else {
+		policyPath = entryPolicyPath(policyPath, BranchPatternFragment, branchPolicy.GetName(), strings.TrimPrefix(branch.Name, "refs/heads/"))
 	}
 
 	if controlStatus.Time.Before(branchPolicy.GetSince().AsTime()) {
@@ -647,6 +835,7 @@ func (pe *PolicyEvaluator) EvaluateControl(ctx context.Context, repo *models.Rep
 		return &EvaluationResult{
 			VerifiedLevels: slsa.SourceVerifiedLevels{slsa.ControlName(slsa.SlsaSourceLevel1)},
 			PolicyPath:     policyPath,
+			PolicyVersion:  version,
 		}, nil
 	}
 
@@ -655,10 +844,17 @@ func (pe *PolicyEvaluator) EvaluateControl(ctx context.Context, repo *models.Rep
 		return nil, fmt.Errorf("error evaluating policy %s: %w", policyPath, err)
 	}
 
+	ruleLevels, rules, err := evaluateRules(branchPolicy.GetRules(), controlsRuleInput(branch.Name, controlStatus))
+	if err != nil {
+		return nil, fmt.Errorf("error evaluating policy %s: %w", policyPath, err)
+	}
+
 	return &EvaluationResult{
-		VerifiedLevels: verifiedLevels,
+		VerifiedLevels: append(verifiedLevels, ruleLevels...),
 		PolicyPath:     policyPath,
+		PolicyVersion:  version,
 		Shortfall:      shortfall,
+		Rules:          rules,
 	}, nil
 }
 
@@ -666,20 +862,68 @@ func (pe *PolicyEvaluator) EvaluateControl(ctx context.Context, repo *models.Rep
 // resulting source level, policy path and any shortfall if we miss the the
 // policy's target.
 func (pe *PolicyEvaluator) EvaluateSourceProv(ctx context.Context, repo *models.Repository, branch *models.Branch, prov *spb.Statement) (*EvaluationResult, error) {
-	rp, policyPath, err := pe.GetPolicy(ctx, repo)
+	provPred, err := attest.GetSourceProvPred(prov)
+	if err != nil {
+		return nil, err
+	}
+
+	return pe.EvaluateSourceProvPred(ctx, repo, branch, provPred)
+}
+
+// EvaluateSourceProvPred evaluates a source provenance predicate, such as
+// one read from the attestation store, against the policy.
+func (pe *PolicyEvaluator) EvaluateSourceProvPred(
+	ctx context.Context, repo *models.Repository, branch *models.Branch, provPred *provenance.SourceProvenancePred,
+) (*EvaluationResult, error) {
+	rp, policyPath, version, err := pe.GetPolicyAt(ctx, repo, pe.At)
 	if err != nil {
 		return nil, fmt.Errorf("getting policy: %w", err)
 	}
 
-	provPred, err := attest.GetSourceProvPred(prov)
+	return evaluateSourceProvPred(rp, rp.GetBranchPolicy(branch.Name), policyPath, version, branch, provPred)
+}
+
+// ReevaluateSourceProv re-runs the evaluation of provenance issued in the
+// past, as done when auditing the levels claimed in its VSA. The policy is
+// read at the point passed or, if it's the zero value, as it was when the
+// provenance was created. A branch policy only applies to provenance created
+// after its since date: older provenance is evaluated against the default
+// policy, which was the one in effect when it was issued.
+func (pe *PolicyEvaluator) ReevaluateSourceProv(
+	ctx context.Context, repo *models.Repository, branch *models.Branch, provPred *provenance.SourceProvenancePred, at PolicyPoint,
+) (*EvaluationResult, error) {
+	if at.IsLatest() && provPred.GetCreatedOn() != nil {
+		createdOn := provPred.GetCreatedOn().AsTime()
+		at.Time = &createdOn
+	}
+
+	rp, policyPath, version, err := pe.GetPolicyAt(ctx, repo, at)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("getting policy: %w", err)
 	}
 
 	branchPolicy := rp.GetBranchPolicy(branch.Name)
+	if branchPolicy != nil && provPred.GetCreatedOn() != nil &&
+		provPred.GetCreatedOn().AsTime().Before(branchPolicy.GetSince().AsTime()) {
+		branchPolicy = nil
+	}
+
+	return evaluateSourceProvPred(rp, branchPolicy, policyPath, version, branch, provPred)
+}
+
+// evaluateSourceProvPred evaluates the controls recorded in a provenance
+// predicate against a branch policy. If the branch policy is nil, the
+// default policy is used.
+func evaluateSourceProvPred(
+	rp *RepoPolicy, branchPolicy *ProtectedBranch, policyPath string, version *PolicyVersion,
+	branch *models.Branch, provPred *provenance.SourceProvenancePred,
+) (*EvaluationResult, error) {
 	if branchPolicy == nil {
 		branchPolicy = createDefaultBranchPolicy(branch)
 		policyPath = DefaultPolicyPath
+		version = nil
+	} else {
+		policyPath = entryPolicyPath(policyPath, BranchPatternFragment, branchPolicy.GetName(), strings.TrimPrefix(branch.Name, "refs/heads/"))
 	}
 
 	verifiedLevels, shortfall, err := evaluateBranchControls(branchPolicy, rp.GetProtectedTag(), slsa.NewControlSetFromProvanenaceControls(provPred.GetControls()))
@@ -687,17 +931,24 @@ func (pe *PolicyEvaluator) EvaluateSourceProv(ctx context.Context, repo *models.
 		return nil, fmt.Errorf("error evaluating policy %s: %w", policyPath, err)
 	}
 
+	ruleLevels, rules, err := evaluateRules(branchPolicy.GetRules(), sourceProvRuleInput(branch.Name, provPred))
+	if err != nil {
+		return nil, fmt.Errorf("error evaluating policy %s: %w", policyPath, err)
+	}
+
 	// Looks good!
 	return &EvaluationResult{
-		VerifiedLevels: verifiedLevels,
+		VerifiedLevels: append(verifiedLevels, ruleLevels...),
 		PolicyPath:     policyPath,
+		PolicyVersion:  version,
 		Shortfall:      shortfall,
+		Rules:          rules,
 	}, nil
 }
 
 // Evaluates the provenance against the policy and returns the resulting source level and policy path
 func (pe *PolicyEvaluator) EvaluateTagProv(ctx context.Context, repo *models.Repository, prov *spb.Statement) (*EvaluationResult, error) {
-	rp, policyPath, err := pe.GetPolicy(ctx, repo)
+	rp, policyPath, version, err := pe.GetPolicyAt(ctx, repo, pe.At)
 	if err != nil {
 		return nil, err
 	}
@@ -707,14 +958,24 @@ func (pe *PolicyEvaluator) EvaluateTagProv(ctx context.Context, repo *models.Rep
 		return nil, err
 	}
 
-	outputVerifiedLevels, err := evaluateTagProv(rp.GetProtectedTag(), provPred)
+	tagPolicy := rp.GetTagPolicy(provPred.GetTag())
+	policyPath = entryPolicyPath(policyPath, TagPatternFragment, tagPolicy.GetName(), strings.TrimPrefix(provPred.GetTag(), "refs/tags/"))
+
+	outputVerifiedLevels, err := evaluateTagProv(tagPolicy, provPred)
+	if err != nil {
+		return nil, fmt.Errorf("error evaluating policy %s: %w", policyPath, err)
+	}
+
+	ruleLevels, rules, err := evaluateRules(tagPolicy.GetRules(), tagProvRuleInput(provPred))
 	if err != nil {
 		return nil, fmt.Errorf("error evaluating policy %s: %w", policyPath, err)
 	}
 
 	// Looks good! Tag evaluation has no SLSA-level shortfall concept.
 	return &EvaluationResult{
-		VerifiedLevels: outputVerifiedLevels,
+		VerifiedLevels: append(outputVerifiedLevels, ruleLevels...),
 		PolicyPath:     policyPath,
+		PolicyVersion:  version,
+		Rules:          rules,
 	}, nil
 }
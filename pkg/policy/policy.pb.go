@@ -30,9 +30,14 @@ type RepoPolicy struct {
 	state             protoimpl.MessageState `protogen:"open.v1"`
 	CanonicalRepo     string                 `protobuf:"bytes,1,opt,name=canonical_repo,proto3" json:"canonical_repo,omitempty"`
 	ProtectedBranches []*ProtectedBranch     `protobuf:"bytes,2,rep,name=protected_branches,proto3" json:"protected_branches,omitempty"`
-	ProtectedTag      *ProtectedTag          `protobuf:"bytes,3,opt,name=protected_tag,json=protectedTag,proto3,oneof" json:"protected_tag,omitempty"`
-	unknownFields     protoimpl.UnknownFields
-	sizeCache         protoimpl.SizeCache
+	// The policy for all tags. Also required to record tag hygiene in the
+	// VSAs of the branches.
+	ProtectedTag *ProtectedTag `protobuf:"bytes,3,opt,name=protected_tag,json=protectedTag,proto3,oneof" json:"protected_tag,omitempty"`
+	// Policies for the tags matching a name or pattern. Tags not matching any
+	// of them fall back to protected_tag.
+	ProtectedTags []*ProtectedTag `protobuf:"bytes,4,rep,name=protected_tags,proto3" json:"protected_tags,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
 func (x *RepoPolicy) Reset() {
@@ -86,6 +91,13 @@ func (x *RepoPolicy) GetProtectedTag() *ProtectedTag {
 	return nil
 }
 
+func (x *RepoPolicy) GetProtectedTags() []*ProtectedTag {
+	if x != nil {
+		return x.ProtectedTags
+	}
+	return nil
+}
+
 // When a branch requires multiple controls, they must all be enabled
 // at or before 'since'.
 type ProtectedBranch struct {
@@ -96,8 +108,10 @@ type ProtectedBranch struct {
 	TargetSlsaSourceLevel  string                   `protobuf:"bytes,3,opt,name=target_slsa_source_level,json=targetSlsaSourceLevel,proto3" json:"target_slsa_source_level,omitempty"`
 	RequireReview          bool                     `protobuf:"varint,4,opt,name=require_review,json=requireReview,proto3" json:"require_review,omitempty"`
 	OrgStatusCheckControls []*OrgStatusCheckControl `protobuf:"bytes,5,rep,name=org_status_check_controls,proto3" json:"org_status_check_controls,omitempty"`
-	unknownFields          protoimpl.UnknownFields
-	sizeCache              protoimpl.SizeCache
+	// Custom rules evaluated on the revisions of the branch.
+	Rules         []*PolicyRule `protobuf:"bytes,6,rep,name=rules,proto3" json:"rules,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
 func (x *ProtectedBranch) Reset() {
@@ -165,11 +179,31 @@ func (x *ProtectedBranch) GetOrgStatusCheckControls() []*OrgStatusCheckControl {
 	return nil
 }
 
+func (x *ProtectedBranch) GetRules() []*PolicyRule {
+	if x != nil {
+		return x.Rules
+	}
+	return nil
+}
+
 // The controls required for protected tags.
 type ProtectedTag struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Since         *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=since,proto3" json:"since,omitempty"`
-	TagHygiene    bool                   `protobuf:"varint,2,opt,name=tag_hygiene,json=tagHygiene,proto3" json:"tag_hygiene,omitempty"`
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Since      *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=since,proto3" json:"since,omitempty"`
+	TagHygiene bool                   `protobuf:"varint,2,opt,name=tag_hygiene,json=tagHygiene,proto3" json:"tag_hygiene,omitempty"`
+	// Custom rules evaluated on the tags.
+	Rules []*PolicyRule `protobuf:"bytes,3,rep,name=rules,proto3" json:"rules,omitempty"`
+	// The tag name or glob pattern the entry applies to. Only used in
+	// protected_tags.
+	Name string `protobuf:"bytes,4,opt,name=name,proto3" json:"name,omitempty"`
+	// The minimum SLSA source level the tagged commit must have been
+	// verified at.
+	MinSourceLevel string `protobuf:"bytes,5,opt,name=min_source_level,proto3" json:"min_source_level,omitempty"`
+	// The tagged commit must have been verified on one of these branches.
+	// Entries can be glob patterns.
+	RequiredBranches []string `protobuf:"bytes,6,rep,name=required_branches,proto3" json:"required_branches,omitempty"`
+	// Tags must be annotated and carry a signature verified by the VCS host.
+	RequireSigned bool `protobuf:"varint,7,opt,name=require_signed,proto3" json:"require_signed,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -218,6 +252,41 @@ func (x *ProtectedTag) GetTagHygiene() bool {
 	return false
 }
 
+func (x *ProtectedTag) GetRules() []*PolicyRule {
+	if x != nil {
+		return x.Rules
+	}
+	return nil
+}
+
+func (x *ProtectedTag) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ProtectedTag) GetMinSourceLevel() string {
+	if x != nil {
+		return x.MinSourceLevel
+	}
+	return ""
+}
+
+func (x *ProtectedTag) GetRequiredBranches() []string {
+	if x != nil {
+		return x.RequiredBranches
+	}
+	return nil
+}
+
+func (x *ProtectedTag) GetRequireSigned() bool {
+	if x != nil {
+		return x.RequireSigned
+	}
+	return false
+}
+
 // Used by orgs to require that specific 'checks' are run on protected
 // branches and to associate those checks with a control name to include
 // in provenance and VSAs.
@@ -288,32 +357,125 @@ func (x *OrgStatusCheckControl) GetCheckName() string {
 	return ""
 }
 
+// A named rule written as a CEL expression that must evaluate to a boolean.
+// Rules are evaluated over the controls, the commit data and, when
+// available, the provenance predicate of the revision. Their results are
+// recorded in the evaluation result.
+type PolicyRule struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The name of the rule, it identifies the rule in the results.
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// The CEL expression to evaluate.
+	Expression string `protobuf:"bytes,2,opt,name=expression,proto3" json:"expression,omitempty"`
+	// The property to record in the VSA if the rule passes.
+	// MUST start with `ORG_SOURCE_`. Optional.
+	PropertyName string `protobuf:"bytes,3,opt,name=property_name,json=propertyName,proto3" json:"property_name,omitempty"`
+	// When true, a failing rule fails the evaluation. Otherwise it is only
+	// reported in the results.
+	Required      bool `protobuf:"varint,4,opt,name=required,proto3" json:"required,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PolicyRule) Reset() {
+	*x = PolicyRule{}
+	mi := &file_policy_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PolicyRule) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PolicyRule) ProtoMessage() {}
+
+func (x *PolicyRule) ProtoReflect() protoreflect.Message {
+	mi := &file_policy_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PolicyRule.ProtoReflect.Descriptor instead.
+func (*PolicyRule) Descriptor() ([]byte, []int) {
+	return file_policy_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *PolicyRule) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *PolicyRule) GetExpression() string {
+	if x != nil {
+		return x.Expression
+	}
+	return ""
+}
+
+func (x *PolicyRule) GetPropertyName() string {
+	if x != nil {
+		return x.PropertyName
+	}
+	return ""
+}
+
+func (x *PolicyRule) GetRequired() bool {
+	if x != nil {
+		return x.Required
+	}
+	return false
+}
+
 var File_policy_proto protoreflect.FileDescriptor
 
 const file_policy_proto_rawDesc = "" +
 	"\n" +
-	"\fpolicy.proto\x123in_toto_attestation.predicates.source_provenance.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\xa9\x02\n" +
+	"\fpolicy.proto\x123in_toto_attestation.predicates.source_provenance.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\x94\x03\n" +
 	"\n" +
 	"RepoPolicy\x12&\n" +
 	"\x0ecanonical_repo\x18\x01 \x01(\tR\x0ecanonical_repo\x12t\n" +
 	"\x12protected_branches\x18\x02 \x03(\v2D.in_toto_attestation.predicates.source_provenance.v1.ProtectedBranchR\x12protected_branches\x12k\n" +
-	"\rprotected_tag\x18\x03 \x01(\v2A.in_toto_attestation.predicates.source_provenance.v1.ProtectedTagH\x00R\fprotectedTag\x88\x01\x01B\x10\n" +
-	"\x0e_protected_tag\"\xc2\x02\n" +
+	"\rprotected_tag\x18\x03 \x01(\v2A.in_toto_attestation.predicates.source_provenance.v1.ProtectedTagH\x00R\fprotectedTag\x88\x01\x01\x12i\n" +
+	"\x0eprotected_tags\x18\x04 \x03(\v2A.in_toto_attestation.predicates.source_provenance.v1.ProtectedTagR\x0eprotected_tagsB\x10\n" +
+	"\x0e_protected_tag\"\x99\x03\n" +
 	"\x0fProtectedBranch\x12\x12\n" +
 	"\x04name\x18\x01 \x01(\tR\x04name\x120\n" +
 	"\x05since\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x05since\x127\n" +
 	"\x18target_slsa_source_level\x18\x03 \x01(\tR\x15targetSlsaSourceLevel\x12%\n" +
 	"\x0erequire_review\x18\x04 \x01(\bR\rrequireReview\x12\x88\x01\n" +
-	"\x19org_status_check_controls\x18\x05 \x03(\v2J.in_toto_attestation.predicates.source_provenance.v1.OrgStatusCheckControlR\x19org_status_check_controls\"a\n" +
+	"\x19org_status_check_controls\x18\x05 \x03(\v2J.in_toto_attestation.predicates.source_provenance.v1.OrgStatusCheckControlR\x19org_status_check_controls\x12U\n" +
+	"\x05rules\x18\x06 \x03(\v2?.in_toto_attestation.predicates.source_provenance.v1.PolicyRuleR\x05rules\"\xce\x02\n" +
 	"\fProtectedTag\x120\n" +
 	"\x05since\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x05since\x12\x1f\n" +
 	"\vtag_hygiene\x18\x02 \x01(\bR\n" +
-	"tagHygiene\"\x8d\x01\n" +
+	"tagHygiene\x12U\n" +
+	"\x05rules\x18\x03 \x03(\v2?.in_toto_attestation.predicates.source_provenance.v1.PolicyRuleR\x05rules\x12\x12\n" +
+	"\x04name\x18\x04 \x01(\tR\x04name\x12*\n" +
+	"\x10min_source_level\x18\x05 \x01(\tR\x10min_source_level\x12,\n" +
+	"\x11required_branches\x18\x06 \x03(\tR\x11required_branches\x12&\n" +
+	"\x0erequire_signed\x18\a \x01(\bR\x0erequire_signed\"\x8d\x01\n" +
 	"\x15OrgStatusCheckControl\x12#\n" +
 	"\rproperty_name\x18\x01 \x01(\tR\fpropertyName\x120\n" +
 	"\x05since\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x05since\x12\x1d\n" +
 	"\n" +
-	"check_name\x18\x03 \x01(\tR\tcheckNameB\xdb\x02\n" +
+	"check_name\x18\x03 \x01(\tR\tcheckName\"\x81\x01\n" +
+	"\n" +
+	"PolicyRule\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1e\n" +
+	"\n" +
+	"expression\x18\x02 \x01(\tR\n" +
+	"expression\x12#\n" +
+	"\rproperty_name\x18\x03 \x01(\tR\fpropertyName\x12\x1a\n" +
+	"\brequired\x18\x04 \x01(\bR\brequiredB\xdb\x02\n" +
 	"7com.in_toto_attestation.predicates.source_provenance.v1B\vPolicyProtoP\x01Z0github.com/slsa-framework/source-tool/pkg/policy\xa2\x02\x03IPS\xaa\x020InTotoAttestation.Predicates.SourceProvenance.V1\xca\x020InTotoAttestation\\Predicates\\SourceProvenance\\V1\xe2\x02<InTotoAttestation\\Predicates\\SourceProvenance\\V1\\GPBMetadata\xea\x023InTotoAttestation::Predicates::SourceProvenance::V1b\x06proto3"
 
 var (
@@ -328,26 +490,30 @@ func file_policy_proto_rawDescGZIP() []byte {
 	return file_policy_proto_rawDescData
 }
 
-var file_policy_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_policy_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
 var file_policy_proto_goTypes = []any{
 	(*RepoPolicy)(nil),            // 0: in_toto_attestation.predicates.source_provenance.v1.RepoPolicy
 	(*ProtectedBranch)(nil),       // 1: in_toto_attestation.predicates.source_provenance.v1.ProtectedBranch
 	(*ProtectedTag)(nil),          // 2: in_toto_attestation.predicates.source_provenance.v1.ProtectedTag
 	(*OrgStatusCheckControl)(nil), // 3: in_toto_attestation.predicates.source_provenance.v1.OrgStatusCheckControl
-	(*timestamppb.Timestamp)(nil), // 4: google.protobuf.Timestamp
+	(*PolicyRule)(nil),            // 4: in_toto_attestation.predicates.source_provenance.v1.PolicyRule
+	(*timestamppb.Timestamp)(nil), // 5: google.protobuf.Timestamp
 }
 var file_policy_proto_depIdxs = []int32{
 	1, // 0: in_toto_attestation.predicates.source_provenance.v1.RepoPolicy.protected_branches:type_name -> in_toto_attestation.predicates.source_provenance.v1.ProtectedBranch
 	2, // 1: in_toto_attestation.predicates.source_provenance.v1.RepoPolicy.protected_tag:type_name -> in_toto_attestation.predicates.source_provenance.v1.ProtectedTag
-	4, // 2: in_toto_attestation.predicates.source_provenance.v1.ProtectedBranch.since:type_name -> google.protobuf.Timestamp
-	3, // 3: in_toto_attestation.predicates.source_provenance.v1.ProtectedBranch.org_status_check_controls:type_name -> in_toto_attestation.predicates.source_provenance.v1.OrgStatusCheckControl
-	4, // 4: in_toto_attestation.predicates.source_provenance.v1.ProtectedTag.since:type_name -> google.protobuf.Timestamp
-	4, // 5: in_toto_attestation.predicates.source_provenance.v1.OrgStatusCheckControl.since:type_name -> google.protobuf.Timestamp
-	6, // [6:6] is the sub-list for method output_type
-	6, // [6:6] is the sub-list for method input_type
-	6, // [6:6] is the sub-list for extension type_name
-	6, // [6:6] is the sub-list for extension extendee
-	0, // [0:6] is the sub-list for field type_name
+	2, // 2: in_toto_attestation.predicates.source_provenance.v1.RepoPolicy.protected_tags:type_name -> in_toto_attestation.predicates.source_provenance.v1.ProtectedTag
+	5, // 3: in_toto_attestation.predicates.source_provenance.v1.ProtectedBranch.since:type_name -> google.protobuf.Timestamp
+	3, // 4: in_toto_attestation.predicates.source_provenance.v1.ProtectedBranch.org_status_check_controls:type_name -> in_toto_attestation.predicates.source_provenance.v1.OrgStatusCheckControl
+	4, // 5: in_toto_attestation.predicates.source_provenance.v1.ProtectedBranch.rules:type_name -> in_toto_attestation.predicates.source_provenance.v1.PolicyRule
+	5, // 6: in_toto_attestation.predicates.source_provenance.v1.ProtectedTag.since:type_name -> google.protobuf.Timestamp
+	4, // 7: in_toto_attestation.predicates.source_provenance.v1.ProtectedTag.rules:type_name -> in_toto_attestation.predicates.source_provenance.v1.PolicyRule
+	5, // 8: in_toto_attestation.predicates.source_provenance.v1.OrgStatusCheckControl.since:type_name -> google.protobuf.Timestamp
+	9, // [9:9] is the sub-list for method output_type
+	9, // [9:9] is the sub-list for method input_type
+	9, // [9:9] is the sub-list for extension type_name
+	9, // [9:9] is the sub-list for extension extendee
+	0, // [0:9] is the sub-list for field type_name
 }
 
 func init() { file_policy_proto_init() }
@@ -362,7 +528,7 @@ func file_policy_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_policy_proto_rawDesc), len(file_policy_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   4,
+			NumMessages:   5,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
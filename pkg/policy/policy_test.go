@@ -7,6 +7,7 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -17,6 +18,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/go-git/go-git/v5"
 	"github.com/google/go-github/v88/github" // Use v88
 	spb "github.com/in-toto/attestation/go/v1"
 	"github.com/stretchr/testify/require"
@@ -35,6 +37,9 @@ const (
 	testRepo       = "test-repo"
 	testOwner      = "test-owner"
 	mockPolicyPath = "https://github.example.com/policy.json"
+	// mockPolicyCommit is the commit of the policy repository served by the
+	// mocked policy history.
+	mockPolicyCommit = "0123456789abcdef0123456789abcdef01234567"
 )
 
 var (
@@ -382,6 +387,45 @@ func TestEvaluateSourceProv_Shortfall(t *testing.T) {
 	}
 }
 
+// TestReevaluateSourceProv verifies that provenance created before the branch
+// policy took effect is evaluated against the default policy.
+func TestReevaluateSourceProv(t *testing.T) {
+	policyL3 := RepoPolicy{
+		ProtectedBranches: []*ProtectedBranch{
+			{Name: "main", TargetSlsaSourceLevel: string(slsa.SlsaSourceLevel3), Since: timestamppb.New(fixedTime)},
+		},
+	}
+	policyFilePath := createTempPolicyFile(t, &policyL3)
+	pe := &PolicyEvaluator{UseLocalPolicy: policyFilePath}
+
+	tests := []struct {
+		name         string
+		createdOn    time.Time
+		expectedPath string
+	}{
+		{name: "before-policy", createdOn: earlierFixedTime, expectedPath: DefaultPolicyPath},
+		{name: "after-policy", createdOn: fixedTime.Add(time.Hour), expectedPath: policyFilePath},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provPred := &provenance.SourceProvenancePred{
+				CreatedOn: timestamppb.New(tt.createdOn),
+				Controls:  controlsForLevel(slsa.SlsaSourceLevel3, &earlierFixedTime).ToProvenanceControls(),
+			}
+			result, err := pe.ReevaluateSourceProv(t.Context(), &models.Repository{
+				Hostname: "github.com",
+				Path:     "local/local",
+			}, &models.Branch{Name: "main"}, provPred, PolicyPoint{})
+			if err != nil {
+				t.Fatalf("ReevaluateSourceProv() error = %v, want nil", err)
+			}
+			if result.PolicyPath != tt.expectedPath {
+				t.Errorf("ReevaluateSourceProv() policyPath = %q, want %q", result.PolicyPath, tt.expectedPath)
+			}
+		})
+	}
+}
+
 func createVsaSummary(ref string, verifiedLevels []slsa.ControlName) *provenance.VsaSummary {
 	lvls := make([]string, 0, len(verifiedLevels))
 	for _, l := range verifiedLevels {
@@ -700,7 +744,17 @@ func TestEvaluateControl_Failure(t *testing.T) {
 func setupMockGitHubTestEnv(t *testing.T, targetOwner, targetRepo, targetBranch string, handler http.HandlerFunc) (*ghcontrol.GitHubConnection, *httptest.Server) {
 	t.Helper()
 
-	server := httptest.NewServer(handler)
+	// The policy history lists a single commit, all other requests go to
+	// the test handler.
+	mux := http.NewServeMux()
+	mux.HandleFunc(fmt.Sprintf("/api/v3/repos/%s/%s/commits", sourcePolicyRepoOwner, sourcePolicyRepo), func(w http.ResponseWriter, r *http.Request) {
+		if _, err := w.Write([]byte(`[{"sha": "` + mockPolicyCommit + `", "commit": {"committer": {"date": "2024-01-01T00:00:00Z"}}}]`)); err != nil {
+			t.Fatalf("writing data: %v", err)
+		}
+	})
+	mux.Handle("/", handler)
+
+	server := httptest.NewServer(mux)
 
 	httpClient := server.Client()
 	baseURL := server.URL + "/"
@@ -1224,6 +1278,7 @@ func TestComputeAchievableSlsaLevel(t *testing.T) {
 		controls        *slsa.ControlSet
 		expectedLevel   slsa.SlsaSourceLevel
 		expectShortfall bool
+		expectReason    string
 	}{
 		{
 			name:          "Controls L4-eligible (since 'earlier'), Policy L4 (since 'now'): target met",
@@ -1288,6 +1343,22 @@ func TestComputeAchievableSlsaLevel(t *testing.T) {
 			expectedLevel:   slsa.SlsaSourceLevel1,
 			expectShortfall: true,
 		},
+		{
+			name:         "Controls L3-eligible with a gap before the policy since: target met",
+			branchPolicy: &policyL3Now,
+			controls: controlsForLevelWithGap(slsa.SlsaSourceLevel3, rearlier.Add(-time.Hour), slsa.SLSA_SOURCE_SCS_CONTINUITY,
+				rearlier.Add(-30*time.Minute), rearlier),
+			expectedLevel: slsa.SlsaSourceLevel3,
+		},
+		{
+			name:         "Controls L3-eligible with a continuity gap after the policy since: downgrade to L1, reports gap",
+			branchPolicy: &ProtectedBranch{TargetSlsaSourceLevel: string(slsa.SlsaSourceLevel3), Since: timestamppb.New(rearlier)},
+			controls: controlsForLevelWithGap(slsa.SlsaSourceLevel3, rearlier.Add(-time.Hour), slsa.SLSA_SOURCE_SCS_CONTINUITY,
+				rearlier.Add(time.Minute), rearlier.Add(2*time.Minute)),
+			expectedLevel:   slsa.SlsaSourceLevel1,
+			expectShortfall: true,
+			expectReason:    "not enforced",
+		},
 	}
 
 	for _, tt := range tests {
@@ -1314,6 +1385,9 @@ func TestComputeAchievableSlsaLevel(t *testing.T) {
 				if shortfall.Reason == "" {
 					t.Errorf("computeAchievableSlsaLevel() shortfall.Reason is empty, want non-empty")
 				}
+				if !strings.Contains(shortfall.Reason, tt.expectReason) {
+					t.Errorf("computeAchievableSlsaLevel() shortfall.Reason = %q, want it to contain %q", shortfall.Reason, tt.expectReason)
+				}
 			}
 		})
 	}
@@ -1331,6 +1405,21 @@ func controlsForLevelWithOverride(level slsa.SlsaSourceLevel, baseSince *time.Ti
 	return controls
 }
 
+// controlsForLevelWithGap creates controls for a level enforced since a time
+// where one of the controls was disabled between gapStart and gapEnd.
+func controlsForLevelWithGap(level slsa.SlsaSourceLevel, since time.Time, name slsa.ControlName, gapStart, gapEnd time.Time) *slsa.ControlSet {
+	controls := controlsForLevel(level, &since)
+	for i, c := range controls.Controls {
+		if c.GetName() == name {
+			controls.Controls[i] = &slsa.Control{
+				Name: name, Since: &gapEnd, State: slsa.StateActive,
+				Intervals: slsa.Intervals{{Start: since, End: &gapStart}, {Start: gapEnd}},
+			}
+		}
+	}
+	return controls
+}
+
 func TestComputeEligibleSince(t *testing.T) {
 	time1 := time.Now()
 	time2 := time.Now().Add(time.Hour)
@@ -1434,6 +1523,22 @@ func TestComputeEligibleSince(t *testing.T) {
 			expectedTime: &time1,
 			expectError:  false,
 		},
+		{
+			name: "L3 eligible, continuity interrupted: expect end of the gap",
+			controls: controlsForLevelWithGap(slsa.SlsaSourceLevel3, time1, slsa.SLSA_SOURCE_SCS_CONTINUITY,
+				time1.Add(time.Minute), time2),
+			level:        slsa.SlsaSourceLevel3,
+			expectedTime: &time2,
+		},
+		{
+			name: "L3 controls, continuity window closed: expect nil",
+			controls: controlsForLevelWith(slsa.SlsaSourceLevel2, &time1, &slsa.Control{
+				Name: slsa.SLSA_SOURCE_SCS_PROVENANCE, Since: &time1, State: slsa.StateActive,
+				Intervals: slsa.Intervals{{Start: time1, End: &time2}},
+			}),
+			level:        slsa.SlsaSourceLevel3,
+			expectedTime: nil,
+		},
 		{
 			name:         "L3 eligible (AllZero), L3 requested: expect ZeroTime",
 			controls:     controlsForLevel(slsa.SlsaSourceLevel3, &zeroTime),
@@ -1850,3 +1955,249 @@ func TestGetPolicy_Remote_MalformedJSON(t *testing.T) {
 		})
 	}
 }
+
+func TestGetBranchPolicyPatterns(t *testing.T) {
+	t.Parallel()
+	rp := &RepoPolicy{
+		ProtectedBranches: []*ProtectedBranch{
+			{Name: "release/*"},
+			{Name: "release/1.*"},
+			{Name: "release/1.0"},
+			{Name: "v?/*"},
+			{Name: "v1/*"},
+			{Name: "[bad"},
+		},
+	}
+	for _, tc := range []struct {
+		branch   string
+		expected string
+	}{
+		{branch: "release/1.0", expected: "release/1.0"},
+		{branch: "refs/heads/release/1.0", expected: "release/1.0"},
+		{branch: "release/1.2", expected: "release/1.*"},
+		{branch: "release/2.0", expected: "release/*"},
+		{branch: "v1/main", expected: "v1/*"},
+		{branch: "v2/main", expected: "v?/*"},
+		{branch: "release/1.0/hotfix", expected: ""},
+		{branch: "main", expected: ""},
+		{branch: "[bad]", expected: ""},
+	} {
+		t.Run(tc.branch, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tc.expected, rp.GetBranchPolicy(tc.branch).GetName())
+		})
+	}
+}
+
+func TestEvaluateSourceProvBranchPattern(t *testing.T) {
+	t.Parallel()
+	rp := &RepoPolicy{
+		ProtectedBranches: []*ProtectedBranch{{
+			Name:                  "release/*",
+			Since:                 timestamppb.New(fixedTime),
+			TargetSlsaSourceLevel: string(slsa.SlsaSourceLevel1),
+		}},
+	}
+	pred := &provenance.SourceProvenancePred{CreatedOn: timestamppb.New(fixedTime)}
+
+	branch := &models.Branch{Name: "release/1.0"}
+	result, err := evaluateSourceProvPred(rp, rp.GetBranchPolicy(branch.Name), "path", nil, branch, pred)
+	require.NoError(t, err)
+	require.Equal(t, "path#"+BranchPatternFragment+"release%2F%2A", result.PolicyPath)
+
+	// Branches not matching the pattern use the default policy
+	branch = &models.Branch{Name: "main"}
+	result, err = evaluateSourceProvPred(rp, rp.GetBranchPolicy(branch.Name), "path", nil, branch, pred)
+	require.NoError(t, err)
+	require.Equal(t, DefaultPolicyPath, result.PolicyPath)
+}
+
+func TestGetTagPolicy(t *testing.T) {
+	t.Parallel()
+	all := &ProtectedTag{TagHygiene: true}
+	rp := &RepoPolicy{
+		ProtectedTag: all,
+		ProtectedTags: []*ProtectedTag{
+			{Name: "v*"},
+			{Name: "v1.*"},
+			{Name: "latest"},
+		},
+	}
+	for _, tc := range []struct {
+		tag      string
+		expected string
+		fallback bool
+	}{
+		{tag: "latest", expected: "latest"},
+		{tag: "refs/tags/latest", expected: "latest"},
+		{tag: "v1.2.0", expected: "v1.*"},
+		{tag: "v2.0.0", expected: "v*"},
+		{tag: "nightly", fallback: true},
+	} {
+		t.Run(tc.tag, func(t *testing.T) {
+			t.Parallel()
+			pt := rp.GetTagPolicy(tc.tag)
+			if tc.fallback {
+				require.Same(t, all, pt)
+				return
+			}
+			require.Equal(t, tc.expected, pt.GetName())
+		})
+	}
+	require.Nil(t, (&RepoPolicy{}).GetTagPolicy("v1.0.0"))
+}
+
+func TestCheckTagRequirements(t *testing.T) {
+	t.Parallel()
+	mainL3 := createVsaSummary("refs/heads/main", []slsa.ControlName{slsa.ControlName(slsa.SlsaSourceLevel3)})
+	featureL2 := createVsaSummary("refs/heads/feature/x", []slsa.ControlName{slsa.ControlName(slsa.SlsaSourceLevel2)})
+
+	for _, tc := range []struct {
+		name    string
+		policy  *ProtectedTag
+		pred    *provenance.TagProvenancePred
+		mustErr bool
+	}{
+		{name: "no-requirements", policy: &ProtectedTag{}, pred: &provenance.TagProvenancePred{}},
+		{name: "no-policy", pred: &provenance.TagProvenancePred{}},
+		{
+			name:   "signed",
+			policy: &ProtectedTag{RequireSigned: true},
+			pred:   &provenance.TagProvenancePred{Annotated: true, SignatureVerified: true},
+		},
+		{
+			name:    "lightweight",
+			policy:  &ProtectedTag{RequireSigned: true},
+			pred:    &provenance.TagProvenancePred{},
+			mustErr: true,
+		},
+		{
+			name:    "unverified-signature",
+			policy:  &ProtectedTag{RequireSigned: true},
+			pred:    &provenance.TagProvenancePred{Annotated: true},
+			mustErr: true,
+		},
+		{
+			name:   "min-level",
+			policy: &ProtectedTag{MinSourceLevel: string(slsa.SlsaSourceLevel3)},
+			pred:   &provenance.TagProvenancePred{VsaSummaries: []*provenance.VsaSummary{featureL2, mainL3}},
+		},
+		{
+			name:    "below-min-level",
+			policy:  &ProtectedTag{MinSourceLevel: string(slsa.SlsaSourceLevel3)},
+			pred:    &provenance.TagProvenancePred{VsaSummaries: []*provenance.VsaSummary{featureL2}},
+			mustErr: true,
+		},
+		{
+			name:    "no-vsa",
+			policy:  &ProtectedTag{MinSourceLevel: string(slsa.SlsaSourceLevel1)},
+			pred:    &provenance.TagProvenancePred{},
+			mustErr: true,
+		},
+		{
+			name:    "invalid-min-level",
+			policy:  &ProtectedTag{MinSourceLevel: "LEVEL_9000"},
+			pred:    &provenance.TagProvenancePred{VsaSummaries: []*provenance.VsaSummary{mainL3}},
+			mustErr: true,
+		},
+		{
+			name:   "required-branch",
+			policy: &ProtectedTag{RequiredBranches: []string{"main"}},
+			pred:   &provenance.TagProvenancePred{VsaSummaries: []*provenance.VsaSummary{featureL2, mainL3}},
+		},
+		{
+			name:   "required-branch-pattern",
+			policy: &ProtectedTag{RequiredBranches: []string{"release/*", "feature/*"}},
+			pred:   &provenance.TagProvenancePred{VsaSummaries: []*provenance.VsaSummary{featureL2}},
+		},
+		{
+			name:    "not-on-required-branch",
+			policy:  &ProtectedTag{RequiredBranches: []string{"main"}},
+			pred:    &provenance.TagProvenancePred{VsaSummaries: []*provenance.VsaSummary{featureL2}},
+			mustErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			err := checkTagRequirements(tc.policy, tc.pred)
+			if tc.mustErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestEvaluateTagProvPattern(t *testing.T) {
+	t.Parallel()
+	tagHygiene := &slsa.Control{Name: slsa.SLSA_SOURCE_SCS_PROTECTED_REFS, Since: &earlierFixedTime, State: slsa.StateActive}
+	controls := slsa.ControlSet{Controls: []*slsa.Control{tagHygiene}}
+	mainL3 := createVsaSummary("refs/heads/main", []slsa.ControlName{slsa.ControlName(slsa.SlsaSourceLevel3)})
+
+	rp := createTestPolicy(&ProtectedBranch{Name: "main", TargetSlsaSourceLevel: string(slsa.SlsaSourceLevel3), Since: timestamppb.New(fixedTime)})
+	rp.ProtectedTag = &ProtectedTag{Since: timestamppb.New(fixedTime), TagHygiene: true}
+	rp.ProtectedTags = []*ProtectedTag{{
+		Name:             "v*",
+		Since:            timestamppb.New(fixedTime),
+		TagHygiene:       true,
+		MinSourceLevel:   string(slsa.SlsaSourceLevel3),
+		RequiredBranches: []string{"main"},
+		RequireSigned:    true,
+	}}
+	policyPath := createTempPolicyFile(t, &rp)
+	defer os.Remove(policyPath) //nolint:errcheck
+	pe := &PolicyEvaluator{UseLocalPolicy: policyPath}
+	repo := &models.Repository{Hostname: "github.com", Path: "local/local", DefaultBranch: "main"}
+
+	evaluate := func(pred *provenance.TagProvenancePred) (*EvaluationResult, error) {
+		pred.Controls = controls.ToProvenanceControls()
+		pred.VsaSummaries = []*provenance.VsaSummary{mainL3}
+		return pe.EvaluateTagProv(t.Context(), repo, createStatementForTest(t, pred, provenance.TagProvPredicateType))
+	}
+
+	// Release tags match the pattern and must be signed
+	result, err := evaluate(&provenance.TagProvenancePred{Tag: "v1.0.0", Annotated: true, SignatureVerified: true})
+	require.NoError(t, err)
+	require.Equal(t, policyPath+"#"+TagPatternFragment+"v%2A", result.PolicyPath)
+	require.Contains(t, result.VerifiedLevels, slsa.ControlName(slsa.SlsaSourceLevel3))
+
+	_, err = evaluate(&provenance.TagProvenancePred{Tag: "v1.0.0"})
+	require.Error(t, err)
+
+	// Other tags fall back to protected_tag
+	result, err = evaluate(&provenance.TagProvenancePred{Tag: "nightly"})
+	require.NoError(t, err)
+	require.Equal(t, policyPath, result.PolicyPath)
+}
+
+func TestCheckLocalDir(t *testing.T) {
+	t.Parallel()
+	repo := &models.Repository{Hostname: "github.com", Path: "example/repo"}
+	for _, tc := range []struct {
+		name    string
+		source  PolicySource
+		mustErr bool
+	}{
+		{name: "no-policy", source: &staticSource{}},
+		{name: "policy-in-source", source: &staticSource{policy: &RepoPolicy{}}, mustErr: true},
+		{name: "source-fails", source: &staticSource{err: errors.New("synthetic error")}, mustErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			dir := t.TempDir()
+			_, err := git.PlainInit(dir, false)
+			require.NoError(t, err)
+
+			// The policy is looked up in the evaluator source, not in the
+			// community repository
+			pe := &PolicyEvaluator{Source: tc.source}
+			err = pe.checkLocalDir(t.Context(), repo, dir)
+			if tc.mustErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,231 @@
+// SPDX-FileCopyrightText: Copyright 2026 The SLSA Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/slsa-framework/source-tool/pkg/provenance"
+	"github.com/slsa-framework/source-tool/pkg/slsa"
+)
+
+// Variables available to the rule expressions
+const (
+	// RuleVarControls is a map of the names of the controls in force to the
+	// time they have been enforced since.
+	RuleVarControls = "controls"
+	// RuleVarBranch is the name of the branch, empty when evaluating a tag
+	RuleVarBranch = "branch"
+	// RuleVarTag is the name of the tag, empty when evaluating a branch
+	RuleVarTag = "tag"
+	// RuleVarCommit is a map with the data of the commit: actor,
+	// activity_type, time, prev_commit and parents.
+	RuleVarCommit = "commit"
+	// RuleVarProvenance is the provenance predicate being evaluated, an
+	// empty map when evaluating the controls read from the VCS.
+	RuleVarProvenance = "provenance"
+)
+
+// RuleResult is the outcome of evaluating a policy rule
+type RuleResult struct {
+	Name     string
+	Passed   bool
+	Required bool
+	// Error is set when the rule could not be evaluated, the rule fails
+	Error string
+}
+
+// ruleInput is the data the rules are evaluated over
+type ruleInput struct {
+	controls   *slsa.ControlSet
+	branch     string
+	tag        string
+	commit     map[string]any
+	provenance proto.Message
+}
+
+// controlsRuleInput returns the rule input for the controls read from the VCS
+func controlsRuleInput(branch string, controls *slsa.ControlSet) *ruleInput {
+	return &ruleInput{
+		controls: controls,
+		branch:   branch,
+		commit: map[string]any{
+			"actor":         controls.ActorLogin,
+			"activity_type": controls.ActivityType,
+			"time":          controls.CommitPushTime,
+			"prev_commit":   "",
+			"parents":       []string{},
+		},
+	}
+}
+
+// sourceProvRuleInput returns the rule input for a source provenance predicate
+func sourceProvRuleInput(branch string, pred *provenance.SourceProvenancePred) *ruleInput {
+	return &ruleInput{
+		controls: slsa.NewControlSetFromProvanenaceControls(pred.GetControls()),
+		branch:   branch,
+		commit: map[string]any{
+			"actor":         pred.GetActor(),
+			"activity_type": pred.GetActivityType(),
+			"time":          pred.GetCreatedOn().AsTime(),
+			"prev_commit":   pred.GetPrevCommit(),
+			"parents":       pred.GetParents(),
+		},
+		provenance: pred,
+	}
+}
+
+// tagProvRuleInput returns the rule input for a tag provenance predicate
+func tagProvRuleInput(pred *provenance.TagProvenancePred) *ruleInput {
+	return &ruleInput{
+		controls: slsa.NewControlSetFromProvanenaceControls(pred.GetControls()),
+		tag:      pred.GetTag(),
+		commit: map[string]any{
+			"actor":         pred.GetActor(),
+			"activity_type": "",
+			"time":          pred.GetCreatedOn().AsTime(),
+			"prev_commit":   "",
+			"parents":       []string{},
+		},
+		provenance: pred,
+	}
+}
+
+// activation returns the values of the rule variables
+func (ri *ruleInput) activation() map[string]any {
+	controls := map[string]time.Time{}
+	for _, c := range ri.controls.GetActiveControls().Controls {
+		since := time.Unix(0, 0).UTC()
+		if c.GetSince() != nil {
+			since = *c.GetSince()
+		}
+		controls[c.GetName().String()] = since
+	}
+
+	var prov any = map[string]any{}
+	if ri.provenance != nil {
+		prov = ri.provenance
+	}
+
+	return map[string]any{
+		RuleVarControls:   controls,
+		RuleVarBranch:     ri.branch,
+		RuleVarTag:        ri.tag,
+		RuleVarCommit:     ri.commit,
+		RuleVarProvenance: prov,
+	}
+}
+
+// ruleEnv returns the CEL environment rules are compiled in
+var ruleEnv = sync.OnceValues(func() (*cel.Env, error) {
+	return cel.NewEnv(
+		cel.Types(&provenance.SourceProvenancePred{}, &provenance.TagProvenancePred{}),
+		cel.Variable(RuleVarControls, cel.MapType(cel.StringType, cel.TimestampType)),
+		cel.Variable(RuleVarBranch, cel.StringType),
+		cel.Variable(RuleVarTag, cel.StringType),
+		cel.Variable(RuleVarCommit, cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable(RuleVarProvenance, cel.DynType),
+	)
+})
+
+// rulePrograms caches the compiled rule expressions
+var rulePrograms sync.Map
+
+// compileRule compiles a rule expression, which must evaluate to a boolean
+func compileRule(expression string) (cel.Program, error) {
+	if prg, ok := rulePrograms.Load(expression); ok {
+		return prg.(cel.Program), nil //nolint:errcheck,forcetypeassert
+	}
+
+	env, err := ruleEnv()
+	if err != nil {
+		return nil, fmt.Errorf("creating rule environment: %w", err)
+	}
+	ast, iss := env.Compile(expression)
+	if iss.Err() != nil {
+		return nil, iss.Err()
+	}
+	if !ast.OutputType().IsExactType(cel.BoolType) {
+		return nil, fmt.Errorf("expression must evaluate to a bool, not %s", ast.OutputType())
+	}
+	prg, err := env.Program(ast)
+	if err != nil {
+		return nil, err
+	}
+	rulePrograms.Store(expression, prg)
+	return prg, nil
+}
+
+// ValidateRule checks the rule is well formed and its expression compiles
+func ValidateRule(rule *PolicyRule) error {
+	errs := []error{}
+	if rule.GetName() == "" {
+		errs = append(errs, errors.New("rule has no name"))
+	}
+	if rule.GetPropertyName() != "" && !strings.HasPrefix(rule.GetPropertyName(), slsa.AllowedOrgPropPrefix) {
+		errs = append(errs, fmt.Errorf(
+			"rule %q specifies an invalid property name %v, custom property names MUST start with %v",
+			rule.GetName(), rule.GetPropertyName(), slsa.AllowedOrgPropPrefix,
+		))
+	}
+	if _, err := compileRule(rule.GetExpression()); err != nil {
+		errs = append(errs, fmt.Errorf("compiling rule %q: %w", rule.GetName(), err))
+	}
+	return errors.Join(errs...)
+}
+
+// evaluateRules runs the rules over the input. It returns the properties of
+// the rules that passed to record in the VSA and the results of all rules.
+// Invalid rules and failing required rules are an error.
+func evaluateRules(rules []*PolicyRule, in *ruleInput) (slsa.SourceVerifiedLevels, []*RuleResult, error) {
+	levels := slsa.SourceVerifiedLevels{}
+	results := []*RuleResult{}
+	if len(rules) == 0 {
+		return levels, results, nil
+	}
+
+	activation := in.activation()
+	for _, rule := range rules {
+		if err := ValidateRule(rule); err != nil {
+			return nil, nil, err
+		}
+		prg, err := compileRule(rule.GetExpression())
+		if err != nil {
+			return nil, nil, err
+		}
+
+		res := &RuleResult{Name: rule.GetName(), Required: rule.GetRequired()}
+		out, _, err := prg.Eval(activation)
+		switch {
+		case err != nil:
+			res.Error = err.Error()
+		case out == types.True:
+			res.Passed = true
+		}
+		results = append(results, res)
+
+		if res.Passed {
+			if rule.GetPropertyName() != "" {
+				levels = append(levels, slsa.ControlName(rule.GetPropertyName()))
+			}
+			continue
+		}
+
+		if rule.GetRequired() {
+			if res.Error != "" {
+				return nil, nil, fmt.Errorf("policy requires rule %q, but it could not be evaluated: %s", rule.GetName(), res.Error)
+			}
+			return nil, nil, fmt.Errorf("policy requires rule %q, but it failed", rule.GetName())
+		}
+	}
+	return levels, results, nil
+}
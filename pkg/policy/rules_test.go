@@ -0,0 +1,152 @@
+// SPDX-FileCopyrightText: Copyright 2026 The SLSA Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package policy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/slsa-framework/source-tool/pkg/provenance"
+	"github.com/slsa-framework/source-tool/pkg/slsa"
+	"github.com/slsa-framework/source-tool/pkg/sourcetool/models"
+)
+
+func TestEvaluateRules(t *testing.T) {
+	t.Parallel()
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	pred := &provenance.SourceProvenancePred{
+		Actor:     "octocat",
+		Branch:    "refs/heads/main",
+		CreatedOn: timestamppb.New(since.Add(24 * time.Hour)),
+		Controls: []*provenance.Control{
+			{Name: slsa.SLSA_SOURCE_SCS_CONTINUITY.String(), Since: timestamppb.New(since)},
+			{Name: slsa.SLSA_SOURCE_SCS_TWO_PARTY_REVIEW.String(), Since: timestamppb.New(since)},
+		},
+		Parents: []string{"abc"},
+	}
+
+	for _, tc := range []struct {
+		name     string
+		rules    []*PolicyRule
+		levels   slsa.SourceVerifiedLevels
+		results  []*RuleResult
+		mustErr  bool
+		controls bool
+	}{
+		{
+			name:    "no-rules",
+			levels:  slsa.SourceVerifiedLevels{},
+			results: []*RuleResult{},
+		},
+		{
+			name: "passing-rule-records-property",
+			rules: []*PolicyRule{
+				{Name: "review", Expression: `"SLSA_SOURCE_SCS_TWO_PARTY_REVIEW" in controls`, PropertyName: "ORG_SOURCE_REVIEWED"},
+			},
+			levels:  slsa.SourceVerifiedLevels{"ORG_SOURCE_REVIEWED"},
+			results: []*RuleResult{{Name: "review", Passed: true}},
+		},
+		{
+			name: "provenance-and-commit-data",
+			rules: []*PolicyRule{
+				{Name: "actor", Expression: `provenance.actor == "octocat" && commit.actor == "octocat" && size(commit.parents) == 1`},
+				{Name: "branch", Expression: `branch == "main" && tag == ""`},
+				{Name: "since", Expression: `controls["SLSA_SOURCE_SCS_CONTINUITY"] < commit.time`},
+			},
+			levels: slsa.SourceVerifiedLevels{},
+			results: []*RuleResult{
+				{Name: "actor", Passed: true}, {Name: "branch", Passed: true}, {Name: "since", Passed: true},
+			},
+		},
+		{
+			name: "failing-rule-is-reported",
+			rules: []*PolicyRule{
+				{Name: "tests", Expression: `"ORG_SOURCE_TESTED" in controls`, PropertyName: "ORG_SOURCE_TESTED_RULE"},
+			},
+			levels:  slsa.SourceVerifiedLevels{},
+			results: []*RuleResult{{Name: "tests"}},
+		},
+		{
+			name: "evaluation-error-fails-rule",
+			rules: []*PolicyRule{
+				{Name: "missing", Expression: `controls["ORG_SOURCE_TESTED"] < commit.time`},
+			},
+			levels:  slsa.SourceVerifiedLevels{},
+			results: []*RuleResult{{Name: "missing", Error: "no such key: ORG_SOURCE_TESTED"}},
+		},
+		{
+			name:     "controls-have-no-provenance",
+			controls: true,
+			rules: []*PolicyRule{
+				{Name: "no-prov", Expression: `!has(provenance.actor) && commit.actor == "octocat"`},
+			},
+			levels:  slsa.SourceVerifiedLevels{},
+			results: []*RuleResult{{Name: "no-prov", Passed: true}},
+		},
+		{
+			name: "failing-required-rule",
+			rules: []*PolicyRule{
+				{Name: "tests", Expression: `"ORG_SOURCE_TESTED" in controls`, Required: true},
+			},
+			mustErr: true,
+		},
+		{
+			name:    "non-bool-expression",
+			rules:   []*PolicyRule{{Name: "bad", Expression: `branch`}},
+			mustErr: true,
+		},
+		{
+			name:    "syntax-error",
+			rules:   []*PolicyRule{{Name: "bad", Expression: `branch ==`}},
+			mustErr: true,
+		},
+		{
+			name:    "invalid-property-name",
+			rules:   []*PolicyRule{{Name: "bad", Expression: `true`, PropertyName: "SLSA_SOURCE_LEVEL_4"}},
+			mustErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			in := sourceProvRuleInput("main", pred)
+			if tc.controls {
+				controls := slsa.NewControlSetFromProvanenaceControls(pred.GetControls())
+				controls.ActorLogin = "octocat"
+				in = controlsRuleInput("main", controls)
+			}
+			levels, results, err := evaluateRules(tc.rules, in)
+			if tc.mustErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.levels, levels)
+			require.Equal(t, tc.results, results)
+		})
+	}
+}
+
+func TestEvaluateSourceProvRules(t *testing.T) {
+	t.Parallel()
+	branch := &models.Branch{Name: "main"}
+	rp := &RepoPolicy{
+		ProtectedBranches: []*ProtectedBranch{{
+			Name:                  "main",
+			Since:                 timestamppb.New(fixedTime),
+			TargetSlsaSourceLevel: string(slsa.SlsaSourceLevel1),
+			Rules: []*PolicyRule{
+				{Name: "actor", Expression: `commit.actor.endsWith("[bot]") == false`, PropertyName: "ORG_SOURCE_HUMAN_PUSH"},
+			},
+		}},
+	}
+	pred := &provenance.SourceProvenancePred{Actor: "octocat", CreatedOn: timestamppb.New(fixedTime)}
+
+	result, err := evaluateSourceProvPred(rp, rp.GetBranchPolicy("main"), "path", nil, branch, pred)
+	require.NoError(t, err)
+	require.Contains(t, result.VerifiedLevels, slsa.ControlName("ORG_SOURCE_HUMAN_PUSH"))
+	require.Equal(t, []*RuleResult{{Name: "actor", Passed: true}}, result.Rules)
+}
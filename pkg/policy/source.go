@@ -0,0 +1,498 @@
+// SPDX-FileCopyrightText: Copyright 2026 The SLSA Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package policy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/google/go-github/v88/github"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/slsa-framework/source-tool/pkg/sourcetool/models"
+)
+
+const (
+	// DefaultInRepoPolicyPath is the path of the policy file read from the
+	// repository itself by the in-repo policy source.
+	DefaultInRepoPolicyPath = ".slsa/source-policy.json"
+
+	// policyFileName is the name of the policy file in the policy repository
+	// and HTTPS endpoint layouts.
+	policyFileName = "source-policy.json"
+
+	githubHostname = "github.com"
+
+	// Prefixes of the policy source specs
+	SourceSpecGitHub     = "github:"
+	SourceSpecRepository = "repo:"
+	SourceSpecFile       = "file:"
+	SourceSpecHTTPS      = "https://"
+)
+
+// ErrNoPolicyHistory is returned by sources that can only read the latest
+// policy when asked for a policy at a past point.
+var ErrNoPolicyHistory = errors.New("policy source has no history")
+
+// PolicySource is a location repository policies are read from
+type PolicySource interface {
+	// GetPolicyAt returns the policy of the repository at a point in time or
+	// commit, the path where it was read and its version. The policy is nil
+	// when the source has no policy for the repository at that point.
+	GetPolicyAt(context.Context, *models.Repository, PolicyPoint) (*RepoPolicy, string, *PolicyVersion, error)
+
+	// String describes the source
+	String() string
+}
+
+// ClientFn returns the GitHub client used by the sources reading from GitHub
+type ClientFn func() (*github.Client, error)
+
+// SourceChain reads policies from a list of sources in order of precedence,
+// the first source with a policy for the repository wins. Errors are not
+// skipped: a source that fails to read stops the chain to avoid falling back
+// silently to a lower precedence policy. Sources without history are read
+// at their latest version instead, the version returned is marked as such.
+type SourceChain []PolicySource
+
+// GetPolicyAt returns the policy of the highest precedence source that has one
+func (sc SourceChain) GetPolicyAt(ctx context.Context, repo *models.Repository, at PolicyPoint) (*RepoPolicy, string, *PolicyVersion, error) {
+	for _, s := range sc {
+		rp, path, version, err := s.GetPolicyAt(ctx, repo, at)
+		if errors.Is(err, ErrNoPolicyHistory) {
+			rp, path, version, err = s.GetPolicyAt(ctx, repo, PolicyPoint{})
+			if version != nil {
+				version.Latest = true
+			}
+		}
+		if err != nil {
+			return nil, "", nil, fmt.Errorf("reading policy from %s: %w", s, err)
+		}
+		if rp != nil {
+			return rp, path, version, nil
+		}
+	}
+	return nil, "", nil, nil
+}
+
+func (sc SourceChain) String() string {
+	descrs := []string{}
+	for _, s := range sc {
+		descrs = append(descrs, s.String())
+	}
+	return strings.Join(descrs, ", ")
+}
+
+// ParsePolicySource parses a policy source spec:
+//
+//	github:owner/repo   a policy repository on GitHub (like the community repo)
+//	repo:[path]         a file in the repository itself, .slsa/source-policy.json by default
+//	file:path           a local policy file or a directory with the policy repo layout
+//	https://host/path   an HTTPS endpoint serving the policy repo layout
+//
+// The GitHub sources use the client returned by fn.
+func ParsePolicySource(spec string, fn ClientFn) (PolicySource, error) {
+	switch {
+	case strings.HasPrefix(spec, SourceSpecGitHub):
+		owner, repo, ok := strings.Cut(strings.TrimPrefix(spec, SourceSpecGitHub), "/")
+		if !ok || owner == "" || repo == "" || strings.Contains(repo, "/") {
+			return nil, fmt.Errorf("invalid policy repository in %q, must be owner/repo", spec)
+		}
+		return NewGitHubRepoSource(owner, repo, fn), nil
+	case strings.HasPrefix(spec, SourceSpecRepository):
+		return NewInRepoSource(strings.TrimPrefix(spec, SourceSpecRepository), fn), nil
+	case strings.HasPrefix(spec, SourceSpecFile):
+		path := strings.TrimPrefix(spec, SourceSpecFile)
+		if path == "" {
+			return nil, fmt.Errorf("no path in policy source %q", spec)
+		}
+		return &FilesystemSource{Path: path}, nil
+	case strings.HasPrefix(spec, SourceSpecHTTPS):
+		u, err := url.Parse(spec)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("invalid policy source URL %q", spec)
+		}
+		return &HTTPSource{URL: spec}, nil
+	default:
+		return nil, fmt.Errorf("unknown policy source %q", spec)
+	}
+}
+
+// NewSourceChain parses a list of policy source specs into a chain, in the
+// order of precedence.
+func NewSourceChain(specs []string, fn ClientFn) (SourceChain, error) {
+	chain := SourceChain{}
+	errs := []error{}
+	for _, spec := range specs {
+		s, err := ParsePolicySource(spec, fn)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		chain = append(chain, s)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return chain, nil
+}
+
+// GitHubRepoSource reads policies from a policy repository hosted on GitHub,
+// such as the community repository. Policies are stored in it under
+// policy/<hostname>/<owner>/<repo>/source-policy.json.
+type GitHubRepoSource struct {
+	Owner string
+	Repo  string
+	files githubFiles
+}
+
+// NewGitHubRepoSource returns a source reading from the owner/repo policy
+// repository.
+func NewGitHubRepoSource(owner, repo string, fn ClientFn) *GitHubRepoSource {
+	return &GitHubRepoSource{
+		Owner: owner, Repo: repo, files: githubFiles{getClient: fn},
+	}
+}
+
+// GetPolicyAt reads the policy of repo from the policy repository
+func (s *GitHubRepoSource) GetPolicyAt(ctx context.Context, repo *models.Repository, at PolicyPoint) (*RepoPolicy, string, *PolicyVersion, error) {
+	path := getPolicyPath(repo)
+	if path == "" {
+		return nil, "", nil, nil
+	}
+	return s.files.getAt(ctx, s.Owner, s.Repo, path, at)
+}
+
+func (s *GitHubRepoSource) String() string {
+	return SourceSpecGitHub + s.Owner + "/" + s.Repo
+}
+
+// InRepoSource reads the policy from a file checked into the repository
+// itself. The file is read from the default branch of the repository and
+// point in time lookups use its history there.
+type InRepoSource struct {
+	// Path of the policy file in the repository
+	Path  string
+	files githubFiles
+}
+
+// NewInRepoSource returns a source reading the policy at path in the
+// repository, an empty path means DefaultInRepoPolicyPath.
+func NewInRepoSource(path string, fn ClientFn) *InRepoSource {
+	if path == "" {
+		path = DefaultInRepoPolicyPath
+	}
+	return &InRepoSource{Path: path, files: githubFiles{getClient: fn}}
+}
+
+// GetPolicyAt reads the policy file from the repository. Repositories not
+// hosted on GitHub have no in-repo policy.
+func (s *InRepoSource) GetPolicyAt(ctx context.Context, repo *models.Repository, at PolicyPoint) (*RepoPolicy, string, *PolicyVersion, error) {
+	if repoHostname(repo) != githubHostname {
+		return nil, "", nil, nil
+	}
+	owner, name, err := repo.PathAsGitHubOwnerName()
+	if err != nil {
+		return nil, "", nil, err
+	}
+	return s.files.getAt(ctx, owner, name, strings.TrimPrefix(s.Path, "/"), at)
+}
+
+func (s *InRepoSource) String() string {
+	return SourceSpecRepository + s.Path
+}
+
+// FilesystemSource reads policies from disk. If Path is a file, it is the
+// policy of all repositories. If it is a directory it is read as a clone of
+// a policy repository and the policy of each repository is looked up in its
+// layout.
+//
+// Files tracked in git are read at past points from their history.
+type FilesystemSource struct {
+	Path string
+}
+
+// GetPolicyAt reads the policy from the filesystem
+func (s *FilesystemSource) GetPolicyAt(_ context.Context, repo *models.Repository, at PolicyPoint) (*RepoPolicy, string, *PolicyVersion, error) {
+	info, err := os.Stat(s.Path)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	if !info.IsDir() {
+		return getLocalPolicyAt(s.Path, at)
+	}
+
+	rel := getPolicyPath(repo)
+	if rel == "" {
+		return nil, "", nil, nil
+	}
+	path := filepath.Join(s.Path, filepath.FromSlash(rel))
+	if _, err := os.Stat(path); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, "", nil, nil
+		}
+		return nil, "", nil, err
+	}
+	return getLocalPolicyAt(path, at)
+}
+
+func (s *FilesystemSource) String() string {
+	return SourceSpecFile + s.Path
+}
+
+// HTTPSource reads policies from an HTTPS endpoint serving the policies in
+// the policy repository layout: <URL>/<hostname>/<owner>/<repo>/source-policy.json
+//
+// Endpoints only serve the latest policy, the source has no history to
+// look up policies at a past point and returns ErrNoPolicyHistory.
+type HTTPSource struct {
+	URL string
+
+	// Client is the HTTP client used to fetch the policies, defaults to
+	// http.DefaultClient.
+	Client *http.Client
+}
+
+// GetPolicyAt fetches the policy from the endpoint. A 404 response means the
+// repository has no policy.
+func (s *HTTPSource) GetPolicyAt(ctx context.Context, repo *models.Repository, at PolicyPoint) (*RepoPolicy, string, *PolicyVersion, error) {
+	if !at.IsLatest() {
+		return nil, "", nil, fmt.Errorf("reading policy over HTTPS: %w", ErrNoPolicyHistory)
+	}
+
+	policyURL, err := url.JoinPath(s.URL, repoHostname(repo), repo.Path, policyFileName)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("building policy URL: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, policyURL, nil)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("fetching policy: %w", err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, "", nil, nil
+	default:
+		return nil, "", nil, fmt.Errorf("fetching policy from %s: HTTP %d", policyURL, resp.StatusCode)
+	}
+
+	contents, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("reading policy: %w", err)
+	}
+	p, err := ParsePolicy(contents)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	return p, policyURL, &PolicyVersion{Digest: digestPolicy(contents)}, nil
+}
+
+func (s *HTTPSource) String() string {
+	return s.URL
+}
+
+// sourceHasURI returns true when the policy of the repository read from the
+// source is reported at uri. Policies in GitHub repositories are reported at
+// the URL of the file at the commit read, so any commit matches.
+func sourceHasURI(src PolicySource, repo *models.Repository, uri string) bool {
+	switch s := src.(type) {
+	case SourceChain:
+		return slices.ContainsFunc(s, func(e PolicySource) bool {
+			return sourceHasURI(e, repo, uri)
+		})
+	case *GitHubRepoSource:
+		return githubFileURLMatches(uri, s.Owner, s.Repo, getPolicyPath(repo))
+	case *InRepoSource:
+		if repoHostname(repo) != githubHostname {
+			return false
+		}
+		owner, name, err := repo.PathAsGitHubOwnerName()
+		if err != nil {
+			return false
+		}
+		return githubFileURLMatches(uri, owner, name, strings.TrimPrefix(s.Path, "/"))
+	case *FilesystemSource:
+		if uri == s.Path {
+			return true
+		}
+		rel := getPolicyPath(repo)
+		return rel != "" && uri == filepath.Join(s.Path, filepath.FromSlash(rel))
+	case *HTTPSource:
+		policyURL, err := url.JoinPath(s.URL, repoHostname(repo), repo.Path, policyFileName)
+		return err == nil && uri == policyURL
+	default:
+		return false
+	}
+}
+
+// githubFileURLMatches returns true when uri is the URL of the file at path
+// in the owner/repo GitHub repository, at any commit.
+func githubFileURLMatches(uri, owner, repo, path string) bool {
+	// Owner and repository names are case insensitive, paths are not
+	prefix := fmt.Sprintf("https://github.com/%s/%s/blob/", owner, repo)
+	if len(uri) < len(prefix) || !strings.EqualFold(uri[:len(prefix)], prefix) {
+		return false
+	}
+	_, file, ok := strings.Cut(uri[len(prefix):], "/")
+	return ok && file == path
+}
+
+// repoHostname returns the hostname of the repository, repositories with
+// no hostname are on GitHub.
+func repoHostname(repo *models.Repository) string {
+	if repo.Hostname == "" {
+		return githubHostname
+	}
+	return repo.Hostname
+}
+
+// githubFiles reads versions of policy files stored in GitHub repositories,
+// caching their history and contents.
+type githubFiles struct {
+	getClient ClientFn
+
+	mtx       sync.Mutex
+	histories map[string][]*policyRevision
+	versions  map[string]*versionedPolicy
+}
+
+func (gf *githubFiles) client() (*github.Client, error) {
+	if gf.getClient == nil {
+		return nil, errors.New("unable to get github client, no client set")
+	}
+	return gf.getClient()
+}
+
+// getAt reads the policy file at the point. If the file did not exist at the
+// point we return a nil policy.
+func (gf *githubFiles) getAt(ctx context.Context, owner, repo, path string, at PolicyPoint) (*RepoPolicy, string, *PolicyVersion, error) {
+	ref := at.Commit
+	if ref == "" {
+		revisions, err := gf.history(ctx, owner, repo, path)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		rev := revisionAt(revisions, at.Time)
+		if rev == nil {
+			return nil, "", nil, nil
+		}
+		ref = rev.SHA
+	}
+	return gf.version(ctx, owner, repo, path, ref)
+}
+
+// history lists the commits that modified the file, newest first
+func (gf *githubFiles) history(ctx context.Context, owner, repo, path string) ([]*policyRevision, error) {
+	key := owner + "/" + repo + "/" + path
+
+	gf.mtx.Lock()
+	defer gf.mtx.Unlock()
+	if revisions, ok := gf.histories[key]; ok {
+		return revisions, nil
+	}
+
+	client, err := gf.client()
+	if err != nil {
+		return nil, err
+	}
+
+	revisions := []*policyRevision{}
+	opts := &github.CommitsListOptions{Path: path, ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		commits, resp, err := client.Repositories.ListCommits(ctx, owner, repo, opts)
+		if err != nil {
+			return nil, fmt.Errorf("listing policy history: %w", err)
+		}
+		for _, c := range commits {
+			revisions = append(revisions, &policyRevision{
+				SHA:  c.GetSHA(),
+				Date: c.GetCommit().GetCommitter().GetDate().Time,
+			})
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	slices.SortStableFunc(revisions, func(a, b *policyRevision) int {
+		return b.Date.Compare(a.Date)
+	})
+
+	if gf.histories == nil {
+		gf.histories = map[string][]*policyRevision{}
+	}
+	gf.histories[key] = revisions
+	return revisions, nil
+}
+
+// version fetches the policy file at a commit. If the file does not exist at
+// that commit we return a nil policy.
+func (gf *githubFiles) version(ctx context.Context, owner, repo, path, ref string) (*RepoPolicy, string, *PolicyVersion, error) {
+	key := owner + "/" + repo + "/" + path + "@" + ref
+
+	gf.mtx.Lock()
+	defer gf.mtx.Unlock()
+	if vp, ok := gf.versions[key]; ok {
+		return vp.policy, vp.path, vp.version, nil
+	}
+
+	client, err := gf.client()
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	policyContents, _, resp, err := client.Repositories.GetContents(
+		ctx, owner, repo, path, &github.RepositoryContentGetOptions{Ref: ref},
+	)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return nil, "", nil, nil
+	}
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("fetching policy code: %w", err)
+	}
+
+	content, err := policyContents.GetContent()
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	p := &RepoPolicy{}
+	err = protojson.UnmarshalOptions{
+		DiscardUnknown: false,
+	}.Unmarshal([]byte(content), p)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("unmarshaling policy code: %w", err)
+	}
+
+	vp := &versionedPolicy{
+		policy:  p,
+		path:    policyContents.GetHTMLURL(),
+		version: &PolicyVersion{Commit: ref, Digest: digestPolicy([]byte(content))},
+	}
+	if gf.versions == nil {
+		gf.versions = map[string]*versionedPolicy{}
+	}
+	gf.versions[key] = vp
+	return vp.policy, vp.path, vp.version, nil
+}
@@ -0,0 +1,262 @@
+// SPDX-FileCopyrightText: Copyright 2026 The SLSA Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package policy
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v88/github"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slsa-framework/source-tool/pkg/slsa"
+	"github.com/slsa-framework/source-tool/pkg/sourcetool/models"
+)
+
+// staticSource is a policy source returning a fixed result
+type staticSource struct {
+	policy *RepoPolicy
+	err    error
+}
+
+func (s *staticSource) GetPolicyAt(context.Context, *models.Repository, PolicyPoint) (*RepoPolicy, string, *PolicyVersion, error) {
+	return s.policy, "static", nil, s.err
+}
+
+func (s *staticSource) String() string { return "static" }
+
+func TestParsePolicySource(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		spec     string
+		expected PolicySource
+		mustErr  bool
+	}{
+		{spec: "github:example/policies", expected: &GitHubRepoSource{Owner: "example", Repo: "policies"}},
+		{spec: "repo:", expected: &InRepoSource{Path: DefaultInRepoPolicyPath}},
+		{spec: "repo:policy/slsa.json", expected: &InRepoSource{Path: "policy/slsa.json"}},
+		{spec: "file:/etc/policy.json", expected: &FilesystemSource{Path: "/etc/policy.json"}},
+		{spec: "https://policies.example.com/slsa", expected: &HTTPSource{URL: "https://policies.example.com/slsa"}},
+		{spec: "github:example", mustErr: true},
+		{spec: "github:example/policies/extra", mustErr: true},
+		{spec: "file:", mustErr: true},
+		{spec: "http://policies.example.com", mustErr: true},
+		{spec: "example/policies", mustErr: true},
+	} {
+		t.Run(tc.spec, func(t *testing.T) {
+			t.Parallel()
+			s, err := ParsePolicySource(tc.spec, nil)
+			if tc.mustErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected.String(), s.String())
+			require.IsType(t, tc.expected, s)
+		})
+	}
+}
+
+func TestSourceChain(t *testing.T) {
+	t.Parallel()
+	first := &RepoPolicy{CanonicalRepo: "first"}
+	second := &RepoPolicy{CanonicalRepo: "second"}
+	repo := &models.Repository{Hostname: "github.com", Path: "owner/repo"}
+
+	for _, tc := range []struct {
+		name     string
+		chain    SourceChain
+		expected *RepoPolicy
+		mustErr  bool
+	}{
+		{name: "empty", chain: SourceChain{}},
+		{name: "first-wins", chain: SourceChain{&staticSource{policy: first}, &staticSource{policy: second}}, expected: first},
+		{name: "fallback", chain: SourceChain{&staticSource{}, &staticSource{policy: second}}, expected: second},
+		{name: "no-policy", chain: SourceChain{&staticSource{}, &staticSource{}}},
+		{name: "error-stops", chain: SourceChain{&staticSource{err: errors.New("boom")}, &staticSource{policy: second}}, mustErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			rp, _, _, err := tc.chain.GetPolicyAt(t.Context(), repo, PolicyPoint{})
+			if tc.mustErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, rp)
+		})
+	}
+}
+
+func TestFilesystemSource(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	repo := &models.Repository{Hostname: "github.com", Path: "owner/repo"}
+
+	path := filepath.Join(dir, filepath.FromSlash(getPolicyPath(repo)))
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, policyWithLevel(t, slsa.SlsaSourceLevel2), 0o600))
+
+	// Directories are read in the policy repository layout
+	s := &FilesystemSource{Path: dir}
+	rp, gotPath, version, err := s.GetPolicyAt(t.Context(), repo, PolicyPoint{})
+	require.NoError(t, err)
+	require.Equal(t, string(slsa.SlsaSourceLevel2), targetLevel(rp))
+	require.Equal(t, path, gotPath)
+	require.NotEmpty(t, version.Digest)
+
+	rp, _, _, err = s.GetPolicyAt(t.Context(), &models.Repository{Hostname: "github.com", Path: "owner/other"}, PolicyPoint{})
+	require.NoError(t, err)
+	require.Nil(t, rp)
+
+	// Files are the policy of any repository
+	s = &FilesystemSource{Path: path}
+	rp, _, _, err = s.GetPolicyAt(t.Context(), &models.Repository{Hostname: "github.com", Path: "owner/other"}, PolicyPoint{})
+	require.NoError(t, err)
+	require.Equal(t, string(slsa.SlsaSourceLevel2), targetLevel(rp))
+}
+
+func TestHTTPSource(t *testing.T) {
+	t.Parallel()
+	contents := policyWithLevel(t, slsa.SlsaSourceLevel3)
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/policies/github.com/owner/repo/source-policy.json" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, err := w.Write(contents)
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+
+	s := &HTTPSource{URL: server.URL + "/policies", Client: server.Client()}
+	rp, path, version, err := s.GetPolicyAt(t.Context(), &models.Repository{Hostname: "github.com", Path: "owner/repo"}, PolicyPoint{})
+	require.NoError(t, err)
+	require.Equal(t, string(slsa.SlsaSourceLevel3), targetLevel(rp))
+	require.Equal(t, server.URL+"/policies/github.com/owner/repo/source-policy.json", path)
+	require.Equal(t, digestPolicy(contents), version.Digest)
+
+	rp, _, _, err = s.GetPolicyAt(t.Context(), &models.Repository{Hostname: "github.com", Path: "owner/other"}, PolicyPoint{})
+	require.NoError(t, err)
+	require.Nil(t, rp)
+
+	_, _, _, err = s.GetPolicyAt(t.Context(), &models.Repository{Hostname: "github.com", Path: "owner/repo"}, PolicyPoint{Commit: "abc"})
+	require.ErrorIs(t, err, ErrNoPolicyHistory)
+}
+
+func TestSourceChainNoHistory(t *testing.T) {
+	t.Parallel()
+	contents := policyWithLevel(t, slsa.SlsaSourceLevel3)
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/github.com/owner/repo/source-policy.json" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if _, err := w.Write(contents); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	second := &RepoPolicy{CanonicalRepo: "second"}
+	chain := SourceChain{&HTTPSource{URL: server.URL, Client: server.Client()}, &staticSource{policy: second}}
+	at := PolicyPoint{Time: github.Ptr(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))}
+
+	// The endpoint has a policy, it is read at its latest version
+	rp, _, version, err := chain.GetPolicyAt(t.Context(), &models.Repository{Hostname: "github.com", Path: "owner/repo"}, at)
+	require.NoError(t, err)
+	require.Equal(t, string(slsa.SlsaSourceLevel3), targetLevel(rp))
+	require.True(t, version.Latest)
+	require.Equal(t, digestPolicy(contents), version.Digest)
+
+	// Without a policy in the endpoint the chain moves on
+	rp, _, _, err = chain.GetPolicyAt(t.Context(), &models.Repository{Hostname: "github.com", Path: "owner/other"}, at)
+	require.NoError(t, err)
+	require.Equal(t, second, rp)
+}
+
+func TestInRepoSource(t *testing.T) {
+	t.Parallel()
+	contents := policyWithLevel(t, slsa.SlsaSourceLevel2)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/repos/owner/repo/commits", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, DefaultInRepoPolicyPath, r.URL.Query().Get("path"))
+		_, err := w.Write([]byte(`[{"sha": "ccc", "commit": {"committer": {"date": "2024-01-01T00:00:00Z"}}}]`))
+		require.NoError(t, err)
+	})
+	mux.HandleFunc("/api/v3/repos/owner/repo/contents/.slsa/source-policy.json", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "ccc", r.URL.Query().Get("ref"))
+		data, err := json.Marshal(&github.RepositoryContent{
+			Type:     github.Ptr("file"),
+			Encoding: github.Ptr("base64"),
+			Content:  github.Ptr(base64.StdEncoding.EncodeToString(contents)),
+		})
+		require.NoError(t, err)
+		_, err = w.Write(data)
+		require.NoError(t, err)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	client, err := github.NewClient(
+		github.WithHTTPClient(server.Client()),
+		github.WithEnterpriseURLs(server.URL+"/", server.URL+"/"),
+	)
+	require.NoError(t, err)
+
+	s := NewInRepoSource("", func() (*github.Client, error) { return client, nil })
+	rp, _, version, err := s.GetPolicyAt(t.Context(), &models.Repository{Hostname: "github.com", Path: "owner/repo"}, PolicyPoint{})
+	require.NoError(t, err)
+	require.Equal(t, string(slsa.SlsaSourceLevel2), targetLevel(rp))
+	require.Equal(t, "ccc", version.Commit)
+
+	// Repositories not hosted on GitHub have no in-repo policy
+	rp, _, _, err = s.GetPolicyAt(t.Context(), &models.Repository{Hostname: "gitlab.com", Path: "owner/repo"}, PolicyPoint{})
+	require.NoError(t, err)
+	require.Nil(t, rp)
+}
+
+func TestIsSourceURI(t *testing.T) {
+	t.Parallel()
+	repo := &models.Repository{Hostname: "github.com", Path: "example/repo"}
+	community := "https://github.com/slsa-framework/source-policies/blob/0123abc/policy/github.com/example/repo/source-policy.json"
+	inRepo := "https://github.com/example/repo/blob/0123abc/.slsa/source-policy.json"
+
+	for _, tc := range []struct {
+		name     string
+		eval     *PolicyEvaluator
+		uri      string
+		expected bool
+	}{
+		{name: "community", eval: &PolicyEvaluator{}, uri: community, expected: true},
+		{name: "community-fragment", eval: &PolicyEvaluator{}, uri: community + "#branch=release%2F%2A", expected: true},
+		{name: "community-case", eval: &PolicyEvaluator{}, uri: strings.Replace(community, "slsa-framework", "SLSA-Framework", 1), expected: true},
+		{name: "community-other-repo", eval: &PolicyEvaluator{}, uri: strings.Replace(community, "example/repo", "example/other", 1)},
+		{name: "fork", eval: &PolicyEvaluator{}, uri: strings.Replace(community, "slsa-framework", "someone", 1)},
+		{name: "in-repo", eval: &PolicyEvaluator{Source: NewInRepoSource("", nil)}, uri: inRepo, expected: true},
+		{name: "in-repo-vs-community", eval: &PolicyEvaluator{Source: NewInRepoSource("", nil)}, uri: community},
+		{
+			name:     "chain",
+			eval:     &PolicyEvaluator{Source: SourceChain{NewInRepoSource("", nil), NewGitHubRepoSource("slsa-framework", "source-policies", nil)}},
+			uri:      community,
+			expected: true,
+		},
+		{name: "local-file", eval: &PolicyEvaluator{UseLocalPolicy: "/tmp/policy.json"}, uri: "/tmp/policy.json", expected: true},
+		{name: "local-file-vs-community", eval: &PolicyEvaluator{UseLocalPolicy: "/tmp/policy.json"}, uri: community},
+		{name: "default", eval: &PolicyEvaluator{}, uri: DefaultPolicyPath},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tc.expected, tc.eval.IsSourceURI(repo, tc.uri))
+		})
+	}
+}
@@ -0,0 +1,289 @@
+// SPDX-FileCopyrightText: Copyright 2026 The SLSA Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package policy
+
+import (
+	"fmt"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/slsa-framework/source-tool/pkg/ghcontrol"
+	"github.com/slsa-framework/source-tool/pkg/slsa"
+)
+
+// Severity of a policy finding
+type Severity string
+
+const (
+	// SeverityError findings make evaluations fail or not mean what the
+	// policy says.
+	SeverityError Severity = "error"
+	// SeverityWarning findings are likely mistakes.
+	SeverityWarning Severity = "warning"
+	// SeverityInfo findings are informational.
+	SeverityInfo Severity = "info"
+)
+
+// Finding is a problem found when validating a policy
+type Finding struct {
+	Severity Severity `json:"severity"`
+	// Field is the location of the problem in the policy, eg
+	// protected_branches[0].target_slsa_source_level
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+func (f *Finding) String() string {
+	if f.Field == "" {
+		return fmt.Sprintf("%s: %s", f.Severity, f.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", f.Severity, f.Field, f.Message)
+}
+
+// Findings is the result of validating a policy
+type Findings []*Finding
+
+// HasErrors returns true if any of the findings is an error
+func (fs Findings) HasErrors() bool {
+	return slices.ContainsFunc(fs, func(f *Finding) bool { return f.Severity == SeverityError })
+}
+
+func (fs Findings) String() string {
+	var sb strings.Builder
+	for _, f := range fs {
+		sb.WriteString(f.String() + "\n")
+	}
+	return sb.String()
+}
+
+// ValidateOptions control the checks run when validating a policy
+type ValidateOptions struct {
+	// Now is the time since dates are checked against, defaults to the
+	// current time.
+	Now time.Time
+
+	// Branches are the current controls of the branches of the repository,
+	// keyed by name. When set, the policy is also checked against them:
+	// branches named in the policy must exist and their controls must
+	// satisfy the policy since its since dates.
+	Branches map[string]*slsa.ControlSet
+}
+
+type ValidateOptFn func(*ValidateOptions)
+
+// WithValidateTime sets the time since dates are checked against
+func WithValidateTime(t time.Time) ValidateOptFn {
+	return func(vo *ValidateOptions) {
+		vo.Now = t
+	}
+}
+
+// WithBranchControls checks the policy against the current controls of
+// the repository branches.
+func WithBranchControls(branches map[string]*slsa.ControlSet) ValidateOptFn {
+	return func(vo *ValidateOptions) {
+		vo.Branches = branches
+	}
+}
+
+// Validate checks the policy and returns the problems found in it. Without
+// options, only the policy itself is checked.
+func Validate(rp *RepoPolicy, funcs ...ValidateOptFn) Findings {
+	opts := ValidateOptions{Now: time.Now()}
+	for _, f := range funcs {
+		f(&opts)
+	}
+
+	v := &validator{opts: &opts, findings: Findings{}}
+	if rp.GetCanonicalRepo() == "" {
+		v.add(SeverityError, "canonical_repo", "the policy does not set the canonical repository")
+	}
+	if len(rp.GetProtectedBranches()) == 0 {
+		v.add(SeverityWarning, "protected_branches", "the policy protects no branches")
+	}
+
+	seen := map[string]bool{}
+	for i, pb := range rp.GetProtectedBranches() {
+		field := fmt.Sprintf("protected_branches[%d]", i)
+		if seen[pb.GetName()] {
+			v.add(SeverityError, field+".name", fmt.Sprintf("branch %q is listed more than once", pb.GetName()))
+		}
+		seen[pb.GetName()] = true
+		v.checkBranch(field, pb)
+	}
+
+	if rp.GetProtectedTag() != nil {
+		v.checkTag("protected_tag", rp.GetProtectedTag(), rp)
+	}
+
+	seen = map[string]bool{}
+	for i, pt := range rp.GetProtectedTags() {
+		field := fmt.Sprintf("protected_tags[%d]", i)
+		switch {
+		case pt.GetName() == "":
+			v.add(SeverityError, field+".name", "tag entries must have a name or pattern")
+		case seen[pt.GetName()]:
+			v.add(SeverityError, field+".name", fmt.Sprintf("tag %q is listed more than once", pt.GetName()))
+		default:
+			v.checkPattern(field+".name", pt.GetName())
+		}
+		seen[pt.GetName()] = true
+		v.checkTag(field, pt, rp)
+	}
+
+	if opts.Branches != nil {
+		v.checkLive(rp)
+	}
+	return v.findings
+}
+
+// validator accumulates the findings of a validation
+type validator struct {
+	opts     *ValidateOptions
+	findings Findings
+}
+
+func (v *validator) add(severity Severity, field, msg string) {
+	v.findings = append(v.findings, &Finding{Severity: severity, Field: field, Message: msg})
+}
+
+func (v *validator) checkPattern(field, name string) {
+	if !IsBranchPattern(name) {
+		return
+	}
+	if _, err := path.Match(name, ""); err != nil {
+		v.add(SeverityError, field, fmt.Sprintf("malformed pattern %q: %v", name, err))
+	}
+}
+
+func (v *validator) checkSince(field string, since *timestamppb.Timestamp) {
+	if since == nil {
+		v.add(SeverityError, field, "since date not set")
+		return
+	}
+	if since.AsTime().After(v.opts.Now) {
+		v.add(SeverityError, field, fmt.Sprintf("since date %s is in the future", since.AsTime().Format(time.RFC3339)))
+	}
+}
+
+func (v *validator) checkLevel(field, level string) {
+	if !slsa.IsSlsaSourceLevel(slsa.ControlName(level)) {
+		v.add(SeverityError, field, fmt.Sprintf("%q is not a valid SLSA source level", level))
+	}
+}
+
+func (v *validator) checkPropertyName(field, name string) {
+	if !strings.HasPrefix(name, slsa.AllowedOrgPropPrefix) {
+		v.add(SeverityError, field, fmt.Sprintf("property name %q must start with %s", name, slsa.AllowedOrgPropPrefix))
+	}
+}
+
+func (v *validator) checkRules(field string, rules []*PolicyRule) {
+	for i, rule := range rules {
+		if err := ValidateRule(rule); err != nil {
+			v.add(SeverityError, fmt.Sprintf("%s.rules[%d]", field, i), err.Error())
+		}
+	}
+}
+
+func (v *validator) checkBranch(field string, pb *ProtectedBranch) {
+	if pb.GetName() == "" {
+		v.add(SeverityError, field+".name", "branch entries must have a name or pattern")
+	}
+	v.checkPattern(field+".name", pb.GetName())
+	v.checkSince(field+".since", pb.GetSince())
+	v.checkLevel(field+".target_slsa_source_level", pb.GetTargetSlsaSourceLevel())
+
+	checks := map[string]bool{}
+	for i, oc := range pb.GetOrgStatusCheckControls() {
+		ocField := fmt.Sprintf("%s.org_status_check_controls[%d]", field, i)
+		v.checkPropertyName(ocField+".property_name", oc.GetPropertyName())
+		v.checkSince(ocField+".since", oc.GetSince())
+		if oc.GetCheckName() == "" {
+			v.add(SeverityError, ocField+".check_name", "check name not set")
+		} else if checks[oc.GetCheckName()] {
+			v.add(SeverityWarning, ocField+".check_name", fmt.Sprintf("check %q is listed more than once", oc.GetCheckName()))
+		}
+		checks[oc.GetCheckName()] = true
+	}
+	v.checkRules(field, pb.GetRules())
+}
+
+func (v *validator) checkTag(field string, pt *ProtectedTag, rp *RepoPolicy) {
+	// The since date only matters for tag hygiene
+	if pt.GetTagHygiene() || pt.GetSince() != nil {
+		v.checkSince(field+".since", pt.GetSince())
+	}
+	if pt.GetMinSourceLevel() != "" {
+		v.checkLevel(field+".min_source_level", pt.GetMinSourceLevel())
+	}
+	for i, b := range pt.GetRequiredBranches() {
+		bField := fmt.Sprintf("%s.required_branches[%d]", field, i)
+		v.checkPattern(bField, b)
+		if !IsBranchPattern(b) && rp.GetBranchPolicy(b) == nil {
+			v.add(SeverityWarning, bField, fmt.Sprintf("branch %q is not protected by the policy, its commits are only verified at %s", b, slsa.SlsaSourceLevel1))
+		}
+	}
+	v.checkRules(field, pt.GetRules())
+}
+
+// checkLive checks the policy can be met by the current controls of the
+// repository branches.
+func (v *validator) checkLive(rp *RepoPolicy) {
+	names := slices.Sorted(maps.Keys(v.opts.Branches))
+	for i, pb := range rp.GetProtectedBranches() {
+		field := fmt.Sprintf("protected_branches[%d]", i)
+		matched := false
+		for _, name := range names {
+			// Only check the branches this entry applies to
+			if rp.GetBranchPolicy(name) != pb {
+				continue
+			}
+			matched = true
+			v.checkBranchControls(field, name, pb, v.opts.Branches[name].GetActiveControls())
+		}
+		switch {
+		case matched:
+		case IsBranchPattern(pb.GetName()):
+			v.add(SeverityInfo, field+".name", fmt.Sprintf("no branch matching %q was checked against the repository", pb.GetName()))
+		default:
+			v.add(SeverityError, field+".name", fmt.Sprintf("branch %q not found in the repository", pb.GetName()))
+		}
+	}
+
+	// Tag protection applies to the whole repository, any branch reports it
+	if len(names) > 0 {
+		if _, err := computeTagHygiene(nil, rp.GetProtectedTag(), v.opts.Branches[names[0]].GetActiveControls()); err != nil {
+			v.add(SeverityError, "protected_tag.tag_hygiene", err.Error())
+		}
+	}
+}
+
+func (v *validator) checkBranchControls(field, branch string, pb *ProtectedBranch, controls *slsa.ControlSet) {
+	if _, shortfall := computeAchievableSlsaLevel(pb, controls); shortfall != nil {
+		v.add(SeverityError, field+".target_slsa_source_level", fmt.Sprintf("branch %q: %s", branch, shortfall.Reason))
+	}
+	if _, err := computeReviewEnforced(pb, nil, controls); err != nil {
+		v.add(SeverityError, field+".require_review", fmt.Sprintf("branch %q: %v", branch, err))
+	}
+	for i, oc := range pb.GetOrgStatusCheckControls() {
+		ocField := fmt.Sprintf("%s.org_status_check_controls[%d]", field, i)
+		control := controls.GetControl(ghcontrol.CheckNameToControlName(oc.GetCheckName()))
+		switch {
+		case control == nil:
+			v.add(SeverityError, ocField+".check_name", fmt.Sprintf(
+				"branch %q: check %q is not required by the repository rulesets", branch, oc.GetCheckName(),
+			))
+		case control.GetSince() != nil && oc.GetSince().AsTime().Before(*control.GetSince()):
+			v.add(SeverityError, ocField+".since", fmt.Sprintf(
+				"branch %q: check %q is only required since %v", branch, oc.GetCheckName(), *control.GetSince(),
+			))
+		}
+	}
+}
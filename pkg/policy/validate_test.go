@@ -0,0 +1,203 @@
+// SPDX-FileCopyrightText: Copyright 2026 The SLSA Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package policy
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/slsa-framework/source-tool/pkg/ghcontrol"
+	"github.com/slsa-framework/source-tool/pkg/slsa"
+)
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	since := timestamppb.New(now.Add(-30 * 24 * time.Hour))
+	future := timestamppb.New(now.Add(24 * time.Hour))
+
+	validPolicy := func() *RepoPolicy {
+		return &RepoPolicy{
+			CanonicalRepo: "https://github.com/example/repo",
+			ProtectedBranches: []*ProtectedBranch{{
+				Name:                  "main",
+				Since:                 since,
+				TargetSlsaSourceLevel: string(slsa.SlsaSourceLevel3),
+				OrgStatusCheckControls: []*OrgStatusCheckControl{
+					{CheckName: "test", PropertyName: "ORG_SOURCE_TESTED", Since: since},
+				},
+			}},
+			ProtectedTag: &ProtectedTag{Since: since, TagHygiene: true},
+		}
+	}
+
+	for _, tc := range []struct {
+		name     string
+		mutate   func(*RepoPolicy)
+		expected []*Finding
+	}{
+		{name: "valid", mutate: func(*RepoPolicy) {}},
+		{
+			name:   "no-canonical-repo",
+			mutate: func(rp *RepoPolicy) { rp.CanonicalRepo = "" },
+			expected: []*Finding{
+				{Severity: SeverityError, Field: "canonical_repo"},
+			},
+		},
+		{
+			name:   "no-branches",
+			mutate: func(rp *RepoPolicy) { rp.ProtectedBranches = nil },
+			expected: []*Finding{
+				{Severity: SeverityWarning, Field: "protected_branches"},
+			},
+		},
+		{
+			name: "bad-level-and-future-since",
+			mutate: func(rp *RepoPolicy) {
+				rp.ProtectedBranches[0].TargetSlsaSourceLevel = "LEVEL_3"
+				rp.ProtectedBranches[0].Since = future
+			},
+			expected: []*Finding{
+				{Severity: SeverityError, Field: "protected_branches[0].since"},
+				{Severity: SeverityError, Field: "protected_branches[0].target_slsa_source_level"},
+			},
+		},
+		{
+			name: "org-control-problems",
+			mutate: func(rp *RepoPolicy) {
+				rp.ProtectedBranches[0].OrgStatusCheckControls = append(
+					rp.ProtectedBranches[0].OrgStatusCheckControls,
+					&OrgStatusCheckControl{CheckName: "test", PropertyName: "TESTED"},
+				)
+			},
+			expected: []*Finding{
+				{Severity: SeverityError, Field: "protected_branches[0].org_status_check_controls[1].property_name"},
+				{Severity: SeverityError, Field: "protected_branches[0].org_status_check_controls[1].since"},
+				{Severity: SeverityWarning, Field: "protected_branches[0].org_status_check_controls[1].check_name"},
+			},
+		},
+		{
+			name: "duplicate-and-malformed-branches",
+			mutate: func(rp *RepoPolicy) {
+				rp.ProtectedBranches = append(rp.ProtectedBranches,
+					&ProtectedBranch{Name: "main", Since: since, TargetSlsaSourceLevel: string(slsa.SlsaSourceLevel1)},
+					&ProtectedBranch{Name: "release/[", Since: since, TargetSlsaSourceLevel: string(slsa.SlsaSourceLevel1)},
+				)
+			},
+			expected: []*Finding{
+				{Severity: SeverityError, Field: "protected_branches[1].name"},
+				{Severity: SeverityError, Field: "protected_branches[2].name"},
+			},
+		},
+		{
+			name: "invalid-rule",
+			mutate: func(rp *RepoPolicy) {
+				rp.ProtectedBranches[0].Rules = []*PolicyRule{{Name: "bad", Expression: "branch"}}
+			},
+			expected: []*Finding{
+				{Severity: SeverityError, Field: "protected_branches[0].rules[0]"},
+			},
+		},
+		{
+			name: "tag-problems",
+			mutate: func(rp *RepoPolicy) {
+				rp.ProtectedTags = []*ProtectedTag{
+					{Name: "v*", MinSourceLevel: "L3", RequiredBranches: []string{"main", "develop"}},
+					{Name: "v*"},
+					{},
+				}
+			},
+			expected: []*Finding{
+				{Severity: SeverityError, Field: "protected_tags[0].min_source_level"},
+				{Severity: SeverityWarning, Field: "protected_tags[0].required_branches[1]"},
+				{Severity: SeverityError, Field: "protected_tags[1].name"},
+				{Severity: SeverityError, Field: "protected_tags[2].name"},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			rp := validPolicy()
+			tc.mutate(rp)
+			requireFindings(t, tc.expected, Validate(rp, WithValidateTime(now)))
+		})
+	}
+}
+
+func TestValidateLive(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	since := now.Add(-30 * 24 * time.Hour)
+	before := since.Add(-24 * time.Hour)
+	after := since.Add(24 * time.Hour)
+
+	rp := &RepoPolicy{
+		CanonicalRepo: "https://github.com/example/repo",
+		ProtectedBranches: []*ProtectedBranch{
+			{
+				Name:                  "main",
+				Since:                 timestamppb.New(since),
+				TargetSlsaSourceLevel: string(slsa.SlsaSourceLevel2),
+				OrgStatusCheckControls: []*OrgStatusCheckControl{
+					{CheckName: "test", PropertyName: "ORG_SOURCE_TESTED", Since: timestamppb.New(since)},
+					{CheckName: "lint", PropertyName: "ORG_SOURCE_LINTED", Since: timestamppb.New(since)},
+				},
+			},
+			{Name: "release/*", Since: timestamppb.New(since), TargetSlsaSourceLevel: string(slsa.SlsaSourceLevel2)},
+			{Name: "develop", Since: timestamppb.New(since), TargetSlsaSourceLevel: string(slsa.SlsaSourceLevel1)},
+		},
+		ProtectedTag: &ProtectedTag{Since: timestamppb.New(since), TagHygiene: true},
+	}
+
+	controls := func(at time.Time, extra ...slsa.ControlName) *slsa.ControlSet {
+		cs := &slsa.ControlSet{}
+		for _, name := range append(slices.Clone(slsa.GetRequiredControlsForLevel(slsa.SlsaSourceLevel2)), extra...) {
+			cs.AddControl(&slsa.Control{Name: name, Since: &at, State: slsa.StateActive})
+		}
+		return cs
+	}
+
+	findings := Validate(rp, WithValidateTime(now), WithBranchControls(map[string]*slsa.ControlSet{
+		// Lint is not required and tags are protected after the policy date
+		"main": controls(before, ghcontrol.CheckNameToControlName("test"), slsa.SLSA_SOURCE_SCS_PROTECTED_REFS),
+		// Level 2 controls were enabled after the policy date
+		"release/1.0": controls(after),
+	}))
+	requireFindings(t, []*Finding{
+		{Severity: SeverityError, Field: "protected_branches[0].org_status_check_controls[1].check_name"},
+		{Severity: SeverityError, Field: "protected_branches[1].target_slsa_source_level"},
+		{Severity: SeverityError, Field: "protected_branches[2].name"},
+	}, findings)
+
+	findings = Validate(rp, WithValidateTime(now), WithBranchControls(map[string]*slsa.ControlSet{
+		"main": controls(after, ghcontrol.CheckNameToControlName("test"), ghcontrol.CheckNameToControlName("lint")),
+	}))
+	requireFindings(t, []*Finding{
+		{Severity: SeverityError, Field: "protected_branches[0].target_slsa_source_level"},
+		{Severity: SeverityError, Field: "protected_branches[0].org_status_check_controls[0].since"},
+		{Severity: SeverityError, Field: "protected_branches[0].org_status_check_controls[1].since"},
+		{Severity: SeverityInfo, Field: "protected_branches[1].name"},
+		{Severity: SeverityError, Field: "protected_branches[2].name"},
+		{Severity: SeverityError, Field: "protected_tag.tag_hygiene"},
+	}, findings)
+	require.True(t, findings.HasErrors())
+}
+
+// requireFindings checks the severities and fields of the findings
+func requireFindings(t *testing.T, expected []*Finding, findings Findings) {
+	t.Helper()
+	got := []*Finding{}
+	for _, f := range findings {
+		require.NotEmpty(t, f.Message)
+		got = append(got, &Finding{Severity: f.Severity, Field: f.Field})
+	}
+	if len(expected) == 0 {
+		expected = []*Finding{}
+	}
+	require.Equal(t, expected, got, findings.String())
+}
@@ -0,0 +1,222 @@
+// SPDX-FileCopyrightText: Copyright 2026 The SLSA Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package policy
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/slsa-framework/source-tool/pkg/sourcetool/models"
+)
+
+// DigestTypeSha256 is the digest of the policy contents recorded in the VSA
+const DigestTypeSha256 = "sha256"
+
+// PolicyPoint selects the version of a policy to read. The zero value
+// selects the latest policy.
+type PolicyPoint struct {
+	// Time reads the policy as it was at this time
+	Time *time.Time
+	// Commit reads the policy at this commit of the policy repository. It
+	// takes precedence over Time.
+	Commit string
+}
+
+// IsLatest returns true if the point selects the latest policy
+func (pp PolicyPoint) IsLatest() bool {
+	return pp.Time == nil && pp.Commit == ""
+}
+
+// PolicyVersion identifies the version of a policy read by the evaluator
+type PolicyVersion struct {
+	// Commit of the policy repository the policy was read from. Empty when
+	// reading a local policy file from disk.
+	Commit string
+	// Digest is the sha256 hex digest of the policy file
+	Digest string
+	// Latest is set when the policy was requested at a past point but the
+	// source has no history, the latest version was read instead.
+	Latest bool
+}
+
+// DigestSet returns the version as a digest set to record in the VSA policy
+// descriptor.
+func (pv *PolicyVersion) DigestSet() map[string]string {
+	if pv == nil {
+		return nil
+	}
+	ret := map[string]string{DigestTypeSha256: pv.Digest}
+	if pv.Commit != "" {
+		ret[models.DigestTypeGitCommit] = pv.Commit
+	}
+	return ret
+}
+
+// policyRevision is a commit of the policy repository that changed the
+// policy file of a repository.
+type policyRevision struct {
+	SHA  string
+	Date time.Time
+}
+
+// versionedPolicy is a policy read at a policy repository commit
+type versionedPolicy struct {
+	policy  *RepoPolicy
+	path    string
+	version *PolicyVersion
+}
+
+// GetPolicyAt fetches the policy for a repository as it was at a point in
+// time or commit of the policy repository. The policy is nil if there was no
+// policy for the repository at that point.
+//
+// Local policies (UseLocalPolicy) can only be resolved in the past when the
+// file is tracked in a git repository, its history is read from there.
+func (pe *PolicyEvaluator) GetPolicyAt(ctx context.Context, repo *models.Repository, at PolicyPoint) (*RepoPolicy, string, *PolicyVersion, error) {
+	return pe.policySource().GetPolicyAt(ctx, repo, at)
+}
+
+// revisionAt returns the latest revision at the time, nil meaning now.
+// Revisions must be sorted newest first.
+func revisionAt(revisions []*policyRevision, t *time.Time) *policyRevision {
+	for _, rev := range revisions {
+		if t == nil || !rev.Date.After(*t) {
+			return rev
+		}
+	}
+	return nil
+}
+
+// getLocalPolicyAt reads a local policy file. Reading it at a past point
+// requires the file to be tracked in git, files outside of a repository have
+// no history and are read as they are unless a commit is requested.
+func getLocalPolicyAt(path string, at PolicyPoint) (*RepoPolicy, string, *PolicyVersion, error) {
+	if at.Commit == "" && !isInGitRepo(path) {
+		at = PolicyPoint{}
+	}
+
+	if at.IsLatest() {
+		contents, err := os.ReadFile(path)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		p, err := ParsePolicy(contents)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		return p, path, &PolicyVersion{Digest: digestPolicy(contents)}, nil
+	}
+
+	commit, rel, err := localPolicyCommit(path, at)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("reading the history of %s: %w", path, err)
+	}
+	if commit == nil {
+		return nil, "", nil, nil
+	}
+
+	f, err := commit.File(rel)
+	if err != nil {
+		if errors.Is(err, object.ErrFileNotFound) {
+			return nil, "", nil, nil
+		}
+		return nil, "", nil, fmt.Errorf("reading %s at %s: %w", rel, commit.Hash, err)
+	}
+	contents, err := f.Contents()
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("reading %s at %s: %w", rel, commit.Hash, err)
+	}
+
+	p, err := ParsePolicy([]byte(contents))
+	if err != nil {
+		return nil, "", nil, err
+	}
+	return p, path, &PolicyVersion{Commit: commit.Hash.String(), Digest: digestPolicy([]byte(contents))}, nil
+}
+
+// localPolicyCommit finds the commit of the git repository containing the
+// policy file at the point. Returns the commit (nil if the file did not
+// exist then) and the path of the file in the repository.
+func localPolicyCommit(path string, at PolicyPoint) (*object.Commit, string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, "", err
+	}
+	repo, err := git.PlainOpenWithOptions(filepath.Dir(abs), &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return nil, "", err
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		return nil, "", err
+	}
+	rel, err := filepath.Rel(wt.Filesystem.Root(), abs)
+	if err != nil {
+		return nil, "", err
+	}
+	rel = filepath.ToSlash(rel)
+
+	if at.Commit != "" {
+		commit, err := repo.CommitObject(plumbing.NewHash(at.Commit))
+		if err != nil {
+			return nil, "", fmt.Errorf("reading commit %s: %w", at.Commit, err)
+		}
+		return commit, rel, nil
+	}
+
+	iter, err := repo.Log(&git.LogOptions{FileName: &rel, Order: git.LogOrderCommitterTime})
+	if err != nil {
+		return nil, "", err
+	}
+	defer iter.Close()
+	for {
+		commit, err := iter.Next()
+		if errors.Is(err, io.EOF) {
+			// The file did not exist at the time
+			return nil, rel, nil
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		if !commit.Committer.When.After(*at.Time) {
+			return commit, rel, nil
+		}
+	}
+}
+
+// isInGitRepo returns true if the file is inside a git repository
+func isInGitRepo(path string) bool {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	_, err = git.PlainOpenWithOptions(filepath.Dir(abs), &git.PlainOpenOptions{DetectDotGit: true})
+	return err == nil
+}
+
+// ParsePolicy parses the JSON encoded policy
+func ParsePolicy(contents []byte) (*RepoPolicy, error) {
+	var p RepoPolicy
+	if err := protojson.Unmarshal(contents, &p); err != nil {
+		return nil, fmt.Errorf("unmarshaling json: %w", err)
+	}
+	return &p, nil
+}
+
+func digestPolicy(contents []byte) string {
+	sum := sha256.Sum256(contents)
+	return hex.EncodeToString(sum[:])
+}
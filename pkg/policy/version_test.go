@@ -0,0 +1,169 @@
+// SPDX-FileCopyrightText: Copyright 2026 The SLSA Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package policy
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/google/go-github/v88/github"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/slsa-framework/source-tool/pkg/slsa"
+	"github.com/slsa-framework/source-tool/pkg/sourcetool/models"
+)
+
+func policyWithLevel(t *testing.T, level slsa.SlsaSourceLevel) []byte {
+	t.Helper()
+	data, err := protojson.Marshal(&RepoPolicy{
+		ProtectedBranches: []*ProtectedBranch{
+			{Name: "main", TargetSlsaSourceLevel: string(level), Since: timestamppb.New(fixedTime)},
+		},
+	})
+	require.NoError(t, err)
+	return data
+}
+
+func targetLevel(rp *RepoPolicy) string {
+	return rp.GetBranchPolicy("main").GetTargetSlsaSourceLevel()
+}
+
+func TestGetPolicyAtLocal(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+	wt, err := repo.Worktree()
+	require.NoError(t, err)
+
+	path := filepath.Join(dir, "policy.json")
+	t1 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	t2 := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	commits := []string{}
+	for i, level := range []slsa.SlsaSourceLevel{slsa.SlsaSourceLevel1, slsa.SlsaSourceLevel3} {
+		require.NoError(t, os.WriteFile(path, policyWithLevel(t, level), 0o600))
+		_, err := wt.Add("policy.json")
+		require.NoError(t, err)
+		sig := &object.Signature{Name: "Tester", Email: "test@example.com", When: []time.Time{t1, t2}[i]}
+		h, err := wt.Commit("update policy", &git.CommitOptions{Author: sig, Committer: sig})
+		require.NoError(t, err)
+		commits = append(commits, h.String())
+	}
+
+	pe := &PolicyEvaluator{UseLocalPolicy: path}
+	repository := &models.Repository{Hostname: "github.com", Path: "owner/repo"}
+	at := func(t time.Time) *time.Time { return &t }
+
+	for _, tc := range []struct {
+		name           string
+		point          PolicyPoint
+		expectedLevel  string
+		expectedCommit string
+	}{
+		{name: "latest", point: PolicyPoint{}, expectedLevel: string(slsa.SlsaSourceLevel3)},
+		{name: "before-policy", point: PolicyPoint{Time: at(t1.Add(-time.Hour))}},
+		{name: "first-version", point: PolicyPoint{Time: at(t1.Add(time.Hour))}, expectedLevel: string(slsa.SlsaSourceLevel1), expectedCommit: commits[0]},
+		{name: "second-version", point: PolicyPoint{Time: at(t2)}, expectedLevel: string(slsa.SlsaSourceLevel3), expectedCommit: commits[1]},
+		{name: "commit", point: PolicyPoint{Commit: commits[0]}, expectedLevel: string(slsa.SlsaSourceLevel1), expectedCommit: commits[0]},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			rp, _, version, err := pe.GetPolicyAt(t.Context(), repository, tc.point)
+			require.NoError(t, err)
+			if tc.expectedLevel == "" {
+				require.Nil(t, rp)
+				return
+			}
+			require.Equal(t, tc.expectedLevel, targetLevel(rp))
+			require.NotNil(t, version)
+			require.Equal(t, tc.expectedCommit, version.Commit)
+			require.Len(t, version.Digest, 64)
+		})
+	}
+}
+
+func TestGetPolicyAtRemote(t *testing.T) {
+	t.Parallel()
+	t1 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	t2 := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	contents := map[string][]byte{
+		"aaa": policyWithLevel(t, slsa.SlsaSourceLevel1),
+		"bbb": policyWithLevel(t, slsa.SlsaSourceLevel3),
+	}
+
+	var contentRequests atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/repos/slsa-framework/source-policies/commits", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "policy/github.com/owner/repo/source-policy.json", r.URL.Query().Get("path"))
+		data, err := json.Marshal([]map[string]any{
+			{"sha": "bbb", "commit": map[string]any{"committer": map[string]any{"date": t2}}},
+			{"sha": "aaa", "commit": map[string]any{"committer": map[string]any{"date": t1}}},
+		})
+		require.NoError(t, err)
+		_, err = w.Write(data)
+		require.NoError(t, err)
+	})
+	mux.HandleFunc("/api/v3/repos/slsa-framework/source-policies/contents/", func(w http.ResponseWriter, r *http.Request) {
+		contentRequests.Add(1)
+		ref := r.URL.Query().Get("ref")
+		content, ok := contents[ref]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		data, err := json.Marshal(&github.RepositoryContent{
+			Type:     github.Ptr("file"),
+			Encoding: github.Ptr("base64"),
+			Content:  github.Ptr(base64.StdEncoding.EncodeToString(content)),
+			HTMLURL:  github.Ptr(fmt.Sprintf("https://github.com/slsa-framework/source-policies/blob/%s/policy.json", ref)),
+		})
+		require.NoError(t, err)
+		_, err = w.Write(data)
+		require.NoError(t, err)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	client, err := github.NewClient(
+		github.WithHTTPClient(server.Client()),
+		github.WithEnterpriseURLs(server.URL+"/", server.URL+"/"),
+	)
+	require.NoError(t, err)
+
+	pe := &PolicyEvaluator{client: client}
+	repository := &models.Repository{Hostname: "github.com", Path: "owner/repo"}
+	at := func(t time.Time) *time.Time { return &t }
+
+	rp, path, version, err := pe.GetPolicyAt(t.Context(), repository, PolicyPoint{})
+	require.NoError(t, err)
+	require.Equal(t, string(slsa.SlsaSourceLevel3), targetLevel(rp))
+	require.Equal(t, "bbb", version.Commit)
+	require.Contains(t, path, "/blob/bbb/")
+
+	rp, _, version, err = pe.GetPolicyAt(t.Context(), repository, PolicyPoint{Time: at(t2.Add(-time.Hour))})
+	require.NoError(t, err)
+	require.Equal(t, string(slsa.SlsaSourceLevel1), targetLevel(rp))
+	require.Equal(t, "aaa", version.Commit)
+	require.Equal(t, map[string]string{"sha256": version.Digest, "gitCommit": "aaa"}, version.DigestSet())
+
+	rp, _, _, err = pe.GetPolicyAt(t.Context(), repository, PolicyPoint{Time: at(t1.Add(-time.Hour))})
+	require.NoError(t, err)
+	require.Nil(t, rp)
+
+	// Versions are cached
+	_, _, _, err = pe.GetPolicyAt(t.Context(), repository, PolicyPoint{Commit: "aaa"})
+	require.NoError(t, err)
+	require.Equal(t, int32(2), contentRequests.Load())
+}
@@ -65,3 +65,23 @@ func (ctl *Control) MarshalJSON() ([]byte, error) {
 		},
 	)
 }
+
+func (iv *Interval) MarshalJSON() ([]byte, error) {
+	var start, end string
+	if iv.GetStart() != nil {
+		start = iv.GetStart().AsTime().Format("2006-01-02T15:04:05.000Z")
+	}
+	if iv.GetEnd() != nil {
+		end = iv.GetEnd().AsTime().Format("2006-01-02T15:04:05.000Z")
+	}
+
+	return json.Marshal(
+		&struct {
+			Start string `json:"start"`
+			End   string `json:"end,omitempty"`
+		}{
+			Start: start,
+			End:   end,
+		},
+	)
+}
@@ -37,7 +37,10 @@ type SourceProvenancePred struct {
 	Branch       string                 `protobuf:"bytes,5,opt,name=branch,proto3" json:"branch,omitempty"`
 	CreatedOn    *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=created_on,json=createdOn,proto3,oneof" json:"created_on,omitempty"` // TODO: get the author of the PR (if this was from a PR).
 	// The controls enabled at the time this commit was pushed.
-	Controls      []*Control `protobuf:"bytes,7,rep,name=controls,proto3" json:"controls,omitempty"`
+	Controls []*Control `protobuf:"bytes,7,rep,name=controls,proto3" json:"controls,omitempty"`
+	// All the parents of the commit, first parent first. For merge commits
+	// prev_commit is the first parent, the rest are the merged-in commits.
+	Parents       []string `protobuf:"bytes,8,rep,name=parents,proto3" json:"parents,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -121,12 +124,23 @@ func (x *SourceProvenancePred) GetControls() []*Control {
 	return nil
 }
 
+func (x *SourceProvenancePred) GetParents() []string {
+	if x != nil {
+		return x.Parents
+	}
+	return nil
+}
+
 type Control struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
 	// The name of the control
 	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
 	// The time from which this control has been continuously enforced/observed.
-	Since         *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=since,proto3" json:"since,omitempty"`
+	Since *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=since,proto3" json:"since,omitempty"`
+	// The windows during which the control was enforced, oldest first. The
+	// gaps between them are the times when the control was disabled. When set,
+	// since is the start of the last interval. Older provenance only has since.
+	Intervals     []*Interval `protobuf:"bytes,3,rep,name=intervals,proto3" json:"intervals,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -175,6 +189,67 @@ func (x *Control) GetSince() *timestamppb.Timestamp {
 	return nil
 }
 
+func (x *Control) GetIntervals() []*Interval {
+	if x != nil {
+		return x.Intervals
+	}
+	return nil
+}
+
+// A window of time during which a control was enforced.
+type Interval struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Start *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=start,proto3" json:"start,omitempty"`
+	// The end of the window, not set if the control is still enforced.
+	End           *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=end,proto3" json:"end,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Interval) Reset() {
+	*x = Interval{}
+	mi := &file_provenance_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Interval) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Interval) ProtoMessage() {}
+
+func (x *Interval) ProtoReflect() protoreflect.Message {
+	mi := &file_provenance_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Interval.ProtoReflect.Descriptor instead.
+func (*Interval) Descriptor() ([]byte, []int) {
+	return file_provenance_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Interval) GetStart() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Start
+	}
+	return nil
+}
+
+func (x *Interval) GetEnd() *timestamppb.Timestamp {
+	if x != nil {
+		return x.End
+	}
+	return nil
+}
+
 type TagProvenancePred struct {
 	state     protoimpl.MessageState `protogen:"open.v1"`
 	RepoUri   string                 `protobuf:"bytes,1,opt,name=repo_uri,json=repoUri,proto3" json:"repo_uri,omitempty"`
@@ -182,15 +257,19 @@ type TagProvenancePred struct {
 	Tag       string                 `protobuf:"bytes,3,opt,name=tag,proto3" json:"tag,omitempty"`
 	CreatedOn *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=created_on,json=createdOn,proto3,oneof" json:"created_on,omitempty"`
 	// The tag related controls enabled at the time this tag was created/updated.
-	Controls      []*Control    `protobuf:"bytes,7,rep,name=controls,proto3" json:"controls,omitempty"`
-	VsaSummaries  []*VsaSummary `protobuf:"bytes,8,rep,name=vsa_summaries,json=vsaSummaries,proto3" json:"vsa_summaries,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	Controls     []*Control    `protobuf:"bytes,7,rep,name=controls,proto3" json:"controls,omitempty"`
+	VsaSummaries []*VsaSummary `protobuf:"bytes,8,rep,name=vsa_summaries,json=vsaSummaries,proto3" json:"vsa_summaries,omitempty"`
+	// True when the tag points to an annotated tag object.
+	Annotated bool `protobuf:"varint,9,opt,name=annotated,proto3" json:"annotated,omitempty"`
+	// True when the tag object is signed and the VCS host verified the signature.
+	SignatureVerified bool `protobuf:"varint,10,opt,name=signature_verified,json=signatureVerified,proto3" json:"signature_verified,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
 }
 
 func (x *TagProvenancePred) Reset() {
 	*x = TagProvenancePred{}
-	mi := &file_provenance_proto_msgTypes[2]
+	mi := &file_provenance_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -202,7 +281,7 @@ func (x *TagProvenancePred) String() string {
 func (*TagProvenancePred) ProtoMessage() {}
 
 func (x *TagProvenancePred) ProtoReflect() protoreflect.Message {
-	mi := &file_provenance_proto_msgTypes[2]
+	mi := &file_provenance_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -215,7 +294,7 @@ func (x *TagProvenancePred) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TagProvenancePred.ProtoReflect.Descriptor instead.
 func (*TagProvenancePred) Descriptor() ([]byte, []int) {
-	return file_provenance_proto_rawDescGZIP(), []int{2}
+	return file_provenance_proto_rawDescGZIP(), []int{3}
 }
 
 func (x *TagProvenancePred) GetRepoUri() string {
@@ -260,6 +339,20 @@ func (x *TagProvenancePred) GetVsaSummaries() []*VsaSummary {
 	return nil
 }
 
+func (x *TagProvenancePred) GetAnnotated() bool {
+	if x != nil {
+		return x.Annotated
+	}
+	return false
+}
+
+func (x *TagProvenancePred) GetSignatureVerified() bool {
+	if x != nil {
+		return x.SignatureVerified
+	}
+	return false
+}
+
 // Summary of a summary
 type VsaSummary struct {
 	state          protoimpl.MessageState `protogen:"open.v1"`
@@ -271,7 +364,7 @@ type VsaSummary struct {
 
 func (x *VsaSummary) Reset() {
 	*x = VsaSummary{}
-	mi := &file_provenance_proto_msgTypes[3]
+	mi := &file_provenance_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -283,7 +376,7 @@ func (x *VsaSummary) String() string {
 func (*VsaSummary) ProtoMessage() {}
 
 func (x *VsaSummary) ProtoReflect() protoreflect.Message {
-	mi := &file_provenance_proto_msgTypes[3]
+	mi := &file_provenance_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -296,7 +389,7 @@ func (x *VsaSummary) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use VsaSummary.ProtoReflect.Descriptor instead.
 func (*VsaSummary) Descriptor() ([]byte, []int) {
-	return file_provenance_proto_rawDescGZIP(), []int{3}
+	return file_provenance_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *VsaSummary) GetSourceRefs() []string {
@@ -317,7 +410,7 @@ var File_provenance_proto protoreflect.FileDescriptor
 
 const file_provenance_proto_rawDesc = "" +
 	"\n" +
-	"\x10provenance.proto\x123in_toto_attestation.predicates.source_provenance.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\xce\x02\n" +
+	"\x10provenance.proto\x123in_toto_attestation.predicates.source_provenance.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\xe8\x02\n" +
 	"\x14SourceProvenancePred\x12\x1f\n" +
 	"\vprev_commit\x18\x01 \x01(\tR\n" +
 	"prevCommit\x12\x19\n" +
@@ -327,11 +420,16 @@ const file_provenance_proto_rawDesc = "" +
 	"\x06branch\x18\x05 \x01(\tR\x06branch\x12>\n" +
 	"\n" +
 	"created_on\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampH\x00R\tcreatedOn\x88\x01\x01\x12X\n" +
-	"\bcontrols\x18\a \x03(\v2<.in_toto_attestation.predicates.source_provenance.v1.ControlR\bcontrolsB\r\n" +
-	"\v_created_on\"O\n" +
+	"\bcontrols\x18\a \x03(\v2<.in_toto_attestation.predicates.source_provenance.v1.ControlR\bcontrols\x12\x18\n" +
+	"\aparents\x18\b \x03(\tR\aparentsB\r\n" +
+	"\v_created_on\"\xac\x01\n" +
 	"\aControl\x12\x12\n" +
 	"\x04name\x18\x01 \x01(\tR\x04name\x120\n" +
-	"\x05since\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x05since\"\xe5\x02\n" +
+	"\x05since\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x05since\x12[\n" +
+	"\tintervals\x18\x03 \x03(\v2=.in_toto_attestation.predicates.source_provenance.v1.IntervalR\tintervals\"j\n" +
+	"\bInterval\x120\n" +
+	"\x05start\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x05start\x12,\n" +
+	"\x03end\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x03end\"\xb2\x03\n" +
 	"\x11TagProvenancePred\x12\x19\n" +
 	"\brepo_uri\x18\x01 \x01(\tR\arepoUri\x12\x14\n" +
 	"\x05actor\x18\x02 \x01(\tR\x05actor\x12\x10\n" +
@@ -339,7 +437,10 @@ const file_provenance_proto_rawDesc = "" +
 	"\n" +
 	"created_on\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampH\x00R\tcreatedOn\x88\x01\x01\x12X\n" +
 	"\bcontrols\x18\a \x03(\v2<.in_toto_attestation.predicates.source_provenance.v1.ControlR\bcontrols\x12d\n" +
-	"\rvsa_summaries\x18\b \x03(\v2?.in_toto_attestation.predicates.source_provenance.v1.VsaSummaryR\fvsaSummariesB\r\n" +
+	"\rvsa_summaries\x18\b \x03(\v2?.in_toto_attestation.predicates.source_provenance.v1.VsaSummaryR\fvsaSummaries\x12\x1c\n" +
+	"\tannotated\x18\t \x01(\bR\tannotated\x12-\n" +
+	"\x12signature_verified\x18\n" +
+	" \x01(\bR\x11signatureVerifiedB\r\n" +
 	"\v_created_on\"V\n" +
 	"\n" +
 	"VsaSummary\x12\x1f\n" +
@@ -360,26 +461,30 @@ func file_provenance_proto_rawDescGZIP() []byte {
 	return file_provenance_proto_rawDescData
 }
 
-var file_provenance_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_provenance_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
 var file_provenance_proto_goTypes = []any{
 	(*SourceProvenancePred)(nil),  // 0: in_toto_attestation.predicates.source_provenance.v1.SourceProvenancePred
 	(*Control)(nil),               // 1: in_toto_attestation.predicates.source_provenance.v1.Control
-	(*TagProvenancePred)(nil),     // 2: in_toto_attestation.predicates.source_provenance.v1.TagProvenancePred
-	(*VsaSummary)(nil),            // 3: in_toto_attestation.predicates.source_provenance.v1.VsaSummary
-	(*timestamppb.Timestamp)(nil), // 4: google.protobuf.Timestamp
+	(*Interval)(nil),              // 2: in_toto_attestation.predicates.source_provenance.v1.Interval
+	(*TagProvenancePred)(nil),     // 3: in_toto_attestation.predicates.source_provenance.v1.TagProvenancePred
+	(*VsaSummary)(nil),            // 4: in_toto_attestation.predicates.source_provenance.v1.VsaSummary
+	(*timestamppb.Timestamp)(nil), // 5: google.protobuf.Timestamp
 }
 var file_provenance_proto_depIdxs = []int32{
-	4, // 0: in_toto_attestation.predicates.source_provenance.v1.SourceProvenancePred.created_on:type_name -> google.protobuf.Timestamp
+	5, // 0: in_toto_attestation.predicates.source_provenance.v1.SourceProvenancePred.created_on:type_name -> google.protobuf.Timestamp
 	1, // 1: in_toto_attestation.predicates.source_provenance.v1.SourceProvenancePred.controls:type_name -> in_toto_attestation.predicates.source_provenance.v1.Control
-	4, // 2: in_toto_attestation.predicates.source_provenance.v1.Control.since:type_name -> google.protobuf.Timestamp
-	4, // 3: in_toto_attestation.predicates.source_provenance.v1.TagProvenancePred.created_on:type_name -> google.protobuf.Timestamp
-	1, // 4: in_toto_attestation.predicates.source_provenance.v1.TagProvenancePred.controls:type_name -> in_toto_attestation.predicates.source_provenance.v1.Control
-	3, // 5: in_toto_attestation.predicates.source_provenance.v1.TagProvenancePred.vsa_summaries:type_name -> in_toto_attestation.predicates.source_provenance.v1.VsaSummary
-	6, // [6:6] is the sub-list for method output_type
-	6, // [6:6] is the sub-list for method input_type
-	6, // [6:6] is the sub-list for extension type_name
-	6, // [6:6] is the sub-list for extension extendee
-	0, // [0:6] is the sub-list for field type_name
+	5, // 2: in_toto_attestation.predicates.source_provenance.v1.Control.since:type_name -> google.protobuf.Timestamp
+	2, // 3: in_toto_attestation.predicates.source_provenance.v1.Control.intervals:type_name -> in_toto_attestation.predicates.source_provenance.v1.Interval
+	5, // 4: in_toto_attestation.predicates.source_provenance.v1.Interval.start:type_name -> google.protobuf.Timestamp
+	5, // 5: in_toto_attestation.predicates.source_provenance.v1.Interval.end:type_name -> google.protobuf.Timestamp
+	5, // 6: in_toto_attestation.predicates.source_provenance.v1.TagProvenancePred.created_on:type_name -> google.protobuf.Timestamp
+	1, // 7: in_toto_attestation.predicates.source_provenance.v1.TagProvenancePred.controls:type_name -> in_toto_attestation.predicates.source_provenance.v1.Control
+	4, // 8: in_toto_attestation.predicates.source_provenance.v1.TagProvenancePred.vsa_summaries:type_name -> in_toto_attestation.predicates.source_provenance.v1.VsaSummary
+	9, // [9:9] is the sub-list for method output_type
+	9, // [9:9] is the sub-list for method input_type
+	9, // [9:9] is the sub-list for extension type_name
+	9, // [9:9] is the sub-list for extension extendee
+	0, // [0:9] is the sub-list for field type_name
 }
 
 func init() { file_provenance_proto_init() }
@@ -388,14 +493,14 @@ func file_provenance_proto_init() {
 		return
 	}
 	file_provenance_proto_msgTypes[0].OneofWrappers = []any{}
-	file_provenance_proto_msgTypes[2].OneofWrappers = []any{}
+	file_provenance_proto_msgTypes[3].OneofWrappers = []any{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_provenance_proto_rawDesc), len(file_provenance_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   4,
+			NumMessages:   5,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
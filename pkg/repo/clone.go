@@ -132,6 +132,19 @@ func (c *Clone) Add(path string) error {
 	return nil
 }
 
+// Remove deletes a file from the worktree and the staging area
+func (c *Clone) Remove(path string) error {
+	wtree, err := c.repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("getting clone worktree: %w", err)
+	}
+
+	if _, err := wtree.Remove(path); err != nil {
+		return fmt.Errorf("removing file: %w", err)
+	}
+	return nil
+}
+
 // Add adds all modified files to the staging area
 func (c *Clone) AddAll() error {
 	wtree, err := c.repo.Worktree()
@@ -238,6 +251,12 @@ func (c *Clone) AddFiles(clone *Clone, files []*PullRequestFileEntry) error {
 		if fentry.Path == "" {
 			return fmt.Errorf("file entry #%d has no path set", i)
 		}
+		if fentry.Remove {
+			if err := clone.Remove(fentry.Path); err != nil {
+				return fmt.Errorf("removing %q from the cloned repo: %w", fentry.Path, err)
+			}
+			continue
+		}
 		file, err := clone.fs.Create(fentry.Path)
 		if err != nil {
 			return fmt.Errorf("creating file in cloned repo: %w", err)
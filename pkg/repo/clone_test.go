@@ -107,3 +107,75 @@ func TestCloneAddFiles_CreatesValidCommit(t *testing.T) {
 		t.Errorf("workflow.yaml not found in commit tree: %v", err)
 	}
 }
+
+// TestCloneAddFiles_RemovesFiles checks that entries marked for removal are
+// deleted from the commit tree.
+func TestCloneAddFiles_RemovesFiles(t *testing.T) {
+	repo, err := git.Init(memory.NewStorage(), memfs.New())
+	if err != nil {
+		t.Fatalf("Failed to initialize test repository: %v", err)
+	}
+	wtree, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("Failed to get worktree: %v", err)
+	}
+
+	clone := &Clone{
+		Repository: models.Repository{
+			Hostname: "github.com",
+			Path:     "test/repo",
+		},
+		repo: repo,
+		fs:   wtree.Filesystem,
+	}
+
+	useGit := false
+	commitOpts := &options.CommitOptions{
+		Name:   "Workflow Bot",
+		Email:  "bot@example.com",
+		UseGit: &useGit,
+	}
+
+	// Commit the file first
+	if err := clone.AddFiles(clone, []*PullRequestFileEntry{
+		{Path: "workflow.yaml", Reader: strings.NewReader("name: Test Workflow\n")},
+		{Path: "README.md", Reader: strings.NewReader("# Test\n")},
+	}); err != nil {
+		t.Fatalf("AddFiles failed: %v", err)
+	}
+	commitOpts.Message = "Add SLSA Source Provenance Workflow"
+	if err := clone.Commit(commitOpts); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+
+	// Now remove it
+	if err := clone.AddFiles(clone, []*PullRequestFileEntry{
+		{Path: "workflow.yaml", Remove: true},
+	}); err != nil {
+		t.Fatalf("AddFiles failed: %v", err)
+	}
+	commitOpts.Message = "Remove SLSA Source Provenance Workflow"
+	if err := clone.Commit(commitOpts); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+
+	ref, err := repo.Head()
+	if err != nil {
+		t.Fatalf("Failed to get HEAD reference: %v", err)
+	}
+	commit, err := repo.CommitObject(ref.Hash())
+	if err != nil {
+		t.Fatalf("Failed to get commit object: %v", err)
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		t.Fatalf("Failed to get commit tree: %v", err)
+	}
+
+	if _, err := tree.File("workflow.yaml"); err == nil {
+		t.Errorf("workflow.yaml should have been removed from the commit tree")
+	}
+	if _, err := tree.File("README.md"); err != nil {
+		t.Errorf("README.md not found in commit tree: %v", err)
+	}
+}
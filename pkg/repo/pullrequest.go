@@ -56,6 +56,9 @@ type PullRequestFileEntry struct {
 
 	// io Reader to read the file data
 	Reader io.Reader
+
+	// Remove deletes the file from the repository instead of writing it
+	Remove bool
 }
 
 // CloneRepo clones the remote repository either to disk or to a memory filesystem
@@ -78,7 +81,7 @@ func (prm *PullRequestManager) CreatePullRequest(repo *models.Repository, opts *
 
 // PullRequestFiles gets a list of files and opens a pull request in a repo
 // to check them in. If the files already exist in the repo they will be
-// updated with the new versions.
+// updated with the new versions. Entries marked for removal are deleted.
 func (prm *PullRequestManager) PullRequestFileList(
 	repo *models.Repository, opts *options.PullRequestFileListOptions, files []*PullRequestFileEntry,
 ) (*models.PullRequest, error) {
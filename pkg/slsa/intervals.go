@@ -0,0 +1,177 @@
+// SPDX-FileCopyrightText: Copyright 2026 The SLSA Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package slsa
+
+import (
+	"slices"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/slsa-framework/source-tool/pkg/provenance"
+)
+
+// Interval is a window of time during which a control was enforced. An
+// interval with no End is still open (the control is enforced to date).
+type Interval struct {
+	Start time.Time
+	End   *time.Time
+}
+
+// Contains returns true if the time falls within the interval. As with the
+// since dates, controls only cover times strictly after they were enabled.
+func (i Interval) Contains(t time.Time) bool {
+	if !t.After(i.Start) {
+		return false
+	}
+	return i.End == nil || t.Before(*i.End)
+}
+
+// Intervals is a list of enforcement windows
+type Intervals []Interval
+
+// Normalize returns the intervals sorted by start time with the overlapping
+// and adjacent windows merged.
+func (is Intervals) Normalize() Intervals {
+	if len(is) == 0 {
+		return Intervals{}
+	}
+	sorted := slices.Clone(is)
+	slices.SortFunc(sorted, func(a, b Interval) int {
+		return a.Start.Compare(b.Start)
+	})
+
+	ret := Intervals{sorted[0]}
+	for _, iv := range sorted[1:] {
+		last := &ret[len(ret)-1]
+		if last.End != nil && iv.Start.After(*last.End) {
+			ret = append(ret, iv)
+			continue
+		}
+		// The windows touch, extend the last one
+		if last.End != nil && (iv.End == nil || iv.End.After(*last.End)) {
+			last.End = iv.End
+		}
+	}
+	return ret
+}
+
+// Union returns the windows where either of the interval lists is active
+func (is Intervals) Union(other Intervals) Intervals {
+	return append(slices.Clone(is), other...).Normalize()
+}
+
+// Intersect returns the windows where both interval lists are active
+func (is Intervals) Intersect(other Intervals) Intervals {
+	a, b := is.Normalize(), other.Normalize()
+	ret := Intervals{}
+	for _, x := range a {
+		for _, y := range b {
+			start := x.Start
+			if y.Start.After(start) {
+				start = y.Start
+			}
+			end := earlierEnd(x.End, y.End)
+			if end != nil && !end.After(start) {
+				continue
+			}
+			ret = append(ret, Interval{Start: start, End: end})
+		}
+	}
+	return ret.Normalize()
+}
+
+// At returns the (normalized) interval containing the time or nil if the
+// time falls in a gap.
+func (is Intervals) At(t time.Time) *Interval {
+	for _, iv := range is.Normalize() {
+		if iv.Contains(t) {
+			return &iv
+		}
+	}
+	return nil
+}
+
+// Gaps returns the windows between the intervals, when the control was not
+// enforced. The time before the first interval is not considered a gap.
+func (is Intervals) Gaps() Intervals {
+	norm := is.Normalize()
+	ret := Intervals{}
+	for i := 1; i < len(norm); i++ {
+		end := norm[i].Start
+		ret = append(ret, Interval{Start: *norm[i-1].End, End: &end})
+	}
+	return ret
+}
+
+// Last returns the most recent interval or nil if the list is empty
+func (is Intervals) Last() *Interval {
+	norm := is.Normalize()
+	if len(norm) == 0 {
+		return nil
+	}
+	return &norm[len(norm)-1]
+}
+
+// ClipBefore returns the intervals truncated to end at t. Intervals starting
+// at or after t are dropped.
+func (is Intervals) ClipBefore(t time.Time) Intervals {
+	ret := Intervals{}
+	for _, iv := range is.Normalize() {
+		if !iv.Start.Before(t) {
+			break
+		}
+		if iv.End == nil || iv.End.After(t) {
+			end := t
+			iv.End = &end
+		}
+		ret = append(ret, iv)
+	}
+	return ret
+}
+
+// ToProvenance converts the intervals to their provenance representation
+func (is Intervals) ToProvenance() []*provenance.Interval {
+	ret := []*provenance.Interval{}
+	for _, iv := range is {
+		piv := &provenance.Interval{Start: timestamppb.New(iv.Start)}
+		if iv.End != nil {
+			piv.End = timestamppb.New(*iv.End)
+		}
+		ret = append(ret, piv)
+	}
+	return ret
+}
+
+// IntervalsFromProvenance reads the intervals recorded in a provenance
+// control. Returns nil when there are none (older provenance).
+func IntervalsFromProvenance(pivs []*provenance.Interval) Intervals {
+	if len(pivs) == 0 {
+		return nil
+	}
+	ret := Intervals{}
+	for _, piv := range pivs {
+		iv := Interval{Start: piv.GetStart().AsTime()}
+		if piv.GetEnd() != nil {
+			end := piv.GetEnd().AsTime()
+			iv.End = &end
+		}
+		ret = append(ret, iv)
+	}
+	return ret.Normalize()
+}
+
+// earlierEnd returns the earliest of two interval ends, nil meaning open
+func earlierEnd(a, b *time.Time) *time.Time {
+	switch {
+	case a == nil:
+		return b
+	case b == nil:
+		return a
+	case a.Before(*b):
+		return a
+	default:
+		return b
+	}
+}
@@ -0,0 +1,84 @@
+// SPDX-FileCopyrightText: Copyright 2026 The SLSA Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package slsa
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIntervals(t *testing.T) {
+	t.Parallel()
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(h int) time.Time { return base.Add(time.Duration(h) * time.Hour) }
+	end := func(h int) *time.Time { t := at(h); return &t }
+
+	t.Run("normalize", func(t *testing.T) {
+		t.Parallel()
+		got := Intervals{
+			{Start: at(5), End: end(8)},
+			{Start: at(0), End: end(2)},
+			{Start: at(2), End: end(3)},
+			{Start: at(7)},
+		}.Normalize()
+		require.Equal(t, Intervals{{Start: at(0), End: end(3)}, {Start: at(5)}}, got)
+	})
+
+	t.Run("intersect", func(t *testing.T) {
+		t.Parallel()
+		a := Intervals{{Start: at(0), End: end(4)}, {Start: at(6)}}
+		b := Intervals{{Start: at(2), End: end(7)}}
+		require.Equal(t, Intervals{{Start: at(2), End: end(4)}, {Start: at(6), End: end(7)}}, a.Intersect(b))
+		require.Empty(t, a.Intersect(Intervals{{Start: at(4), End: end(5)}}))
+	})
+
+	t.Run("at", func(t *testing.T) {
+		t.Parallel()
+		ivs := Intervals{{Start: at(0), End: end(2)}, {Start: at(2), End: end(3)}, {Start: at(5)}}
+		require.Nil(t, ivs.At(at(0)))
+		require.Equal(t, at(0), ivs.At(at(2)).Start)
+		require.Nil(t, ivs.At(at(4)))
+		require.Equal(t, at(5), ivs.At(at(100)).Start)
+	})
+
+	t.Run("gaps", func(t *testing.T) {
+		t.Parallel()
+		ivs := Intervals{{Start: at(5)}, {Start: at(0), End: end(2)}, {Start: at(2), End: end(3)}}
+		require.Equal(t, Intervals{{Start: at(3), End: end(5)}}, ivs.Gaps())
+		require.Empty(t, Intervals{{Start: at(0)}}.Gaps())
+	})
+
+	t.Run("clip", func(t *testing.T) {
+		t.Parallel()
+		ivs := Intervals{{Start: at(0), End: end(2)}, {Start: at(3)}}
+		require.Equal(t, Intervals{{Start: at(0), End: end(2)}, {Start: at(3), End: end(4)}}, ivs.ClipBefore(at(4)))
+		require.Equal(t, Intervals{{Start: at(0), End: end(1)}}, ivs.ClipBefore(at(1)))
+		require.Empty(t, ivs.ClipBefore(at(0)))
+	})
+
+	t.Run("provenance", func(t *testing.T) {
+		t.Parallel()
+		ctl := &Control{
+			Name: SLSA_SOURCE_SCS_CONTINUITY, State: StateActive, Since: end(5),
+			Intervals: Intervals{{Start: at(0), End: end(2)}, {Start: at(5)}},
+		}
+		set := &ControlSet{Controls: []*Control{ctl}}
+		got := NewControlSetFromProvanenaceControls(set.ToProvenanceControls())
+		require.Len(t, got.Controls, 1)
+		require.True(t, at(5).Equal(*got.Controls[0].GetSince()))
+		require.Len(t, got.Controls[0].GetIntervals(), 2)
+		require.True(t, at(2).Equal(*got.Controls[0].GetIntervals()[0].End))
+
+		// Continuous controls and older provenance only record since
+		ctl.Intervals = nil
+		prov := set.ToProvenanceControls()
+		require.Empty(t, prov[0].GetIntervals())
+		got = NewControlSetFromProvanenaceControls(prov)
+		require.Nil(t, got.Controls[0].Intervals)
+		require.Len(t, got.Controls[0].GetIntervals(), 1)
+		require.True(t, at(5).Equal(got.Controls[0].GetIntervals()[0].Start))
+	})
+}
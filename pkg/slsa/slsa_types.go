@@ -94,9 +94,10 @@ func NewControlSetFromProvanenaceControls(provControls []*provenance.Control) *C
 	for _, ctl := range provControls {
 		t := ctl.GetSince().AsTime()
 		set.Controls = append(set.Controls, &Control{
-			Name:  ControlName(ctl.GetName()),
-			State: StateActive,
-			Since: &t,
+			Name:      ControlName(ctl.GetName()),
+			State:     StateActive,
+			Since:     &t,
+			Intervals: IntervalsFromProvenance(ctl.GetIntervals()),
 		})
 	}
 	return set
@@ -139,9 +140,13 @@ type ControlSet struct {
 
 // Control captures the status of a control as seen from a VCS system
 type Control struct {
-	Name              ControlName
-	State             ControlState `json:"control_state"`
-	Since             *time.Time   `json:"since,omitempty"`
+	Name  ControlName
+	State ControlState `json:"control_state"`
+	Since *time.Time   `json:"since,omitempty"`
+	// Intervals are the windows when the control was enforced. They are only
+	// set when the backend knows the control history, Since is then the
+	// start of the last window.
+	Intervals         Intervals `json:"intervals,omitempty"`
 	Message           string
 	RecommendedAction *ControlRecommendedAction
 }
@@ -154,6 +159,19 @@ func (cs *Control) GetSince() *time.Time {
 	return cs.Since
 }
 
+// GetIntervals returns the enforcement windows of the control. When the
+// history is not known, the control is assumed to be enforced continuously
+// from its since date. Returns nil if the control has no since date.
+func (cs *Control) GetIntervals() Intervals {
+	if len(cs.Intervals) > 0 {
+		return cs.Intervals.Normalize()
+	}
+	if cs.Since == nil {
+		return nil
+	}
+	return Intervals{{Start: *cs.Since}}
+}
+
 // ControlRecommendedAction captures the recommended action to complete
 // a control's implementation.
 type ControlRecommendedAction struct {
@@ -243,6 +261,10 @@ func (cs *ControlSet) ToProvenanceControls() []*provenance.Control {
 		if ctl.Since != nil {
 			c.Since = timestamppb.New(*ctl.Since)
 		}
+		// A single open window is already described by since
+		if ivs := ctl.GetIntervals(); len(ivs) > 1 || (len(ivs) == 1 && ivs[0].End != nil) {
+			c.Intervals = ivs.ToProvenance()
+		}
 		ret = append(ret, c)
 	}
 	return ret
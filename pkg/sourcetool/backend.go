@@ -0,0 +1,207 @@
+// SPDX-FileCopyrightText: Copyright 2026 The SLSA Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package sourcetool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/slsa-framework/source-tool/pkg/slsa"
+	"github.com/slsa-framework/source-tool/pkg/sourcetool/models"
+)
+
+var _ models.VcsBackend = (*hostBackend)(nil)
+
+// ErrUnknownHost is returned when no backend handles the repository hostname
+var ErrUnknownHost = errors.New("repository hostname is not handled by any backend")
+
+// hostBackend is a VcsBackend that routes each call to the backend that
+// handles the repository hostname. Repositories without a hostname are
+// handled by the default backend, unknown hostnames are an error.
+type hostBackend struct {
+	defaultBackend models.VcsBackend
+	backends       map[string]models.VcsBackend
+}
+
+// forRepo returns the backend that handles a repository
+func (hb *hostBackend) forRepo(repo *models.Repository) (models.VcsBackend, error) {
+	if repo == nil || repo.Hostname == "" {
+		return hb.defaultBackend, nil
+	}
+	if b, ok := hb.backends[repo.Hostname]; ok {
+		return b, nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownHost, repo.Hostname)
+}
+
+// forBranch returns the backend that handles the repository of a branch
+func (hb *hostBackend) forBranch(branch *models.Branch) (models.VcsBackend, error) {
+	if branch == nil {
+		return hb.defaultBackend, nil
+	}
+	return hb.forRepo(branch.Repository)
+}
+
+func (hb *hostBackend) GetBranchControls(ctx context.Context, branch *models.Branch) (*slsa.ControlSet, error) {
+	b, err := hb.forBranch(branch)
+	if err != nil {
+		return nil, err
+	}
+	return b.GetBranchControls(ctx, branch)
+}
+
+func (hb *hostBackend) GetBranchControlsAtCommit(ctx context.Context, branch *models.Branch, commit *models.Commit) (*slsa.ControlSet, error) {
+	b, err := hb.forBranch(branch)
+	if err != nil {
+		return nil, err
+	}
+	return b.GetBranchControlsAtCommit(ctx, branch, commit)
+}
+
+func (hb *hostBackend) GetTagControls(ctx context.Context, branch *models.Branch, tag *models.Tag) (*slsa.ControlSet, error) {
+	b, err := hb.forBranch(branch)
+	if err != nil {
+		return nil, err
+	}
+	return b.GetTagControls(ctx, branch, tag)
+}
+
+func (hb *hostBackend) ControlConfigurationDescr(branch *models.Branch, config models.ControlConfiguration) string {
+	b, err := hb.forBranch(branch)
+	if err != nil {
+		return ""
+	}
+	return b.ControlConfigurationDescr(branch, config)
+}
+
+func (hb *hostBackend) ConfigureControls(repo *models.Repository, branches []*models.Branch, configs []models.ControlConfiguration) error {
+	b, err := hb.forRepo(repo)
+	if err != nil {
+		return err
+	}
+	return b.ConfigureControls(repo, branches, configs)
+}
+
+func (hb *hostBackend) GetLatestCommit(ctx context.Context, repo *models.Repository, branch *models.Branch) (*models.Commit, error) {
+	b, err := hb.forRepo(repo)
+	if err != nil {
+		return nil, err
+	}
+	return b.GetLatestCommit(ctx, repo, branch)
+}
+
+func (hb *hostBackend) ControlPrecheck(repo *models.Repository, branches []*models.Branch, config models.ControlConfiguration) (bool, string, models.ControlPreRemediationFn, error) {
+	b, err := hb.forRepo(repo)
+	if err != nil {
+		return false, "", nil, err
+	}
+	return b.ControlPrecheck(repo, branches, config)
+}
+
+func (hb *hostBackend) GetPreviousCommit(ctx context.Context, branch *models.Branch, commit *models.Commit) (*models.Commit, error) {
+	b, err := hb.forBranch(branch)
+	if err != nil {
+		return nil, err
+	}
+	return b.GetPreviousCommit(ctx, branch, commit)
+}
+
+func (hb *hostBackend) GetCommitHistory(ctx context.Context, branch *models.Branch, commit *models.Commit, limit int) ([]*models.Commit, error) {
+	b, err := hb.forBranch(branch)
+	if err != nil {
+		return nil, err
+	}
+	return b.GetCommitHistory(ctx, branch, commit, limit)
+}
+
+func (hb *hostBackend) GetCommitParents(ctx context.Context, branch *models.Branch, commit *models.Commit) ([]*models.Commit, error) {
+	b, err := hb.forBranch(branch)
+	if err != nil {
+		return nil, err
+	}
+	return b.GetCommitParents(ctx, branch, commit)
+}
+
+func (hb *hostBackend) GetDefaultBranch(ctx context.Context, repo *models.Repository) (*models.Branch, error) {
+	b, err := hb.forRepo(repo)
+	if err != nil {
+		return nil, err
+	}
+	return b.GetDefaultBranch(ctx, repo)
+}
+
+func (hb *hostBackend) GetRevisionCommit(ctx context.Context, repo *models.Repository, rev models.Revision) (*models.Commit, error) {
+	b, err := hb.forRepo(repo)
+	if err != nil {
+		return nil, err
+	}
+	return b.GetRevisionCommit(ctx, repo, rev)
+}
+
+func (hb *hostBackend) GetTagInfo(ctx context.Context, repo *models.Repository, tag *models.Tag) (*models.TagInfo, error) {
+	b, err := hb.forRepo(repo)
+	if err != nil {
+		return nil, err
+	}
+	return b.GetTagInfo(ctx, repo, tag)
+}
+
+func (hb *hostBackend) PromoteControls(ctx context.Context, repo *models.Repository) ([]string, error) {
+	b, err := hb.forRepo(repo)
+	if err != nil {
+		return nil, err
+	}
+	return b.PromoteControls(ctx, repo)
+}
+
+func (hb *hostBackend) GetStagedRuleFailures(ctx context.Context, repo *models.Repository) ([]*models.StagedRuleFailure, error) {
+	b, err := hb.forRepo(repo)
+	if err != nil {
+		return nil, err
+	}
+	return b.GetStagedRuleFailures(ctx, repo)
+}
+
+func (hb *hostBackend) GetManagedControls(ctx context.Context, repo *models.Repository) ([]models.ControlConfiguration, error) {
+	b, err := hb.forRepo(repo)
+	if err != nil {
+		return nil, err
+	}
+	return b.GetManagedControls(ctx, repo)
+}
+
+func (hb *hostBackend) RemoveControls(ctx context.Context, repo *models.Repository, configs []models.ControlConfiguration) error {
+	b, err := hb.forRepo(repo)
+	if err != nil {
+		return err
+	}
+	return b.RemoveControls(ctx, repo, configs)
+}
+
+func (hb *hostBackend) PlanControls(
+	ctx context.Context, repo *models.Repository, branches []*models.Branch, configs []models.ControlConfiguration,
+) ([]*models.PlannedChange, error) {
+	b, err := hb.forRepo(repo)
+	if err != nil {
+		return nil, err
+	}
+	return b.PlanControls(ctx, repo, branches, configs)
+}
+
+func (hb *hostBackend) CheckPlannedChange(ctx context.Context, repo *models.Repository, change *models.PlannedChange) error {
+	b, err := hb.forRepo(repo)
+	if err != nil {
+		return err
+	}
+	return b.CheckPlannedChange(ctx, repo, change)
+}
+
+func (hb *hostBackend) ApplyPlannedChange(ctx context.Context, repo *models.Repository, change *models.PlannedChange) error {
+	b, err := hb.forRepo(repo)
+	if err != nil {
+		return err
+	}
+	return b.ApplyPlannedChange(ctx, repo, change)
+}
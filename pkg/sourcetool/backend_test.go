@@ -0,0 +1,65 @@
+// SPDX-FileCopyrightText: Copyright 2026 The SLSA Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package sourcetool
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/slsa-framework/source-tool/pkg/sourcetool/models"
+	"github.com/slsa-framework/source-tool/pkg/sourcetool/models/modelsfakes"
+)
+
+func TestHostBackend(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		name       string
+		repo       *models.Repository
+		expectHost bool
+		mustErr    bool
+	}{
+		{name: "github", repo: &models.Repository{Hostname: "github.com", Path: "a/b"}},
+		{name: "no-hostname", repo: &models.Repository{Path: "a/b"}},
+		{name: "nil-repo", repo: nil},
+		{name: "gitlab", repo: &models.Repository{Hostname: "gitlab.example.com", Path: "a/b"}, expectHost: true},
+		{name: "unknown-host", repo: &models.Repository{Hostname: "git.example.com", Path: "a/b"}, mustErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			def := &modelsfakes.FakeVcsBackend{}
+			def.GetLatestCommitReturns(&models.Commit{SHA: "default"}, nil)
+			gl := &modelsfakes.FakeVcsBackend{}
+			gl.GetLatestCommitReturns(&models.Commit{SHA: "gitlab"}, nil)
+
+			hb := &hostBackend{
+				defaultBackend: def,
+				backends:       map[string]models.VcsBackend{"github.com": def, "gitlab.example.com": gl},
+			}
+
+			commit, err := hb.GetLatestCommit(t.Context(), tc.repo, &models.Branch{Name: "main", Repository: tc.repo})
+			if tc.mustErr {
+				require.ErrorIs(t, err, ErrUnknownHost)
+				_, err = hb.GetBranchControls(t.Context(), &models.Branch{Name: "main", Repository: tc.repo})
+				require.ErrorIs(t, err, ErrUnknownHost)
+				require.Equal(t, 0, def.GetLatestCommitCallCount())
+				require.Equal(t, 0, gl.GetLatestCommitCallCount())
+				return
+			}
+			require.NoError(t, err)
+			_, err = hb.GetBranchControls(t.Context(), &models.Branch{Name: "main", Repository: tc.repo})
+			require.NoError(t, err)
+
+			if tc.expectHost {
+				require.Equal(t, "gitlab", commit.SHA)
+				require.Equal(t, 1, gl.GetBranchControlsCallCount())
+				require.Equal(t, 0, def.GetBranchControlsCallCount())
+				return
+			}
+			require.Equal(t, "default", commit.SHA)
+			require.Equal(t, 1, def.GetBranchControlsCallCount())
+			require.Equal(t, 0, gl.GetBranchControlsCallCount())
+		})
+	}
+}
@@ -81,6 +81,14 @@ type Options struct {
 type Backend struct {
 	authenticator *auth.Authenticator
 	Options       *models.BackendOptions
+	verifier      attest.Verifier
+}
+
+// SetVerifier sets the verifier used to check the signatures of the
+// attestations read from the repository. It must be set before reading
+// the branch controls.
+func (b *Backend) SetVerifier(v attest.Verifier) {
+	b.verifier = v
 }
 
 // getGitHubConnection builds a github connector to a repository
@@ -103,9 +111,7 @@ func (b *Backend) getGitHubConnection(repository *models.Repository, ref string)
 		return nil, err
 	}
 
-	ghc := ghcontrol.NewGhConnectionWithClient(owner, name, ref, client)
-	ghc.Options.AllowMergeCommits = b.Options.AllowMergeCommits
-	return ghc, nil
+	return ghcontrol.NewGhConnectionWithClient(owner, name, ref, client), nil
 }
 
 func (b *Backend) GetBranchControls(ctx context.Context, branch *models.Branch) (*slsa.ControlSet, error) {
@@ -124,7 +130,45 @@ func (b *Backend) GetBranchControls(ctx context.Context, branch *models.Branch)
 		return nil, fmt.Errorf("fetching latest commit from %q: %w", branch.FullRef(), err)
 	}
 
-	return b.GetBranchControlsAtCommit(ctx, branch, &models.Commit{SHA: commit})
+	status, err := b.GetBranchControlsAtCommit(ctx, branch, &models.Commit{SHA: commit})
+	if err != nil {
+		return nil, err
+	}
+
+	// Rulesets staged in evaluate mode don't enforce their controls yet,
+	// they are reported in progress until they are promoted.
+	staged, err := ghc.StagedControls(ctx, branch.FullRef())
+	if err != nil {
+		return nil, fmt.Errorf("reading staged rulesets: %w", err)
+	}
+	markStagedControls(branch.Repository, status, staged)
+
+	return status, nil
+}
+
+// markStagedControls switches the controls not enabled in the status but
+// implemented by a staged ruleset to in progress.
+func markStagedControls(r *models.Repository, status *slsa.ControlSet, staged map[slsa.ControlName]string) {
+	// The org controls follow the SCS controls they are derived from
+	if name, ok := staged[slsa.SLSA_SOURCE_SCS_CONTINUITY]; ok {
+		staged[slsa.SLSA_SOURCE_ORG_CONTINUITY] = name
+	}
+	if name, ok := staged[slsa.SLSA_SOURCE_SCS_PROTECTED_REFS]; ok {
+		staged[slsa.SLSA_SOURCE_ORG_SAFE_EXPUNGE] = name
+	}
+
+	for _, c := range status.Controls {
+		name, ok := staged[c.Name]
+		if !ok || c.State != slsa.StateNotEnabled {
+			continue
+		}
+		c.State = slsa.StateInProgress
+		c.Message = fmt.Sprintf("(ruleset %q in evaluate mode)", name)
+		c.RecommendedAction = &slsa.ControlRecommendedAction{
+			Message: "Promote the staged rulesets to enforce them",
+			Command: fmt.Sprintf("sourcetool setup promote %s", r.Path),
+		}
+	}
 }
 
 // GetBranchControlsAtCommit
@@ -143,16 +187,33 @@ func (b *Backend) GetBranchControlsAtCommit(ctx context.Context, branch *models.
 	}
 
 	// The branch controls returned from ghcontrol only include the 4
-	// legacy checks sourcetool did (continuity, review, RequiredChecks, tag hygiene)
-	activeControls, err := ghc.GetBranchControls(ctx, branch.FullRef())
-	if err != nil {
+	// legacy checks sourcetool did (continuity, review, RequiredChecks, tag hygiene).
+	// They are computed from the ruleset history at the time the commit was
+	// pushed. If the push is not in the repository activity, we fall back to
+	// the controls active now. If the history can't be read, the controls
+	// are unknown and none is vouched for.
+	var activeControls *slsa.ControlSet
+	ghStatus, err := ghc.GetBranchControlsAtCommit(ctx, commit.SHA, branch.FullRef())
+	switch {
+	case err == nil:
+		activeControls = ghStatus.Controls
+	case errors.Is(err, ghcontrol.ErrHistoryUnavailable):
+		log.Printf("Controls at %s are unknown, none will be reported: %v", commit.SHA, err)
+		activeControls = &slsa.ControlSet{}
+	case errors.Is(err, ghcontrol.ErrActivityNotFound):
+		log.Printf("Push of %s not found in the repository activity, using current controls", commit.SHA)
+		activeControls, err = ghc.GetBranchControls(ctx, branch.FullRef())
+		if err != nil {
+			return nil, fmt.Errorf("checking status: %w", err)
+		}
+	default:
 		return nil, fmt.Errorf("checking status: %w", err)
 	}
 
 	// We need to manually check for PROVENANCE_AVAILABLE which is not
 	// handled by ghcontrol
 	attester, err := attest.NewAttester(
-		attest.WithBackend(b), attest.WithVerifier(attest.GetDefaultVerifier()),
+		attest.WithBackend(b), attest.WithVerifier(b.verifier),
 		attest.WithAuthenticator(b.authenticator),
 	)
 	if err != nil {
@@ -212,6 +273,11 @@ func (b *Backend) GetBranchControlsAtCommit(ctx context.Context, branch *models.
 	// NewControlSet returns all the controls for the framework in
 	// StateNotEnabled.
 	status := slsa.NewControlSet()
+	if ghStatus != nil {
+		status.CommitPushTime = ghStatus.CommitPushTime
+		status.ActorLogin = ghStatus.ActorLogin
+		status.ActivityType = ghStatus.ActivityType
+	}
 	sinceForever := time.Unix(1207836000, 0) // April 10, 2008 (when github came online)
 	for i, ctrl := range status.Controls {
 		// Check if it's an inherent control, turn it on  and don't look back
@@ -225,6 +291,7 @@ func (b *Backend) GetBranchControlsAtCommit(ctx context.Context, branch *models.
 		// Check if it's one of the active controls and enable it and copy the since date
 		if c := activeControls.GetControl(ctrl.Name); c != nil {
 			status.Controls[i].Since = c.Since
+			status.Controls[i].Intervals = c.Intervals
 			status.Controls[i].State = slsa.StateActive
 			status.Controls[i].Message = b.controlImplementationMessage(c.GetName())
 		}
@@ -234,6 +301,7 @@ func (b *Backend) GetBranchControlsAtCommit(ctx context.Context, branch *models.
 		if ctrl.Name == slsa.SLSA_SOURCE_ORG_SAFE_EXPUNGE {
 			if c := activeControls.GetControl(slsa.SLSA_SOURCE_SCS_PROTECTED_REFS); c != nil {
 				status.Controls[i].Since = c.Since
+				status.Controls[i].Intervals = c.Intervals
 				status.Controls[i].State = slsa.StateActive
 				status.Controls[i].Message = b.controlImplementationMessage(ctrl.Name)
 			}
@@ -243,15 +311,16 @@ func (b *Backend) GetBranchControlsAtCommit(ctx context.Context, branch *models.
 		if ctrl.Name == slsa.SLSA_SOURCE_ORG_CONTINUITY {
 			if c := activeControls.GetControl(slsa.SLSA_SOURCE_SCS_CONTINUITY); c != nil {
 				status.Controls[i].Since = c.Since
+				status.Controls[i].Intervals = c.Intervals
 				status.Controls[i].State = slsa.StateActive
 				status.Controls[i].Message = b.controlImplementationMessage(ctrl.Name)
 			}
 		}
 	}
 
-	// The only control which can be in in_progress state in GitHub is
-	// provenance generation when the PR is open but not merged. We check
-	// here and report back the status.
+	// Provenance generation is in progress when the PR is open but not
+	// merged. We check here and report back the status. The controls of
+	// staged rulesets are switched to in progress in GetBranchControls.
 	switchProvCtlToInProgress := false
 	var provenanceMessage string
 	if c := activeControls.GetControl(slsa.SLSA_SOURCE_SCS_PROVENANCE); c == nil {
@@ -321,8 +390,8 @@ func (b *Backend) ControlConfigurationDescr(branch *models.Branch, config models
 	switch config {
 	case models.CONFIG_BRANCH_RULES:
 		return fmt.Sprintf(
-			"Enable push and delete protection on %s for branch %s",
-			repo.Path, branch.Name,
+			"Enable push and delete protection on %s for branch %s%s",
+			repo.Path, branch.Name, b.stagingDescr(),
 		)
 	case models.CONFIG_GEN_PROVENANCE:
 		return fmt.Sprintf(
@@ -336,8 +405,13 @@ func (b *Backend) ControlConfigurationDescr(branch *models.Branch, config models
 		)
 	case models.CONFIG_TAG_RULES:
 		return fmt.Sprintf(
-			"Enable force push/update/delete protection for all tags in %s",
-			repo.Path,
+			"Enable force push/update/delete protection for all tags in %s%s",
+			repo.Path, b.stagingDescr(),
+		)
+	case models.CONFIG_REVIEW_RULES:
+		return fmt.Sprintf(
+			"Require code owner approval of pull requests to %s in %s%s",
+			branch.Name, repo.Path, b.stagingDescr(),
 		)
 	default:
 		return ""
@@ -359,6 +433,15 @@ func (b *Backend) GetLatestCommit(ctx context.Context, r *models.Repository, bra
 	return &models.Commit{SHA: sha}, nil
 }
 
+// stagingDescr returns the note added to the ruleset descriptions when
+// they are created in evaluate mode
+func (b *Backend) stagingDescr() string {
+	if b.Options != nil && b.Options.StageRules {
+		return " (staged in evaluate mode)"
+	}
+	return ""
+}
+
 // GetRecommendedAction returns the recommended action based on the
 // status of a SLSA control
 func (b *Backend) getRecommendedAction(r *models.Repository, _ *models.Branch, control slsa.ControlName, state slsa.ControlState) *slsa.ControlRecommendedAction {
@@ -394,18 +477,26 @@ func (b *Backend) getRecommendedAction(r *models.Repository, _ *models.Branch, c
 			}
 		}
 		return nil
+	case slsa.SLSA_SOURCE_SCS_TWO_PARTY_REVIEW:
+		if state == slsa.StateNotEnabled {
+			return &slsa.ControlRecommendedAction{
+				Message: "Require code owner review of pull requests",
+				Command: fmt.Sprintf("sourcetool setup controls --config=%s %s", models.CONFIG_REVIEW_RULES, r.Path),
+			}
+		}
+		return nil
 	default:
 		return nil
 	}
 }
 
-// GetPreviousCommit takes a commit in
+// GetPreviousCommit takes a commit in a branch and returns the commit
+// preceding it. For merge commits this is the first parent.
 func (b *Backend) GetPreviousCommit(ctx context.Context, branch *models.Branch, commit *models.Commit) (*models.Commit, error) {
 	ghx, err := b.getGitHubConnection(branch.Repository, branch.FullRef())
 	if err != nil {
 		return nil, err
 	}
-	// TODO:IMPLEMENT
 	rawCommit, err := ghx.GetPriorCommit(ctx, commit.SHA)
 	if err != nil {
 		return nil, fmt.Errorf("fetching previous commit: %w", err)
@@ -415,6 +506,54 @@ func (b *Backend) GetPreviousCommit(ctx context.Context, branch *models.Branch,
 	}, nil
 }
 
+// GetCommitHistory returns up to limit commits of the first-parent history
+// of the branch starting at commit, newest first.
+func (b *Backend) GetCommitHistory(ctx context.Context, branch *models.Branch, commit *models.Commit, limit int) ([]*models.Commit, error) {
+	ghx, err := b.getGitHubConnection(branch.Repository, branch.FullRef())
+	if err != nil {
+		return nil, err
+	}
+	rawCommits, err := ghx.GetFirstParentHistory(ctx, commit.SHA, limit)
+	if err != nil {
+		return nil, fmt.Errorf("reading commit history: %w", err)
+	}
+
+	commits := make([]*models.Commit, 0, len(rawCommits))
+	for _, rc := range rawCommits {
+		c := &models.Commit{
+			SHA:     rc.GetSHA(),
+			Author:  rc.GetAuthor().GetLogin(),
+			Message: rc.GetCommit().GetMessage(),
+			Parents: make([]string, 0, len(rc.Parents)),
+		}
+		if date := rc.GetCommit().GetCommitter().Date; date != nil {
+			c.Time = &date.Time
+		}
+		for _, p := range rc.Parents {
+			c.Parents = append(c.Parents, p.GetSHA())
+		}
+		commits = append(commits, c)
+	}
+	return commits, nil
+}
+
+// GetCommitParents returns all the parents of a commit, first parent first
+func (b *Backend) GetCommitParents(ctx context.Context, branch *models.Branch, commit *models.Commit) ([]*models.Commit, error) {
+	ghx, err := b.getGitHubConnection(branch.Repository, branch.FullRef())
+	if err != nil {
+		return nil, err
+	}
+	shas, err := ghx.GetCommitParents(ctx, commit.SHA)
+	if err != nil {
+		return nil, fmt.Errorf("fetching commit parents: %w", err)
+	}
+	parents := make([]*models.Commit, 0, len(shas))
+	for _, sha := range shas {
+		parents = append(parents, &models.Commit{SHA: sha})
+	}
+	return parents, nil
+}
+
 // GetDefaultBranch returns the default branch
 func (b *Backend) GetDefaultBranch(ctx context.Context, repo *models.Repository) (*models.Branch, error) {
 	ghx, err := b.getGitHubConnection(repo, "")
@@ -455,3 +594,12 @@ func (b *Backend) GetRevisionCommit(ctx context.Context, repo *models.Repository
 		return nil, errors.New("not implemented yet or invalid revision")
 	}
 }
+
+// GetTagInfo reads the tag object of a tag
+func (b *Backend) GetTagInfo(ctx context.Context, repo *models.Repository, tag *models.Tag) (*models.TagInfo, error) {
+	ghx, err := b.getGitHubConnection(repo, "")
+	if err != nil {
+		return nil, err
+	}
+	return ghx.GetTagInfo(ctx, tag.GetName())
+}
@@ -12,6 +12,7 @@ import (
 
 	"github.com/google/go-github/v88/github"
 
+	"github.com/slsa-framework/source-tool/pkg/ghcontrol"
 	"github.com/slsa-framework/source-tool/pkg/repo"
 	"github.com/slsa-framework/source-tool/pkg/repo/options"
 	"github.com/slsa-framework/source-tool/pkg/sourcetool/models"
@@ -31,6 +32,17 @@ const (
 	// workflowCommitMessage will be used as the commit message and the PR title
 	workflowCommitMessage = "Add SLSA Source Provenance Workflow"
 
+	// workflowRemovalMessage is the title of the pull request removing
+	// the workflow
+	workflowRemovalMessage = "Remove SLSA Source Provenance Workflow"
+
+	// workflowRemovalPRBody is the body of the pull request that removes the
+	// provenance workflow
+	workflowRemovalPRBody = `This pull request removes the workflow generating ` +
+		`[SLSA](https://slsa.dev/) Source provenance data from the repository.` + "\n\n" +
+		`Note: This is an automated PR created using the ` +
+		`[SLSA sourcetool](https://github.com/slsa-framework/source-tool) utility.` + "\n"
+
 	// workflowPRBody is the body of the pull request that adds the provenance workflow
 	workflowPRBody = `This pull request adds a new workflow to the repository to generate ` +
 		`[SLSA](https://slsa.dev/) Source provenance data on every push.` + "\n\n" +
@@ -82,22 +94,45 @@ func (b *Backend) checkPushAccess(r *models.Repository) (bool, error) {
 	return true, nil
 }
 
+// managedRulesets maps the control configurations to the name of the
+// ruleset sourcetool creates for them.
+var managedRulesets = map[models.ControlConfiguration]string{
+	models.CONFIG_BRANCH_RULES: ghcontrol.BranchRulesetName,
+	models.CONFIG_TAG_RULES:    ghcontrol.TagRulesetName,
+	models.CONFIG_REVIEW_RULES: ghcontrol.ReviewRulesetName,
+}
+
 // CreateWorkflowPR creates the pull request to add the provenance workflow
 // to the specified repository.
 func (b *Backend) CreateWorkflowPR(r *models.Repository, branches []*models.Branch) (*models.PullRequest, error) {
-	if len(branches) == 0 {
-		return nil, errors.New("no branches specified")
+	workflowYAML, err := b.renderWorkflow(branches)
+	if err != nil {
+		return nil, err
 	}
 
-	user, err := b.authenticator.WhoAmI()
+	pr, err := b.openWorkflowPR(r, workflowCommitMessage, workflowPRBody, &repo.PullRequestFileEntry{
+		Path:   workflowPath,
+		Reader: strings.NewReader(workflowYAML),
+	})
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("creating workflow pull request: %w", err)
+	}
+
+	// Success!
+	return pr, nil
+}
+
+// renderWorkflow returns the provenance workflow for the branches, pinned
+// to the latest release of the actions repository.
+func (b *Backend) renderWorkflow(branches []*models.Branch) (string, error) {
+	if len(branches) == 0 {
+		return "", errors.New("no branches specified")
 	}
 
 	// Get the actions repo tag
 	actionsTag, actionsHash, err := b.GetLatestActionsTag()
 	if err != nil {
-		return nil, fmt.Errorf("getting latest actions tag: %w", err)
+		return "", fmt.Errorf("getting latest actions tag: %w", err)
 	}
 
 	// Populate the branches in the workflow template
@@ -105,10 +140,32 @@ func (b *Backend) CreateWorkflowPR(r *models.Repository, branches []*models.Bran
 	for _, b := range branches {
 		quotedBranchesList = append(quotedBranchesList, fmt.Sprintf("%q", b.Name))
 	}
-	workflowYAML := fmt.Sprintf(
+	return fmt.Sprintf(
 		workflowData, strings.Join(quotedBranchesList, ", "),
 		ActionsOrg, ActionsRepo, actionsHash, actionsTag,
-	)
+	), nil
+}
+
+// CreateWorkflowRemovalPR opens a pull request removing the provenance
+// workflow from the repository.
+func (b *Backend) CreateWorkflowRemovalPR(r *models.Repository) (*models.PullRequest, error) {
+	pr, err := b.openWorkflowPR(r, workflowRemovalMessage, workflowRemovalPRBody, &repo.PullRequestFileEntry{
+		Path:   workflowPath,
+		Remove: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating workflow removal pull request: %w", err)
+	}
+	return pr, nil
+}
+
+// openWorkflowPR opens a pull request changing the workflow file. When the
+// user has no push access to the repository, it is opened from their fork.
+func (b *Backend) openWorkflowPR(r *models.Repository, title, body string, entry *repo.PullRequestFileEntry) (*models.PullRequest, error) {
+	user, err := b.authenticator.WhoAmI()
+	if err != nil {
+		return nil, err
+	}
 
 	// We need to determine if the user needs a fork
 	hasPush, err := b.checkPushAccess(r)
@@ -128,29 +185,18 @@ func (b *Backend) CreateWorkflowPR(r *models.Repository, branches []*models.Bran
 	prManager.Options.UseFork = !hasPush
 
 	// Open the pull request
-	pr, err := prManager.PullRequestFileList(
+	return prManager.PullRequestFileList(
 		r,
 		&options.PullRequestFileListOptions{
-			Title: workflowCommitMessage,
-			Body:  workflowPRBody,
+			Title: title,
+			Body:  body,
 			CommitOptions: options.CommitOptions{
 				Name:  user.GetLogin(),
 				Email: commitEmailForActor(user),
 			},
 		},
-		[]*repo.PullRequestFileEntry{
-			{
-				Path:   workflowPath,
-				Reader: strings.NewReader(workflowYAML),
-			},
-		},
+		[]*repo.PullRequestFileEntry{entry},
 	)
-	if err != nil {
-		return nil, fmt.Errorf("creating workflow pull request: %w", err)
-	}
-
-	// Success!
-	return pr, nil
 }
 
 // commitEmailForActor returns the no-reply email address to use when authoring
@@ -244,7 +290,7 @@ func (b *Backend) CreateRepoRuleset(r *models.Repository, branches []*models.Bra
 		return err
 	}
 
-	if err := ghc.EnableBranchRules(context.Background()); err != nil {
+	if err := ghc.EnableBranchRules(context.Background(), b.rulesEnforcement()); err != nil {
 		return fmt.Errorf("enabling branch protection rules: %w", err)
 	}
 
@@ -261,13 +307,247 @@ func (b *Backend) CreateTagRuleset(r *models.Repository) error {
 		return err
 	}
 
-	if err := ghc.EnableTagRules(context.Background()); err != nil {
+	if err := ghc.EnableTagRules(context.Background(), b.rulesEnforcement()); err != nil {
 		return fmt.Errorf("enabling tag protection rules: %w", err)
 	}
 
 	return nil
 }
 
+// CreateReviewRuleset creates (or amends) the ruleset requiring code owner
+// review on the branch
+func (b *Backend) CreateReviewRuleset(r *models.Repository, branches []*models.Branch) error {
+	if r == nil {
+		return errors.New("unable to create review ruleset, repository not defined")
+	}
+
+	if len(branches) != 1 {
+		return errors.New("review rules must be configured for exactly one branch")
+	}
+
+	ghc, err := b.getGitHubConnection(r, branches[0].FullRef())
+	if err != nil {
+		return err
+	}
+
+	if err := ghc.EnableReviewRules(context.Background(), b.rulesEnforcement()); err != nil {
+		return fmt.Errorf("enabling review rules: %w", err)
+	}
+
+	return nil
+}
+
+// rulesEnforcement returns the enforcement of the rulesets created in the
+// repository. When staging, they are created in evaluate mode.
+func (b *Backend) rulesEnforcement() github.RulesetEnforcement {
+	if b.Options != nil && b.Options.StageRules {
+		return github.RulesetEnforcementEvaluate
+	}
+	return github.RulesetEnforcementActive
+}
+
+// PromoteControls switches the rulesets staged in the repository to active
+// enforcement and returns their names.
+func (b *Backend) PromoteControls(ctx context.Context, r *models.Repository) ([]string, error) {
+	ghc, err := b.getGitHubConnection(r, "")
+	if err != nil {
+		return nil, err
+	}
+
+	promoted, err := ghc.PromoteRulesets(ctx)
+	if err != nil {
+		return promoted, fmt.Errorf("promoting staged rulesets: %w", err)
+	}
+	return promoted, nil
+}
+
+// GetStagedRuleFailures returns the pushes of the last month that the staged
+// rulesets would have blocked if they were active.
+func (b *Backend) GetStagedRuleFailures(ctx context.Context, r *models.Repository) ([]*models.StagedRuleFailure, error) {
+	ghc, err := b.getGitHubConnection(r, "")
+	if err != nil {
+		return nil, err
+	}
+
+	suites, err := ghc.StagedRuleFailures(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("reading rule suite insights: %w", err)
+	}
+
+	failures := []*models.StagedRuleFailure{}
+	for _, s := range suites {
+		f := &models.StagedRuleFailure{
+			Ref:      s.Ref,
+			Actor:    s.ActorName,
+			Before:   s.BeforeSHA,
+			After:    s.AfterSHA,
+			PushedAt: s.PushedAt,
+			Rules:    []string{},
+		}
+		for _, e := range s.RuleEvaluations {
+			f.Rules = append(f.Rules, fmt.Sprintf("%s (%s)", e.RuleSource.Name, e.RuleType))
+		}
+		failures = append(failures, f)
+	}
+	return failures, nil
+}
+
+// GetManagedControls returns the control configurations that sourcetool
+// set up in the repository: the rulesets it created and the provenance
+// workflow, either merged or in an open pull request.
+func (b *Backend) GetManagedControls(ctx context.Context, r *models.Repository) ([]models.ControlConfiguration, error) {
+	ghc, err := b.getGitHubConnection(r, "")
+	if err != nil {
+		return nil, err
+	}
+
+	rulesets, err := ghc.ListManagedRulesets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	names := map[string]struct{}{}
+	for _, rs := range rulesets {
+		names[rs.Name] = struct{}{}
+	}
+
+	pr, err := b.FindWorkflowPR(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	exists, err := b.workflowExists(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	configs := []models.ControlConfiguration{}
+	for _, config := range []models.ControlConfiguration{
+		models.CONFIG_BRANCH_RULES, models.CONFIG_GEN_PROVENANCE, models.CONFIG_TAG_RULES, models.CONFIG_REVIEW_RULES,
+	} {
+		if config == models.CONFIG_GEN_PROVENANCE {
+			if pr != nil || exists {
+				configs = append(configs, config)
+			}
+			continue
+		}
+		if _, ok := names[managedRulesets[config]]; ok {
+			configs = append(configs, config)
+		}
+	}
+	return configs, nil
+}
+
+// RemoveControls undoes the control configurations set up by sourcetool.
+// Rulesets are deleted. The provenance workflow pull request is closed if
+// still open, once merged a pull request to remove the workflow is opened.
+func (b *Backend) RemoveControls(ctx context.Context, r *models.Repository, configs []models.ControlConfiguration) error {
+	ghc, err := b.getGitHubConnection(r, "")
+	if err != nil {
+		return err
+	}
+
+	errs := []error{}
+	for _, config := range configs {
+		switch config {
+		case models.CONFIG_BRANCH_RULES, models.CONFIG_TAG_RULES, models.CONFIG_REVIEW_RULES:
+			if _, err := ghc.DeleteManagedRuleset(ctx, managedRulesets[config]); err != nil {
+				errs = append(errs, fmt.Errorf("removing %s: %w", config, err))
+			}
+		case models.CONFIG_GEN_PROVENANCE:
+			if err := b.removeWorkflow(ctx, r); err != nil {
+				errs = append(errs, fmt.Errorf("removing %s: %w", config, err))
+			}
+		case models.CONFIG_POLICY:
+			// Noop, this is not handled by the VCS handler
+		default:
+			errs = append(errs, fmt.Errorf("unknown configuration flag: %q", config))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// removeWorkflow closes the open provenance workflow pull request and opens
+// a pull request to remove the workflow if it was already merged.
+func (b *Backend) removeWorkflow(ctx context.Context, r *models.Repository) error {
+	pr, err := b.searchPullRequestsByTitle(ctx, r, workflowCommitMessage)
+	if err != nil {
+		return fmt.Errorf("searching for provenance workflow pull request: %w", err)
+	}
+	if pr != nil {
+		if err := b.closePullRequest(ctx, r, pr); err != nil {
+			return err
+		}
+	}
+
+	exists, err := b.workflowExists(ctx, r)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return nil
+	}
+
+	// Don't open the removal pull request twice
+	removal, err := b.searchPullRequestsByTitle(ctx, r, workflowRemovalMessage)
+	if err != nil {
+		return fmt.Errorf("searching for workflow removal pull request: %w", err)
+	}
+	if removal != nil {
+		return nil
+	}
+
+	_, err = b.CreateWorkflowRemovalPR(r)
+	return err
+}
+
+// closePullRequest closes a pull request. If its head branch lives in the
+// repository, the branch is deleted too.
+func (b *Backend) closePullRequest(ctx context.Context, r *models.Repository, pr *github.PullRequest) error {
+	owner, repoName, err := r.PathAsGitHubOwnerName()
+	if err != nil {
+		return err
+	}
+	client, err := b.authenticator.GetGitHubClient()
+	if err != nil {
+		return err
+	}
+
+	if _, _, err := client.PullRequests.Edit(ctx, owner, repoName, pr.GetNumber(), &github.PullRequest{
+		State: github.Ptr("closed"),
+	}); err != nil {
+		return fmt.Errorf("closing pull request #%d: %w", pr.GetNumber(), err)
+	}
+
+	if pr.GetHead().GetRepo().GetFullName() != fmt.Sprintf("%s/%s", owner, repoName) {
+		return nil
+	}
+	if _, err := client.Git.DeleteRef(ctx, owner, repoName, "heads/"+pr.GetHead().GetRef()); err != nil {
+		return fmt.Errorf("deleting pull request branch %q: %w", pr.GetHead().GetRef(), err)
+	}
+	return nil
+}
+
+// workflowExists checks if the provenance workflow is checked in the default
+// branch of the repository.
+func (b *Backend) workflowExists(ctx context.Context, r *models.Repository) (bool, error) {
+	owner, repoName, err := r.PathAsGitHubOwnerName()
+	if err != nil {
+		return false, err
+	}
+	client, err := b.authenticator.GetGitHubClient()
+	if err != nil {
+		return false, err
+	}
+
+	_, _, resp, err := client.Repositories.GetContents(ctx, owner, repoName, workflowPath, nil)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return false, nil
+		}
+		return false, fmt.Errorf("checking for the provenance workflow: %w", err)
+	}
+	return true, nil
+}
+
 // CreateRepositoryFork creates a fork of a repo into the logged-in user's org.
 // Optionally the fork can have a different name than the original.
 func (b *Backend) createRepositoryFork(
@@ -332,6 +612,28 @@ func (b *Backend) ControlPrecheck(
 			}
 			return "successfully created the repository fork", nil
 		}, nil
+	case models.CONFIG_REVIEW_RULES:
+		// Code owner review can only be required when all files have owners
+		if len(branches) != 1 {
+			return false, "", nil, errors.New("review rules must be configured for exactly one branch")
+		}
+		ghc, err := b.getGitHubConnection(r, branches[0].FullRef())
+		if err != nil {
+			return false, "", nil, err
+		}
+		if _, err := ghc.CheckCodeowners(context.Background()); err != nil {
+			if !errors.Is(err, ghcontrol.ErrCodeownersNotFound) &&
+				!errors.Is(err, ghcontrol.ErrCodeownersIncomplete) &&
+				!errors.Is(err, ghcontrol.ErrCodeownersInvalid) {
+				return false, "", nil, fmt.Errorf("checking CODEOWNERS file in branch %s: %w", branches[0].Name, err)
+			}
+			// The file has to be fixed by hand, there is no remediation
+			msg := "Code owner review can't be required in branch %s:\n%v\n\n"
+			msg += "Add a CODEOWNERS file assigning owners to all the files in the\n"
+			msg += "repository and run the setup again.\n"
+			return false, fmt.Sprintf(msg, branches[0].Name, err), nil, nil
+		}
+		return true, "", nil, nil
 	default:
 		return true, "", nil, nil
 	}
@@ -369,6 +671,12 @@ func (b *Backend) ConfigureControls(r *models.Repository, branches []*models.Bra
 					errs = append(errs, fmt.Errorf("opening SLSA source workflow pull request: %w", err))
 				}
 			}
+		case models.CONFIG_REVIEW_RULES:
+			if err := b.CreateReviewRuleset(r, branches); err != nil {
+				if !errors.Is(err, models.ErrProtectionAlreadyInPlace) {
+					errs = append(errs, fmt.Errorf("creating review rules in the repository: %w", err))
+				}
+			}
 		case models.CONFIG_POLICY:
 			// Noop, this is not handled by the VCS handler
 		default:
@@ -0,0 +1,236 @@
+// SPDX-FileCopyrightText: Copyright 2026 The SLSA Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/google/go-github/v88/github"
+
+	"github.com/slsa-framework/source-tool/pkg/ghcontrol"
+	"github.com/slsa-framework/source-tool/pkg/repo"
+	"github.com/slsa-framework/source-tool/pkg/sourcetool/models"
+)
+
+// PlanControls returns the changes that ConfigureControls would make in the
+// repository without touching it.
+func (b *Backend) PlanControls(
+	ctx context.Context, r *models.Repository, branches []*models.Branch, configs []models.ControlConfiguration,
+) ([]*models.PlannedChange, error) {
+	changes := []*models.PlannedChange{}
+	for _, config := range configs {
+		var change *models.PlannedChange
+		var err error
+		switch config {
+		case models.CONFIG_BRANCH_RULES, models.CONFIG_TAG_RULES, models.CONFIG_REVIEW_RULES:
+			change, err = b.planRuleset(ctx, r, branches, config)
+		case models.CONFIG_GEN_PROVENANCE:
+			change, err = b.planWorkflow(ctx, r, branches)
+		case models.CONFIG_POLICY:
+			// Noop, this is not handled by the VCS handler
+			continue
+		default:
+			err = fmt.Errorf("unknown configuration flag: %q", config)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("planning %s: %w", config, err)
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// planRuleset returns the change to the ruleset managed by sourcetool for
+// the control configuration.
+func (b *Backend) planRuleset(
+	ctx context.Context, r *models.Repository, branches []*models.Branch, config models.ControlConfiguration,
+) (*models.PlannedChange, error) {
+	ref := ""
+	if config != models.CONFIG_TAG_RULES {
+		if len(branches) != 1 {
+			return nil, errors.New("branch rules must be planned for exactly one branch")
+		}
+		ref = branches[0].FullRef()
+	}
+
+	ghc, err := b.getGitHubConnection(r, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	var plan *ghcontrol.RulesetPlan
+	//nolint:exhaustive // Only the configs managing rulesets are planned here
+	switch config {
+	case models.CONFIG_BRANCH_RULES:
+		plan, err = ghc.PlanBranchRules(ctx, b.rulesEnforcement())
+	case models.CONFIG_TAG_RULES:
+		plan, err = ghc.PlanTagRules(ctx, b.rulesEnforcement())
+	case models.CONFIG_REVIEW_RULES:
+		plan, err = ghc.PlanReviewRules(ctx, b.rulesEnforcement())
+	}
+
+	change := &models.PlannedChange{
+		Config:   config,
+		Resource: models.PlanResourceRuleset,
+		Name:     managedRulesets[config],
+	}
+	if errors.Is(err, models.ErrProtectionAlreadyInPlace) {
+		change.Action = models.PlanActionNoop
+		change.Reason = "controls already in place"
+		return change, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	change.Action = models.PlanActionCreate
+	if plan.Current != nil {
+		change.Action = models.PlanActionUpdate
+		change.Current, err = json.Marshal(plan.Current)
+		if err != nil {
+			return nil, fmt.Errorf("marshaling current ruleset: %w", err)
+		}
+	}
+	change.Desired, err = json.Marshal(plan.Desired)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling planned ruleset: %w", err)
+	}
+	return change, nil
+}
+
+// planWorkflow returns the change adding the provenance workflow
+func (b *Backend) planWorkflow(ctx context.Context, r *models.Repository, branches []*models.Branch) (*models.PlannedChange, error) {
+	change := &models.PlannedChange{
+		Config:   models.CONFIG_GEN_PROVENANCE,
+		Resource: models.PlanResourceFile,
+		Name:     workflowPath,
+	}
+
+	pr, err := b.FindWorkflowPR(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	if pr != nil {
+		change.Action = models.PlanActionNoop
+		change.Reason = fmt.Sprintf("pull request #%d is already open", pr.Number)
+		return change, nil
+	}
+
+	exists, err := b.workflowExists(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		change.Action = models.PlanActionNoop
+		change.Reason = "workflow already in the repository"
+		return change, nil
+	}
+
+	contents, err := b.renderWorkflow(branches)
+	if err != nil {
+		return nil, err
+	}
+	change.Action = models.PlanActionCreate
+	change.Contents = contents
+	return change, nil
+}
+
+// rulesetPlan reads the ruleset plan from a planned change. Only the
+// rulesets managed by sourcetool can be planned.
+func rulesetPlan(change *models.PlannedChange) (*ghcontrol.RulesetPlan, error) {
+	plan := &ghcontrol.RulesetPlan{Desired: &github.RepositoryRuleset{}}
+	if err := json.Unmarshal(change.Desired, plan.Desired); err != nil {
+		return nil, fmt.Errorf("reading planned ruleset: %w", err)
+	}
+	if !slices.Contains(ghcontrol.ManagedRulesets, plan.Desired.Name) || plan.Desired.Name != change.Name {
+		return nil, fmt.Errorf("ruleset %q is not managed by sourcetool", plan.Desired.Name)
+	}
+	if len(change.Current) > 0 {
+		plan.Current = &github.RepositoryRuleset{}
+		if err := json.Unmarshal(change.Current, plan.Current); err != nil {
+			return nil, fmt.Errorf("reading current ruleset: %w", err)
+		}
+	}
+	return plan, nil
+}
+
+// CheckPlannedChange verifies the resource touched by the change has not
+// been modified since the change was planned.
+func (b *Backend) CheckPlannedChange(ctx context.Context, r *models.Repository, change *models.PlannedChange) error {
+	if change.Action == models.PlanActionNoop {
+		return nil
+	}
+
+	switch change.Resource {
+	case models.PlanResourceRuleset:
+		plan, err := rulesetPlan(change)
+		if err != nil {
+			return err
+		}
+		ghc, err := b.getGitHubConnection(r, "")
+		if err != nil {
+			return err
+		}
+		return ghc.CheckRulesetPlan(ctx, plan)
+	case models.PlanResourceFile:
+		if change.Name != workflowPath {
+			return fmt.Errorf("unexpected file in plan: %q", change.Name)
+		}
+		pr, err := b.FindWorkflowPR(ctx, r)
+		if err != nil {
+			return err
+		}
+		if pr != nil {
+			return fmt.Errorf("workflow pull request #%d was opened: %w", pr.Number, models.ErrPlanOutdated)
+		}
+		exists, err := b.workflowExists(ctx, r)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return fmt.Errorf("workflow was added to the repository: %w", models.ErrPlanOutdated)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown resource in plan: %q", change.Resource)
+	}
+}
+
+// ApplyPlannedChange makes the planned change in the repository
+func (b *Backend) ApplyPlannedChange(ctx context.Context, r *models.Repository, change *models.PlannedChange) error {
+	if change.Action == models.PlanActionNoop {
+		return nil
+	}
+
+	switch change.Resource {
+	case models.PlanResourceRuleset:
+		plan, err := rulesetPlan(change)
+		if err != nil {
+			return err
+		}
+		ghc, err := b.getGitHubConnection(r, "")
+		if err != nil {
+			return err
+		}
+		return ghc.ApplyRulesetPlan(ctx, plan)
+	case models.PlanResourceFile:
+		if change.Name != workflowPath {
+			return fmt.Errorf("unexpected file in plan: %q", change.Name)
+		}
+		if _, err := b.openWorkflowPR(r, workflowCommitMessage, workflowPRBody, &repo.PullRequestFileEntry{
+			Path:   workflowPath,
+			Reader: strings.NewReader(change.Contents),
+		}); err != nil {
+			return fmt.Errorf("creating workflow pull request: %w", err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown resource in plan: %q", change.Resource)
+	}
+}
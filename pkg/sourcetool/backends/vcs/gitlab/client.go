@@ -0,0 +1,354 @@
+// SPDX-FileCopyrightText: Copyright 2026 The SLSA Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package gitlab
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// ErrNotFound is returned by the client when the API responds with a 404
+var ErrNotFound = errors.New("resource not found")
+
+// Access levels as defined by the GitLab API
+const (
+	AccessLevelNone       = 0
+	AccessLevelDeveloper  = 30
+	AccessLevelMaintainer = 40
+)
+
+// Project is the subset of the GitLab project object we use
+type Project struct {
+	ID            int64  `json:"id"`
+	PathWithNS    string `json:"path_with_namespace"`
+	DefaultBranch string `json:"default_branch"`
+	WebURL        string `json:"web_url"`
+}
+
+// Commit is the subset of the GitLab commit object we use
+type Commit struct {
+	ID            string     `json:"id"`
+	ParentIDs     []string   `json:"parent_ids"`
+	AuthorName    string     `json:"author_name"`
+	AuthorEmail   string     `json:"author_email"`
+	CommittedDate *time.Time `json:"committed_date"`
+	Message       string     `json:"message"`
+}
+
+// BranchInfo is the GitLab branch object
+type BranchInfo struct {
+	Name      string  `json:"name"`
+	Commit    *Commit `json:"commit"`
+	Protected bool    `json:"protected"`
+}
+
+// TagInfo is the GitLab tag object
+type TagInfo struct {
+	Name    string  `json:"name"`
+	Target  string  `json:"target"`
+	Commit  *Commit `json:"commit"`
+	Message string  `json:"message"`
+}
+
+// TagSignature is the signature of an annotated tag
+type TagSignature struct {
+	SignatureType      string `json:"signature_type"`
+	VerificationStatus string `json:"verification_status"`
+}
+
+// AccessLevel describes who is allowed to perform an action on a
+// protected ref.
+type AccessLevel struct {
+	AccessLevel int    `json:"access_level"`
+	UserID      *int64 `json:"user_id,omitempty"`
+	GroupID     *int64 `json:"group_id,omitempty"`
+}
+
+// ProtectedBranch is a protected branch rule. Name can be a wildcard.
+type ProtectedBranch struct {
+	ID                        int64          `json:"id"`
+	Name                      string         `json:"name"`
+	PushAccessLevels          []*AccessLevel `json:"push_access_levels"`
+	MergeAccessLevels         []*AccessLevel `json:"merge_access_levels"`
+	AllowForcePush            bool           `json:"allow_force_push"`
+	CodeOwnerApprovalRequired bool           `json:"code_owner_approval_required"`
+}
+
+// ProtectedTag is a protected tag rule. Name can be a wildcard.
+type ProtectedTag struct {
+	Name               string         `json:"name"`
+	CreateAccessLevels []*AccessLevel `json:"create_access_levels"`
+}
+
+// PushRule are the project push rules (a premium feature)
+type PushRule struct {
+	ID                    int64 `json:"id"`
+	CommitCommitterCheck  bool  `json:"commit_committer_check"`
+	RejectUnsignedCommits bool  `json:"reject_unsigned_commits"`
+	MemberCheck           bool  `json:"member_check"`
+	DenyDeleteTag         bool  `json:"deny_delete_tag"`
+}
+
+// ApprovalRule is a merge request approval rule
+type ApprovalRule struct {
+	ID                int64              `json:"id"`
+	Name              string             `json:"name"`
+	RuleType          string             `json:"rule_type"`
+	ApprovalsRequired int                `json:"approvals_required"`
+	AppliesToAll      bool               `json:"applies_to_all_protected_branches"`
+	ProtectedBranches []*ProtectedBranch `json:"protected_branches"`
+}
+
+// ApprovalSettings are the project-level merge request approval settings
+type ApprovalSettings struct {
+	MergeRequestsAuthorApproval               bool `json:"merge_requests_author_approval"`
+	MergeRequestsDisableCommittersApproval    bool `json:"merge_requests_disable_committers_approval"`
+	DisableOverridingApproversPerMergeRequest bool `json:"disable_overriding_approvers_per_merge_request"`
+	ResetApprovalsOnPush                      bool `json:"reset_approvals_on_push"`
+}
+
+// Client is a minimal client for the GitLab v4 REST API. It only implements
+// the calls the backend needs.
+type Client struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+}
+
+// NewClient returns a new client talking to the API at baseURL, eg
+// https://gitlab.com/api/v4
+func NewClient(baseURL, token string) *Client {
+	return &Client{
+		baseURL:    baseURL,
+		token:      token,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// do performs a request to the API and decodes the response into ret.
+// It returns the response headers to read the pagination data.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, ret any) (http.Header, error) {
+	u := c.baseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("marshaling request body: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, reader)
+	if err != nil {
+		return nil, fmt.Errorf("building request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("PRIVATE-TOKEN", c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("calling GitLab API: %w", err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode == http.StatusNotFound {
+		return resp.Header, ErrNotFound
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024)) //nolint:errcheck
+		return resp.Header, fmt.Errorf("GitLab API returned %d on %s %s: %s", resp.StatusCode, method, path, string(msg))
+	}
+
+	if ret == nil {
+		return resp.Header, nil
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(ret); err != nil {
+		return resp.Header, fmt.Errorf("decoding response: %w", err)
+	}
+	return resp.Header, nil
+}
+
+// projectPath returns the API path to a project
+func projectPath(project string) string {
+	return "/projects/" + url.PathEscape(project)
+}
+
+// GetProject returns a project by its path (eg group/subgroup/name)
+func (c *Client) GetProject(ctx context.Context, project string) (*Project, error) {
+	p := &Project{}
+	if _, err := c.do(ctx, http.MethodGet, projectPath(project), nil, nil, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// GetBranch returns a branch of the project
+func (c *Client) GetBranch(ctx context.Context, project, branch string) (*BranchInfo, error) {
+	b := &BranchInfo{}
+	if _, err := c.do(ctx, http.MethodGet, projectPath(project)+"/repository/branches/"+url.PathEscape(branch), nil, nil, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// GetCommit returns a commit from the repository
+func (c *Client) GetCommit(ctx context.Context, project, sha string) (*Commit, error) {
+	cm := &Commit{}
+	if _, err := c.do(ctx, http.MethodGet, projectPath(project)+"/repository/commits/"+url.PathEscape(sha), nil, nil, cm); err != nil {
+		return nil, err
+	}
+	return cm, nil
+}
+
+// ListFirstParentCommits returns a page of the first-parent history
+// starting at ref, newest first.
+func (c *Client) ListFirstParentCommits(ctx context.Context, project, ref string, perPage int) ([]*Commit, error) {
+	q := url.Values{}
+	q.Set("ref_name", ref)
+	q.Set("first_parent", "true")
+	q.Set("per_page", strconv.Itoa(perPage))
+	ret := []*Commit{}
+	if _, err := c.do(ctx, http.MethodGet, projectPath(project)+"/repository/commits", q, nil, &ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// GetTag returns a tag from the repository
+func (c *Client) GetTag(ctx context.Context, project, tag string) (*TagInfo, error) {
+	t := &TagInfo{}
+	if _, err := c.do(ctx, http.MethodGet, projectPath(project)+"/repository/tags/"+url.PathEscape(tag), nil, nil, t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// GetTagSignature returns the signature of an annotated tag. Unsigned tags
+// return ErrNotFound.
+func (c *Client) GetTagSignature(ctx context.Context, project, tag string) (*TagSignature, error) {
+	sig := &TagSignature{}
+	if _, err := c.do(ctx, http.MethodGet, projectPath(project)+"/repository/tags/"+url.PathEscape(tag)+"/signature", nil, nil, sig); err != nil {
+		return nil, err
+	}
+	return sig, nil
+}
+
+// ListProtectedBranches returns all the protected branch rules in the project
+func (c *Client) ListProtectedBranches(ctx context.Context, project string) ([]*ProtectedBranch, error) {
+	return listAll[*ProtectedBranch](ctx, c, projectPath(project)+"/protected_branches")
+}
+
+// ListProtectedTags returns all the protected tag rules in the project
+func (c *Client) ListProtectedTags(ctx context.Context, project string) ([]*ProtectedTag, error) {
+	return listAll[*ProtectedTag](ctx, c, projectPath(project)+"/protected_tags")
+}
+
+// ListApprovalRules returns the project-level merge request approval rules
+func (c *Client) ListApprovalRules(ctx context.Context, project string) ([]*ApprovalRule, error) {
+	return listAll[*ApprovalRule](ctx, c, projectPath(project)+"/approval_rules")
+}
+
+// GetApprovalSettings returns the merge request approval settings of the project
+func (c *Client) GetApprovalSettings(ctx context.Context, project string) (*ApprovalSettings, error) {
+	s := &ApprovalSettings{}
+	if _, err := c.do(ctx, http.MethodGet, projectPath(project)+"/approvals", nil, nil, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// GetPushRule returns the project push rules. If the project has no push
+// rules configured (or the feature is not available) it returns nil.
+func (c *Client) GetPushRule(ctx context.Context, project string) (*PushRule, error) {
+	// The API returns a literal null when no rules are set
+	var r *PushRule
+	if _, err := c.do(ctx, http.MethodGet, projectPath(project)+"/push_rule", nil, nil, &r); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return r, nil
+}
+
+// ProtectBranch creates a new protected branch rule
+func (c *Client) ProtectBranch(ctx context.Context, project string, pb *ProtectedBranch) error {
+	body := map[string]any{
+		"name":               pb.Name,
+		"allow_force_push":   pb.AllowForcePush,
+		"push_access_level":  firstAccessLevel(pb.PushAccessLevels, AccessLevelMaintainer),
+		"merge_access_level": firstAccessLevel(pb.MergeAccessLevels, AccessLevelMaintainer),
+	}
+	_, err := c.do(ctx, http.MethodPost, projectPath(project)+"/protected_branches", nil, body, nil)
+	return err
+}
+
+// DisableForcePush updates an existing protected branch rule to disallow
+// force pushes.
+func (c *Client) DisableForcePush(ctx context.Context, project, name string) error {
+	_, err := c.do(
+		ctx, http.MethodPatch, projectPath(project)+"/protected_branches/"+url.PathEscape(name),
+		nil, map[string]any{"allow_force_push": false}, nil,
+	)
+	return err
+}
+
+// ProtectTag creates a new protected tag rule
+func (c *Client) ProtectTag(ctx context.Context, project string, pt *ProtectedTag) error {
+	body := map[string]any{
+		"name":                pt.Name,
+		"create_access_level": firstAccessLevel(pt.CreateAccessLevels, AccessLevelMaintainer),
+	}
+	_, err := c.do(ctx, http.MethodPost, projectPath(project)+"/protected_tags", nil, body, nil)
+	return err
+}
+
+func firstAccessLevel(levels []*AccessLevel, def int) int {
+	if len(levels) == 0 || levels[0] == nil {
+		return def
+	}
+	return levels[0].AccessLevel
+}
+
+// listAll fetches all the pages of a list endpoint following the
+// X-Next-Page header.
+func listAll[T any](ctx context.Context, c *Client, path string) ([]T, error) {
+	ret := []T{}
+	page := "1"
+	for page != "" {
+		var items []T
+		h, err := c.do(ctx, http.MethodGet, path, url.Values{
+			"per_page": []string{"100"},
+			"page":     []string{page},
+		}, nil, &items)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, items...)
+
+		page = h.Get("X-Next-Page")
+		if _, err := strconv.Atoi(page); err != nil {
+			page = ""
+		}
+	}
+	return ret, nil
+}
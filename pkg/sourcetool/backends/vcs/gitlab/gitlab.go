@@ -0,0 +1,701 @@
+// SPDX-FileCopyrightText: Copyright 2026 The SLSA Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package gitlab
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/carabiner-dev/attestation"
+	vsa "github.com/in-toto/attestation/go/predicates/vsa/v1"
+
+	"github.com/slsa-framework/source-tool/pkg/attest"
+	"github.com/slsa-framework/source-tool/pkg/provenance"
+	"github.com/slsa-framework/source-tool/pkg/slsa"
+	"github.com/slsa-framework/source-tool/pkg/sourcetool/models"
+)
+
+// Hostname is the hostname of the GitLab SaaS instance
+const Hostname = "gitlab.com"
+
+// TokenEnvVar is the environment variable the backend reads the GitLab
+// API token from.
+const TokenEnvVar = "GITLAB_TOKEN"
+
+// InherentControls are the controls that are always true because we are
+// in git and GitLab.
+var InherentControls = slsa.ControlNameSet{
+	// GitLab uses git
+	slsa.SLSA_SOURCE_ORG_SCS,
+
+	// GitLab enforces access control
+	slsa.SLSA_SOURCE_ORG_ACCESS_CONTROL,
+
+	// GitLab gives you a project id/uri
+	slsa.SLSA_SOURCE_SCS_REPO_ID,
+
+	// Git commit
+	slsa.SLSA_SOURCE_SCS_REVISION_ID,
+
+	// git diff
+	slsa.SLSA_SOURCE_SCS_DIFF_DISPLAY,
+
+	// Git is change history
+	slsa.SLSA_SOURCE_SCS_HISTORY,
+
+	// Both git and GitLab have user identities
+	slsa.SLSA_SOURCE_SCS_IDENTITY,
+}
+
+// attestationReader is the subset of the attester the backend uses to
+// look for provenance and VSAs.
+type attestationReader interface {
+	GetRevisionProvenance(context.Context, *models.Branch, *models.Commit) (*provenance.SourceProvenancePred, error)
+	GetRevisionVSA(context.Context, *models.Branch, models.Revision) (attestation.Envelope, *vsa.VerificationSummary, error)
+}
+
+// New returns a new GitLab backend. The API token is read from the
+// GITLAB_TOKEN environment variable.
+func New(options *models.BackendOptions) *Backend {
+	return &Backend{
+		Options: options,
+		token:   os.Getenv(TokenEnvVar),
+	}
+}
+
+// Backend implements the GitLab sourcetool backend
+type Backend struct {
+	Options  *models.BackendOptions
+	token    string
+	verifier attest.Verifier
+
+	// apiURL overrides the API endpoint computed from the repository
+	// hostname. Used for testing.
+	apiURL string
+
+	attesterOnce sync.Once
+	attesterErr  error
+	attestations attestationReader
+}
+
+// getClient returns an API client for the GitLab instance hosting the
+// repository.
+func (b *Backend) getClient(repository *models.Repository) (*Client, string, error) {
+	if repository == nil {
+		return nil, "", errors.New("unable to build GitLab client, repository is nil")
+	}
+
+	project := strings.Trim(repository.Path, "/")
+	if project == "" {
+		return nil, "", errors.New("repository path not set")
+	}
+
+	apiURL := b.apiURL
+	if apiURL == "" {
+		if repository.Hostname == "" {
+			return nil, "", errors.New("repository hostname not set")
+		}
+		apiURL = fmt.Sprintf("https://%s/api/v4", repository.Hostname)
+	}
+
+	return NewClient(apiURL, b.token), project, nil
+}
+
+// SetVerifier sets the verifier used to check the signatures of the
+// attestations read from the repository. It must be set before reading
+// the branch controls.
+func (b *Backend) SetVerifier(v attest.Verifier) {
+	b.verifier = v
+}
+
+// getAttestationReader returns the reader used to check for provenance
+// and VSAs in the repository notes.
+func (b *Backend) getAttestationReader() (attestationReader, error) {
+	b.attesterOnce.Do(func() {
+		if b.attestations != nil {
+			return
+		}
+		b.attestations, b.attesterErr = attest.NewAttester(
+			attest.WithBackend(b), attest.WithVerifier(b.verifier),
+		)
+	})
+	return b.attestations, b.attesterErr
+}
+
+func (b *Backend) GetBranchControls(ctx context.Context, branch *models.Branch) (*slsa.ControlSet, error) {
+	if branch.Repository == nil {
+		return nil, fmt.Errorf("branch has no repository")
+	}
+
+	commit, err := b.GetLatestCommit(ctx, branch.Repository, branch)
+	if err != nil {
+		return nil, fmt.Errorf("fetching latest commit from %q: %w", branch.FullRef(), err)
+	}
+
+	return b.GetBranchControlsAtCommit(ctx, branch, commit)
+}
+
+// GetBranchControlsAtCommit returns the full control catalog with the
+// controls enforced on the branch.
+func (b *Backend) GetBranchControlsAtCommit(ctx context.Context, branch *models.Branch, commit *models.Commit) (*slsa.ControlSet, error) {
+	if branch.Repository == nil {
+		return nil, fmt.Errorf("branch has no repository")
+	}
+
+	if commit == nil {
+		return nil, errors.New("commit is not set")
+	}
+
+	activeControls, err := b.getActiveControls(ctx, branch, commit)
+	if err != nil {
+		return nil, fmt.Errorf("checking status: %w", err)
+	}
+
+	if err := b.addAttestationControls(ctx, activeControls, branch, commit); err != nil {
+		return nil, err
+	}
+
+	// NewControlSet returns all the controls for the framework in
+	// StateNotEnabled.
+	status := slsa.NewControlSet()
+	sinceForever := time.Unix(1318032000, 0) // October 8, 2011 (first GitLab release)
+	for i, ctrl := range status.Controls {
+		if c := InherentControls.GetControl(ctrl.Name); c != "" {
+			status.Controls[i].Since = &sinceForever
+			status.Controls[i].State = slsa.StateActive
+			status.Controls[i].Message = "Inherent"
+			continue
+		}
+
+		if c := activeControls.GetControl(ctrl.Name); c != nil {
+			status.Controls[i].Since = c.Since
+			status.Controls[i].State = slsa.StateActive
+			status.Controls[i].Message = c.Message
+		}
+
+		// Without force push, content cannot be expunged.
+		if ctrl.Name == slsa.SLSA_SOURCE_ORG_SAFE_EXPUNGE {
+			if c := activeControls.GetControl(slsa.SLSA_SOURCE_SCS_CONTINUITY); c != nil {
+				status.Controls[i].Since = c.Since
+				status.Controls[i].State = slsa.StateActive
+				status.Controls[i].Message = c.Message
+			}
+		}
+
+		// Tie org continuity to the branch continuity
+		if ctrl.Name == slsa.SLSA_SOURCE_ORG_CONTINUITY {
+			if c := activeControls.GetControl(slsa.SLSA_SOURCE_SCS_CONTINUITY); c != nil {
+				status.Controls[i].Since = c.Since
+				status.Controls[i].State = slsa.StateActive
+				status.Controls[i].Message = c.Message
+			}
+		}
+	}
+
+	for i := range status.Controls {
+		status.Controls[i].RecommendedAction = b.getRecommendedAction(
+			branch.Repository, status.Controls[i].Name, status.Controls[i].State,
+		)
+	}
+
+	return status, nil
+}
+
+// getActiveControls reads the project protections from the API and maps
+// them to the SLSA controls they implement.
+//
+// GitLab does not record when a protection was enabled, so the controls
+// are only vouched for starting now, when they are observed. The commit date
+// is set by the committer and can't be trusted for this. Provenance
+// attestations carry older since dates forward as the chain of commits grows.
+func (b *Backend) getActiveControls(ctx context.Context, branch *models.Branch, commit *models.Commit) (*slsa.ControlSet, error) {
+	client, project, err := b.getClient(branch.Repository)
+	if err != nil {
+		return nil, err
+	}
+
+	rawCommit, err := client.GetCommit(ctx, project, commit.SHA)
+	if err != nil {
+		return nil, fmt.Errorf("fetching commit %q: %w", commit.SHA, err)
+	}
+
+	since := time.Now()
+	commitTime := since
+	if rawCommit.CommittedDate != nil {
+		commitTime = *rawCommit.CommittedDate
+	}
+
+	controls := &slsa.ControlSet{
+		RepoUri:        branch.Repository.GetHttpURL(),
+		Branch:         branch.FullRef(),
+		Time:           since,
+		CommitPushTime: commitTime,
+	}
+
+	protectedBranches, err := client.ListProtectedBranches(ctx, project)
+	if err != nil {
+		return nil, fmt.Errorf("listing protected branches: %w", err)
+	}
+	rules := matchingBranchRules(protectedBranches, branch.Name)
+
+	if continuityEnforced(rules) {
+		controls.AddControl(&slsa.Control{
+			Name:    slsa.SLSA_SOURCE_SCS_CONTINUITY,
+			Since:   &since,
+			Message: "Branch is protected and force pushes are disabled",
+		})
+	}
+
+	pushRule, err := client.GetPushRule(ctx, project)
+	if err != nil {
+		return nil, fmt.Errorf("reading push rules: %w", err)
+	}
+
+	protectedTags, err := client.ListProtectedTags(ctx, project)
+	if err != nil {
+		return nil, fmt.Errorf("listing protected tags: %w", err)
+	}
+
+	if msg := tagProtectionMessage(protectedTags, pushRule); msg != "" {
+		controls.AddControl(&slsa.Control{
+			Name:    slsa.SLSA_SOURCE_SCS_PROTECTED_REFS,
+			Since:   &since,
+			Message: msg,
+		})
+	}
+
+	// Review is only meaningful when changes can only land through
+	// merge requests.
+	if continuityEnforced(rules) && mergeRequestOnly(rules) {
+		reviewed, err := b.reviewEnforced(ctx, client, project, rules)
+		if err != nil {
+			return nil, err
+		}
+		if reviewed {
+			controls.AddControl(&slsa.Control{
+				Name:    slsa.SLSA_SOURCE_SCS_TWO_PARTY_REVIEW,
+				Since:   &since,
+				Message: "Merge requests require approval from someone other than the author",
+			})
+		}
+	}
+
+	return controls, nil
+}
+
+// reviewEnforced checks the merge request approval rules and settings to
+// determine if changes require approval from someone other than the author.
+func (b *Backend) reviewEnforced(ctx context.Context, client *Client, project string, rules []*ProtectedBranch) (bool, error) {
+	settings, err := client.GetApprovalSettings(ctx, project)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("reading approval settings: %w", err)
+	}
+
+	// If authors can approve their own changes or the rules can be
+	// overridden in each merge request, there is no two party review.
+	if settings.MergeRequestsAuthorApproval || !settings.DisableOverridingApproversPerMergeRequest {
+		return false, nil
+	}
+
+	approvalRules, err := client.ListApprovalRules(ctx, project)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("listing approval rules: %w", err)
+	}
+
+	for _, ar := range approvalRules {
+		if ar.ApprovalsRequired < 1 {
+			continue
+		}
+		// Rules without protected branches apply to all branches
+		if ar.AppliesToAll || len(ar.ProtectedBranches) == 0 {
+			return true, nil
+		}
+		for _, pb := range ar.ProtectedBranches {
+			for _, r := range rules {
+				if pb.Name == r.Name {
+					return true, nil
+				}
+			}
+		}
+	}
+	return false, nil
+}
+
+// addAttestationControls checks for provenance and VSAs on the commit
+// and adds them to the control set.
+func (b *Backend) addAttestationControls(ctx context.Context, activeControls *slsa.ControlSet, branch *models.Branch, commit *models.Commit) error {
+	reader, err := b.getAttestationReader()
+	if err != nil {
+		return err
+	}
+
+	pred, err := reader.GetRevisionProvenance(ctx, branch, commit)
+	if err != nil {
+		return fmt.Errorf("attempting to read provenance from commit %q: %w", commit.SHA, err)
+	}
+	if pred != nil {
+		// Carry over the since date from the previous attestation
+		ctrl := &slsa.Control{
+			Name:    slsa.SLSA_SOURCE_SCS_PROVENANCE,
+			Message: "Signed provenance metadata is being published on every commit",
+		}
+		if pc := pred.GetControl(slsa.SLSA_SOURCE_SCS_PROVENANCE.String()); pc != nil {
+			if pc.GetSince() != nil && pc.GetSince().AsTime().Unix() != 0 {
+				t := pc.GetSince().AsTime()
+				ctrl.Since = &t
+			}
+		}
+		activeControls.AddControl(ctrl)
+	} else {
+		log.Printf("No provenance attestation found on %s", commit.SHA)
+	}
+
+	_, vsaPred, err := reader.GetRevisionVSA(ctx, branch, commit)
+	if err != nil {
+		return fmt.Errorf("reading VSA: %w", err)
+	}
+	if vsaPred != nil {
+		activeControls.AddControl(&slsa.Control{
+			Name: slsa.SLSA_SOURCE_SCS_VSA,
+		})
+	}
+	return nil
+}
+
+// matchingBranchRules returns the protected branch rules that apply to
+// a branch, honoring GitLab wildcards.
+func matchingBranchRules(rules []*ProtectedBranch, branchName string) []*ProtectedBranch {
+	ret := []*ProtectedBranch{}
+	for _, r := range rules {
+		if matchesWildcard(r.Name, branchName) {
+			ret = append(ret, r)
+		}
+	}
+	return ret
+}
+
+// matchesWildcard matches a name against a GitLab protected ref name
+// where * matches any string.
+func matchesWildcard(pattern, name string) bool {
+	if !strings.Contains(pattern, "*") {
+		return pattern == name
+	}
+	parts := strings.Split(pattern, "*")
+	for i := range parts {
+		parts[i] = regexp.QuoteMeta(parts[i])
+	}
+	re, err := regexp.Compile("^" + strings.Join(parts, ".*") + "$")
+	if err != nil {
+		return false
+	}
+	return re.MatchString(name)
+}
+
+// continuityEnforced returns true when the branch is protected and none
+// of the rules matching it allow force pushes. When several rules match a
+// branch, GitLab applies the most permissive one.
+func continuityEnforced(rules []*ProtectedBranch) bool {
+	if len(rules) == 0 {
+		return false
+	}
+	for _, r := range rules {
+		if r.AllowForcePush {
+			return false
+		}
+	}
+	return true
+}
+
+// mergeRequestOnly returns true when nobody can push directly to the
+// branch, forcing all changes through merge requests.
+func mergeRequestOnly(rules []*ProtectedBranch) bool {
+	if len(rules) == 0 {
+		return false
+	}
+	for _, r := range rules {
+		for _, al := range r.PushAccessLevels {
+			if al.AccessLevel != AccessLevelNone || al.UserID != nil || al.GroupID != nil {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// tagProtectionMessage returns the message describing the tag protection
+// in place or an empty string if tags are not protected. Tags are protected
+// when a protected tag rule covers all tags or when push rules deny
+// deleting them.
+func tagProtectionMessage(tags []*ProtectedTag, pushRule *PushRule) string {
+	for _, t := range tags {
+		if t.Name == "*" {
+			return "All tags are protected in the project"
+		}
+	}
+	if pushRule != nil && pushRule.DenyDeleteTag {
+		return "Push rules deny deleting tags in the project"
+	}
+	return ""
+}
+
+func (b *Backend) GetTagControls(ctx context.Context, branch *models.Branch, tag *models.Tag) (*slsa.ControlSet, error) {
+	if tag.Commit == nil || tag.Commit.SHA == "" {
+		return nil, errors.New("tag commit is empty")
+	}
+	return b.GetBranchControlsAtCommit(ctx, branch, tag.Commit)
+}
+
+func (b *Backend) ControlConfigurationDescr(branch *models.Branch, config models.ControlConfiguration) string {
+	repo := branch.Repository
+	if repo == nil {
+		repo = &models.Repository{
+			Path: "your project",
+		}
+	}
+
+	switch config {
+	case models.CONFIG_BRANCH_RULES:
+		return fmt.Sprintf(
+			"Protect branch %s in %s and disable force pushes",
+			branch.Name, repo.Path,
+		)
+	case models.CONFIG_GEN_PROVENANCE:
+		return "Provenance generation is not yet supported on GitLab"
+	case models.CONFIG_REVIEW_RULES:
+		return "Configuring review rules is not yet supported on GitLab"
+	case models.CONFIG_POLICY:
+		return fmt.Sprintf(
+			"Open a pull request on the SLSA policy repo to check-in %s SLSA source policy",
+			repo.Path,
+		)
+	case models.CONFIG_TAG_RULES:
+		return fmt.Sprintf(
+			"Protect all tags in %s",
+			repo.Path,
+		)
+	default:
+		return ""
+	}
+}
+
+// GetLatestCommit returns the latest commit from a branch
+func (b *Backend) GetLatestCommit(ctx context.Context, r *models.Repository, branch *models.Branch) (*models.Commit, error) {
+	client, project, err := b.getClient(r)
+	if err != nil {
+		return nil, fmt.Errorf("building GitLab client: %w", err)
+	}
+
+	info, err := client.GetBranch(ctx, project, branch.Name)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, fmt.Errorf("%w: %s", models.ErrBranchNotFound, branch.Name)
+		}
+		return nil, fmt.Errorf("reading latest commit: %w", err)
+	}
+	if info.Commit == nil {
+		return nil, fmt.Errorf("branch %q has no commit", branch.Name)
+	}
+
+	return toModelCommit(info.Commit), nil
+}
+
+// getRecommendedAction returns the recommended action based on the
+// status of a SLSA control
+func (b *Backend) getRecommendedAction(r *models.Repository, control slsa.ControlName, state slsa.ControlState) *slsa.ControlRecommendedAction {
+	if state != slsa.StateNotEnabled {
+		return nil
+	}
+
+	//nolint:exhaustive // Not all drivers handle all controls
+	switch control {
+	case slsa.SLSA_SOURCE_ORG_CONTINUITY, slsa.SLSA_SOURCE_SCS_CONTINUITY:
+		return &slsa.ControlRecommendedAction{
+			Message: "Protect the branch and disable force pushes",
+			Command: fmt.Sprintf("sourcetool setup controls --config=%s %s", models.CONFIG_BRANCH_RULES, r.Path),
+		}
+	case slsa.SLSA_SOURCE_SCS_PROTECTED_REFS:
+		return &slsa.ControlRecommendedAction{
+			Message: "Protect all tags in the project",
+			Command: fmt.Sprintf("sourcetool setup controls --config=%s %s", models.CONFIG_TAG_RULES, r.Path),
+		}
+	case slsa.SLSA_SOURCE_SCS_TWO_PARTY_REVIEW:
+		return &slsa.ControlRecommendedAction{
+			Message: "Require merge request approvals and disallow author approval",
+		}
+	default:
+		return nil
+	}
+}
+
+// GetPreviousCommit returns the parent of a commit. For merge commits this
+// is the first parent.
+func (b *Backend) GetPreviousCommit(ctx context.Context, branch *models.Branch, commit *models.Commit) (*models.Commit, error) {
+	parents, err := b.GetCommitParents(ctx, branch, commit)
+	if err != nil {
+		return nil, fmt.Errorf("fetching previous commit: %w", err)
+	}
+
+	if len(parents) == 0 {
+		return nil, fmt.Errorf("there is no commit earlier than %s, that isn't yet supported", commit.SHA)
+	}
+
+	return parents[0], nil
+}
+
+// GetCommitHistory returns up to limit commits of the first-parent history
+// of the branch starting at commit, newest first.
+func (b *Backend) GetCommitHistory(ctx context.Context, branch *models.Branch, commit *models.Commit, limit int) ([]*models.Commit, error) {
+	client, project, err := b.getClient(branch.Repository)
+	if err != nil {
+		return nil, err
+	}
+
+	commits := []*models.Commit{}
+	next := commit.SHA
+	for next != "" && (limit <= 0 || len(commits) < limit) {
+		page, err := client.ListFirstParentCommits(ctx, project, next, 100)
+		if err != nil {
+			return nil, fmt.Errorf("reading commit history: %w", err)
+		}
+		for _, c := range page {
+			if limit > 0 && len(commits) >= limit {
+				break
+			}
+			commits = append(commits, toModelCommit(c))
+			next = ""
+			if len(c.ParentIDs) > 0 {
+				next = c.ParentIDs[0]
+			}
+		}
+		// A short page means we reached the root commit
+		if len(page) < 100 {
+			break
+		}
+	}
+	return commits, nil
+}
+
+// GetCommitParents returns all the parents of a commit, first parent first
+func (b *Backend) GetCommitParents(ctx context.Context, branch *models.Branch, commit *models.Commit) ([]*models.Commit, error) {
+	client, project, err := b.getClient(branch.Repository)
+	if err != nil {
+		return nil, err
+	}
+
+	rawCommit, err := client.GetCommit(ctx, project, commit.SHA)
+	if err != nil {
+		return nil, err
+	}
+
+	parents := make([]*models.Commit, 0, len(rawCommit.ParentIDs))
+	for _, id := range rawCommit.ParentIDs {
+		parents = append(parents, &models.Commit{SHA: id})
+	}
+	return parents, nil
+}
+
+// GetDefaultBranch returns the default branch
+func (b *Backend) GetDefaultBranch(ctx context.Context, repo *models.Repository) (*models.Branch, error) {
+	client, project, err := b.getClient(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := client.GetProject(ctx, project)
+	if err != nil {
+		return nil, fmt.Errorf("fetching default branch: %w", err)
+	}
+
+	if p.DefaultBranch == "" {
+		return nil, errors.New("project has no default branch")
+	}
+
+	return &models.Branch{
+		Name:       p.DefaultBranch,
+		Repository: repo,
+	}, nil
+}
+
+// GetRevisionCommit returns the commit of a revision (or error)
+func (b *Backend) GetRevisionCommit(ctx context.Context, repo *models.Repository, rev models.Revision) (*models.Commit, error) {
+	switch inst := rev.(type) {
+	case *models.Commit:
+		return inst, nil
+	case *models.Tag:
+		if inst.Commit == nil {
+			client, project, err := b.getClient(repo)
+			if err != nil {
+				return nil, err
+			}
+			tag, err := client.GetTag(ctx, project, inst.GetName())
+			if err != nil {
+				return nil, fmt.Errorf("fetching tag %q: %w", inst.GetName(), err)
+			}
+			if tag.Commit == nil {
+				return nil, fmt.Errorf("tag %q has no commit", inst.GetName())
+			}
+			inst.Commit = toModelCommit(tag.Commit)
+		}
+		return inst.Commit, nil
+	default:
+		return nil, errors.New("not implemented yet or invalid revision")
+	}
+}
+
+// GetTagInfo reads the tag object of a tag
+func (b *Backend) GetTagInfo(ctx context.Context, repo *models.Repository, tag *models.Tag) (*models.TagInfo, error) {
+	client, project, err := b.getClient(repo)
+	if err != nil {
+		return nil, err
+	}
+	t, err := client.GetTag(ctx, project, tag.GetName())
+	if err != nil {
+		return nil, fmt.Errorf("fetching tag %q: %w", tag.GetName(), err)
+	}
+
+	// The target of annotated tags is the tag object, not the commit
+	info := &models.TagInfo{
+		Annotated: t.Commit != nil && t.Target != "" && t.Target != t.Commit.ID,
+	}
+	if !info.Annotated {
+		return info, nil
+	}
+
+	sig, err := client.GetTagSignature(ctx, project, tag.GetName())
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return info, nil
+	case err != nil:
+		return nil, fmt.Errorf("fetching signature of tag %q: %w", tag.GetName(), err)
+	}
+	info.SignatureVerified = sig.VerificationStatus == "verified"
+	return info, nil
+}
+
+func toModelCommit(c *Commit) *models.Commit {
+	parents := []string{}
+	if c.ParentIDs != nil {
+		parents = append(parents, c.ParentIDs...)
+	}
+	return &models.Commit{
+		SHA:     c.ID,
+		Author:  c.AuthorName,
+		Time:    c.CommittedDate,
+		Message: c.Message,
+		Parents: parents,
+	}
+}
@@ -0,0 +1,506 @@
+// SPDX-FileCopyrightText: Copyright 2026 The SLSA Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package gitlab
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/carabiner-dev/attestation"
+	vsa "github.com/in-toto/attestation/go/predicates/vsa/v1"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/slsa-framework/source-tool/pkg/provenance"
+	"github.com/slsa-framework/source-tool/pkg/slsa"
+	"github.com/slsa-framework/source-tool/pkg/sourcetool/models"
+)
+
+const (
+	testProject = "/projects/group%2Frepo"
+	testSHA     = "abc123abc123abc123abc123abc123abc123abc1"
+	testParent  = "def456def456def456def456def456def456def4"
+)
+
+var commitTime = time.Unix(1678886400, 0).UTC() // March 15, 2023 00:00:00 UTC
+
+// fakeAttestations is a canned attestation reader
+type fakeAttestations struct {
+	prov *provenance.SourceProvenancePred
+	vsa  *vsa.VerificationSummary
+}
+
+func (fa *fakeAttestations) GetRevisionProvenance(context.Context, *models.Branch, *models.Commit) (*provenance.SourceProvenancePred, error) {
+	return fa.prov, nil
+}
+
+func (fa *fakeAttestations) GetRevisionVSA(context.Context, *models.Branch, models.Revision) (attestation.Envelope, *vsa.VerificationSummary, error) {
+	return nil, fa.vsa, nil
+}
+
+// mockGitLab is a fake GitLab API. Responses are keyed by
+// "METHOD path" and requests are recorded.
+type mockGitLab struct {
+	mtx       sync.Mutex
+	responses map[string]any
+	requests  map[string][]map[string]any
+}
+
+func newMockGitLab(t *testing.T, responses map[string]any) (*mockGitLab, *httptest.Server) {
+	t.Helper()
+	m := &mockGitLab{responses: responses, requests: map[string][]map[string]any{}}
+	srv := httptest.NewServer(m)
+	t.Cleanup(srv.Close)
+	return m, srv
+}
+
+func (m *mockGitLab) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key := r.Method + " " + r.URL.EscapedPath()
+
+	m.mtx.Lock()
+	if r.Body != nil {
+		body := map[string]any{}
+		data, _ := io.ReadAll(r.Body) //nolint:errcheck
+		if len(data) > 0 {
+			_ = json.Unmarshal(data, &body) //nolint:errcheck
+		}
+		m.requests[key] = append(m.requests[key], body)
+	}
+	resp, ok := m.responses[key]
+	m.mtx.Unlock()
+
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if resp == nil {
+		w.WriteHeader(http.StatusCreated)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp) //nolint:errcheck
+}
+
+func (m *mockGitLab) requestsTo(key string) []map[string]any {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	return m.requests[key]
+}
+
+func newTestBackend(url string, att attestationReader) *Backend {
+	b := New(&models.BackendOptions{})
+	b.apiURL = url
+	b.attestations = att
+	return b
+}
+
+func testBranch() *models.Branch {
+	return &models.Branch{
+		Name: "main",
+		Repository: &models.Repository{
+			Hostname: Hostname,
+			Path:     "group/repo",
+		},
+	}
+}
+
+// baseResponses returns the API responses of an unprotected project
+func baseResponses() map[string]any {
+	return map[string]any{
+		"GET " + testProject + "/repository/commits/" + testSHA: &Commit{
+			ID: testSHA, ParentIDs: []string{testParent}, CommittedDate: &commitTime,
+		},
+		"GET " + testProject + "/protected_branches": []*ProtectedBranch{},
+		"GET " + testProject + "/protected_tags":     []*ProtectedTag{},
+		"GET " + testProject + "/approvals":          &ApprovalSettings{},
+		"GET " + testProject + "/approval_rules":     []*ApprovalRule{},
+	}
+}
+
+func TestGetBranchControlsAtCommit(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		name      string
+		responses func() map[string]any
+		att       *fakeAttestations
+		active    []slsa.ControlName
+		notActive []slsa.ControlName
+		observed  bool
+	}{
+		{
+			name:      "unprotected",
+			responses: baseResponses,
+			att:       &fakeAttestations{},
+			active:    InherentControls,
+			notActive: []slsa.ControlName{
+				slsa.SLSA_SOURCE_SCS_CONTINUITY, slsa.SLSA_SOURCE_ORG_CONTINUITY,
+				slsa.SLSA_SOURCE_SCS_PROTECTED_REFS, slsa.SLSA_SOURCE_SCS_TWO_PARTY_REVIEW,
+				slsa.SLSA_SOURCE_SCS_PROVENANCE, slsa.SLSA_SOURCE_SCS_VSA,
+			},
+		},
+		{
+			name: "protected-no-force-push",
+			responses: func() map[string]any {
+				r := baseResponses()
+				r["GET "+testProject+"/protected_branches"] = []*ProtectedBranch{
+					{Name: "main", PushAccessLevels: []*AccessLevel{{AccessLevel: AccessLevelMaintainer}}},
+				}
+				return r
+			},
+			att:      &fakeAttestations{},
+			observed: true,
+			active: []slsa.ControlName{
+				slsa.SLSA_SOURCE_SCS_CONTINUITY, slsa.SLSA_SOURCE_ORG_CONTINUITY, slsa.SLSA_SOURCE_ORG_SAFE_EXPUNGE,
+			},
+			notActive: []slsa.ControlName{slsa.SLSA_SOURCE_SCS_TWO_PARTY_REVIEW},
+		},
+		{
+			name: "wildcard-allows-force-push",
+			responses: func() map[string]any {
+				r := baseResponses()
+				r["GET "+testProject+"/protected_branches"] = []*ProtectedBranch{
+					{Name: "main"},
+					{Name: "ma*", AllowForcePush: true},
+				}
+				return r
+			},
+			att:       &fakeAttestations{},
+			notActive: []slsa.ControlName{slsa.SLSA_SOURCE_SCS_CONTINUITY, slsa.SLSA_SOURCE_ORG_CONTINUITY},
+		},
+		{
+			name: "merge-request-review",
+			responses: func() map[string]any {
+				r := baseResponses()
+				r["GET "+testProject+"/protected_branches"] = []*ProtectedBranch{
+					{Name: "main", PushAccessLevels: []*AccessLevel{{AccessLevel: AccessLevelNone}}},
+				}
+				r["GET "+testProject+"/approvals"] = &ApprovalSettings{
+					DisableOverridingApproversPerMergeRequest: true,
+				}
+				r["GET "+testProject+"/approval_rules"] = []*ApprovalRule{
+					{Name: "reviewers", ApprovalsRequired: 1, ProtectedBranches: []*ProtectedBranch{{Name: "main"}}},
+				}
+				return r
+			},
+			att:      &fakeAttestations{},
+			observed: true,
+			active:   []slsa.ControlName{slsa.SLSA_SOURCE_SCS_CONTINUITY, slsa.SLSA_SOURCE_SCS_TWO_PARTY_REVIEW},
+		},
+		{
+			name: "author-can-approve",
+			responses: func() map[string]any {
+				r := baseResponses()
+				r["GET "+testProject+"/protected_branches"] = []*ProtectedBranch{
+					{Name: "main", PushAccessLevels: []*AccessLevel{{AccessLevel: AccessLevelNone}}},
+				}
+				r["GET "+testProject+"/approvals"] = &ApprovalSettings{
+					MergeRequestsAuthorApproval:               true,
+					DisableOverridingApproversPerMergeRequest: true,
+				}
+				r["GET "+testProject+"/approval_rules"] = []*ApprovalRule{
+					{Name: "reviewers", ApprovalsRequired: 1},
+				}
+				return r
+			},
+			att:       &fakeAttestations{},
+			observed:  true,
+			active:    []slsa.ControlName{slsa.SLSA_SOURCE_SCS_CONTINUITY},
+			notActive: []slsa.ControlName{slsa.SLSA_SOURCE_SCS_TWO_PARTY_REVIEW},
+		},
+		{
+			name: "protected-tags",
+			responses: func() map[string]any {
+				r := baseResponses()
+				r["GET "+testProject+"/protected_tags"] = []*ProtectedTag{{Name: "*"}}
+				return r
+			},
+			att:      &fakeAttestations{},
+			observed: true,
+			active:   []slsa.ControlName{slsa.SLSA_SOURCE_SCS_PROTECTED_REFS},
+		},
+		{
+			name: "push-rule-deny-tag-delete",
+			responses: func() map[string]any {
+				r := baseResponses()
+				r["GET "+testProject+"/push_rule"] = &PushRule{DenyDeleteTag: true}
+				return r
+			},
+			att:      &fakeAttestations{},
+			observed: true,
+			active:   []slsa.ControlName{slsa.SLSA_SOURCE_SCS_PROTECTED_REFS},
+		},
+		{
+			name:      "attestations",
+			responses: baseResponses,
+			att: &fakeAttestations{
+				prov: &provenance.SourceProvenancePred{
+					Controls: []*provenance.Control{
+						{Name: slsa.SLSA_SOURCE_SCS_PROVENANCE.String(), Since: timestamppb.New(commitTime.Add(-time.Hour))},
+					},
+				},
+				vsa: &vsa.VerificationSummary{},
+			},
+			active: []slsa.ControlName{slsa.SLSA_SOURCE_SCS_PROVENANCE},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			_, srv := newMockGitLab(t, tc.responses())
+			b := newTestBackend(srv.URL, tc.att)
+			start := time.Now()
+
+			status, err := b.GetBranchControlsAtCommit(t.Context(), testBranch(), &models.Commit{SHA: testSHA})
+			require.NoError(t, err)
+			require.Len(t, status.Controls, len(slsa.AllLevelControls))
+
+			for _, name := range tc.active {
+				ctrl := status.GetControl(name)
+				require.NotNil(t, ctrl, name)
+				require.Equal(t, slsa.StateActive, ctrl.State, name)
+				require.NotNil(t, ctrl.Since, name)
+				// Protections are only vouched for since they are observed,
+				// never since the (committer supplied) commit date.
+				if tc.observed {
+					require.False(t, ctrl.Since.Before(start), name)
+					continue
+				}
+				require.False(t, ctrl.Since.After(commitTime), name)
+			}
+			if tc.att.vsa != nil {
+				require.Equal(t, slsa.StateActive, status.GetControl(slsa.SLSA_SOURCE_SCS_VSA).State)
+			}
+			for _, name := range tc.notActive {
+				ctrl := status.GetControl(name)
+				require.NotNil(t, ctrl, name)
+				require.Equal(t, slsa.StateNotEnabled, ctrl.State, name)
+			}
+		})
+	}
+}
+
+func TestMatchesWildcard(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		pattern string
+		name    string
+		expect  bool
+	}{
+		{"main", "main", true},
+		{"main", "mainline", false},
+		{"*", "main", true},
+		{"release/*", "release/v1.0", true},
+		{"release/*", "releases", false},
+		{"*-stable", "v1-stable", true},
+		{"v1.*", "v1x2", false},
+	} {
+		t.Run(tc.pattern+"/"+tc.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tc.expect, matchesWildcard(tc.pattern, tc.name))
+		})
+	}
+}
+
+func TestGetPreviousCommit(t *testing.T) {
+	t.Parallel()
+	mergeSHA := "0000000000000000000000000000000000000001"
+	for _, tc := range []struct {
+		name    string
+		sha     string
+		expect  string
+		parents int
+		mustErr bool
+	}{
+		{name: "single-parent", sha: testSHA, expect: testParent, parents: 1},
+		{name: "no-parents", sha: testParent, mustErr: true},
+		{name: "merge", sha: mergeSHA, expect: testParent, parents: 2},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			_, srv := newMockGitLab(t, map[string]any{
+				"GET " + testProject + "/repository/commits/" + testSHA:    &Commit{ID: testSHA, ParentIDs: []string{testParent}},
+				"GET " + testProject + "/repository/commits/" + testParent: &Commit{ID: testParent},
+				"GET " + testProject + "/repository/commits/" + mergeSHA:   &Commit{ID: mergeSHA, ParentIDs: []string{testParent, testSHA}},
+			})
+			b := newTestBackend(srv.URL, nil)
+
+			parents, err := b.GetCommitParents(t.Context(), testBranch(), &models.Commit{SHA: tc.sha})
+			require.NoError(t, err)
+			require.Len(t, parents, tc.parents)
+
+			prev, err := b.GetPreviousCommit(t.Context(), testBranch(), &models.Commit{SHA: tc.sha})
+			if tc.mustErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, prev.SHA)
+		})
+	}
+}
+
+func TestRepositoryData(t *testing.T) {
+	t.Parallel()
+	_, srv := newMockGitLab(t, map[string]any{
+		"GET " + testProject: &Project{ID: 1, DefaultBranch: "trunk"},
+		"GET " + testProject + "/repository/branches/trunk": &BranchInfo{
+			Name: "trunk", Commit: &Commit{ID: testSHA, CommittedDate: &commitTime},
+		},
+		"GET " + testProject + "/repository/tags/v1.0.0": &TagInfo{
+			Name: "v1.0.0", Target: testParent, Commit: &Commit{ID: testParent},
+		},
+		"GET " + testProject + "/repository/tags/v2.0.0": &TagInfo{
+			Name: "v2.0.0", Target: "0a0a0a", Commit: &Commit{ID: testSHA},
+		},
+		"GET " + testProject + "/repository/tags/v2.0.0/signature": &TagSignature{
+			SignatureType: "PGP", VerificationStatus: "verified",
+		},
+		"GET " + testProject + "/repository/tags/v2.1.0": &TagInfo{
+			Name: "v2.1.0", Target: "0b0b0b", Commit: &Commit{ID: testSHA},
+		},
+	})
+	b := newTestBackend(srv.URL, nil)
+	repo := testBranch().Repository
+
+	branch, err := b.GetDefaultBranch(t.Context(), repo)
+	require.NoError(t, err)
+	require.Equal(t, "trunk", branch.Name)
+
+	commit, err := b.GetLatestCommit(t.Context(), repo, branch)
+	require.NoError(t, err)
+	require.Equal(t, testSHA, commit.SHA)
+	require.Equal(t, commitTime, *commit.Time)
+
+	commit, err = b.GetRevisionCommit(t.Context(), repo, &models.Tag{Name: "v1.0.0"})
+	require.NoError(t, err)
+	require.Equal(t, testParent, commit.SHA)
+
+	_, err = b.GetRevisionCommit(t.Context(), repo, &models.Tag{Name: "nope"})
+	require.Error(t, err)
+
+	for tag, expected := range map[string]*models.TagInfo{
+		"v1.0.0": {},
+		"v2.0.0": {Annotated: true, SignatureVerified: true},
+		"v2.1.0": {Annotated: true},
+	} {
+		info, err := b.GetTagInfo(t.Context(), repo, &models.Tag{Name: tag})
+		require.NoError(t, err, tag)
+		require.Equal(t, expected, info, tag)
+	}
+}
+
+func TestConfigureControls(t *testing.T) {
+	t.Parallel()
+	t.Run("protect-new", func(t *testing.T) {
+		t.Parallel()
+		r := baseResponses()
+		r["POST "+testProject+"/protected_branches"] = nil
+		r["POST "+testProject+"/protected_tags"] = nil
+		m, srv := newMockGitLab(t, r)
+		b := newTestBackend(srv.URL, nil)
+
+		err := b.ConfigureControls(
+			testBranch().Repository, []*models.Branch{testBranch()},
+			[]models.ControlConfiguration{models.CONFIG_BRANCH_RULES, models.CONFIG_TAG_RULES, models.CONFIG_POLICY},
+		)
+		require.NoError(t, err)
+
+		reqs := m.requestsTo("POST " + testProject + "/protected_branches")
+		require.Len(t, reqs, 1)
+		require.Equal(t, "main", reqs[0]["name"])
+		require.Equal(t, false, reqs[0]["allow_force_push"])
+
+		reqs = m.requestsTo("POST " + testProject + "/protected_tags")
+		require.Len(t, reqs, 1)
+		require.Equal(t, "*", reqs[0]["name"])
+	})
+	t.Run("disable-force-push", func(t *testing.T) {
+		t.Parallel()
+		r := baseResponses()
+		r["GET "+testProject+"/protected_branches"] = []*ProtectedBranch{{Name: "main", AllowForcePush: true}}
+		r["PATCH "+testProject+"/protected_branches/main"] = nil
+		m, srv := newMockGitLab(t, r)
+		b := newTestBackend(srv.URL, nil)
+
+		require.NoError(t, b.ProtectBranches(t.Context(), testBranch().Repository, []*models.Branch{testBranch()}))
+		reqs := m.requestsTo("PATCH " + testProject + "/protected_branches/main")
+		require.Len(t, reqs, 1)
+		require.Equal(t, false, reqs[0]["allow_force_push"])
+	})
+	t.Run("already-in-place", func(t *testing.T) {
+		t.Parallel()
+		r := baseResponses()
+		r["GET "+testProject+"/protected_branches"] = []*ProtectedBranch{{Name: "main"}}
+		r["GET "+testProject+"/protected_tags"] = []*ProtectedTag{{Name: "*"}}
+		_, srv := newMockGitLab(t, r)
+		b := newTestBackend(srv.URL, nil)
+
+		err := b.ProtectBranches(t.Context(), testBranch().Repository, []*models.Branch{testBranch()})
+		require.ErrorIs(t, err, models.ErrProtectionAlreadyInPlace)
+		err = b.ProtectTags(t.Context(), testBranch().Repository)
+		require.ErrorIs(t, err, models.ErrProtectionAlreadyInPlace)
+
+		require.NoError(t, b.ConfigureControls(
+			testBranch().Repository, []*models.Branch{testBranch()},
+			[]models.ControlConfiguration{models.CONFIG_BRANCH_RULES, models.CONFIG_TAG_RULES},
+		))
+	})
+	t.Run("provenance-unsupported", func(t *testing.T) {
+		t.Parallel()
+		b := newTestBackend("http://localhost:0", nil)
+		err := b.ConfigureControls(
+			testBranch().Repository, []*models.Branch{testBranch()},
+			[]models.ControlConfiguration{models.CONFIG_GEN_PROVENANCE},
+		)
+		require.ErrorIs(t, err, ErrProvenanceNotSupported)
+	})
+	t.Run("review-unsupported", func(t *testing.T) {
+		t.Parallel()
+		b := newTestBackend("http://localhost:0", nil)
+		err := b.ConfigureControls(
+			testBranch().Repository, []*models.Branch{testBranch()},
+			[]models.ControlConfiguration{models.CONFIG_REVIEW_RULES},
+		)
+		require.ErrorIs(t, err, ErrReviewRulesNotSupported)
+	})
+	t.Run("staging-unsupported", func(t *testing.T) {
+		t.Parallel()
+		b := newTestBackend("http://localhost:0", nil)
+		b.Options.StageRules = true
+		err := b.ConfigureControls(
+			testBranch().Repository, []*models.Branch{testBranch()},
+			[]models.ControlConfiguration{models.CONFIG_BRANCH_RULES, models.CONFIG_TAG_RULES},
+		)
+		require.ErrorIs(t, err, ErrStagingNotSupported)
+	})
+}
+
+func TestListAllPagination(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := r.URL.Query().Get("page")
+		w.Header().Set("Content-Type", "application/json")
+		switch page {
+		case "1":
+			w.Header().Set("X-Next-Page", "2")
+			_ = json.NewEncoder(w).Encode([]*ProtectedTag{{Name: "v1"}}) //nolint:errcheck
+		case "2":
+			w.Header().Set("X-Next-Page", "")
+			_ = json.NewEncoder(w).Encode([]*ProtectedTag{{Name: "v2"}}) //nolint:errcheck
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	tags, err := NewClient(srv.URL, "token").ListProtectedTags(t.Context(), "group/repo")
+	require.NoError(t, err)
+	require.Len(t, tags, 2)
+	require.Equal(t, "v2", tags[1].Name)
+}
@@ -0,0 +1,202 @@
+// SPDX-FileCopyrightText: Copyright 2026 The SLSA Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package gitlab
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/slsa-framework/source-tool/pkg/sourcetool/models"
+)
+
+// ErrProvenanceNotSupported is returned when trying to configure provenance
+// generation in a GitLab project.
+var ErrProvenanceNotSupported = errors.New("provenance generation is not yet supported on GitLab")
+
+// ErrReviewRulesNotSupported is returned when trying to configure review
+// rules in a GitLab project.
+var ErrReviewRulesNotSupported = errors.New("configuring review rules is not yet supported on GitLab")
+
+// ErrStagingNotSupported is returned when trying to stage rules, GitLab
+// protections have no evaluate mode.
+var ErrStagingNotSupported = errors.New("staging rules in evaluate mode is not supported on GitLab")
+
+// ErrRemovalNotSupported is returned when trying to remove controls from a
+// GitLab project.
+var ErrRemovalNotSupported = errors.New("removing controls is not yet supported on GitLab")
+
+// ErrPlanNotSupported is returned when trying to plan changes to a GitLab
+// project.
+var ErrPlanNotSupported = errors.New("planning changes is not yet supported on GitLab")
+
+// ProtectBranches protects the branches in the project and ensures force
+// pushes are disabled.
+func (b *Backend) ProtectBranches(ctx context.Context, r *models.Repository, branches []*models.Branch) error {
+	if len(branches) == 0 {
+		return errors.New("no branches specified")
+	}
+
+	client, project, err := b.getClient(r)
+	if err != nil {
+		return err
+	}
+
+	rules, err := client.ListProtectedBranches(ctx, project)
+	if err != nil {
+		return fmt.Errorf("listing protected branches: %w", err)
+	}
+
+	changed := false
+	for _, branch := range branches {
+		matching := matchingBranchRules(rules, branch.Name)
+		if continuityEnforced(matching) {
+			continue
+		}
+
+		changed = true
+		// If the branch has no rules, protect it
+		if len(matching) == 0 {
+			if err := client.ProtectBranch(ctx, project, &ProtectedBranch{
+				Name:              branch.Name,
+				AllowForcePush:    false,
+				PushAccessLevels:  []*AccessLevel{{AccessLevel: AccessLevelMaintainer}},
+				MergeAccessLevels: []*AccessLevel{{AccessLevel: AccessLevelMaintainer}},
+			}); err != nil {
+				return fmt.Errorf("protecting branch %q: %w", branch.Name, err)
+			}
+			continue
+		}
+
+		// Otherwise, disable force pushes in the rules allowing them
+		for _, rule := range matching {
+			if !rule.AllowForcePush {
+				continue
+			}
+			if err := client.DisableForcePush(ctx, project, rule.Name); err != nil {
+				return fmt.Errorf("disabling force push in %q: %w", rule.Name, err)
+			}
+		}
+	}
+
+	if !changed {
+		return models.ErrProtectionAlreadyInPlace
+	}
+	return nil
+}
+
+// ProtectTags creates a protected tag rule covering all tags in the project
+func (b *Backend) ProtectTags(ctx context.Context, r *models.Repository) error {
+	client, project, err := b.getClient(r)
+	if err != nil {
+		return err
+	}
+
+	tags, err := client.ListProtectedTags(ctx, project)
+	if err != nil {
+		return fmt.Errorf("listing protected tags: %w", err)
+	}
+
+	for _, t := range tags {
+		if t.Name == "*" {
+			return models.ErrProtectionAlreadyInPlace
+		}
+	}
+
+	if err := client.ProtectTag(ctx, project, &ProtectedTag{
+		Name:               "*",
+		CreateAccessLevels: []*AccessLevel{{AccessLevel: AccessLevelMaintainer}},
+	}); err != nil {
+		return fmt.Errorf("protecting tags: %w", err)
+	}
+	return nil
+}
+
+// ControlPrecheck checks the prerequisites of a control configuration. There
+// are no prechecks in GitLab for now.
+func (b *Backend) ControlPrecheck(
+	_ *models.Repository, _ []*models.Branch, config models.ControlConfiguration,
+) (ok bool, remediationMessage string, remediateFn models.ControlPreRemediationFn, err error) {
+	//nolint:exhaustive // Not all configs have prechecks
+	switch config {
+	case models.CONFIG_GEN_PROVENANCE:
+		return false, "", nil, ErrProvenanceNotSupported
+	case models.CONFIG_REVIEW_RULES:
+		return false, "", nil, ErrReviewRulesNotSupported
+	default:
+		return true, "", nil, nil
+	}
+}
+
+// ConfigureControls configure the SLSA controls in the repository
+func (b *Backend) ConfigureControls(r *models.Repository, branches []*models.Branch, configs []models.ControlConfiguration) error {
+	ctx := context.Background()
+	errs := []error{}
+	for _, config := range configs {
+		if b.Options != nil && b.Options.StageRules &&
+			(config == models.CONFIG_BRANCH_RULES || config == models.CONFIG_TAG_RULES) {
+			errs = append(errs, ErrStagingNotSupported)
+			continue
+		}
+		switch config {
+		case models.CONFIG_BRANCH_RULES:
+			if err := b.ProtectBranches(ctx, r, branches); err != nil {
+				if !errors.Is(err, models.ErrProtectionAlreadyInPlace) {
+					errs = append(errs, fmt.Errorf("protecting branches: %w", err))
+				}
+			}
+		case models.CONFIG_TAG_RULES:
+			if err := b.ProtectTags(ctx, r); err != nil {
+				if !errors.Is(err, models.ErrProtectionAlreadyInPlace) {
+					errs = append(errs, fmt.Errorf("protecting tags: %w", err))
+				}
+			}
+		case models.CONFIG_GEN_PROVENANCE:
+			errs = append(errs, ErrProvenanceNotSupported)
+		case models.CONFIG_REVIEW_RULES:
+			errs = append(errs, ErrReviewRulesNotSupported)
+		case models.CONFIG_POLICY:
+			// Noop, this is not handled by the VCS handler
+		default:
+			errs = append(errs, fmt.Errorf("unknown configuration flag: %q", config))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// PromoteControls is not supported, rules are never staged on GitLab
+func (b *Backend) PromoteControls(context.Context, *models.Repository) ([]string, error) {
+	return nil, ErrStagingNotSupported
+}
+
+// GetStagedRuleFailures is not supported, rules are never staged on GitLab
+func (b *Backend) GetStagedRuleFailures(context.Context, *models.Repository) ([]*models.StagedRuleFailure, error) {
+	return nil, ErrStagingNotSupported
+}
+
+// GetManagedControls returns no configurations, the protections sourcetool
+// creates can't be told apart from the ones in the project.
+func (b *Backend) GetManagedControls(context.Context, *models.Repository) ([]models.ControlConfiguration, error) {
+	return []models.ControlConfiguration{}, nil
+}
+
+// RemoveControls is not supported on GitLab yet
+func (b *Backend) RemoveControls(context.Context, *models.Repository, []models.ControlConfiguration) error {
+	return ErrRemovalNotSupported
+}
+
+// PlanControls is not supported on GitLab yet
+func (b *Backend) PlanControls(context.Context, *models.Repository, []*models.Branch, []models.ControlConfiguration) ([]*models.PlannedChange, error) {
+	return nil, ErrPlanNotSupported
+}
+
+// CheckPlannedChange is not supported on GitLab yet
+func (b *Backend) CheckPlannedChange(context.Context, *models.Repository, *models.PlannedChange) error {
+	return ErrPlanNotSupported
+}
+
+// ApplyPlannedChange is not supported on GitLab yet
+func (b *Backend) ApplyPlannedChange(context.Context, *models.Repository, *models.PlannedChange) error {
+	return ErrPlanNotSupported
+}
@@ -0,0 +1,98 @@
+// SPDX-FileCopyrightText: Copyright 2026 The SLSA Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package local
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/slsa-framework/source-tool/pkg/slsa"
+)
+
+// AllBranches is the branch name in the config that applies to all branches
+const AllBranches = "*"
+
+// Config declares the repository on disk and the controls enforced on
+// it. As there is no hosting system to query, the controls are taken at
+// face value from the config file.
+type Config struct {
+	// RepoPath is the path to the git repository (bare or not). Relative
+	// paths are resolved from the config file location.
+	RepoPath string `json:"repo_path"`
+
+	// DefaultBranch overrides the branch HEAD points to
+	DefaultBranch string `json:"default_branch,omitempty"`
+
+	// Branches maps branch names to the controls enforced on them. The
+	// controls under "*" apply to all branches.
+	Branches map[string][]*ControlDeclaration `json:"branches"`
+}
+
+// ControlDeclaration declares a control enforced on a branch
+type ControlDeclaration struct {
+	Name slsa.ControlName `json:"name"`
+
+	// Since is the time the control was enabled. Commits before it are
+	// considered unprotected by the control. If not set, the control is
+	// considered enabled since the beginning of the repository.
+	Since *time.Time `json:"since,omitempty"`
+}
+
+// LoadConfig reads a backend configuration file
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading config file: %w", err)
+	}
+
+	conf := &Config{}
+	if err := json.Unmarshal(data, conf); err != nil {
+		return nil, fmt.Errorf("parsing config file: %w", err)
+	}
+
+	if conf.RepoPath != "" && !filepath.IsAbs(conf.RepoPath) {
+		conf.RepoPath = filepath.Join(filepath.Dir(path), conf.RepoPath)
+	}
+
+	if err := conf.Validate(); err != nil {
+		return nil, err
+	}
+	return conf, nil
+}
+
+// Validate checks the configuration is complete
+func (c *Config) Validate() error {
+	errs := []error{}
+	if c.RepoPath == "" {
+		errs = append(errs, errors.New("repository path not set in config"))
+	}
+
+	for branch, decls := range c.Branches {
+		for _, d := range decls {
+			if d == nil || d.Name == "" {
+				errs = append(errs, fmt.Errorf("control with no name declared in branch %q", branch))
+				continue
+			}
+			if slsa.AllLevelControls.GetControl(d.Name) == "" {
+				errs = append(errs, fmt.Errorf("unknown control %q declared in branch %q", d.Name, branch))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// BranchControls returns the controls declared for a branch, including
+// those declared for all branches.
+func (c *Config) BranchControls(branch string) []*ControlDeclaration {
+	ret := []*ControlDeclaration{}
+	ret = append(ret, c.Branches[AllBranches]...)
+	if branch != AllBranches {
+		ret = append(ret, c.Branches[branch]...)
+	}
+	return ret
+}
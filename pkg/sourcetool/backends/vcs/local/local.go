@@ -0,0 +1,496 @@
+// SPDX-FileCopyrightText: Copyright 2026 The SLSA Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package local
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/carabiner-dev/attestation"
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	vsa "github.com/in-toto/attestation/go/predicates/vsa/v1"
+
+	"github.com/slsa-framework/source-tool/pkg/attest"
+	"github.com/slsa-framework/source-tool/pkg/provenance"
+	"github.com/slsa-framework/source-tool/pkg/slsa"
+	"github.com/slsa-framework/source-tool/pkg/sourcetool/models"
+)
+
+// ErrNotSupported is returned when trying to configure controls. Controls
+// are declared in the config file, there is nothing to configure.
+var ErrNotSupported = errors.New("controls in local repositories are declared in the backend config file")
+
+// InherentControls are the controls that are always true because we are
+// in git.
+var InherentControls = slsa.ControlNameSet{
+	// git is a source control system
+	slsa.SLSA_SOURCE_ORG_SCS,
+
+	// Git commit
+	slsa.SLSA_SOURCE_SCS_REVISION_ID,
+
+	// git diff
+	slsa.SLSA_SOURCE_SCS_DIFF_DISPLAY,
+
+	// Git is change history
+	slsa.SLSA_SOURCE_SCS_HISTORY,
+}
+
+// sinceForever is the date assigned to inherent controls and to the
+// declared controls without a since date.
+var sinceForever = time.Unix(1112832000, 0) // April 7, 2005 (first git commit)
+
+// attestationReader is the subset of the attester the backend uses to
+// look for provenance and VSAs.
+type attestationReader interface {
+	GetRevisionProvenance(context.Context, *models.Branch, *models.Commit) (*provenance.SourceProvenancePred, error)
+	GetRevisionVSA(context.Context, *models.Branch, models.Revision) (attestation.Envelope, *vsa.VerificationSummary, error)
+}
+
+// New returns a new local backend reading the repository and controls
+// defined in the config.
+func New(options *models.BackendOptions, config *Config) *Backend {
+	return &Backend{
+		Options: options,
+		Config:  config,
+	}
+}
+
+// Backend implements a sourcetool backend that reads all its data from a
+// git repository on disk. Provenance and VSAs are read from the repository
+// notes (refs/notes/commits).
+type Backend struct {
+	Options  *models.BackendOptions
+	Config   *Config
+	verifier attest.Verifier
+
+	repoOnce sync.Once
+	repoErr  error
+	repo     *git.Repository
+
+	attesterOnce sync.Once
+	attesterErr  error
+	attestations attestationReader
+}
+
+// Notes returns the attestation repository that reads and writes
+// attestations in the notes of the local repository.
+func (b *Backend) Notes() (*Notes, error) {
+	repo, err := b.openRepo()
+	if err != nil {
+		return nil, err
+	}
+	path, err := filepath.Abs(b.Config.RepoPath)
+	if err != nil {
+		return nil, fmt.Errorf("resolving repository path: %w", err)
+	}
+	return &Notes{repo: repo, repoURL: "file://" + path}, nil
+}
+
+// openRepo opens the git repository once
+func (b *Backend) openRepo() (*git.Repository, error) {
+	b.repoOnce.Do(func() {
+		if b.Config == nil {
+			b.repoErr = errors.New("local backend has no config")
+			return
+		}
+		b.repo, b.repoErr = git.PlainOpenWithOptions(b.Config.RepoPath, &git.PlainOpenOptions{
+			DetectDotGit: true,
+		})
+		if b.repoErr != nil {
+			b.repoErr = fmt.Errorf("opening repository at %q: %w", b.Config.RepoPath, b.repoErr)
+		}
+	})
+	return b.repo, b.repoErr
+}
+
+// SetVerifier sets the verifier used to check the signatures of the
+// attestations read from the repository. It must be set before reading
+// the branch controls.
+func (b *Backend) SetVerifier(v attest.Verifier) {
+	b.verifier = v
+}
+
+// getAttestationReader returns the reader used to check for provenance
+// and VSAs in the repository notes.
+func (b *Backend) getAttestationReader() (attestationReader, error) {
+	b.attesterOnce.Do(func() {
+		if b.attestations != nil {
+			return
+		}
+		notes, err := b.Notes()
+		if err != nil {
+			b.attesterErr = err
+			return
+		}
+		b.attestations, b.attesterErr = attest.NewAttester(
+			attest.WithBackend(b), attest.WithVerifier(b.verifier),
+			attest.WithNotesCollector(false), attest.WithGithubCollector(false),
+			attest.WithAttestationRepository(notes),
+		)
+	})
+	return b.attestations, b.attesterErr
+}
+
+// getCommit reads a commit from the object database
+func (b *Backend) getCommit(sha string) (*object.Commit, error) {
+	repo, err := b.openRepo()
+	if err != nil {
+		return nil, err
+	}
+	commit, err := repo.CommitObject(plumbing.NewHash(sha))
+	if err != nil {
+		return nil, fmt.Errorf("reading commit %q: %w", sha, err)
+	}
+	return commit, nil
+}
+
+func (b *Backend) GetBranchControls(ctx context.Context, branch *models.Branch) (*slsa.ControlSet, error) {
+	commit, err := b.GetLatestCommit(ctx, branch.Repository, branch)
+	if err != nil {
+		return nil, fmt.Errorf("fetching latest commit from %q: %w", branch.FullRef(), err)
+	}
+	return b.GetBranchControlsAtCommit(ctx, branch, commit)
+}
+
+// GetBranchControlsAtCommit returns the full control catalog with the
+// controls declared for the branch that were in force at the commit time.
+func (b *Backend) GetBranchControlsAtCommit(ctx context.Context, branch *models.Branch, commit *models.Commit) (*slsa.ControlSet, error) {
+	if commit == nil {
+		return nil, errors.New("commit is not set")
+	}
+
+	rawCommit, err := b.getCommit(commit.SHA)
+	if err != nil {
+		return nil, err
+	}
+	commitTime := rawCommit.Committer.When
+
+	status := slsa.NewControlSet()
+	status.Branch = branch.FullRef()
+	status.CommitPushTime = commitTime
+	status.Time = time.Now()
+	if branch.Repository != nil {
+		status.RepoUri = branch.Repository.GetHttpURL()
+	}
+
+	for _, ctrl := range status.Controls {
+		if c := InherentControls.GetControl(ctrl.Name); c != "" {
+			ctrl.Since = &sinceForever
+			ctrl.State = slsa.StateActive
+			ctrl.Message = "Inherent"
+		}
+	}
+
+	for _, decl := range b.Config.BranchControls(branch.Name) {
+		since := sinceForever
+		if decl.Since != nil {
+			since = *decl.Since
+		}
+		// Controls enabled after the commit did not protect it
+		if since.After(commitTime) {
+			continue
+		}
+		if ctrl := status.GetControl(decl.Name); ctrl != nil {
+			ctrl.Since = &since
+			ctrl.State = slsa.StateActive
+			ctrl.Message = "Declared in the backend configuration"
+		}
+	}
+
+	if err := b.addAttestationControls(ctx, status, branch, commit); err != nil {
+		return nil, err
+	}
+
+	return status, nil
+}
+
+// addAttestationControls checks for provenance and VSAs on the commit
+// notes and turns on their controls.
+func (b *Backend) addAttestationControls(ctx context.Context, status *slsa.ControlSet, branch *models.Branch, commit *models.Commit) error {
+	reader, err := b.getAttestationReader()
+	if err != nil {
+		return err
+	}
+
+	pred, err := reader.GetRevisionProvenance(ctx, branch, commit)
+	if err != nil {
+		return fmt.Errorf("attempting to read provenance from commit %q: %w", commit.SHA, err)
+	}
+	if pred != nil {
+		ctrl := status.GetControl(slsa.SLSA_SOURCE_SCS_PROVENANCE)
+		ctrl.State = slsa.StateActive
+		ctrl.Message = "Signed provenance metadata is being published on every commit"
+		if pc := pred.GetControl(slsa.SLSA_SOURCE_SCS_PROVENANCE.String()); pc != nil {
+			if pc.GetSince() != nil && pc.GetSince().AsTime().Unix() != 0 {
+				t := pc.GetSince().AsTime()
+				ctrl.Since = &t
+			}
+		}
+	} else {
+		log.Printf("No provenance attestation found on %s", commit.SHA)
+	}
+
+	_, vsaPred, err := reader.GetRevisionVSA(ctx, branch, commit)
+	if err != nil {
+		return fmt.Errorf("reading VSA: %w", err)
+	}
+	if vsaPred != nil {
+		status.SetControlState(slsa.SLSA_SOURCE_SCS_VSA, slsa.StateActive)
+	}
+	return nil
+}
+
+func (b *Backend) GetTagControls(ctx context.Context, branch *models.Branch, tag *models.Tag) (*slsa.ControlSet, error) {
+	if tag.Commit == nil || tag.Commit.SHA == "" {
+		return nil, errors.New("tag commit is empty")
+	}
+	return b.GetBranchControlsAtCommit(ctx, branch, tag.Commit)
+}
+
+func (b *Backend) ControlConfigurationDescr(branch *models.Branch, config models.ControlConfiguration) string {
+	switch config {
+	case models.CONFIG_BRANCH_RULES, models.CONFIG_TAG_RULES, models.CONFIG_GEN_PROVENANCE, models.CONFIG_REVIEW_RULES:
+		return fmt.Sprintf("Declare the controls of branch %s in the local backend config", branch.Name)
+	case models.CONFIG_POLICY:
+		return "Write the repository SLSA source policy"
+	default:
+		return ""
+	}
+}
+
+// ConfigureControls is not supported in local repositories, controls are
+// declared in the config file.
+func (b *Backend) ConfigureControls(_ *models.Repository, _ []*models.Branch, configs []models.ControlConfiguration) error {
+	for _, config := range configs {
+		if config != models.CONFIG_POLICY {
+			return ErrNotSupported
+		}
+	}
+	return nil
+}
+
+// PromoteControls is not supported in local repositories, controls are
+// declared in the config file.
+func (b *Backend) PromoteControls(context.Context, *models.Repository) ([]string, error) {
+	return nil, ErrNotSupported
+}
+
+// GetStagedRuleFailures is not supported in local repositories, there are
+// no rules to stage.
+func (b *Backend) GetStagedRuleFailures(context.Context, *models.Repository) ([]*models.StagedRuleFailure, error) {
+	return nil, ErrNotSupported
+}
+
+// GetManagedControls returns no configurations, controls in local
+// repositories are declared in the config file.
+func (b *Backend) GetManagedControls(context.Context, *models.Repository) ([]models.ControlConfiguration, error) {
+	return []models.ControlConfiguration{}, nil
+}
+
+// RemoveControls is not supported in local repositories, there is nothing
+// configured to remove.
+func (b *Backend) RemoveControls(context.Context, *models.Repository, []models.ControlConfiguration) error {
+	return ErrNotSupported
+}
+
+// PlanControls is not supported in local repositories, there is nothing
+// to configure.
+func (b *Backend) PlanControls(context.Context, *models.Repository, []*models.Branch, []models.ControlConfiguration) ([]*models.PlannedChange, error) {
+	return nil, ErrNotSupported
+}
+
+// CheckPlannedChange is not supported in local repositories
+func (b *Backend) CheckPlannedChange(context.Context, *models.Repository, *models.PlannedChange) error {
+	return ErrNotSupported
+}
+
+// ApplyPlannedChange is not supported in local repositories
+func (b *Backend) ApplyPlannedChange(context.Context, *models.Repository, *models.PlannedChange) error {
+	return ErrNotSupported
+}
+
+// ControlPrecheck always passes, there are no prerequisites to check
+func (b *Backend) ControlPrecheck(*models.Repository, []*models.Branch, models.ControlConfiguration) (bool, string, models.ControlPreRemediationFn, error) {
+	return true, "", nil, nil
+}
+
+// GetLatestCommit returns the commit at the tip of a branch
+func (b *Backend) GetLatestCommit(_ context.Context, _ *models.Repository, branch *models.Branch) (*models.Commit, error) {
+	repo, err := b.openRepo()
+	if err != nil {
+		return nil, err
+	}
+
+	ref, err := repo.Reference(plumbing.NewBranchReferenceName(branch.Name), true)
+	if err != nil {
+		if errors.Is(err, plumbing.ErrReferenceNotFound) {
+			return nil, fmt.Errorf("%w: %s", models.ErrBranchNotFound, branch.Name)
+		}
+		return nil, fmt.Errorf("reading branch %q: %w", branch.Name, err)
+	}
+
+	commit, err := b.getCommit(ref.Hash().String())
+	if err != nil {
+		return nil, err
+	}
+	return toModelCommit(commit), nil
+}
+
+// GetPreviousCommit returns the parent of a commit. For merge commits this
+// is the first parent.
+func (b *Backend) GetPreviousCommit(ctx context.Context, branch *models.Branch, commit *models.Commit) (*models.Commit, error) {
+	parents, err := b.GetCommitParents(ctx, branch, commit)
+	if err != nil {
+		return nil, fmt.Errorf("fetching previous commit: %w", err)
+	}
+
+	if len(parents) == 0 {
+		return nil, fmt.Errorf("there is no commit earlier than %s, that isn't yet supported", commit.SHA)
+	}
+
+	return parents[0], nil
+}
+
+// GetCommitHistory returns up to limit commits of the first-parent history
+// of the branch starting at commit, newest first.
+func (b *Backend) GetCommitHistory(_ context.Context, _ *models.Branch, commit *models.Commit, limit int) ([]*models.Commit, error) {
+	commits := []*models.Commit{}
+	next := commit.SHA
+	for next != "" && (limit <= 0 || len(commits) < limit) {
+		rawCommit, err := b.getCommit(next)
+		if err != nil {
+			return nil, fmt.Errorf("reading commit history: %w", err)
+		}
+		commits = append(commits, toModelCommit(rawCommit))
+		next = ""
+		if rawCommit.NumParents() > 0 {
+			next = rawCommit.ParentHashes[0].String()
+		}
+	}
+	return commits, nil
+}
+
+// GetCommitParents returns all the parents of a commit, first parent first
+func (b *Backend) GetCommitParents(_ context.Context, _ *models.Branch, commit *models.Commit) ([]*models.Commit, error) {
+	rawCommit, err := b.getCommit(commit.SHA)
+	if err != nil {
+		return nil, err
+	}
+
+	parents := make([]*models.Commit, 0, rawCommit.NumParents())
+	for _, h := range rawCommit.ParentHashes {
+		parents = append(parents, &models.Commit{SHA: h.String()})
+	}
+	return parents, nil
+}
+
+// GetDefaultBranch returns the branch configured as default or, if not
+// set, the branch HEAD points to.
+func (b *Backend) GetDefaultBranch(_ context.Context, repo *models.Repository) (*models.Branch, error) {
+	if b.Config != nil && b.Config.DefaultBranch != "" {
+		return &models.Branch{Name: b.Config.DefaultBranch, Repository: repo}, nil
+	}
+
+	gitRepo, err := b.openRepo()
+	if err != nil {
+		return nil, err
+	}
+
+	head, err := gitRepo.Storer.Reference(plumbing.HEAD)
+	if err != nil {
+		return nil, fmt.Errorf("reading HEAD: %w", err)
+	}
+
+	if head.Type() != plumbing.SymbolicReference || !head.Target().IsBranch() {
+		return nil, errors.New("HEAD does not point to a branch")
+	}
+
+	return &models.Branch{
+		Name:       head.Target().Short(),
+		Repository: repo,
+	}, nil
+}
+
+// GetRevisionCommit returns the commit of a revision (or error)
+func (b *Backend) GetRevisionCommit(_ context.Context, _ *models.Repository, rev models.Revision) (*models.Commit, error) {
+	switch inst := rev.(type) {
+	case *models.Commit:
+		return inst, nil
+	case *models.Tag:
+		if inst.Commit == nil {
+			repo, err := b.openRepo()
+			if err != nil {
+				return nil, err
+			}
+			ref, err := repo.Tag(inst.GetName())
+			if err != nil {
+				return nil, fmt.Errorf("reading tag %q: %w", inst.GetName(), err)
+			}
+
+			// Annotated tags point to a tag object, lightweight tags
+			// point directly to the commit.
+			hash := ref.Hash()
+			if tagObj, err := repo.TagObject(hash); err == nil {
+				commit, err := tagObj.Commit()
+				if err != nil {
+					return nil, fmt.Errorf("reading commit of tag %q: %w", inst.GetName(), err)
+				}
+				hash = commit.Hash
+			}
+
+			commit, err := b.getCommit(hash.String())
+			if err != nil {
+				return nil, err
+			}
+			inst.Commit = toModelCommit(commit)
+		}
+		return inst.Commit, nil
+	default:
+		return nil, errors.New("not implemented yet or invalid revision")
+	}
+}
+
+// GetTagInfo reads the tag object of a tag. The local backend does not
+// verify signatures, so they are never reported as verified.
+func (b *Backend) GetTagInfo(_ context.Context, _ *models.Repository, tag *models.Tag) (*models.TagInfo, error) {
+	repo, err := b.openRepo()
+	if err != nil {
+		return nil, err
+	}
+	ref, err := repo.Tag(tag.GetName())
+	if err != nil {
+		return nil, fmt.Errorf("reading tag %q: %w", tag.GetName(), err)
+	}
+	// Lightweight tags point directly to the commit
+	if _, err := repo.TagObject(ref.Hash()); err != nil {
+		if errors.Is(err, plumbing.ErrObjectNotFound) {
+			return &models.TagInfo{}, nil
+		}
+		return nil, fmt.Errorf("reading tag object of %q: %w", tag.GetName(), err)
+	}
+	return &models.TagInfo{Annotated: true}, nil
+}
+
+func toModelCommit(c *object.Commit) *models.Commit {
+	t := c.Committer.When
+	parents := make([]string, 0, c.NumParents())
+	for _, h := range c.ParentHashes {
+		parents = append(parents, h.String())
+	}
+	return &models.Commit{
+		SHA:     c.Hash.String(),
+		Author:  c.Author.Name,
+		Time:    &t,
+		Message: c.Message,
+		Parents: parents,
+	}
+}
@@ -0,0 +1,260 @@
+// SPDX-FileCopyrightText: Copyright 2026 The SLSA Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package local
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/carabiner-dev/attestation"
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	vsa "github.com/in-toto/attestation/go/predicates/vsa/v1"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slsa-framework/source-tool/pkg/provenance"
+	"github.com/slsa-framework/source-tool/pkg/slsa"
+	"github.com/slsa-framework/source-tool/pkg/sourcetool/models"
+)
+
+var (
+	firstTime  = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	secondTime = time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// noAttestations is an attestation reader that never finds anything
+type noAttestations struct{}
+
+func (noAttestations) GetRevisionProvenance(context.Context, *models.Branch, *models.Commit) (*provenance.SourceProvenancePred, error) {
+	return nil, nil
+}
+
+func (noAttestations) GetRevisionVSA(context.Context, *models.Branch, models.Revision) (attestation.Envelope, *vsa.VerificationSummary, error) {
+	return nil, nil, nil
+}
+
+// testRepo is a repository on disk with two commits on main, an
+// annotated tag on the first one and a merge commit on the merge branch.
+type testRepo struct {
+	path   string
+	first  string
+	second string
+	merge  string
+}
+
+func newTestRepo(t *testing.T) *testRepo {
+	t.Helper()
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+
+	// Point HEAD to main
+	require.NoError(t, repo.Storer.SetReference(
+		plumbing.NewSymbolicReference(plumbing.HEAD, plumbing.NewBranchReferenceName("main")),
+	))
+
+	wt, err := repo.Worktree()
+	require.NoError(t, err)
+
+	commit := func(msg string, when time.Time, parents ...plumbing.Hash) plumbing.Hash {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "file.txt"), []byte(msg), 0o600))
+		_, err := wt.Add("file.txt")
+		require.NoError(t, err)
+		sig := &object.Signature{Name: "Tester", Email: "test@example.com", When: when}
+		h, err := wt.Commit(msg, &git.CommitOptions{Author: sig, Committer: sig, Parents: parents})
+		require.NoError(t, err)
+		return h
+	}
+
+	first := commit("first", firstTime)
+	second := commit("second", secondTime)
+
+	_, err = repo.CreateTag("v1.0.0", first, &git.CreateTagOptions{
+		Tagger:  &object.Signature{Name: "Tester", Email: "test@example.com", When: firstTime},
+		Message: "v1.0.0",
+	})
+	require.NoError(t, err)
+
+	merge := commit("merge", secondTime.Add(time.Hour), second, first)
+	require.NoError(t, repo.Storer.SetReference(plumbing.NewHashReference(plumbing.NewBranchReferenceName("merge"), merge)))
+	require.NoError(t, repo.Storer.SetReference(plumbing.NewHashReference(plumbing.NewBranchReferenceName("main"), second)))
+
+	return &testRepo{path: dir, first: first.String(), second: second.String(), merge: merge.String()}
+}
+
+func newTestBackend(t *testing.T, tr *testRepo, conf *Config) *Backend {
+	t.Helper()
+	if conf == nil {
+		conf = &Config{}
+	}
+	conf.RepoPath = tr.path
+	b := New(&models.BackendOptions{}, conf)
+	b.attestations = noAttestations{}
+	return b
+}
+
+func testBranch(name string) *models.Branch {
+	return &models.Branch{
+		Name:       name,
+		Repository: &models.Repository{Hostname: "github.com", Path: "example/repo"},
+	}
+}
+
+func TestGetBranchControlsAtCommit(t *testing.T) {
+	t.Parallel()
+	tr := newTestRepo(t)
+	controlSince := firstTime.Add(24 * time.Hour)
+	b := newTestBackend(t, tr, &Config{
+		Branches: map[string][]*ControlDeclaration{
+			AllBranches: {{Name: slsa.SLSA_SOURCE_SCS_IDENTITY}},
+			"main": {
+				{Name: slsa.SLSA_SOURCE_SCS_CONTINUITY, Since: &controlSince},
+			},
+		},
+	})
+
+	for _, tc := range []struct {
+		name      string
+		commit    string
+		active    []slsa.ControlName
+		notActive []slsa.ControlName
+	}{
+		{
+			name:      "before-since",
+			commit:    tr.first,
+			active:    append(InherentControls, slsa.SLSA_SOURCE_SCS_IDENTITY),
+			notActive: []slsa.ControlName{slsa.SLSA_SOURCE_SCS_CONTINUITY, slsa.SLSA_SOURCE_SCS_PROVENANCE},
+		},
+		{
+			name:   "after-since",
+			commit: tr.second,
+			active: []slsa.ControlName{slsa.SLSA_SOURCE_SCS_CONTINUITY, slsa.SLSA_SOURCE_SCS_IDENTITY},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			status, err := b.GetBranchControlsAtCommit(t.Context(), testBranch("main"), &models.Commit{SHA: tc.commit})
+			require.NoError(t, err)
+			require.Len(t, status.Controls, len(slsa.AllLevelControls))
+			for _, name := range tc.active {
+				ctrl := status.GetControl(name)
+				require.Equal(t, slsa.StateActive, ctrl.State, name)
+				require.NotNil(t, ctrl.Since, name)
+			}
+			for _, name := range tc.notActive {
+				require.Equal(t, slsa.StateNotEnabled, status.GetControl(name).State, name)
+			}
+		})
+	}
+}
+
+func TestRepositoryData(t *testing.T) {
+	t.Parallel()
+	tr := newTestRepo(t)
+	b := newTestBackend(t, tr, nil)
+
+	branch, err := b.GetDefaultBranch(t.Context(), testBranch("").Repository)
+	require.NoError(t, err)
+	require.Equal(t, "main", branch.Name)
+
+	commit, err := b.GetLatestCommit(t.Context(), branch.Repository, branch)
+	require.NoError(t, err)
+	require.Equal(t, tr.second, commit.SHA)
+	require.True(t, secondTime.Equal(*commit.Time))
+
+	prev, err := b.GetPreviousCommit(t.Context(), branch, commit)
+	require.NoError(t, err)
+	require.Equal(t, tr.first, prev.SHA)
+
+	_, err = b.GetPreviousCommit(t.Context(), branch, prev)
+	require.Error(t, err)
+
+	tagCommit, err := b.GetRevisionCommit(t.Context(), branch.Repository, &models.Tag{Name: "v1.0.0"})
+	require.NoError(t, err)
+	require.Equal(t, tr.first, tagCommit.SHA)
+
+	_, err = b.GetRevisionCommit(t.Context(), branch.Repository, &models.Tag{Name: "v9.9.9"})
+	require.Error(t, err)
+
+	tagInfo, err := b.GetTagInfo(t.Context(), branch.Repository, &models.Tag{Name: "v1.0.0"})
+	require.NoError(t, err)
+	require.Equal(t, &models.TagInfo{Annotated: true}, tagInfo)
+
+	b.Config.DefaultBranch = "merge"
+	branch, err = b.GetDefaultBranch(t.Context(), testBranch("").Repository)
+	require.NoError(t, err)
+	require.Equal(t, "merge", branch.Name)
+}
+
+func TestGetPreviousCommitMerge(t *testing.T) {
+	t.Parallel()
+	tr := newTestRepo(t)
+	b := newTestBackend(t, tr, nil)
+
+	prev, err := b.GetPreviousCommit(t.Context(), testBranch("merge"), &models.Commit{SHA: tr.merge})
+	require.NoError(t, err)
+	require.Equal(t, tr.second, prev.SHA)
+
+	parents, err := b.GetCommitParents(t.Context(), testBranch("merge"), &models.Commit{SHA: tr.merge})
+	require.NoError(t, err)
+	require.Len(t, parents, 2)
+	require.Equal(t, tr.second, parents[0].SHA)
+}
+
+func TestGetCommitHistory(t *testing.T) {
+	t.Parallel()
+	tr := newTestRepo(t)
+	b := newTestBackend(t, tr, nil)
+
+	history, err := b.GetCommitHistory(t.Context(), testBranch("merge"), &models.Commit{SHA: tr.merge}, 0)
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+	require.Equal(t, []string{tr.merge, tr.second, tr.first}, []string{history[0].SHA, history[1].SHA, history[2].SHA})
+	require.Equal(t, []string{tr.second, tr.first}, history[0].Parents)
+	require.Empty(t, history[2].Parents)
+
+	history, err = b.GetCommitHistory(t.Context(), testBranch("merge"), &models.Commit{SHA: tr.merge}, 2)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+}
+
+func TestLoadConfig(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		name    string
+		data    string
+		mustErr bool
+	}{
+		{name: "valid", data: `{"repo_path": "repo.git", "branches": {"main": [{"name": "SLSA_SOURCE_SCS_CONTINUITY", "since": "2024-01-01T00:00:00Z"}]}}`},
+		{name: "no-repo", data: `{"branches": {}}`, mustErr: true},
+		{name: "unknown-control", data: `{"repo_path": "r", "branches": {"main": [{"name": "BOGUS"}]}}`, mustErr: true},
+		{name: "invalid-json", data: `{`, mustErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			dir := t.TempDir()
+			path := filepath.Join(dir, "config.json")
+			require.NoError(t, os.WriteFile(path, []byte(tc.data), 0o600))
+			conf, err := LoadConfig(path)
+			if tc.mustErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, filepath.Join(dir, "repo.git"), conf.RepoPath)
+			require.Len(t, conf.BranchControls("main"), 1)
+		})
+	}
+}
+
+func TestConfigureControls(t *testing.T) {
+	t.Parallel()
+	b := New(&models.BackendOptions{}, &Config{})
+	require.NoError(t, b.ConfigureControls(nil, nil, []models.ControlConfiguration{models.CONFIG_POLICY}))
+	require.ErrorIs(t, b.ConfigureControls(nil, nil, []models.ControlConfiguration{models.CONFIG_BRANCH_RULES}), ErrNotSupported)
+}
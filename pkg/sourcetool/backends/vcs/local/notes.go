@@ -0,0 +1,154 @@
+// SPDX-FileCopyrightText: Copyright 2026 The SLSA Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package local
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/carabiner-dev/attestation"
+	"github.com/carabiner-dev/collector/envelope"
+	"github.com/carabiner-dev/collector/filters"
+	"github.com/carabiner-dev/collector/repository/note"
+	"github.com/carabiner-dev/jsonl"
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	intoto "github.com/in-toto/attestation/go/v1"
+)
+
+// NotesRef is the reference holding the attestations notes
+const NotesRef = "refs/notes/commits"
+
+var (
+	_ attestation.Fetcher          = (*Notes)(nil)
+	_ attestation.FetcherBySubject = (*Notes)(nil)
+	_ attestation.Storer           = (*Notes)(nil)
+)
+
+// Notes is an attestation repository that reads the attestations bundles
+// stored in the git notes of a local repository. Commits with no notes
+// (or repositories without a notes reference) simply return no attestations.
+type Notes struct {
+	repo    *git.Repository
+	storer  attestation.Storer
+	repoURL string
+}
+
+// Fetch is a noop, notes can only be read by subject
+func (n *Notes) Fetch(context.Context, attestation.FetchOptions) ([]attestation.Envelope, error) {
+	return []attestation.Envelope{}, nil
+}
+
+// FetchBySubject reads the notes of the commits in the subjects and
+// returns the attestations matching them.
+func (n *Notes) FetchBySubject(_ context.Context, _ attestation.FetchOptions, subjects []attestation.Subject) ([]attestation.Envelope, error) {
+	tree, err := n.notesTree()
+	if err != nil {
+		return nil, err
+	}
+
+	matcher := &filters.SubjectHashMatcher{HashSets: []map[string]string{}}
+	all := []attestation.Envelope{}
+	seen := map[string]struct{}{}
+	for _, s := range subjects {
+		digests := s.GetDigest()
+		matcher.HashSets = append(matcher.HashSets, digests)
+		if tree == nil {
+			continue
+		}
+		for _, algo := range []string{intoto.AlgorithmGitCommit.String(), intoto.AlgorithmSHA1.String()} {
+			sha := digests[algo]
+			if _, ok := seen[sha]; ok || len(sha) < 3 {
+				continue
+			}
+			seen[sha] = struct{}{}
+
+			envelopes, err := readNote(tree, sha)
+			if err != nil {
+				return nil, err
+			}
+			all = append(all, envelopes...)
+		}
+	}
+
+	// Notes tag subjects with either algorithm, normalize the subjects
+	// to match both.
+	for _, hs := range matcher.HashSets {
+		if v, ok := hs[intoto.AlgorithmSHA1.String()]; ok {
+			if _, ok := hs[intoto.AlgorithmGitCommit.String()]; !ok {
+				hs[intoto.AlgorithmGitCommit.String()] = v
+			}
+		}
+	}
+
+	return attestation.NewQuery().WithFilter(matcher).Run(all), nil
+}
+
+// Store writes the attestations to the notes of the local repository
+func (n *Notes) Store(ctx context.Context, opts attestation.StoreOptions, envelopes []attestation.Envelope) error {
+	if n.storer == nil {
+		s, err := note.NewDynamic(note.DynamicRepoURL(n.repoURL), note.WithPush(false))
+		if err != nil {
+			return fmt.Errorf("creating notes storer: %w", err)
+		}
+		n.storer = s
+	}
+	return n.storer.Store(ctx, opts, envelopes)
+}
+
+// notesTree returns the tree of the notes reference or nil if the
+// repository has no notes.
+func (n *Notes) notesTree() (*object.Tree, error) {
+	ref, err := n.repo.Reference(plumbing.ReferenceName(NotesRef), true)
+	if err != nil {
+		if errors.Is(err, plumbing.ErrReferenceNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading notes reference: %w", err)
+	}
+
+	commit, err := n.repo.CommitObject(ref.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("reading notes commit: %w", err)
+	}
+
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("reading notes tree: %w", err)
+	}
+	return tree, nil
+}
+
+// readNote parses the attestations bundle in the note of a commit. Notes
+// can be stored sharded (ab/cdef...) or at the root of the tree.
+func readNote(tree *object.Tree, sha string) ([]attestation.Envelope, error) {
+	file, err := tree.File(sha[0:2] + "/" + sha[2:])
+	if err != nil {
+		file, err = tree.File(sha)
+	}
+	if err != nil {
+		return nil, nil
+	}
+
+	data, err := file.Contents()
+	if err != nil {
+		return nil, fmt.Errorf("reading note of %s: %w", sha, err)
+	}
+
+	ret := []attestation.Envelope{}
+	for i, r := range jsonl.IterateBundle(bytes.NewReader([]byte(data))) {
+		if r == nil {
+			continue
+		}
+		envelopes, err := envelope.Parsers.Parse(r)
+		if err != nil {
+			return nil, fmt.Errorf("parsing attestation %d in note of %s: %w", i, sha, err)
+		}
+		ret = append(ret, envelopes...)
+	}
+	return ret, nil
+}
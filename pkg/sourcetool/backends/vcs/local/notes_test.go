@@ -0,0 +1,69 @@
+// SPDX-FileCopyrightText: Copyright 2026 The SLSA Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package local
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/carabiner-dev/attestation"
+	"github.com/carabiner-dev/collector/envelope/dsse"
+	intoto "github.com/in-toto/attestation/go/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func testEnvelope(t *testing.T, sha string) attestation.Envelope {
+	t.Helper()
+	statement := fmt.Sprintf(
+		`{"_type":"https://in-toto.io/Statement/v1","subject":[{"name":"git:%s","digest":{"gitCommit":"%s","sha1":"%s"}}],"predicateType":"https://slsa.dev/provenance/v1","predicate":{}}`,
+		sha, sha, sha,
+	)
+	data, err := json.Marshal(map[string]any{
+		"payloadType": "application/vnd.in-toto+json",
+		"payload":     base64.StdEncoding.EncodeToString([]byte(statement)),
+		"signatures":  []map[string]string{{"sig": "dGVzdHNpZw=="}},
+	})
+	require.NoError(t, err)
+
+	envs, err := (&dsse.Parser{}).ParseStream(bytes.NewReader(data))
+	require.NoError(t, err)
+	require.Len(t, envs, 1)
+	return envs[0]
+}
+
+func TestNotes(t *testing.T) {
+	t.Parallel()
+	tr := newTestRepo(t)
+	b := newTestBackend(t, tr, nil)
+
+	notes, err := b.Notes()
+	require.NoError(t, err)
+
+	subject := func(sha string) []attestation.Subject {
+		return []attestation.Subject{&intoto.ResourceDescriptor{Digest: map[string]string{"sha1": sha}}}
+	}
+
+	// No notes ref yet, reading must not fail
+	envs, err := notes.FetchBySubject(t.Context(), attestation.FetchOptions{}, subject(tr.second))
+	require.NoError(t, err)
+	require.Empty(t, envs)
+
+	require.NoError(t, notes.Store(t.Context(), attestation.StoreOptions{}, []attestation.Envelope{testEnvelope(t, tr.second)}))
+
+	// Reopen the notes to read the reference from disk
+	b = newTestBackend(t, tr, nil)
+	notes, err = b.Notes()
+	require.NoError(t, err)
+
+	envs, err = notes.FetchBySubject(t.Context(), attestation.FetchOptions{}, subject(tr.second))
+	require.NoError(t, err)
+	require.Len(t, envs, 1)
+
+	envs, err = notes.FetchBySubject(t.Context(), attestation.FetchOptions{}, subject(tr.first))
+	require.NoError(t, err)
+	require.Empty(t, envs)
+}
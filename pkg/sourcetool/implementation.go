@@ -33,6 +33,7 @@ type toolImplementation interface {
 	CreatePolicyPR(*auth.Authenticator, *options.Options, *models.Repository, *policy.RepoPolicy) (*models.PullRequest, error)
 	CheckForks(*options.Options) error
 	SearchPullRequest(context.Context, *auth.Authenticator, *models.Repository, string) (*models.PullRequest, error)
+	ClosePullRequest(context.Context, *auth.Authenticator, *models.PullRequest) error
 	GetBranchControls(context.Context, models.VcsBackend, *models.Branch) (*slsa.ControlSet, error)
 	GetBranchControlsAtCommit(context.Context, models.VcsBackend, *models.Branch, *models.Commit) (*slsa.ControlSet, error)
 	ConfigureControls(models.VcsBackend, *models.Repository, []*models.Branch, []models.ControlConfiguration) error
@@ -76,6 +77,11 @@ func (impl *defaultToolImplementation) VerifyOptionsForFullOnboard(a *auth.Authe
 	return errors.Join(errs...)
 }
 
+// policyFilePath returns the path of the repository policy in the policy repo
+func policyFilePath(owner, name string) string {
+	return fmt.Sprintf("policy/github.com/%s/%s/source-policy.json", owner, name)
+}
+
 // CreatePolicyPR creates a pull request to push the policy
 func (impl *defaultToolImplementation) CreatePolicyPR(a *auth.Authenticator, opts *options.Options, r *models.Repository, p *policy.RepoPolicy) (*models.PullRequest, error) {
 	if p == nil {
@@ -137,7 +143,7 @@ func (impl *defaultToolImplementation) CreatePolicyPR(a *auth.Authenticator, opt
 		},
 		[]*repo.PullRequestFileEntry{
 			{
-				Path:   fmt.Sprintf("policy/github.com/%s/%s/source-policy.json", repoOwner, repoName),
+				Path:   policyFilePath(repoOwner, repoName),
 				Reader: bytes.NewReader(policyJson),
 			},
 		},
@@ -213,12 +219,36 @@ func (impl *defaultToolImplementation) SearchPullRequest(ctx context.Context, a
 	return nil, nil
 }
 
+// ClosePullRequest closes a pull request in its repository
+func (impl *defaultToolImplementation) ClosePullRequest(ctx context.Context, a *auth.Authenticator, pr *models.PullRequest) error {
+	owner, repoName, err := pr.Repo.PathAsGitHubOwnerName()
+	if err != nil {
+		return err
+	}
+
+	client, err := a.GetGitHubClient()
+	if err != nil {
+		return err
+	}
+
+	if _, _, err := client.PullRequests.Edit(ctx, owner, repoName, pr.Number, &github.PullRequest{
+		State: github.Ptr("closed"),
+	}); err != nil {
+		return fmt.Errorf("closing pull request #%d: %w", pr.Number, err)
+	}
+	return nil
+}
+
 // GetPolicyStatus returns the status of the policy as a slsa ControlStatus
 func (impl *defaultToolImplementation) GetPolicyStatus(
 	ctx context.Context, a *auth.Authenticator, opts *options.Options, r *models.Repository,
 ) (*slsa.Control, error) {
 	// First: Look for the policy. If found then we are done
-	pcy, _, err := policy.NewPolicyEvaluator().GetPolicy(ctx, r)
+	pe, err := newPolicyEvaluator(a, opts)
+	if err != nil {
+		return nil, err
+	}
+	pcy, _, err := pe.GetPolicy(ctx, r)
 	if err != nil {
 		return nil, fmt.Errorf("checking if the repository has a policy %w", err)
 	}
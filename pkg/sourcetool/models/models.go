@@ -21,6 +21,8 @@ import (
 var (
 	ErrProtectionAlreadyInPlace = errors.New("controls already in place in the repository")
 	ErrRepositoryAccessDenied   = errors.New("access to repository denied")
+	ErrPlanOutdated             = errors.New("the repository changed since the plan was made")
+	ErrBranchNotFound           = errors.New("branch not found in the repository")
 )
 
 // AttestationStorageReader abstracts an attestation storage system where
@@ -47,17 +49,47 @@ type VcsBackend interface {
 	GetLatestCommit(context.Context, *Repository, *Branch) (*Commit, error)
 	ControlPrecheck(*Repository, []*Branch, ControlConfiguration) (bool, string, ControlPreRemediationFn, error)
 	GetPreviousCommit(context.Context, *Branch, *Commit) (*Commit, error)
+	GetCommitParents(context.Context, *Branch, *Commit) ([]*Commit, error)
+	GetCommitHistory(context.Context, *Branch, *Commit, int) ([]*Commit, error)
 	GetDefaultBranch(context.Context, *Repository) (*Branch, error)
 	GetRevisionCommit(context.Context, *Repository, Revision) (*Commit, error)
+	GetTagInfo(context.Context, *Repository, *Tag) (*TagInfo, error)
+	PromoteControls(context.Context, *Repository) ([]string, error)
+	GetStagedRuleFailures(context.Context, *Repository) ([]*StagedRuleFailure, error)
+	GetManagedControls(context.Context, *Repository) ([]ControlConfiguration, error)
+	RemoveControls(context.Context, *Repository, []ControlConfiguration) error
+	PlanControls(context.Context, *Repository, []*Branch, []ControlConfiguration) ([]*PlannedChange, error)
+	CheckPlannedChange(context.Context, *Repository, *PlannedChange) error
+	ApplyPlannedChange(context.Context, *Repository, *PlannedChange) error
 }
 
 type BackendOptions struct {
+	// Deprecated: merge commits are always supported.
 	AllowMergeCommits bool
 	DriverOptions     any
+
+	// StageRules creates the repository rules in evaluate mode, they are
+	// reported without blocking pushes until they are promoted.
+	StageRules bool
+}
+
+// StagedRuleFailure is a push that the rules staged in evaluate mode would
+// have blocked if they were enforced
+type StagedRuleFailure struct {
+	Ref      string
+	Actor    string
+	Before   string
+	After    string
+	PushedAt time.Time
+
+	// Rules describes the staged rules that failed
+	Rules []string
 }
 
 // ControlPreRemediation is a function returned by the VCS backends
-// when checking for prerequisites that the user may optionally run
+// when checking for prerequisites that the user may optionally run. When
+// a prerequisite can't be fixed automatically, no function is returned and
+// the precheck message explains what to do.
 type ControlPreRemediationFn func() (string, error)
 
 type ControlConfiguration string
@@ -67,6 +99,7 @@ const (
 	CONFIG_GEN_PROVENANCE ControlConfiguration = "CONFIG_GEN_PROVENANCE"
 	CONFIG_BRANCH_RULES   ControlConfiguration = "CONFIG_BRANCH_RULES"
 	CONFIG_TAG_RULES      ControlConfiguration = "CONFIG_TAG_RULES"
+	CONFIG_REVIEW_RULES   ControlConfiguration = "CONFIG_REVIEW_RULES"
 )
 
 // Digest algorithm names used in attestation subjects
@@ -80,6 +113,9 @@ type Commit struct {
 	Author  string
 	Time    *time.Time
 	Message string
+	// Parents are the SHAs of the parent commits, first parent first. It is
+	// nil when the backend did not read them.
+	Parents []string
 }
 
 // Both tags and branches must implement the reference interface
@@ -187,6 +223,16 @@ func (t *Tag) FullRef() string {
 	return "refs/tags/" + t.Name
 }
 
+// TagInfo describes the git object a tag points to
+type TagInfo struct {
+	// Annotated is true when the tag points to a tag object instead of
+	// pointing directly to the commit.
+	Annotated bool
+	// SignatureVerified is true when the tag object is signed and the VCS
+	// host verified the signature.
+	SignatureVerified bool
+}
+
 // PullRequest models a GitHub pull request.
 // If we need to use this outside of the repo.PullRequestManager and other
 // GitHub-specific code we should model a ChangeRequest or similar interface
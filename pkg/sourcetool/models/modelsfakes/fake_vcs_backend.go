@@ -10,6 +10,32 @@ import (
 )
 
 type FakeVcsBackend struct {
+	ApplyPlannedChangeStub        func(context.Context, *models.Repository, *models.PlannedChange) error
+	applyPlannedChangeMutex       sync.RWMutex
+	applyPlannedChangeArgsForCall []struct {
+		arg1 context.Context
+		arg2 *models.Repository
+		arg3 *models.PlannedChange
+	}
+	applyPlannedChangeReturns struct {
+		result1 error
+	}
+	applyPlannedChangeReturnsOnCall map[int]struct {
+		result1 error
+	}
+	CheckPlannedChangeStub        func(context.Context, *models.Repository, *models.PlannedChange) error
+	checkPlannedChangeMutex       sync.RWMutex
+	checkPlannedChangeArgsForCall []struct {
+		arg1 context.Context
+		arg2 *models.Repository
+		arg3 *models.PlannedChange
+	}
+	checkPlannedChangeReturns struct {
+		result1 error
+	}
+	checkPlannedChangeReturnsOnCall map[int]struct {
+		result1 error
+	}
 	ConfigureControlsStub        func(*models.Repository, []*models.Branch, []models.ControlConfiguration) error
 	configureControlsMutex       sync.RWMutex
 	configureControlsArgsForCall []struct {
@@ -83,6 +109,37 @@ type FakeVcsBackend struct {
 		result1 *slsa.ControlSet
 		result2 error
 	}
+	GetCommitHistoryStub        func(context.Context, *models.Branch, *models.Commit, int) ([]*models.Commit, error)
+	getCommitHistoryMutex       sync.RWMutex
+	getCommitHistoryArgsForCall []struct {
+		arg1 context.Context
+		arg2 *models.Branch
+		arg3 *models.Commit
+		arg4 int
+	}
+	getCommitHistoryReturns struct {
+		result1 []*models.Commit
+		result2 error
+	}
+	getCommitHistoryReturnsOnCall map[int]struct {
+		result1 []*models.Commit
+		result2 error
+	}
+	GetCommitParentsStub        func(context.Context, *models.Branch, *models.Commit) ([]*models.Commit, error)
+	getCommitParentsMutex       sync.RWMutex
+	getCommitParentsArgsForCall []struct {
+		arg1 context.Context
+		arg2 *models.Branch
+		arg3 *models.Commit
+	}
+	getCommitParentsReturns struct {
+		result1 []*models.Commit
+		result2 error
+	}
+	getCommitParentsReturnsOnCall map[int]struct {
+		result1 []*models.Commit
+		result2 error
+	}
 	GetDefaultBranchStub        func(context.Context, *models.Repository) (*models.Branch, error)
 	getDefaultBranchMutex       sync.RWMutex
 	getDefaultBranchArgsForCall []struct {
@@ -112,6 +169,20 @@ type FakeVcsBackend struct {
 		result1 *models.Commit
 		result2 error
 	}
+	GetManagedControlsStub        func(context.Context, *models.Repository) ([]models.ControlConfiguration, error)
+	getManagedControlsMutex       sync.RWMutex
+	getManagedControlsArgsForCall []struct {
+		arg1 context.Context
+		arg2 *models.Repository
+	}
+	getManagedControlsReturns struct {
+		result1 []models.ControlConfiguration
+		result2 error
+	}
+	getManagedControlsReturnsOnCall map[int]struct {
+		result1 []models.ControlConfiguration
+		result2 error
+	}
 	GetPreviousCommitStub        func(context.Context, *models.Branch, *models.Commit) (*models.Commit, error)
 	getPreviousCommitMutex       sync.RWMutex
 	getPreviousCommitArgsForCall []struct {
@@ -142,6 +213,20 @@ type FakeVcsBackend struct {
 		result1 *models.Commit
 		result2 error
 	}
+	GetStagedRuleFailuresStub        func(context.Context, *models.Repository) ([]*models.StagedRuleFailure, error)
+	getStagedRuleFailuresMutex       sync.RWMutex
+	getStagedRuleFailuresArgsForCall []struct {
+		arg1 context.Context
+		arg2 *models.Repository
+	}
+	getStagedRuleFailuresReturns struct {
+		result1 []*models.StagedRuleFailure
+		result2 error
+	}
+	getStagedRuleFailuresReturnsOnCall map[int]struct {
+		result1 []*models.StagedRuleFailure
+		result2 error
+	}
 	GetTagControlsStub        func(context.Context, *models.Branch, *models.Tag) (*slsa.ControlSet, error)
 	getTagControlsMutex       sync.RWMutex
 	getTagControlsArgsForCall []struct {
@@ -157,10 +242,194 @@ type FakeVcsBackend struct {
 		result1 *slsa.ControlSet
 		result2 error
 	}
+	GetTagInfoStub        func(context.Context, *models.Repository, *models.Tag) (*models.TagInfo, error)
+	getTagInfoMutex       sync.RWMutex
+	getTagInfoArgsForCall []struct {
+		arg1 context.Context
+		arg2 *models.Repository
+		arg3 *models.Tag
+	}
+	getTagInfoReturns struct {
+		result1 *models.TagInfo
+		result2 error
+	}
+	getTagInfoReturnsOnCall map[int]struct {
+		result1 *models.TagInfo
+		result2 error
+	}
+	PlanControlsStub        func(context.Context, *models.Repository, []*models.Branch, []models.ControlConfiguration) ([]*models.PlannedChange, error)
+	planControlsMutex       sync.RWMutex
+	planControlsArgsForCall []struct {
+		arg1 context.Context
+		arg2 *models.Repository
+		arg3 []*models.Branch
+		arg4 []models.ControlConfiguration
+	}
+	planControlsReturns struct {
+		result1 []*models.PlannedChange
+		result2 error
+	}
+	planControlsReturnsOnCall map[int]struct {
+		result1 []*models.PlannedChange
+		result2 error
+	}
+	PromoteControlsStub        func(context.Context, *models.Repository) ([]string, error)
+	promoteControlsMutex       sync.RWMutex
+	promoteControlsArgsForCall []struct {
+		arg1 context.Context
+		arg2 *models.Repository
+	}
+	promoteControlsReturns struct {
+		result1 []string
+		result2 error
+	}
+	promoteControlsReturnsOnCall map[int]struct {
+		result1 []string
+		result2 error
+	}
+	RemoveControlsStub        func(context.Context, *models.Repository, []models.ControlConfiguration) error
+	removeControlsMutex       sync.RWMutex
+	removeControlsArgsForCall []struct {
+		arg1 context.Context
+		arg2 *models.Repository
+		arg3 []models.ControlConfiguration
+	}
+	removeControlsReturns struct {
+		result1 error
+	}
+	removeControlsReturnsOnCall map[int]struct {
+		result1 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
 
+func (fake *FakeVcsBackend) ApplyPlannedChange(arg1 context.Context, arg2 *models.Repository, arg3 *models.PlannedChange) error {
+	fake.applyPlannedChangeMutex.Lock()
+	ret, specificReturn := fake.applyPlannedChangeReturnsOnCall[len(fake.applyPlannedChangeArgsForCall)]
+	fake.applyPlannedChangeArgsForCall = append(fake.applyPlannedChangeArgsForCall, struct {
+		arg1 context.Context
+		arg2 *models.Repository
+		arg3 *models.PlannedChange
+	}{arg1, arg2, arg3})
+	stub := fake.ApplyPlannedChangeStub
+	fakeReturns := fake.applyPlannedChangeReturns
+	fake.recordInvocation("ApplyPlannedChange", []interface{}{arg1, arg2, arg3})
+	fake.applyPlannedChangeMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeVcsBackend) ApplyPlannedChangeCallCount() int {
+	fake.applyPlannedChangeMutex.RLock()
+	defer fake.applyPlannedChangeMutex.RUnlock()
+	return len(fake.applyPlannedChangeArgsForCall)
+}
+
+func (fake *FakeVcsBackend) ApplyPlannedChangeCalls(stub func(context.Context, *models.Repository, *models.PlannedChange) error) {
+	fake.applyPlannedChangeMutex.Lock()
+	defer fake.applyPlannedChangeMutex.Unlock()
+	fake.ApplyPlannedChangeStub = stub
+}
+
+func (fake *FakeVcsBackend) ApplyPlannedChangeArgsForCall(i int) (context.Context, *models.Repository, *models.PlannedChange) {
+	fake.applyPlannedChangeMutex.RLock()
+	defer fake.applyPlannedChangeMutex.RUnlock()
+	argsForCall := fake.applyPlannedChangeArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeVcsBackend) ApplyPlannedChangeReturns(result1 error) {
+	fake.applyPlannedChangeMutex.Lock()
+	defer fake.applyPlannedChangeMutex.Unlock()
+	fake.ApplyPlannedChangeStub = nil
+	fake.applyPlannedChangeReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeVcsBackend) ApplyPlannedChangeReturnsOnCall(i int, result1 error) {
+	fake.applyPlannedChangeMutex.Lock()
+	defer fake.applyPlannedChangeMutex.Unlock()
+	fake.ApplyPlannedChangeStub = nil
+	if fake.applyPlannedChangeReturnsOnCall == nil {
+		fake.applyPlannedChangeReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.applyPlannedChangeReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeVcsBackend) CheckPlannedChange(arg1 context.Context, arg2 *models.Repository, arg3 *models.PlannedChange) error {
+	fake.checkPlannedChangeMutex.Lock()
+	ret, specificReturn := fake.checkPlannedChangeReturnsOnCall[len(fake.checkPlannedChangeArgsForCall)]
+	fake.checkPlannedChangeArgsForCall = append(fake.checkPlannedChangeArgsForCall, struct {
+		arg1 context.Context
+		arg2 *models.Repository
+		arg3 *models.PlannedChange
+	}{arg1, arg2, arg3})
+	stub := fake.CheckPlannedChangeStub
+	fakeReturns := fake.checkPlannedChangeReturns
+	fake.recordInvocation("CheckPlannedChange", []interface{}{arg1, arg2, arg3})
+	fake.checkPlannedChangeMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeVcsBackend) CheckPlannedChangeCallCount() int {
+	fake.checkPlannedChangeMutex.RLock()
+	defer fake.checkPlannedChangeMutex.RUnlock()
+	return len(fake.checkPlannedChangeArgsForCall)
+}
+
+func (fake *FakeVcsBackend) CheckPlannedChangeCalls(stub func(context.Context, *models.Repository, *models.PlannedChange) error) {
+	fake.checkPlannedChangeMutex.Lock()
+	defer fake.checkPlannedChangeMutex.Unlock()
+	fake.CheckPlannedChangeStub = stub
+}
+
+func (fake *FakeVcsBackend) CheckPlannedChangeArgsForCall(i int) (context.Context, *models.Repository, *models.PlannedChange) {
+	fake.checkPlannedChangeMutex.RLock()
+	defer fake.checkPlannedChangeMutex.RUnlock()
+	argsForCall := fake.checkPlannedChangeArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeVcsBackend) CheckPlannedChangeReturns(result1 error) {
+	fake.checkPlannedChangeMutex.Lock()
+	defer fake.checkPlannedChangeMutex.Unlock()
+	fake.CheckPlannedChangeStub = nil
+	fake.checkPlannedChangeReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeVcsBackend) CheckPlannedChangeReturnsOnCall(i int, result1 error) {
+	fake.checkPlannedChangeMutex.Lock()
+	defer fake.checkPlannedChangeMutex.Unlock()
+	fake.CheckPlannedChangeStub = nil
+	if fake.checkPlannedChangeReturnsOnCall == nil {
+		fake.checkPlannedChangeReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.checkPlannedChangeReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeVcsBackend) ConfigureControls(arg1 *models.Repository, arg2 []*models.Branch, arg3 []models.ControlConfiguration) error {
 	var arg2Copy []*models.Branch
 	if arg2 != nil {
@@ -504,6 +773,139 @@ func (fake *FakeVcsBackend) GetBranchControlsAtCommitReturnsOnCall(i int, result
 	}{result1, result2}
 }
 
+func (fake *FakeVcsBackend) GetCommitHistory(arg1 context.Context, arg2 *models.Branch, arg3 *models.Commit, arg4 int) ([]*models.Commit, error) {
+	fake.getCommitHistoryMutex.Lock()
+	ret, specificReturn := fake.getCommitHistoryReturnsOnCall[len(fake.getCommitHistoryArgsForCall)]
+	fake.getCommitHistoryArgsForCall = append(fake.getCommitHistoryArgsForCall, struct {
+		arg1 context.Context
+		arg2 *models.Branch
+		arg3 *models.Commit
+		arg4 int
+	}{arg1, arg2, arg3, arg4})
+	stub := fake.GetCommitHistoryStub
+	fakeReturns := fake.getCommitHistoryReturns
+	fake.recordInvocation("GetCommitHistory", []interface{}{arg1, arg2, arg3, arg4})
+	fake.getCommitHistoryMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeVcsBackend) GetCommitHistoryCallCount() int {
+	fake.getCommitHistoryMutex.RLock()
+	defer fake.getCommitHistoryMutex.RUnlock()
+	return len(fake.getCommitHistoryArgsForCall)
+}
+
+func (fake *FakeVcsBackend) GetCommitHistoryCalls(stub func(context.Context, *models.Branch, *models.Commit, int) ([]*models.Commit, error)) {
+	fake.getCommitHistoryMutex.Lock()
+	defer fake.getCommitHistoryMutex.Unlock()
+	fake.GetCommitHistoryStub = stub
+}
+
+func (fake *FakeVcsBackend) GetCommitHistoryArgsForCall(i int) (context.Context, *models.Branch, *models.Commit, int) {
+	fake.getCommitHistoryMutex.RLock()
+	defer fake.getCommitHistoryMutex.RUnlock()
+	argsForCall := fake.getCommitHistoryArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeVcsBackend) GetCommitHistoryReturns(result1 []*models.Commit, result2 error) {
+	fake.getCommitHistoryMutex.Lock()
+	defer fake.getCommitHistoryMutex.Unlock()
+	fake.GetCommitHistoryStub = nil
+	fake.getCommitHistoryReturns = struct {
+		result1 []*models.Commit
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeVcsBackend) GetCommitHistoryReturnsOnCall(i int, result1 []*models.Commit, result2 error) {
+	fake.getCommitHistoryMutex.Lock()
+	defer fake.getCommitHistoryMutex.Unlock()
+	fake.GetCommitHistoryStub = nil
+	if fake.getCommitHistoryReturnsOnCall == nil {
+		fake.getCommitHistoryReturnsOnCall = make(map[int]struct {
+			result1 []*models.Commit
+			result2 error
+		})
+	}
+	fake.getCommitHistoryReturnsOnCall[i] = struct {
+		result1 []*models.Commit
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeVcsBackend) GetCommitParents(arg1 context.Context, arg2 *models.Branch, arg3 *models.Commit) ([]*models.Commit, error) {
+	fake.getCommitParentsMutex.Lock()
+	ret, specificReturn := fake.getCommitParentsReturnsOnCall[len(fake.getCommitParentsArgsForCall)]
+	fake.getCommitParentsArgsForCall = append(fake.getCommitParentsArgsForCall, struct {
+		arg1 context.Context
+		arg2 *models.Branch
+		arg3 *models.Commit
+	}{arg1, arg2, arg3})
+	stub := fake.GetCommitParentsStub
+	fakeReturns := fake.getCommitParentsReturns
+	fake.recordInvocation("GetCommitParents", []interface{}{arg1, arg2, arg3})
+	fake.getCommitParentsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeVcsBackend) GetCommitParentsCallCount() int {
+	fake.getCommitParentsMutex.RLock()
+	defer fake.getCommitParentsMutex.RUnlock()
+	return len(fake.getCommitParentsArgsForCall)
+}
+
+func (fake *FakeVcsBackend) GetCommitParentsCalls(stub func(context.Context, *models.Branch, *models.Commit) ([]*models.Commit, error)) {
+	fake.getCommitParentsMutex.Lock()
+	defer fake.getCommitParentsMutex.Unlock()
+	fake.GetCommitParentsStub = stub
+}
+
+func (fake *FakeVcsBackend) GetCommitParentsArgsForCall(i int) (context.Context, *models.Branch, *models.Commit) {
+	fake.getCommitParentsMutex.RLock()
+	defer fake.getCommitParentsMutex.RUnlock()
+	argsForCall := fake.getCommitParentsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeVcsBackend) GetCommitParentsReturns(result1 []*models.Commit, result2 error) {
+	fake.getCommitParentsMutex.Lock()
+	defer fake.getCommitParentsMutex.Unlock()
+	fake.GetCommitParentsStub = nil
+	fake.getCommitParentsReturns = struct {
+		result1 []*models.Commit
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeVcsBackend) GetCommitParentsReturnsOnCall(i int, result1 []*models.Commit, result2 error) {
+	fake.getCommitParentsMutex.Lock()
+	defer fake.getCommitParentsMutex.Unlock()
+	fake.GetCommitParentsStub = nil
+	if fake.getCommitParentsReturnsOnCall == nil {
+		fake.getCommitParentsReturnsOnCall = make(map[int]struct {
+			result1 []*models.Commit
+			result2 error
+		})
+	}
+	fake.getCommitParentsReturnsOnCall[i] = struct {
+		result1 []*models.Commit
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeVcsBackend) GetDefaultBranch(arg1 context.Context, arg2 *models.Repository) (*models.Branch, error) {
 	fake.getDefaultBranchMutex.Lock()
 	ret, specificReturn := fake.getDefaultBranchReturnsOnCall[len(fake.getDefaultBranchArgsForCall)]
@@ -635,6 +1037,71 @@ func (fake *FakeVcsBackend) GetLatestCommitReturnsOnCall(i int, result1 *models.
 	}{result1, result2}
 }
 
+func (fake *FakeVcsBackend) GetManagedControls(arg1 context.Context, arg2 *models.Repository) ([]models.ControlConfiguration, error) {
+	fake.getManagedControlsMutex.Lock()
+	ret, specificReturn := fake.getManagedControlsReturnsOnCall[len(fake.getManagedControlsArgsForCall)]
+	fake.getManagedControlsArgsForCall = append(fake.getManagedControlsArgsForCall, struct {
+		arg1 context.Context
+		arg2 *models.Repository
+	}{arg1, arg2})
+	stub := fake.GetManagedControlsStub
+	fakeReturns := fake.getManagedControlsReturns
+	fake.recordInvocation("GetManagedControls", []interface{}{arg1, arg2})
+	fake.getManagedControlsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeVcsBackend) GetManagedControlsCallCount() int {
+	fake.getManagedControlsMutex.RLock()
+	defer fake.getManagedControlsMutex.RUnlock()
+	return len(fake.getManagedControlsArgsForCall)
+}
+
+func (fake *FakeVcsBackend) GetManagedControlsCalls(stub func(context.Context, *models.Repository) ([]models.ControlConfiguration, error)) {
+	fake.getManagedControlsMutex.Lock()
+	defer fake.getManagedControlsMutex.Unlock()
+	fake.GetManagedControlsStub = stub
+}
+
+func (fake *FakeVcsBackend) GetManagedControlsArgsForCall(i int) (context.Context, *models.Repository) {
+	fake.getManagedControlsMutex.RLock()
+	defer fake.getManagedControlsMutex.RUnlock()
+	argsForCall := fake.getManagedControlsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeVcsBackend) GetManagedControlsReturns(result1 []models.ControlConfiguration, result2 error) {
+	fake.getManagedControlsMutex.Lock()
+	defer fake.getManagedControlsMutex.Unlock()
+	fake.GetManagedControlsStub = nil
+	fake.getManagedControlsReturns = struct {
+		result1 []models.ControlConfiguration
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeVcsBackend) GetManagedControlsReturnsOnCall(i int, result1 []models.ControlConfiguration, result2 error) {
+	fake.getManagedControlsMutex.Lock()
+	defer fake.getManagedControlsMutex.Unlock()
+	fake.GetManagedControlsStub = nil
+	if fake.getManagedControlsReturnsOnCall == nil {
+		fake.getManagedControlsReturnsOnCall = make(map[int]struct {
+			result1 []models.ControlConfiguration
+			result2 error
+		})
+	}
+	fake.getManagedControlsReturnsOnCall[i] = struct {
+		result1 []models.ControlConfiguration
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeVcsBackend) GetPreviousCommit(arg1 context.Context, arg2 *models.Branch, arg3 *models.Commit) (*models.Commit, error) {
 	fake.getPreviousCommitMutex.Lock()
 	ret, specificReturn := fake.getPreviousCommitReturnsOnCall[len(fake.getPreviousCommitArgsForCall)]
@@ -767,6 +1234,71 @@ func (fake *FakeVcsBackend) GetRevisionCommitReturnsOnCall(i int, result1 *model
 	}{result1, result2}
 }
 
+func (fake *FakeVcsBackend) GetStagedRuleFailures(arg1 context.Context, arg2 *models.Repository) ([]*models.StagedRuleFailure, error) {
+	fake.getStagedRuleFailuresMutex.Lock()
+	ret, specificReturn := fake.getStagedRuleFailuresReturnsOnCall[len(fake.getStagedRuleFailuresArgsForCall)]
+	fake.getStagedRuleFailuresArgsForCall = append(fake.getStagedRuleFailuresArgsForCall, struct {
+		arg1 context.Context
+		arg2 *models.Repository
+	}{arg1, arg2})
+	stub := fake.GetStagedRuleFailuresStub
+	fakeReturns := fake.getStagedRuleFailuresReturns
+	fake.recordInvocation("GetStagedRuleFailures", []interface{}{arg1, arg2})
+	fake.getStagedRuleFailuresMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeVcsBackend) GetStagedRuleFailuresCallCount() int {
+	fake.getStagedRuleFailuresMutex.RLock()
+	defer fake.getStagedRuleFailuresMutex.RUnlock()
+	return len(fake.getStagedRuleFailuresArgsForCall)
+}
+
+func (fake *FakeVcsBackend) GetStagedRuleFailuresCalls(stub func(context.Context, *models.Repository) ([]*models.StagedRuleFailure, error)) {
+	fake.getStagedRuleFailuresMutex.Lock()
+	defer fake.getStagedRuleFailuresMutex.Unlock()
+	fake.GetStagedRuleFailuresStub = stub
+}
+
+func (fake *FakeVcsBackend) GetStagedRuleFailuresArgsForCall(i int) (context.Context, *models.Repository) {
+	fake.getStagedRuleFailuresMutex.RLock()
+	defer fake.getStagedRuleFailuresMutex.RUnlock()
+	argsForCall := fake.getStagedRuleFailuresArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeVcsBackend) GetStagedRuleFailuresReturns(result1 []*models.StagedRuleFailure, result2 error) {
+	fake.getStagedRuleFailuresMutex.Lock()
+	defer fake.getStagedRuleFailuresMutex.Unlock()
+	fake.GetStagedRuleFailuresStub = nil
+	fake.getStagedRuleFailuresReturns = struct {
+		result1 []*models.StagedRuleFailure
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeVcsBackend) GetStagedRuleFailuresReturnsOnCall(i int, result1 []*models.StagedRuleFailure, result2 error) {
+	fake.getStagedRuleFailuresMutex.Lock()
+	defer fake.getStagedRuleFailuresMutex.Unlock()
+	fake.GetStagedRuleFailuresStub = nil
+	if fake.getStagedRuleFailuresReturnsOnCall == nil {
+		fake.getStagedRuleFailuresReturnsOnCall = make(map[int]struct {
+			result1 []*models.StagedRuleFailure
+			result2 error
+		})
+	}
+	fake.getStagedRuleFailuresReturnsOnCall[i] = struct {
+		result1 []*models.StagedRuleFailure
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeVcsBackend) GetTagControls(arg1 context.Context, arg2 *models.Branch, arg3 *models.Tag) (*slsa.ControlSet, error) {
 	fake.getTagControlsMutex.Lock()
 	ret, specificReturn := fake.getTagControlsReturnsOnCall[len(fake.getTagControlsArgsForCall)]
@@ -833,6 +1365,282 @@ func (fake *FakeVcsBackend) GetTagControlsReturnsOnCall(i int, result1 *slsa.Con
 	}{result1, result2}
 }
 
+func (fake *FakeVcsBackend) GetTagInfo(arg1 context.Context, arg2 *models.Repository, arg3 *models.Tag) (*models.TagInfo, error) {
+	fake.getTagInfoMutex.Lock()
+	ret, specificReturn := fake.getTagInfoReturnsOnCall[len(fake.getTagInfoArgsForCall)]
+	fake.getTagInfoArgsForCall = append(fake.getTagInfoArgsForCall, struct {
+		arg1 context.Context
+		arg2 *models.Repository
+		arg3 *models.Tag
+	}{arg1, arg2, arg3})
+	stub := fake.GetTagInfoStub
+	fakeReturns := fake.getTagInfoReturns
+	fake.recordInvocation("GetTagInfo", []interface{}{arg1, arg2, arg3})
+	fake.getTagInfoMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeVcsBackend) GetTagInfoCallCount() int {
+	fake.getTagInfoMutex.RLock()
+	defer fake.getTagInfoMutex.RUnlock()
+	return len(fake.getTagInfoArgsForCall)
+}
+
+func (fake *FakeVcsBackend) GetTagInfoCalls(stub func(context.Context, *models.Repository, *models.Tag) (*models.TagInfo, error)) {
+	fake.getTagInfoMutex.Lock()
+	defer fake.getTagInfoMutex.Unlock()
+	fake.GetTagInfoStub = stub
+}
+
+func (fake *FakeVcsBackend) GetTagInfoArgsForCall(i int) (context.Context, *models.Repository, *models.Tag) {
+	fake.getTagInfoMutex.RLock()
+	defer fake.getTagInfoMutex.RUnlock()
+	argsForCall := fake.getTagInfoArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeVcsBackend) GetTagInfoReturns(result1 *models.TagInfo, result2 error) {
+	fake.getTagInfoMutex.Lock()
+	defer fake.getTagInfoMutex.Unlock()
+	fake.GetTagInfoStub = nil
+	fake.getTagInfoReturns = struct {
+		result1 *models.TagInfo
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeVcsBackend) GetTagInfoReturnsOnCall(i int, result1 *models.TagInfo, result2 error) {
+	fake.getTagInfoMutex.Lock()
+	defer fake.getTagInfoMutex.Unlock()
+	fake.GetTagInfoStub = nil
+	if fake.getTagInfoReturnsOnCall == nil {
+		fake.getTagInfoReturnsOnCall = make(map[int]struct {
+			result1 *models.TagInfo
+			result2 error
+		})
+	}
+	fake.getTagInfoReturnsOnCall[i] = struct {
+		result1 *models.TagInfo
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeVcsBackend) PlanControls(arg1 context.Context, arg2 *models.Repository, arg3 []*models.Branch, arg4 []models.ControlConfiguration) ([]*models.PlannedChange, error) {
+	var arg3Copy []*models.Branch
+	if arg3 != nil {
+		arg3Copy = make([]*models.Branch, len(arg3))
+		copy(arg3Copy, arg3)
+	}
+	var arg4Copy []models.ControlConfiguration
+	if arg4 != nil {
+		arg4Copy = make([]models.ControlConfiguration, len(arg4))
+		copy(arg4Copy, arg4)
+	}
+	fake.planControlsMutex.Lock()
+	ret, specificReturn := fake.planControlsReturnsOnCall[len(fake.planControlsArgsForCall)]
+	fake.planControlsArgsForCall = append(fake.planControlsArgsForCall, struct {
+		arg1 context.Context
+		arg2 *models.Repository
+		arg3 []*models.Branch
+		arg4 []models.ControlConfiguration
+	}{arg1, arg2, arg3Copy, arg4Copy})
+	stub := fake.PlanControlsStub
+	fakeReturns := fake.planControlsReturns
+	fake.recordInvocation("PlanControls", []interface{}{arg1, arg2, arg3Copy, arg4Copy})
+	fake.planControlsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeVcsBackend) PlanControlsCallCount() int {
+	fake.planControlsMutex.RLock()
+	defer fake.planControlsMutex.RUnlock()
+	return len(fake.planControlsArgsForCall)
+}
+
+func (fake *FakeVcsBackend) PlanControlsCalls(stub func(context.Context, *models.Repository, []*models.Branch, []models.ControlConfiguration) ([]*models.PlannedChange, error)) {
+	fake.planControlsMutex.Lock()
+	defer fake.planControlsMutex.Unlock()
+	fake.PlanControlsStub = stub
+}
+
+func (fake *FakeVcsBackend) PlanControlsArgsForCall(i int) (context.Context, *models.Repository, []*models.Branch, []models.ControlConfiguration) {
+	fake.planControlsMutex.RLock()
+	defer fake.planControlsMutex.RUnlock()
+	argsForCall := fake.planControlsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeVcsBackend) PlanControlsReturns(result1 []*models.PlannedChange, result2 error) {
+	fake.planControlsMutex.Lock()
+	defer fake.planControlsMutex.Unlock()
+	fake.PlanControlsStub = nil
+	fake.planControlsReturns = struct {
+		result1 []*models.PlannedChange
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeVcsBackend) PlanControlsReturnsOnCall(i int, result1 []*models.PlannedChange, result2 error) {
+	fake.planControlsMutex.Lock()
+	defer fake.planControlsMutex.Unlock()
+	fake.PlanControlsStub = nil
+	if fake.planControlsReturnsOnCall == nil {
+		fake.planControlsReturnsOnCall = make(map[int]struct {
+			result1 []*models.PlannedChange
+			result2 error
+		})
+	}
+	fake.planControlsReturnsOnCall[i] = struct {
+		result1 []*models.PlannedChange
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeVcsBackend) PromoteControls(arg1 context.Context, arg2 *models.Repository) ([]string, error) {
+	fake.promoteControlsMutex.Lock()
+	ret, specificReturn := fake.promoteControlsReturnsOnCall[len(fake.promoteControlsArgsForCall)]
+	fake.promoteControlsArgsForCall = append(fake.promoteControlsArgsForCall, struct {
+		arg1 context.Context
+		arg2 *models.Repository
+	}{arg1, arg2})
+	stub := fake.PromoteControlsStub
+	fakeReturns := fake.promoteControlsReturns
+	fake.recordInvocation("PromoteControls", []interface{}{arg1, arg2})
+	fake.promoteControlsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeVcsBackend) PromoteControlsCallCount() int {
+	fake.promoteControlsMutex.RLock()
+	defer fake.promoteControlsMutex.RUnlock()
+	return len(fake.promoteControlsArgsForCall)
+}
+
+func (fake *FakeVcsBackend) PromoteControlsCalls(stub func(context.Context, *models.Repository) ([]string, error)) {
+	fake.promoteControlsMutex.Lock()
+	defer fake.promoteControlsMutex.Unlock()
+	fake.PromoteControlsStub = stub
+}
+
+func (fake *FakeVcsBackend) PromoteControlsArgsForCall(i int) (context.Context, *models.Repository) {
+	fake.promoteControlsMutex.RLock()
+	defer fake.promoteControlsMutex.RUnlock()
+	argsForCall := fake.promoteControlsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeVcsBackend) PromoteControlsReturns(result1 []string, result2 error) {
+	fake.promoteControlsMutex.Lock()
+	defer fake.promoteControlsMutex.Unlock()
+	fake.PromoteControlsStub = nil
+	fake.promoteControlsReturns = struct {
+		result1 []string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeVcsBackend) PromoteControlsReturnsOnCall(i int, result1 []string, result2 error) {
+	fake.promoteControlsMutex.Lock()
+	defer fake.promoteControlsMutex.Unlock()
+	fake.PromoteControlsStub = nil
+	if fake.promoteControlsReturnsOnCall == nil {
+		fake.promoteControlsReturnsOnCall = make(map[int]struct {
+			result1 []string
+			result2 error
+		})
+	}
+	fake.promoteControlsReturnsOnCall[i] = struct {
+		result1 []string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeVcsBackend) RemoveControls(arg1 context.Context, arg2 *models.Repository, arg3 []models.ControlConfiguration) error {
+	var arg3Copy []models.ControlConfiguration
+	if arg3 != nil {
+		arg3Copy = make([]models.ControlConfiguration, len(arg3))
+		copy(arg3Copy, arg3)
+	}
+	fake.removeControlsMutex.Lock()
+	ret, specificReturn := fake.removeControlsReturnsOnCall[len(fake.removeControlsArgsForCall)]
+	fake.removeControlsArgsForCall = append(fake.removeControlsArgsForCall, struct {
+		arg1 context.Context
+		arg2 *models.Repository
+		arg3 []models.ControlConfiguration
+	}{arg1, arg2, arg3Copy})
+	stub := fake.RemoveControlsStub
+	fakeReturns := fake.removeControlsReturns
+	fake.recordInvocation("RemoveControls", []interface{}{arg1, arg2, arg3Copy})
+	fake.removeControlsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeVcsBackend) RemoveControlsCallCount() int {
+	fake.removeControlsMutex.RLock()
+	defer fake.removeControlsMutex.RUnlock()
+	return len(fake.removeControlsArgsForCall)
+}
+
+func (fake *FakeVcsBackend) RemoveControlsCalls(stub func(context.Context, *models.Repository, []models.ControlConfiguration) error) {
+	fake.removeControlsMutex.Lock()
+	defer fake.removeControlsMutex.Unlock()
+	fake.RemoveControlsStub = stub
+}
+
+func (fake *FakeVcsBackend) RemoveControlsArgsForCall(i int) (context.Context, *models.Repository, []models.ControlConfiguration) {
+	fake.removeControlsMutex.RLock()
+	defer fake.removeControlsMutex.RUnlock()
+	argsForCall := fake.removeControlsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeVcsBackend) RemoveControlsReturns(result1 error) {
+	fake.removeControlsMutex.Lock()
+	defer fake.removeControlsMutex.Unlock()
+	fake.RemoveControlsStub = nil
+	fake.removeControlsReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeVcsBackend) RemoveControlsReturnsOnCall(i int, result1 error) {
+	fake.removeControlsMutex.Lock()
+	defer fake.removeControlsMutex.Unlock()
+	fake.RemoveControlsStub = nil
+	if fake.removeControlsReturnsOnCall == nil {
+		fake.removeControlsReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.removeControlsReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeVcsBackend) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
@@ -0,0 +1,132 @@
+// SPDX-FileCopyrightText: Copyright 2026 The SLSA Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// PlanAction is what applying a planned change does to the resource
+type PlanAction string
+
+const (
+	PlanActionCreate PlanAction = "create"
+	PlanActionUpdate PlanAction = "update"
+	PlanActionNoop   PlanAction = "noop"
+)
+
+// PlanResource is the kind of resource touched by a planned change
+type PlanResource string
+
+const (
+	// PlanResourceRuleset is a repository ruleset, its state is its JSON
+	// representation in the hosting API.
+	PlanResourceRuleset PlanResource = "ruleset"
+
+	// PlanResourceFile is a file checked in through a pull request
+	PlanResourceFile PlanResource = "file"
+)
+
+// ControlPlan is the list of changes needed to configure controls in a
+// repository. Plans are saved to a file to be reviewed and then applied
+// exactly as they were made.
+type ControlPlan struct {
+	Hostname   string           `json:"hostname"`
+	Repository string           `json:"repository"`
+	Branches   []string         `json:"branches"`
+	CreatedAt  time.Time        `json:"created_at"`
+	Changes    []*PlannedChange `json:"changes"`
+}
+
+// GetRepository returns the repository the plan configures
+func (p *ControlPlan) GetRepository() *Repository {
+	return &Repository{Hostname: p.Hostname, Path: p.Repository}
+}
+
+// GetBranches returns the branches the plan configures
+func (p *ControlPlan) GetBranches() []*Branch {
+	r := p.GetRepository()
+	branches := []*Branch{}
+	for _, name := range p.Branches {
+		branches = append(branches, &Branch{Name: name, Repository: r})
+	}
+	return branches
+}
+
+// PlannedChange is a change to a resource to configure a control
+type PlannedChange struct {
+	Config   ControlConfiguration `json:"config"`
+	Resource PlanResource         `json:"resource"`
+	Action   PlanAction           `json:"action"`
+
+	// Name identifies the resource, the ruleset name or the file path
+	Name string `json:"name,omitempty"`
+
+	// Current is the state of the resource when the plan was made, empty
+	// when it will be created.
+	Current json.RawMessage `json:"current,omitempty"`
+
+	// Desired is the state of the resource after applying the change
+	Desired json.RawMessage `json:"desired,omitempty"`
+
+	// Contents is the data of the file to check in
+	Contents string `json:"contents,omitempty"`
+
+	// Reason explains why nothing needs to change
+	Reason string `json:"reason,omitempty"`
+}
+
+// Diff returns a unified diff of the resource JSON from its current to its
+// desired state. Files are diffed against an empty file.
+func (c *PlannedChange) Diff() (string, error) {
+	current, err := indentJSON(c.Current)
+	if err != nil {
+		return "", fmt.Errorf("reading current state: %w", err)
+	}
+
+	desired := c.Contents
+	if c.Resource != PlanResourceFile {
+		desired, err = indentJSON(c.Desired)
+		if err != nil {
+			return "", fmt.Errorf("reading desired state: %w", err)
+		}
+	}
+
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        diffLines(current),
+		B:        diffLines(desired),
+		FromFile: "current",
+		ToFile:   "planned",
+		Context:  3,
+	})
+}
+
+// diffLines splits text in lines, all ending with a newline
+func diffLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(strings.TrimSuffix(text, "\n"), "\n")
+	lines[len(lines)-1] += "\n"
+	return lines
+}
+
+// indentJSON formats JSON data for diffing, an empty string if there is none
+func indentJSON(data json.RawMessage) (string, error) {
+	if len(data) == 0 {
+		return "", nil
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, data, "", "  "); err != nil {
+		return "", err
+	}
+	buf.WriteString("\n")
+	return buf.String(), nil
+}
@@ -84,9 +84,20 @@ func WithPolicyRepo(slug string) ConfigFn {
 	}
 }
 
-func WithAllowMergeCommits(allow bool) ConfigFn {
+// WithPolicySources sets the locations to read policies from, in order of
+// precedence. See policy.ParsePolicySource for the supported sources.
+func WithPolicySources(specs ...string) ConfigFn {
 	return func(t *Tool) error {
-		t.Options.AllowMergeCommits = allow
+		t.Options.PolicySources = specs
+		return nil
+	}
+}
+
+// WithAllowMergeCommits is a no-op kept for compatibility.
+//
+// Deprecated: merge commits are always supported.
+func WithAllowMergeCommits(bool) ConfigFn {
+	return func(*Tool) error {
 		return nil
 	}
 }
@@ -101,3 +112,69 @@ func WithExpectedIdentity(issuer, san string) ConfigFn {
 		return nil
 	}
 }
+
+// WithTrustPolicy sets the path to a trust policy file listing the signer
+// identities accepted when verifying attestations. An empty path keeps the
+// expected identity.
+func WithTrustPolicy(path string) ConfigFn {
+	return func(t *Tool) error {
+		t.Options.TrustPolicy = path
+		return nil
+	}
+}
+
+// WithTrustedRoot sets the path to the sigstore trusted root attestations
+// are verified with. An empty path uses the public sigstore instance.
+func WithTrustedRoot(path string) ConfigFn {
+	return func(t *Tool) error {
+		t.Options.TrustedRoot = path
+		return nil
+	}
+}
+
+// WithRequireTlog requires the attestations verified to have a valid
+// transparency log entry.
+func WithRequireTlog(require bool) ConfigFn {
+	return func(t *Tool) error {
+		t.Options.RequireTlog = require
+		return nil
+	}
+}
+
+// WithSigningConfig sets the path to the sigstore signing config of the
+// instance attestations are signed with. An empty path signs with the
+// public sigstore instance.
+func WithSigningConfig(path string) ConfigFn {
+	return func(t *Tool) error {
+		t.Options.SigningConfig = path
+		return nil
+	}
+}
+
+// WithSigningKey sets the key file or KMS key URI attestations are signed
+// with. An empty key signs with a keyless sigstore identity.
+func WithSigningKey(ref string) ConfigFn {
+	return func(t *Tool) error {
+		t.Options.SigningKey = ref
+		return nil
+	}
+}
+
+// WithGitLabHosts sets the hostnames of the GitLab instances handled by
+// the GitLab backend. This replaces the default (gitlab.com), include it
+// when passing self-managed instances if needed.
+func WithGitLabHosts(hosts ...string) ConfigFn {
+	return func(t *Tool) error {
+		t.Options.GitLabHosts = hosts
+		return nil
+	}
+}
+
+// WithLocalBackend configures the tool to read all repository data from
+// a git repository on disk, as declared in the local backend config file.
+func WithLocalBackend(configPath string) ConfigFn {
+	return func(t *Tool) error {
+		t.Options.LocalBackendConfig = configPath
+		return nil
+	}
+}
@@ -7,21 +7,31 @@ import (
 	"fmt"
 
 	"github.com/slsa-framework/source-tool/pkg/policy"
+	"github.com/slsa-framework/source-tool/pkg/sourcetool/backends/vcs/gitlab"
 	"github.com/slsa-framework/source-tool/pkg/sourcetool/models"
 )
 
 type Options struct {
 	// Organization to look for slsa and user forks
 	UserForkOrg string
-	Enforce     bool
-	UseSSH      bool
-	UpdateRepo  bool
+
+	// Enforce creates the repository rules enforced. When false, they are
+	// staged in evaluate mode until promoted.
+	Enforce bool
+
+	UseSSH     bool
+	UpdateRepo bool
 
 	CreatePolicyPR bool
 
 	// PolicyRepo is the repository where the policies are stored
 	PolicyRepo string
 
+	// PolicySources are the locations policies are read from, in order of
+	// precedence. See policy.ParsePolicySource for the format of each
+	// source. When empty, policies are read from the community repository.
+	PolicySources []string
+
 	// Initialize GitHub attestations storer and fetcher
 	InitGHCollector bool
 	InitGHStorer    bool
@@ -38,14 +48,44 @@ type Options struct {
 	ExpectedIssuer string
 	ExpectedSan    string
 
+	// TrustPolicy is the path to a trust policy file listing the signer
+	// identities accepted. When set, it replaces the expected identity.
+	TrustPolicy string
+
+	// TrustedRoot and SigningConfig are the paths to the sigstore trusted
+	// root and signing config of a private sigstore instance. The trusted
+	// root is used to verify attestations and the signing config to sign
+	// them. When empty, the public sigstore instance is used.
+	TrustedRoot   string
+	SigningConfig string
+
+	// RequireTlog requires attestations to be recorded in a transparency
+	// log, the log entries are verified against the trusted root.
+	RequireTlog bool
+
+	// SigningKey is the path to a private key file or a KMS key URI to
+	// sign attestations with instead of a keyless sigstore identity.
+	SigningKey string
+
+	// GitLabHosts are the hostnames of the GitLab instances sourcetool
+	// handles with the GitLab backend. Repositories on hosts other than
+	// these and github.com are rejected.
+	GitLabHosts []string
+
+	// LocalBackendConfig is the path to the config file of the local
+	// backend. When set, all data is read from a git repository on disk.
+	LocalBackendConfig string
+
 	models.BackendOptions
 }
 
 // DefaultOptions holds the default options the tool initializes with
 var Default = Options{
 	PolicyRepo:         fmt.Sprintf("%s/%s", policy.SourcePolicyRepoOwner, policy.SourcePolicyRepo),
+	Enforce:            true,
 	UseSSH:             true,
 	CreatePolicyPR:     true,
 	InitNotesCollector: true,
+	GitLabHosts:        []string{gitlab.Hostname},
 	BackendOptions:     models.BackendOptions{},
 }
@@ -35,6 +35,19 @@ type FakeToolImplementation struct {
 	checkPolicyForkReturnsOnCall map[int]struct {
 		result1 error
 	}
+	ClosePullRequestStub        func(context.Context, *auth.Authenticator, *models.PullRequest) error
+	closePullRequestMutex       sync.RWMutex
+	closePullRequestArgsForCall []struct {
+		arg1 context.Context
+		arg2 *auth.Authenticator
+		arg3 *models.PullRequest
+	}
+	closePullRequestReturns struct {
+		result1 error
+	}
+	closePullRequestReturnsOnCall map[int]struct {
+		result1 error
+	}
 	ConfigureControlsStub        func(models.VcsBackend, *models.Repository, []*models.Branch, []models.ControlConfiguration) error
 	configureControlsMutex       sync.RWMutex
 	configureControlsArgsForCall []struct {
@@ -280,6 +293,69 @@ func (fake *FakeToolImplementation) CheckPolicyForkReturnsOnCall(i int, result1
 	}{result1}
 }
 
+func (fake *FakeToolImplementation) ClosePullRequest(arg1 context.Context, arg2 *auth.Authenticator, arg3 *models.PullRequest) error {
+	fake.closePullRequestMutex.Lock()
+	ret, specificReturn := fake.closePullRequestReturnsOnCall[len(fake.closePullRequestArgsForCall)]
+	fake.closePullRequestArgsForCall = append(fake.closePullRequestArgsForCall, struct {
+		arg1 context.Context
+		arg2 *auth.Authenticator
+		arg3 *models.PullRequest
+	}{arg1, arg2, arg3})
+	stub := fake.ClosePullRequestStub
+	fakeReturns := fake.closePullRequestReturns
+	fake.recordInvocation("ClosePullRequest", []interface{}{arg1, arg2, arg3})
+	fake.closePullRequestMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeToolImplementation) ClosePullRequestCallCount() int {
+	fake.closePullRequestMutex.RLock()
+	defer fake.closePullRequestMutex.RUnlock()
+	return len(fake.closePullRequestArgsForCall)
+}
+
+func (fake *FakeToolImplementation) ClosePullRequestCalls(stub func(context.Context, *auth.Authenticator, *models.PullRequest) error) {
+	fake.closePullRequestMutex.Lock()
+	defer fake.closePullRequestMutex.Unlock()
+	fake.ClosePullRequestStub = stub
+}
+
+func (fake *FakeToolImplementation) ClosePullRequestArgsForCall(i int) (context.Context, *auth.Authenticator, *models.PullRequest) {
+	fake.closePullRequestMutex.RLock()
+	defer fake.closePullRequestMutex.RUnlock()
+	argsForCall := fake.closePullRequestArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeToolImplementation) ClosePullRequestReturns(result1 error) {
+	fake.closePullRequestMutex.Lock()
+	defer fake.closePullRequestMutex.Unlock()
+	fake.ClosePullRequestStub = nil
+	fake.closePullRequestReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeToolImplementation) ClosePullRequestReturnsOnCall(i int, result1 error) {
+	fake.closePullRequestMutex.Lock()
+	defer fake.closePullRequestMutex.Unlock()
+	fake.ClosePullRequestStub = nil
+	if fake.closePullRequestReturnsOnCall == nil {
+		fake.closePullRequestReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.closePullRequestReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeToolImplementation) ConfigureControls(arg1 models.VcsBackend, arg2 *models.Repository, arg3 []*models.Branch, arg4 []models.ControlConfiguration) error {
 	var arg3Copy []*models.Branch
 	if arg3 != nil {
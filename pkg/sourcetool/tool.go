@@ -8,6 +8,7 @@ package sourcetool
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
@@ -28,12 +29,21 @@ import (
 	"github.com/slsa-framework/source-tool/pkg/policy"
 	"github.com/slsa-framework/source-tool/pkg/slsa"
 	"github.com/slsa-framework/source-tool/pkg/sourcetool/backends/vcs/github"
+	"github.com/slsa-framework/source-tool/pkg/sourcetool/backends/vcs/gitlab"
+	"github.com/slsa-framework/source-tool/pkg/sourcetool/backends/vcs/local"
 	"github.com/slsa-framework/source-tool/pkg/sourcetool/models"
 	"github.com/slsa-framework/source-tool/pkg/sourcetool/options"
 )
 
 var ControlConfigurations = []models.ControlConfiguration{
 	models.CONFIG_POLICY, models.CONFIG_GEN_PROVENANCE, models.CONFIG_BRANCH_RULES, models.CONFIG_TAG_RULES,
+	models.CONFIG_REVIEW_RULES,
+}
+
+// OnboardConfigurations are the control configurations set up when
+// onboarding a repository.
+var OnboardConfigurations = []models.ControlConfiguration{
+	models.CONFIG_BRANCH_RULES, models.CONFIG_GEN_PROVENANCE, models.CONFIG_TAG_RULES,
 }
 
 // githubHostname is the hostname of the VCS system sourcetool supports (for now)
@@ -52,7 +62,8 @@ func New(funcs ...ConfigFn) (*Tool, error) {
 		}
 	}
 
-	t.backend = github.New(&t.Options.BackendOptions)
+	// Rules not enforced are staged in evaluate mode
+	t.Options.StageRules = !t.Options.Enforce
 
 	// Build the attestation verifier, honoring any identity overrides
 	verifierOptions := attest.DefaultVerifierOptions
@@ -65,13 +76,57 @@ func New(funcs ...ConfigFn) (*Tool, error) {
 		verifierOptions.ExpectedSan = t.Options.ExpectedSan
 		verifierOptions.AlternateSans = nil
 	}
+	if t.Options.TrustPolicy != "" {
+		tp, err := attest.LoadTrustPolicy(t.Options.TrustPolicy)
+		if err != nil {
+			return nil, err
+		}
+		verifierOptions.TrustPolicy = tp
+	}
+	verifierOptions.TrustedRoot = t.Options.TrustedRoot
+	verifierOptions.RequireTlog = t.Options.RequireTlog
+	verifier := attest.NewBndVerifier(verifierOptions)
+
+	// Route each repository to the backend of its hosting system. The
+	// backends check attestations with the same verifier as the tool.
+	ghBackend := github.New(&t.Options.BackendOptions)
+	ghBackend.SetVerifier(verifier)
+	backends := map[string]models.VcsBackend{githubHostname: ghBackend}
+	if len(t.Options.GitLabHosts) > 0 {
+		glBackend := gitlab.New(&t.Options.BackendOptions)
+		glBackend.SetVerifier(verifier)
+		for _, h := range t.Options.GitLabHosts {
+			backends[h] = glBackend
+		}
+	}
+	t.backend = &hostBackend{
+		defaultBackend: ghBackend,
+		backends:       backends,
+	}
+
+	// When a local backend is configured, all data is read from the
+	// repository on disk and attestations from its git notes.
+	if t.Options.LocalBackendConfig != "" {
+		conf, err := local.LoadConfig(t.Options.LocalBackendConfig)
+		if err != nil {
+			return nil, fmt.Errorf("loading local backend config: %w", err)
+		}
+		lb := local.New(&t.Options.BackendOptions, conf)
+		lb.SetVerifier(verifier)
+		t.localNotes, err = lb.Notes()
+		if err != nil {
+			return nil, err
+		}
+		t.backend = lb
+	}
 
 	// Create the tool's attester
 	attester, err := attest.NewAttester(
-		attest.WithVerifier(attest.NewBndVerifier(verifierOptions)),
+		attest.WithVerifier(verifier),
 		attest.WithBackend(t.backend),
-		attest.WithGithubCollector(t.Options.InitGHCollector),
-		attest.WithNotesCollector(t.Options.InitNotesCollector),
+		attest.WithGithubCollector(t.Options.InitGHCollector && t.localNotes == nil),
+		attest.WithNotesCollector(t.Options.InitNotesCollector && t.localNotes == nil),
+		attest.WithAttestationRepository(t.localRepositories()...),
 		attest.WithAuthenticator(t.Authenticator),
 	)
 	if err != nil {
@@ -92,6 +147,19 @@ type Tool struct {
 	backend       models.VcsBackend
 	Options       options.Options
 	impl          toolImplementation
+
+	// localNotes reads and writes the attestations in the local
+	// repository notes when running with the local backend.
+	localNotes *local.Notes
+}
+
+// localRepositories returns the attestation repositories of the local
+// backend, if configured.
+func (t *Tool) localRepositories() []attestation.Repository {
+	if t.localNotes == nil {
+		return nil
+	}
+	return []attestation.Repository{t.localNotes}
 }
 
 // GetRepoControls returns the controls that are enabled in a repository branch.
@@ -143,20 +211,260 @@ func (t *Tool) GetBranchControlsAtCommit(ctx context.Context, branch *models.Bra
 }
 
 // OnboardRepository configures a repository to set up the required controls
-// to meet SLSA Source L3.
+// to meet SLSA Source L3. Onboarding is transactional: if a control fails to
+// be configured, the controls set up by the onboarding are removed.
 func (t *Tool) OnboardRepository(ctx context.Context, repo *models.Repository, branches []*models.Branch) error {
 	if err := t.impl.VerifyOptionsForFullOnboard(t.Authenticator, &t.Options); err != nil {
 		return fmt.Errorf("verifying options: %w", err)
 	}
 
-	if err := t.backend.ConfigureControls(
-		repo, branches, []models.ControlConfiguration{
-			models.CONFIG_BRANCH_RULES, models.CONFIG_GEN_PROVENANCE, models.CONFIG_TAG_RULES,
-		},
-	); err != nil {
-		return fmt.Errorf("configuring controls: %w", err)
+	// Controls that were already set up are never rolled back
+	existing, err := t.backend.GetManagedControls(ctx, repo)
+	if err != nil {
+		return fmt.Errorf("reading the controls set up in the repository: %w", err)
+	}
+
+	created := []models.ControlConfiguration{}
+	for _, config := range OnboardConfigurations {
+		// The failing configuration is recorded too as it may be half done
+		if !slices.Contains(existing, config) {
+			created = append(created, config)
+		}
+		if err := t.backend.ConfigureControls(repo, branches, []models.ControlConfiguration{config}); err != nil {
+			return t.rollbackControls(ctx, repo, created, fmt.Errorf("configuring controls: %w", err))
+		}
+	}
+
+	return nil
+}
+
+// rollbackControls removes the controls created before err happened, most
+// recent first. It returns err with any rollback error joined.
+func (t *Tool) rollbackControls(ctx context.Context, repo *models.Repository, created []models.ControlConfiguration, err error) error {
+	if len(created) == 0 {
+		return err
+	}
+	slices.Reverse(created)
+	if rerr := t.backend.RemoveControls(ctx, repo, created); rerr != nil {
+		return errors.Join(err, fmt.Errorf("rolling back changes: %w", rerr))
+	}
+	return err
+}
+
+// PlanControls returns the changes that configuring the controls would make,
+// without touching the repository. The plan includes the exact rulesets,
+// workflow and policy to check in.
+func (t *Tool) PlanControls(
+	ctx context.Context, repo *models.Repository, branches []*models.Branch, configs []models.ControlConfiguration,
+) (*models.ControlPlan, error) {
+	plan := &models.ControlPlan{
+		Hostname:   repo.Hostname,
+		Repository: repo.Path,
+		Branches:   []string{},
+		CreatedAt:  time.Now().UTC(),
+		Changes:    []*models.PlannedChange{},
+	}
+	for _, b := range branches {
+		plan.Branches = append(plan.Branches, b.Name)
+	}
+
+	for _, config := range configs {
+		// The policy is not handled by the backend
+		if config == models.CONFIG_POLICY {
+			change, err := t.planPolicy(ctx, repo, branches)
+			if err != nil {
+				return nil, fmt.Errorf("planning %s: %w", config, err)
+			}
+			plan.Changes = append(plan.Changes, change)
+			continue
+		}
+
+		changes, err := t.backend.PlanControls(ctx, repo, branches, []models.ControlConfiguration{config})
+		if err != nil {
+			return nil, err
+		}
+		plan.Changes = append(plan.Changes, changes...)
+	}
+	return plan, nil
+}
+
+// planPolicy returns the change checking in the repository policy
+func (t *Tool) planPolicy(ctx context.Context, repo *models.Repository, branches []*models.Branch) (*models.PlannedChange, error) {
+	owner, name, err := repo.PathAsGitHubOwnerName()
+	if err != nil {
+		return nil, err
+	}
+	change := &models.PlannedChange{
+		Config:   models.CONFIG_POLICY,
+		Resource: models.PlanResourceFile,
+		Name:     policyFilePath(owner, name),
+	}
+
+	pr, err := t.FindPolicyPR(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+	if pr != nil {
+		change.Action = models.PlanActionNoop
+		change.Reason = fmt.Sprintf("policy pull request #%d is already open", pr.Number)
+		return change, nil
+	}
+
+	pcy, err := t.CreateBranchPolicy(ctx, repo, branches)
+	if err != nil {
+		return nil, fmt.Errorf("creating policy: %w", err)
+	}
+	// Marshaled as it will be checked in
+	data, err := json.MarshalIndent(pcy, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshaling policy data: %w", err)
+	}
+	change.Action = models.PlanActionCreate
+	change.Contents = string(data)
+	return change, nil
+}
+
+// ApplyPlan makes the changes in a plan exactly as planned. All changes are
+// checked first, if the repository changed since the plan was made nothing
+// is applied. When a change fails, the changes already applied are undone:
+// created controls are removed, updated rulesets are restored to their
+// planned current state and the policy pull request is closed.
+func (t *Tool) ApplyPlan(ctx context.Context, plan *models.ControlPlan) error {
+	repo := plan.GetRepository()
+
+	changes := []*models.PlannedChange{}
+	for _, c := range plan.Changes {
+		if c.Action != models.PlanActionNoop {
+			changes = append(changes, c)
+		}
+	}
+
+	for _, c := range changes {
+		var err error
+		if c.Config == models.CONFIG_POLICY {
+			err = t.checkPolicyChange(ctx, repo)
+		} else {
+			err = t.backend.CheckPlannedChange(ctx, repo, c)
+		}
+		if err != nil {
+			return fmt.Errorf("checking %s: %w", c.Config, err)
+		}
+	}
+
+	applied := []*models.PlannedChange{}
+	var policyPR *models.PullRequest
+	for _, c := range changes {
+		if c.Config == models.CONFIG_POLICY {
+			pr, err := t.applyPolicyChange(repo, c)
+			if err != nil {
+				return t.rollbackPlan(ctx, repo, applied, policyPR, fmt.Errorf("applying %s: %w", c.Config, err))
+			}
+			policyPR = pr
+			continue
+		}
+
+		// Created controls are recorded before applying as they may be
+		// half done when the change fails.
+		if c.Action == models.PlanActionCreate {
+			applied = append(applied, c)
+		}
+		if err := t.backend.ApplyPlannedChange(ctx, repo, c); err != nil {
+			return t.rollbackPlan(ctx, repo, applied, policyPR, fmt.Errorf("applying %s: %w", c.Config, err))
+		}
+		if c.Action == models.PlanActionUpdate {
+			applied = append(applied, c)
+		}
+	}
+	return nil
+}
+
+// rollbackPlan undoes the plan changes applied before err happened, most
+// recent first, and closes the policy pull request if it was opened. It
+// returns err with any rollback error joined.
+func (t *Tool) rollbackPlan(
+	ctx context.Context, repo *models.Repository, applied []*models.PlannedChange, policyPR *models.PullRequest, err error,
+) error {
+	errs := []error{}
+	for _, c := range slices.Backward(applied) {
+		var rerr error
+		if c.Action == models.PlanActionCreate {
+			rerr = t.backend.RemoveControls(ctx, repo, []models.ControlConfiguration{c.Config})
+		} else {
+			rerr = t.backend.ApplyPlannedChange(ctx, repo, revertChange(c))
+		}
+		if rerr != nil {
+			errs = append(errs, fmt.Errorf("reverting %s: %w", c.Config, rerr))
+		}
+	}
+
+	if policyPR != nil {
+		if rerr := t.impl.ClosePullRequest(ctx, t.Authenticator, policyPR); rerr != nil {
+			errs = append(errs, fmt.Errorf("closing the policy pull request: %w", rerr))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(err, fmt.Errorf("rolling back changes: %w", errors.Join(errs...)))
+	}
+	return err
+}
+
+// revertChange returns the change restoring a resource updated by a planned
+// change to the state it had when the plan was made.
+func revertChange(c *models.PlannedChange) *models.PlannedChange {
+	return &models.PlannedChange{
+		Config:   c.Config,
+		Resource: c.Resource,
+		Action:   models.PlanActionUpdate,
+		Name:     c.Name,
+		Current:  c.Current,
+		Desired:  c.Current,
+	}
+}
+
+// checkPolicyChange verifies no policy pull request was opened since the
+// plan was made.
+func (t *Tool) checkPolicyChange(ctx context.Context, repo *models.Repository) error {
+	pr, err := t.FindPolicyPR(ctx, repo)
+	if err != nil {
+		return err
+	}
+	if pr != nil {
+		return fmt.Errorf("policy pull request #%d was opened: %w", pr.Number, models.ErrPlanOutdated)
+	}
+	return nil
+}
+
+// applyPolicyChange opens the pull request checking in the planned policy
+func (t *Tool) applyPolicyChange(repo *models.Repository, change *models.PlannedChange) (*models.PullRequest, error) {
+	pcy := &policy.RepoPolicy{}
+	if err := json.Unmarshal([]byte(change.Contents), pcy); err != nil {
+		return nil, fmt.Errorf("reading planned policy: %w", err)
+	}
+
+	if err := t.impl.CheckPolicyFork(&t.Options); err != nil {
+		return nil, fmt.Errorf("checking policy repo fork: %w", err)
 	}
 
+	pr, err := t.impl.CreatePolicyPR(t.Authenticator, &t.Options, repo, pcy)
+	if err != nil {
+		return nil, fmt.Errorf("opening the policy pull request: %w", err)
+	}
+	return pr, nil
+}
+
+// GetManagedControls returns the control configurations that sourcetool has
+// set up in the repository.
+func (t *Tool) GetManagedControls(ctx context.Context, r *models.Repository) ([]models.ControlConfiguration, error) {
+	return t.backend.GetManagedControls(ctx, r)
+}
+
+// RemoveControls undoes the control configurations set up by sourcetool in
+// the repository. The repository policy is not removed.
+func (t *Tool) RemoveControls(ctx context.Context, r *models.Repository, configs []models.ControlConfiguration) error {
+	if err := t.backend.RemoveControls(ctx, r, configs); err != nil {
+		return fmt.Errorf("removing controls: %w", err)
+	}
 	return nil
 }
 
@@ -222,22 +530,53 @@ func (t *Tool) CheckPolicyRepoFork(_ context.Context) (bool, error) {
 	return true, nil
 }
 
-// CreateBranchPolicy creates a repository policy
-func (t *Tool) CreateBranchPolicy(ctx context.Context, r *models.Repository, branches []*models.Branch) (*policy.RepoPolicy, error) {
-	if len(branches) > 1 {
-		// Change this once we support merging policies
-		return nil, fmt.Errorf("only one branch is supported at a time")
-	}
-	if branches == nil {
+// CreateBranchPolicy creates a repository policy protecting the branches at
+// the level their current controls support. When a base policy is set in
+// the options, the new branch entries are merged into it.
+func (t *Tool) CreateBranchPolicy(
+	ctx context.Context, r *models.Repository, branches []*models.Branch, funcs ...PolicyOpFn,
+) (*policy.RepoPolicy, error) {
+	if len(branches) == 0 {
 		return nil, errors.New("no branches defined")
 	}
 
-	controls, err := t.impl.GetBranchControls(ctx, t.backend, branches[0])
-	if err != nil {
-		return nil, fmt.Errorf("getting branch controls: %w", err)
+	opts := PolicyOptions{}
+	for _, f := range funcs {
+		if err := f(&opts); err != nil {
+			return nil, err
+		}
 	}
 
-	return t.createPolicy(r, branches[0], controls)
+	if opts.BranchPattern != "" {
+		if len(branches) > 1 {
+			return nil, errors.New("a branch pattern takes a single reference branch")
+		}
+		if !policy.MatchBranchPattern(opts.BranchPattern, branches[0].Name) {
+			return nil, fmt.Errorf("branch %q does not match the pattern %q", branches[0].Name, opts.BranchPattern)
+		}
+	}
+
+	var p *policy.RepoPolicy
+	for _, branch := range branches {
+		controls, err := t.impl.GetBranchControls(ctx, t.backend, branch)
+		if err != nil {
+			return nil, fmt.Errorf("getting branch controls of %s: %w", branch.Name, err)
+		}
+
+		bp, err := t.createPolicy(r, branch, controls)
+		if err != nil {
+			return nil, err
+		}
+		if opts.BranchPattern != "" {
+			bp.GetProtectedBranches()[0].Name = opts.BranchPattern
+		}
+		p = policy.MergePolicies(p, bp)
+	}
+
+	if opts.BasePolicy != nil {
+		p = policy.MergePolicies(opts.BasePolicy, p)
+	}
+	return p, nil
 }
 
 // This function will be moved to the policy package once we start integrating
@@ -280,9 +619,33 @@ func (t *Tool) createPolicy(r *models.Repository, branch *models.Branch, control
 	return p, nil
 }
 
-// GetRepositoryPolicy retrieves the policy of repo from the community
-func (t *Tool) GetRepositoryPolicy(ctx context.Context, r *models.Repository) (*policy.RepoPolicy, error) {
+// newPolicyEvaluator returns a policy evaluator reading the policies from
+// the sources configured in the options, in order of precedence. With no
+// sources configured it reads from the community policy repository.
+func newPolicyEvaluator(a *auth.Authenticator, opts *options.Options) (*policy.PolicyEvaluator, error) {
 	pe := policy.NewPolicyEvaluator()
+	if len(opts.PolicySources) == 0 {
+		return pe, nil
+	}
+
+	if a == nil {
+		a = auth.New()
+	}
+	chain, err := policy.NewSourceChain(opts.PolicySources, a.GetGitHubClient)
+	if err != nil {
+		return nil, fmt.Errorf("parsing policy sources: %w", err)
+	}
+	pe.Source = chain
+	return pe, nil
+}
+
+// GetRepositoryPolicy retrieves the policy of repo from the configured
+// policy sources, the community repository by default.
+func (t *Tool) GetRepositoryPolicy(ctx context.Context, r *models.Repository) (*policy.RepoPolicy, error) {
+	pe, err := newPolicyEvaluator(t.Authenticator, &t.Options)
+	if err != nil {
+		return nil, err
+	}
 	p, _, err := pe.GetPolicy(ctx, r)
 	if err != nil {
 		return nil, fmt.Errorf("getting repository policy: %w", err)
@@ -292,8 +655,10 @@ func (t *Tool) GetRepositoryPolicy(ctx context.Context, r *models.Repository) (*
 }
 
 // CreateRepositoryPolicy creates a policy for a repository
-func (t *Tool) CreateRepositoryPolicy(ctx context.Context, r *models.Repository, branches []*models.Branch) (*policy.RepoPolicy, *models.PullRequest, error) {
-	pcy, err := t.CreateBranchPolicy(ctx, r, branches)
+func (t *Tool) CreateRepositoryPolicy(
+	ctx context.Context, r *models.Repository, branches []*models.Branch, funcs ...PolicyOpFn,
+) (*policy.RepoPolicy, *models.PullRequest, error) {
+	pcy, err := t.CreateBranchPolicy(ctx, r, branches, funcs...)
 	if err != nil {
 		return nil, nil, fmt.Errorf("creating policy for: %w", err)
 	}
@@ -302,14 +667,61 @@ func (t *Tool) CreateRepositoryPolicy(ctx context.Context, r *models.Repository,
 
 	// If the option is set, open the pull request
 	if t.Options.CreatePolicyPR {
-		pr, err = t.impl.CreatePolicyPR(t.Authenticator, &t.Options, r, pcy)
+		pr, err = t.CreatePolicyPR(r, pcy)
 		if err != nil {
-			return nil, nil, fmt.Errorf("opening the policy pull request: %w", err)
+			return nil, nil, err
 		}
 	}
 	return pcy, pr, nil
 }
 
+// CreatePolicyPR opens a pull request in the policy repository checking in
+// the policy as is.
+func (t *Tool) CreatePolicyPR(r *models.Repository, pcy *policy.RepoPolicy) (*models.PullRequest, error) {
+	pr, err := t.impl.CreatePolicyPR(t.Authenticator, &t.Options, r, pcy)
+	if err != nil {
+		return nil, fmt.Errorf("opening the policy pull request: %w", err)
+	}
+	return pr, nil
+}
+
+// LintPolicy validates a repository policy against the current controls of
+// the default branch and of the branches named in the policy. Branches that
+// cannot be read are reported as missing.
+func (t *Tool) LintPolicy(ctx context.Context, r *models.Repository, pcy *policy.RepoPolicy, funcs ...policy.ValidateOptFn) (policy.Findings, error) {
+	defaultBranch, err := t.backend.GetDefaultBranch(ctx, r)
+	if err != nil {
+		return nil, fmt.Errorf("reading default branch: %w", err)
+	}
+	controls, err := t.backend.GetBranchControls(ctx, defaultBranch)
+	if err != nil {
+		return nil, fmt.Errorf("reading controls of branch %s: %w", defaultBranch.Name, err)
+	}
+	branches := map[string]*slsa.ControlSet{defaultBranch.Name: controls}
+
+	for _, pb := range pcy.GetProtectedBranches() {
+		if _, ok := branches[pb.GetName()]; ok || pb.GetName() == "" || policy.IsBranchPattern(pb.GetName()) {
+			continue
+		}
+		branch := &models.Branch{Name: pb.GetName(), Repository: r}
+		// Missing branches are reported by the linter, other errors are not
+		// findings.
+		if _, err := t.backend.GetLatestCommit(ctx, r, branch); err != nil {
+			if errors.Is(err, models.ErrBranchNotFound) {
+				continue
+			}
+			return nil, fmt.Errorf("reading branch %s: %w", branch.Name, err)
+		}
+		controls, err := t.backend.GetBranchControls(ctx, branch)
+		if err != nil {
+			return nil, fmt.Errorf("reading controls of branch %s: %w", branch.Name, err)
+		}
+		branches[branch.Name] = controls
+	}
+
+	return policy.Validate(pcy, append([]policy.ValidateOptFn{policy.WithBranchControls(branches)}, funcs...)...), nil
+}
+
 // CreatePolicyRepoFork creates a fork of the policy repository in the user's GitHub org
 func (t *Tool) CreatePolicyRepoFork(ctx context.Context) error {
 	err := t.impl.CreateRepositoryFork(ctx, t.Authenticator, &models.Repository{
@@ -330,6 +742,18 @@ func (t *Tool) ControlPrecheck(
 	return t.backend.ControlPrecheck(r, branches, config)
 }
 
+// PromoteControls enforces the rules staged in evaluate mode in the
+// repository. It returns the names of the promoted rules.
+func (t *Tool) PromoteControls(ctx context.Context, r *models.Repository) ([]string, error) {
+	return t.backend.PromoteControls(ctx, r)
+}
+
+// GetStagedRuleFailures returns the recent pushes that the rules staged in
+// the repository would have blocked if they were enforced.
+func (t *Tool) GetStagedRuleFailures(ctx context.Context, r *models.Repository) ([]*models.StagedRuleFailure, error) {
+	return t.backend.GetStagedRuleFailures(ctx, r)
+}
+
 // Attester returns an attester object with the tool configuration
 func (t *Tool) Attester() *attest.Attester {
 	return t.attester
@@ -351,6 +775,8 @@ type AttestOptions struct {
 	OutputPath  string
 	UseStdOut   bool
 	Push        bool
+	// PolicyAt selects the version of the policy to evaluate against
+	PolicyAt policy.PolicyPoint
 }
 
 type AttOpFn func(*AttestOptions) error
@@ -362,6 +788,15 @@ func WithLocalPolicy(p string) AttOpFn {
 	}
 }
 
+// WithPolicyAt evaluates the revision against the policy as it was at a
+// point in time or policy repository commit instead of the latest one.
+func WithPolicyAt(at policy.PolicyPoint) AttOpFn {
+	return func(ao *AttestOptions) error {
+		ao.PolicyAt = at
+		return nil
+	}
+}
+
 func WithSign(s bool) AttOpFn {
 	return func(ao *AttestOptions) error {
 		ao.Sign = s
@@ -390,6 +825,41 @@ func WithPush(s bool) AttOpFn {
 	}
 }
 
+// PolicyOptions control the policies created by the tool
+type PolicyOptions struct {
+	// BranchPattern names the protected branch entry with a pattern to
+	// protect all matching branches. The branch passed is used as the
+	// reference to compute the policy and must match it.
+	BranchPattern string
+
+	// BasePolicy is an existing policy the new branch entries are merged
+	// into. Branches already in it keep their since dates unless their
+	// target level changes.
+	BasePolicy *policy.RepoPolicy
+}
+
+type PolicyOpFn func(*PolicyOptions) error
+
+// WithBranchPattern creates the branch policy for all branches matching
+// the pattern instead of just the branch.
+func WithBranchPattern(pattern string) PolicyOpFn {
+	return func(po *PolicyOptions) error {
+		if pattern != "" && !policy.IsBranchPattern(pattern) {
+			return fmt.Errorf("%q is not a branch pattern", pattern)
+		}
+		po.BranchPattern = pattern
+		return nil
+	}
+}
+
+// WithBasePolicy merges the branch entries created into an existing policy
+func WithBasePolicy(p *policy.RepoPolicy) PolicyOpFn {
+	return func(po *PolicyOptions) error {
+		po.BasePolicy = p
+		return nil
+	}
+}
+
 var defaultAttestOptions = AttestOptions{
 	Sign:      true,
 	UseStdOut: true,
@@ -402,6 +872,10 @@ var defaultAttestOptions = AttestOptions{
 type AttestationResult struct {
 	VerifiedLevels slsa.SourceVerifiedLevels
 	Shortfall      *policy.PolicyShortfall
+	// PolicyVersion is the version of the policy recorded in the VSA
+	PolicyVersion *policy.PolicyVersion
+	// Rules are the results of the custom rules in the policy
+	Rules []*policy.RuleResult
 }
 
 // AttestRevision checks the source control system status, the repository policy
@@ -432,7 +906,9 @@ func (t *Tool) AttestRevision(
 	var provenanceData []byte
 	var verifiedLevels slsa.SourceVerifiedLevels
 	var policyPath string
+	var policyVersion *policy.PolicyVersion
 	var shortfall *policy.PolicyShortfall
+	var rules []*policy.RuleResult
 
 	_, isCommit := rev.(*models.Commit)
 	tag, isTag := rev.(*models.Tag)
@@ -448,13 +924,18 @@ func (t *Tool) AttestRevision(
 		// levels. Any level below the policy target is reported as a shortfall, not
 		// an error. We still emit the provenance so the chain is never broken
 		// just because the policy levels are not met immediately.
-		pe := policy.NewPolicyEvaluator()
+		pe, err := newPolicyEvaluator(t.Authenticator, &t.Options)
+		if err != nil {
+			return nil, err
+		}
 		pe.UseLocalPolicy = opts.LocalPolicy
+		pe.At = opts.PolicyAt
 		result, err := pe.EvaluateSourceProv(ctx, branch.Repository, branch, prov)
 		if err != nil {
 			return nil, fmt.Errorf("evaluating provenance with policy: %w", err)
 		}
 		verifiedLevels, policyPath, shortfall = result.VerifiedLevels, result.PolicyPath, result.Shortfall
+		policyVersion, rules = result.PolicyVersion, result.Rules
 
 		provenanceData, err = protojson.Marshal(prov)
 		if err != nil {
@@ -480,13 +961,18 @@ func (t *Tool) AttestRevision(
 		}
 
 		// 2. Run the provenance against the policy to determine the verified levels.
-		pe := policy.NewPolicyEvaluator()
+		pe, err := newPolicyEvaluator(t.Authenticator, &t.Options)
+		if err != nil {
+			return nil, err
+		}
 		pe.UseLocalPolicy = opts.LocalPolicy
+		pe.At = opts.PolicyAt
 		result, err := pe.EvaluateTagProv(ctx, branch.Repository, prov)
 		if err != nil {
 			return nil, fmt.Errorf("evaluating provenance with policy: %w", err)
 		}
 		verifiedLevels, policyPath, shortfall = result.VerifiedLevels, result.PolicyPath, result.Shortfall
+		policyVersion, rules = result.PolicyVersion, result.Rules
 
 		provenanceData, err = protojson.Marshal(prov)
 		if err != nil {
@@ -496,20 +982,25 @@ func (t *Tool) AttestRevision(
 
 	// create vsa
 	vsaData, err = attest.CreateUnsignedSourceVsa(
-		branch, rev.GetCommit(), verifiedLevels, policyPath,
+		branch, rev.GetCommit(), verifiedLevels, policyPath, policyVersion.DigestSet(),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("creating VSA: %w", err)
 	}
 
 	if opts.Sign {
-		provenanceDataString, err := attest.Sign(string(provenanceData))
+		signer := attest.NewBndSigner(attest.SigningOptions{
+			SigningConfig: t.Options.SigningConfig,
+			TrustedRoot:   t.Options.TrustedRoot,
+			Key:           t.Options.SigningKey,
+		})
+		provenanceDataString, err := signer.Sign(string(provenanceData))
 		if err != nil {
 			return nil, err
 		}
 		provenanceData = []byte(provenanceDataString)
 
-		vsaData, err = attest.Sign(vsaData)
+		vsaData, err = signer.Sign(vsaData)
 		if err != nil {
 			return nil, err
 		}
@@ -551,6 +1042,8 @@ func (t *Tool) AttestRevision(
 	return &AttestationResult{
 		VerifiedLevels: verifiedLevels,
 		Shortfall:      shortfall,
+		PolicyVersion:  policyVersion,
+		Rules:          rules,
 	}, nil
 }
 
@@ -561,17 +1054,36 @@ func (t *Tool) getAttestationStore(branch *models.Branch) (*collector.Agent, err
 		return nil, errors.New("no storage locations defined")
 	}
 
-	if t.Authenticator == nil {
-		return nil, errors.New("tool has no authenticator configured")
+	copts := []collector.InitFunction{}
+
+	// With the local backend, notes are written to the repository on disk
+	if t.localNotes != nil && t.Options.InitNotesStorer {
+		copts = append(copts, collector.WithRepository(t.localNotes))
 	}
 
-	token, err := t.Authenticator.ReadToken()
-	if err != nil {
-		return nil, fmt.Errorf("unable to read auth token: %w", err)
+	// The remote storers require the authenticator token. It is a GitHub
+	// token, never send it to repositories hosted elsewhere.
+	hostname := branch.Repository.Hostname
+	onGitHub := hostname == "" || hostname == githubHostname
+	if t.localNotes == nil && !onGitHub && t.Options.InitGHStorer {
+		return nil, fmt.Errorf("pushing to the GitHub attestations store is not supported for %s", hostname)
 	}
-	copts := []collector.InitFunction{}
+
+	var token string
+	if t.localNotes == nil && onGitHub && (t.Options.InitGHStorer || t.Options.InitNotesStorer) {
+		if t.Authenticator == nil {
+			return nil, errors.New("tool has no authenticator configured")
+		}
+
+		var err error
+		token, err = t.Authenticator.ReadToken()
+		if err != nil {
+			return nil, fmt.Errorf("unable to read auth token: %w", err)
+		}
+	}
+
 	// Init GitHub storer
-	if t.Options.InitGHStorer {
+	if t.localNotes == nil && t.Options.InitGHStorer {
 		ghrepo, err := cgithub.New(
 			cgithub.WithRepo(branch.Repository.GetHttpURL()),
 			cgithub.WithToken(token),
@@ -582,11 +1094,20 @@ func (t *Tool) getAttestationStore(branch *models.Branch) (*collector.Agent, err
 		copts = append(copts, collector.WithRepository(ghrepo))
 	}
 
-	// Init commit notes storer
-	if t.Options.InitNotesStorer {
+	// Init commit notes storer. Notes are pushed to GitLab with the
+	// GitLab token.
+	if t.localNotes == nil && t.Options.InitNotesStorer {
+		httpAuth := note.WithHttpAuth("github", token)
+		if !onGitHub {
+			gltoken := os.Getenv(gitlab.TokenEnvVar)
+			if gltoken == "" {
+				return nil, fmt.Errorf("pushing notes to %s requires a GitLab token in %s", hostname, gitlab.TokenEnvVar)
+			}
+			httpAuth = note.WithHttpAuth("oauth2", gltoken)
+		}
 		notesrepo, err := note.NewDynamic(
 			note.DynamicRepoURL(branch.Repository.GetHttpURL()),
-			note.WithHttpAuth("github", token),
+			httpAuth,
 			note.WithPush(true),
 		)
 		if err != nil {
@@ -4,14 +4,29 @@
 package sourcetool
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/carabiner-dev/collector/repository/note"
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
+	"github.com/slsa-framework/source-tool/pkg/auth"
+	"github.com/slsa-framework/source-tool/pkg/policy"
 	"github.com/slsa-framework/source-tool/pkg/slsa"
+	"github.com/slsa-framework/source-tool/pkg/sourcetool/backends/vcs/gitlab"
 	"github.com/slsa-framework/source-tool/pkg/sourcetool/models"
 	"github.com/slsa-framework/source-tool/pkg/sourcetool/models/modelsfakes"
+	"github.com/slsa-framework/source-tool/pkg/sourcetool/options"
 	"github.com/slsa-framework/source-tool/pkg/sourcetool/sourcetoolfakes"
 )
 
@@ -53,6 +68,119 @@ func TestGetBranchControls(t *testing.T) {
 	})
 }
 
+func TestCreateBranchPolicyPattern(t *testing.T) {
+	t.Parallel()
+	repo := &models.Repository{Hostname: "github.com", Path: "example/repo"}
+	for _, tc := range []struct {
+		name     string
+		branch   string
+		pattern  string
+		expected string
+		mustErr  bool
+	}{
+		{name: "no-pattern", branch: "main", expected: "main"},
+		{name: "pattern", branch: "release/1.0", pattern: "release/*", expected: "release/*"},
+		{name: "branch-does-not-match", branch: "main", pattern: "release/*", mustErr: true},
+		{name: "not-a-pattern", branch: "main", pattern: "main", mustErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			i := &sourcetoolfakes.FakeToolImplementation{}
+			tool := &Tool{impl: i}
+			p, err := tool.CreateBranchPolicy(
+				t.Context(), repo, []*models.Branch{{Name: tc.branch, Repository: repo}},
+				WithBranchPattern(tc.pattern),
+			)
+			if tc.mustErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, p.GetProtectedBranches(), 1)
+			require.Equal(t, tc.expected, p.GetProtectedBranches()[0].GetName())
+			require.NotNil(t, p.GetBranchPolicy(tc.branch))
+		})
+	}
+}
+
+func TestCreateBranchPolicyMultiple(t *testing.T) {
+	t.Parallel()
+	repo := &models.Repository{Hostname: "github.com", Path: "example/repo"}
+	since := timestamppb.New(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	branches := []*models.Branch{
+		{Name: "main", Repository: repo},
+		{Name: "develop", Repository: repo},
+	}
+	base := &policy.RepoPolicy{
+		CanonicalRepo: repo.GetHttpURL(),
+		ProtectedBranches: []*policy.ProtectedBranch{
+			{Name: "main", Since: since, TargetSlsaSourceLevel: string(slsa.SlsaSourceLevel1)},
+			{Name: "legacy", Since: since, TargetSlsaSourceLevel: string(slsa.SlsaSourceLevel1)},
+		},
+	}
+
+	i := &sourcetoolfakes.FakeToolImplementation{}
+	tool := &Tool{impl: i}
+
+	p, err := tool.CreateBranchPolicy(t.Context(), repo, branches)
+	require.NoError(t, err)
+	require.Len(t, p.GetProtectedBranches(), 2)
+	require.Equal(t, 2, i.GetBranchControlsCallCount())
+
+	// Merged into the existing policy, main keeps its since date
+	p, err = tool.CreateBranchPolicy(t.Context(), repo, branches, WithBasePolicy(base))
+	require.NoError(t, err)
+	require.Len(t, p.GetProtectedBranches(), 3)
+	require.Equal(t, []string{"main", "legacy", "develop"}, []string{
+		p.GetProtectedBranches()[0].GetName(), p.GetProtectedBranches()[1].GetName(), p.GetProtectedBranches()[2].GetName(),
+	})
+	require.Equal(t, since.AsTime(), p.GetBranchPolicy("main").GetSince().AsTime())
+
+	// A pattern is computed from a single branch
+	_, err = tool.CreateBranchPolicy(t.Context(), repo, branches, WithBranchPattern("*"))
+	require.Error(t, err)
+
+	// Failing to read the controls of any branch is an error
+	i.GetBranchControlsReturnsOnCall(5, nil, errors.New("not found"))
+	_, err = tool.CreateBranchPolicy(t.Context(), repo, branches)
+	require.Error(t, err)
+}
+
+func TestLintPolicy(t *testing.T) {
+	t.Parallel()
+	repo := &models.Repository{Hostname: "github.com", Path: "example/repo"}
+	since := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	pcy := &policy.RepoPolicy{
+		CanonicalRepo: repo.GetHttpURL(),
+		ProtectedBranches: []*policy.ProtectedBranch{
+			{Name: "main", Since: timestamppb.New(since), TargetSlsaSourceLevel: string(slsa.SlsaSourceLevel1)},
+			{Name: "gone", Since: timestamppb.New(since), TargetSlsaSourceLevel: string(slsa.SlsaSourceLevel1)},
+		},
+	}
+
+	backend := &modelsfakes.FakeVcsBackend{}
+	backend.GetDefaultBranchReturns(&models.Branch{Name: "main", Repository: repo}, nil)
+	backend.GetBranchControlsReturns(&slsa.ControlSet{}, nil)
+	backend.GetLatestCommitReturns(nil, fmt.Errorf("%w: gone", models.ErrBranchNotFound))
+	tool := &Tool{backend: backend}
+
+	findings, err := tool.LintPolicy(t.Context(), repo, pcy)
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	require.Equal(t, policy.SeverityError, findings[0].Severity)
+	require.Equal(t, "protected_branches[1].name", findings[0].Field)
+
+	// Other errors reading the branch are not reported as a missing branch
+	backend.GetLatestCommitReturns(nil, errors.New("unauthorized"))
+	_, err = tool.LintPolicy(t.Context(), repo, pcy)
+	require.Error(t, err)
+
+	// Failing to read the default branch is an error
+	backend.GetDefaultBranchReturns(nil, errors.New("unauthorized"))
+	_, err = tool.LintPolicy(t.Context(), repo, pcy)
+	require.Error(t, err)
+}
+
 func TestConfigureControls(t *testing.T) {
 	t.Parallel()
 	syntErr := errors.New("synthetic error")
@@ -276,13 +404,54 @@ func TestCreatePolicyRepoFork(t *testing.T) {
 	}
 }
 
+func TestCreatePolicyPR(t *testing.T) {
+	t.Parallel()
+	repo := &models.Repository{Hostname: "github.com", Path: "example/repo"}
+	pcy := &policy.RepoPolicy{CanonicalRepo: "https://github.com/example/repo"}
+
+	for _, tc := range []struct {
+		name    string
+		err     error
+		mustErr bool
+	}{
+		{name: "normal"},
+		{name: "create-fails", err: errors.New("synthetic error"), mustErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			timp := &sourcetoolfakes.FakeToolImplementation{}
+			timp.CreatePolicyPRReturns(&models.PullRequest{Number: 1}, tc.err)
+			tool, err := New()
+			require.NoError(t, err)
+			tool.impl = timp
+
+			pr, err := tool.CreatePolicyPR(repo, pcy)
+			if tc.mustErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, 1, pr.Number)
+
+			// The policy is checked in as received, it is not built again
+			require.Equal(t, 1, timp.CreatePolicyPRCallCount())
+			_, _, gotRepo, gotPolicy := timp.CreatePolicyPRArgsForCall(0)
+			require.Same(t, repo, gotRepo)
+			require.Same(t, pcy, gotPolicy)
+		})
+	}
+}
+
 func TestOnboardRepository(t *testing.T) {
 	t.Parallel()
+	syntErr := errors.New("synthetic error")
 	for _, tt := range []struct {
 		name    string
 		impl    func(t *testing.T) toolImplementation
-		backend func(t *testing.T) models.VcsBackend
+		backend func(t *testing.T) *modelsfakes.FakeVcsBackend
 		mustErr bool
+		// removed are the configs rolled back, nil if no rollback
+		removed []models.ControlConfiguration
 	}{
 		{
 			name: "normal",
@@ -292,7 +461,7 @@ func TestOnboardRepository(t *testing.T) {
 				timp.VerifyOptionsForFullOnboardReturns(nil)
 				return timp
 			},
-			backend: func(t *testing.T) models.VcsBackend {
+			backend: func(t *testing.T) *modelsfakes.FakeVcsBackend {
 				t.Helper()
 				return &modelsfakes.FakeVcsBackend{}
 			},
@@ -306,7 +475,7 @@ func TestOnboardRepository(t *testing.T) {
 				timp.VerifyOptionsForFullOnboardReturns(errors.New("onboarderr"))
 				return timp
 			},
-			backend: func(t *testing.T) models.VcsBackend {
+			backend: func(t *testing.T) *modelsfakes.FakeVcsBackend {
 				t.Helper()
 				return &modelsfakes.FakeVcsBackend{}
 			},
@@ -320,22 +489,94 @@ func TestOnboardRepository(t *testing.T) {
 				timp.VerifyOptionsForFullOnboardReturns(nil)
 				return timp
 			},
-			backend: func(t *testing.T) models.VcsBackend {
+			backend: func(t *testing.T) *modelsfakes.FakeVcsBackend {
 				t.Helper()
 				bend := &modelsfakes.FakeVcsBackend{}
 				bend.ConfigureControlsReturns(errors.New("configure-error"))
 				return bend
 			},
 			mustErr: true,
+			removed: []models.ControlConfiguration{models.CONFIG_BRANCH_RULES},
+		},
+		{
+			name: "get-managed-controls-fails",
+			impl: func(t *testing.T) toolImplementation {
+				t.Helper()
+				return &sourcetoolfakes.FakeToolImplementation{}
+			},
+			backend: func(t *testing.T) *modelsfakes.FakeVcsBackend {
+				t.Helper()
+				bend := &modelsfakes.FakeVcsBackend{}
+				bend.GetManagedControlsReturns(nil, syntErr)
+				return bend
+			},
+			mustErr: true,
+		},
+		{
+			name: "rollback-created",
+			impl: func(t *testing.T) toolImplementation {
+				t.Helper()
+				return &sourcetoolfakes.FakeToolImplementation{}
+			},
+			backend: func(t *testing.T) *modelsfakes.FakeVcsBackend {
+				t.Helper()
+				bend := &modelsfakes.FakeVcsBackend{}
+				bend.ConfigureControlsReturnsOnCall(2, syntErr)
+				return bend
+			},
+			mustErr: true,
+			removed: []models.ControlConfiguration{
+				models.CONFIG_TAG_RULES, models.CONFIG_GEN_PROVENANCE, models.CONFIG_BRANCH_RULES,
+			},
+		},
+		{
+			name: "rollback-keeps-existing",
+			impl: func(t *testing.T) toolImplementation {
+				t.Helper()
+				return &sourcetoolfakes.FakeToolImplementation{}
+			},
+			backend: func(t *testing.T) *modelsfakes.FakeVcsBackend {
+				t.Helper()
+				bend := &modelsfakes.FakeVcsBackend{}
+				bend.GetManagedControlsReturns([]models.ControlConfiguration{models.CONFIG_BRANCH_RULES}, nil)
+				bend.ConfigureControlsReturnsOnCall(1, syntErr)
+				return bend
+			},
+			mustErr: true,
+			removed: []models.ControlConfiguration{models.CONFIG_GEN_PROVENANCE},
+		},
+		{
+			name: "rollback-fails",
+			impl: func(t *testing.T) toolImplementation {
+				t.Helper()
+				return &sourcetoolfakes.FakeToolImplementation{}
+			},
+			backend: func(t *testing.T) *modelsfakes.FakeVcsBackend {
+				t.Helper()
+				bend := &modelsfakes.FakeVcsBackend{}
+				bend.ConfigureControlsReturnsOnCall(1, syntErr)
+				bend.RemoveControlsReturns(errors.New("remove-error"))
+				return bend
+			},
+			mustErr: true,
+			removed: []models.ControlConfiguration{models.CONFIG_GEN_PROVENANCE, models.CONFIG_BRANCH_RULES},
 		},
 	} {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
+			backend := tt.backend(t)
 			tool := &Tool{
 				impl:    tt.impl(t),
-				backend: tt.backend(t),
+				backend: backend,
 			}
 			err := tool.OnboardRepository(t.Context(), &models.Repository{Path: "example/repo"}, []*models.Branch{{Name: "main"}})
+			if tt.removed == nil {
+				require.Zero(t, backend.RemoveControlsCallCount())
+			} else {
+				require.Equal(t, 1, backend.RemoveControlsCallCount())
+				_, _, removed := backend.RemoveControlsArgsForCall(0)
+				require.Equal(t, tt.removed, removed)
+			}
 			if tt.mustErr {
 				require.Error(t, err)
 				return
@@ -344,3 +585,300 @@ func TestOnboardRepository(t *testing.T) {
 		})
 	}
 }
+
+func TestPlanControls(t *testing.T) {
+	t.Parallel()
+	repo := &models.Repository{Hostname: "github.com", Path: "example/repo"}
+	branches := []*models.Branch{{Name: "main", Repository: repo}}
+
+	t.Run("plan", func(t *testing.T) {
+		t.Parallel()
+		backend := &modelsfakes.FakeVcsBackend{}
+		backend.PlanControlsReturnsOnCall(0, []*models.PlannedChange{
+			{Config: models.CONFIG_BRANCH_RULES, Action: models.PlanActionCreate},
+		}, nil)
+		backend.PlanControlsReturnsOnCall(1, []*models.PlannedChange{
+			{Config: models.CONFIG_TAG_RULES, Action: models.PlanActionNoop},
+		}, nil)
+		tool := &Tool{impl: &sourcetoolfakes.FakeToolImplementation{}, backend: backend}
+
+		plan, err := tool.PlanControls(t.Context(), repo, branches, []models.ControlConfiguration{
+			models.CONFIG_BRANCH_RULES, models.CONFIG_TAG_RULES,
+		})
+		require.NoError(t, err)
+		require.Equal(t, "github.com", plan.Hostname)
+		require.Equal(t, "example/repo", plan.Repository)
+		require.Equal(t, []string{"main"}, plan.Branches)
+		require.Len(t, plan.Changes, 2)
+		require.Equal(t, models.CONFIG_BRANCH_RULES, plan.Changes[0].Config)
+		require.Equal(t, models.CONFIG_TAG_RULES, plan.Changes[1].Config)
+	})
+
+	t.Run("backend-fails", func(t *testing.T) {
+		t.Parallel()
+		backend := &modelsfakes.FakeVcsBackend{}
+		backend.PlanControlsReturns(nil, errors.New("plan-error"))
+		tool := &Tool{impl: &sourcetoolfakes.FakeToolImplementation{}, backend: backend}
+
+		_, err := tool.PlanControls(t.Context(), repo, branches, []models.ControlConfiguration{models.CONFIG_BRANCH_RULES})
+		require.Error(t, err)
+	})
+}
+
+func TestApplyPlan(t *testing.T) {
+	t.Parallel()
+	syntErr := errors.New("synthetic error")
+	newPlan := func(changes ...*models.PlannedChange) *models.ControlPlan {
+		return &models.ControlPlan{
+			Hostname: "github.com", Repository: "example/repo", Branches: []string{"main"}, Changes: changes,
+		}
+	}
+	branchRules := &models.PlannedChange{Config: models.CONFIG_BRANCH_RULES, Resource: models.PlanResourceRuleset, Action: models.PlanActionCreate}
+	workflow := &models.PlannedChange{Config: models.CONFIG_GEN_PROVENANCE, Resource: models.PlanResourceFile, Action: models.PlanActionCreate}
+	tagRules := &models.PlannedChange{Config: models.CONFIG_TAG_RULES, Resource: models.PlanResourceRuleset, Action: models.PlanActionNoop}
+	reviewRules := &models.PlannedChange{
+		Config: models.CONFIG_REVIEW_RULES, Resource: models.PlanResourceRuleset, Action: models.PlanActionUpdate,
+		Current: json.RawMessage(`{"enforcement": "evaluate"}`), Desired: json.RawMessage(`{"enforcement": "active"}`),
+	}
+	pcy := &models.PlannedChange{
+		Config: models.CONFIG_POLICY, Resource: models.PlanResourceFile, Action: models.PlanActionCreate,
+		Contents: `{"canonical_repo": "https://github.com/example/repo"}`,
+	}
+
+	for _, tc := range []struct {
+		name     string
+		plan     *models.ControlPlan
+		prepare  func(*modelsfakes.FakeVcsBackend, *sourcetoolfakes.FakeToolImplementation)
+		applied  int
+		policyPR int
+		// removed are the configs rolled back, nil if no rollback
+		removed []models.ControlConfiguration
+		// reverted are the updated configs restored in the rollback
+		reverted    []models.ControlConfiguration
+		closedPR    bool
+		mustErr     bool
+		expectedErr error
+	}{
+		{
+			name:    "apply",
+			plan:    newPlan(branchRules, workflow, tagRules, reviewRules),
+			applied: 3,
+		},
+		{
+			name:     "policy",
+			plan:     newPlan(branchRules, pcy),
+			applied:  1,
+			policyPR: 1,
+		},
+		{
+			name: "outdated",
+			plan: newPlan(branchRules, workflow, reviewRules),
+			prepare: func(b *modelsfakes.FakeVcsBackend, _ *sourcetoolfakes.FakeToolImplementation) {
+				b.CheckPlannedChangeReturnsOnCall(2, models.ErrPlanOutdated)
+			},
+			mustErr:     true,
+			expectedErr: models.ErrPlanOutdated,
+		},
+		{
+			name: "policy-outdated",
+			plan: newPlan(branchRules, pcy),
+			prepare: func(_ *modelsfakes.FakeVcsBackend, i *sourcetoolfakes.FakeToolImplementation) {
+				i.SearchPullRequestReturns(&models.PullRequest{Number: 1}, nil)
+			},
+			mustErr:     true,
+			expectedErr: models.ErrPlanOutdated,
+		},
+		{
+			name: "rollback",
+			plan: newPlan(branchRules, workflow, reviewRules),
+			prepare: func(b *modelsfakes.FakeVcsBackend, _ *sourcetoolfakes.FakeToolImplementation) {
+				b.ApplyPlannedChangeReturnsOnCall(2, syntErr)
+			},
+			applied: 3,
+			removed: []models.ControlConfiguration{models.CONFIG_GEN_PROVENANCE, models.CONFIG_BRANCH_RULES},
+			mustErr: true,
+		},
+		{
+			name: "rollback-update",
+			plan: newPlan(reviewRules, branchRules),
+			prepare: func(b *modelsfakes.FakeVcsBackend, _ *sourcetoolfakes.FakeToolImplementation) {
+				b.ApplyPlannedChangeReturnsOnCall(1, syntErr)
+			},
+			applied:  3,
+			removed:  []models.ControlConfiguration{models.CONFIG_BRANCH_RULES},
+			reverted: []models.ControlConfiguration{models.CONFIG_REVIEW_RULES},
+			mustErr:  true,
+		},
+		{
+			name: "rollback-policy",
+			plan: newPlan(branchRules, pcy, workflow),
+			prepare: func(b *modelsfakes.FakeVcsBackend, i *sourcetoolfakes.FakeToolImplementation) {
+				i.CreatePolicyPRReturns(&models.PullRequest{Number: 2}, nil)
+				b.ApplyPlannedChangeReturnsOnCall(1, syntErr)
+			},
+			applied:  2,
+			policyPR: 1,
+			removed:  []models.ControlConfiguration{models.CONFIG_GEN_PROVENANCE, models.CONFIG_BRANCH_RULES},
+			closedPR: true,
+			mustErr:  true,
+		},
+		{
+			name: "policy-fails",
+			plan: newPlan(branchRules, pcy),
+			prepare: func(_ *modelsfakes.FakeVcsBackend, i *sourcetoolfakes.FakeToolImplementation) {
+				i.CreatePolicyPRReturns(nil, syntErr)
+			},
+			applied:  1,
+			policyPR: 1,
+			removed:  []models.ControlConfiguration{models.CONFIG_BRANCH_RULES},
+			mustErr:  true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			backend := &modelsfakes.FakeVcsBackend{}
+			impl := &sourcetoolfakes.FakeToolImplementation{}
+			if tc.prepare != nil {
+				tc.prepare(backend, impl)
+			}
+			tool := &Tool{impl: impl, backend: backend}
+
+			err := tool.ApplyPlan(t.Context(), tc.plan)
+			require.Equal(t, tc.applied, backend.ApplyPlannedChangeCallCount())
+			require.Equal(t, tc.policyPR, impl.CreatePolicyPRCallCount())
+			if tc.policyPR > 0 {
+				_, _, _, p := impl.CreatePolicyPRArgsForCall(0)
+				require.Equal(t, "https://github.com/example/repo", p.GetCanonicalRepo())
+			}
+			var removed []models.ControlConfiguration
+			for i := range backend.RemoveControlsCallCount() {
+				_, _, configs := backend.RemoveControlsArgsForCall(i)
+				removed = append(removed, configs...)
+			}
+			require.Equal(t, tc.removed, removed)
+
+			var reverted []models.ControlConfiguration
+			for i := range backend.ApplyPlannedChangeCallCount() {
+				_, _, c := backend.ApplyPlannedChangeArgsForCall(i)
+				if c.Action == models.PlanActionUpdate && bytes.Equal(c.Desired, c.Current) {
+					require.Equal(t, reviewRules.Current, c.Desired)
+					reverted = append(reverted, c.Config)
+				}
+			}
+			require.Equal(t, tc.reverted, reverted)
+
+			require.Equal(t, tc.closedPR, impl.ClosePullRequestCallCount() == 1)
+			if tc.closedPR {
+				_, _, pr := impl.ClosePullRequestArgsForCall(0)
+				require.Equal(t, 2, pr.Number)
+			}
+			if tc.mustErr {
+				require.Error(t, err)
+				if tc.expectedErr != nil {
+					require.ErrorIs(t, err, tc.expectedErr)
+				}
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestAttestRevisionLocalBackend(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	repoDir := filepath.Join(dir, "repo")
+	repo, err := git.PlainInit(repoDir, false)
+	require.NoError(t, err)
+	wt, err := repo.Worktree()
+	require.NoError(t, err)
+
+	var head plumbing.Hash
+	for i, when := range []time.Time{
+		time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+	} {
+		require.NoError(t, os.WriteFile(filepath.Join(repoDir, "README"), fmt.Appendf(nil, "%d", i), 0o600))
+		_, err := wt.Add("README")
+		require.NoError(t, err)
+		sig := &object.Signature{Name: "Tester", Email: "test@example.com", When: when}
+		head, err = wt.Commit("commit", &git.CommitOptions{Author: sig, Committer: sig})
+		require.NoError(t, err)
+	}
+
+	configPath := filepath.Join(dir, "controls.json")
+	require.NoError(t, os.WriteFile(configPath, []byte(`{
+		"repo_path": "repo",
+		"branches": {"master": [{"name": "SLSA_SOURCE_SCS_CONTINUITY"}]}
+	}`), 0o600))
+
+	policyPath := filepath.Join(dir, "policy.json")
+	require.NoError(t, os.WriteFile(policyPath, []byte(`{
+		"canonical_repo": "https://github.com/example/repo",
+		"protected_branches": [{"name": "master", "since": "2024-01-15T00:00:00Z", "target_slsa_source_level": "SLSA_SOURCE_LEVEL_1"}]
+	}`), 0o600))
+
+	tool, err := New(WithLocalBackend(configPath))
+	require.NoError(t, err)
+
+	branch := &models.Branch{
+		Name:       "master",
+		Repository: &models.Repository{Hostname: "github.com", Path: "example/repo"},
+	}
+
+	latest, err := tool.Backend().GetLatestCommit(t.Context(), branch.Repository, branch)
+	require.NoError(t, err)
+	require.Equal(t, head.String(), latest.SHA)
+
+	res, err := tool.AttestRevision(
+		t.Context(), branch, latest,
+		WithLocalPolicy(policyPath), WithSign(false), WithUseStdout(false),
+	)
+	require.NoError(t, err)
+	require.NotNil(t, res)
+}
+
+func TestGetAttestationStoreToken(t *testing.T) {
+	// The GitHub token is read from the environment when there is no
+	// token file in the config directory.
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GITHUB_TOKEN", "github-token")
+
+	for _, tc := range []struct {
+		name        string
+		hostname    string
+		gitlabToken string
+		ghStorer    bool
+		username    string
+		password    string
+		mustErr     bool
+	}{
+		{name: "github", hostname: "github.com", username: "github", password: "github-token"},
+		{name: "gitlab", hostname: "gitlab.com", gitlabToken: "gitlab-token", username: "oauth2", password: "gitlab-token"},
+		{name: "gitlab-no-token", hostname: "gitlab.com", mustErr: true},
+		{name: "gitlab-github-storer", hostname: "gitlab.com", gitlabToken: "gitlab-token", ghStorer: true, mustErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv(gitlab.TokenEnvVar, tc.gitlabToken)
+			tool := &Tool{Options: options.Default, Authenticator: auth.New()}
+			tool.Options.InitNotesStorer = true
+			tool.Options.InitGHStorer = tc.ghStorer
+
+			agent, err := tool.getAttestationStore(&models.Branch{
+				Name:       "main",
+				Repository: &models.Repository{Hostname: tc.hostname, Path: "example/repo"},
+			})
+			if tc.mustErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, agent.Repositories, 1)
+			notes, ok := agent.Repositories[0].(*note.Dynamic)
+			require.True(t, ok)
+			require.Equal(t, tc.username, notes.Options.HttpUsername)
+			require.Equal(t, tc.password, notes.Options.HttpPassword)
+		})
+	}
+}